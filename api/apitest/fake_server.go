@@ -0,0 +1,440 @@
+// Package apitest provides a fake Clash API server for unit tests.
+package apitest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"singbox-launcher/api"
+)
+
+// FakeServer is an httptest server implementing the part of the sing-box Clash API used by the launcher.
+// Proxy names in paths are matched after unescaping, like sing-box does.
+type FakeServer struct {
+	*httptest.Server
+	Secret         string
+	StreamInterval time.Duration // Interval of /traffic and /memory samples (1 second by default)
+
+	mutex       sync.Mutex
+	proxies     map[string]*api.Proxy
+	delays      map[string]int64 // Delay returned by the delay test; missing or 0 means a timeout
+	traffic     api.Traffic
+	memory      api.Memory
+	connections []api.Connection
+	requests    []string
+	configs     api.Configs
+	providers   map[string]*api.RuleProvider // nil: /providers/rules answers an empty array like sing-box
+	noGroup     bool                         // Answer 404 to /group/{name}/delay
+	testTime    time.Duration                // How long a delay test of one proxy takes
+	inFlight    int                          // Running delay tests of single proxies
+	maxInFlight int
+}
+
+// NewFakeServer starts a fake Clash API requiring secret (no authentication if empty).
+// The caller must Close it.
+func NewFakeServer(secret string) *FakeServer {
+	f := &FakeServer{
+		Secret:         secret,
+		StreamInterval: time.Second,
+		proxies:        make(map[string]*api.Proxy),
+		delays:         make(map[string]int64),
+		configs:        api.Configs{Mode: "Rule", ModeList: []string{"Rule", "Global", "Direct"}},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /version", f.handleVersion)
+	mux.HandleFunc("GET /proxies", f.handleProxies)
+	mux.HandleFunc("GET /proxies/{name}", f.handleProxy)
+	mux.HandleFunc("PUT /proxies/{name}", f.handleSwitch)
+	mux.HandleFunc("GET /proxies/{name}/delay", f.handleDelay)
+	mux.HandleFunc("GET /group/{name}/delay", f.handleGroupDelay)
+	mux.HandleFunc("GET /configs", f.handleConfigs)
+	mux.HandleFunc("PATCH /configs", f.handlePatchConfigs)
+	mux.HandleFunc("GET /providers/rules", f.handleRuleProviders)
+	mux.HandleFunc("PUT /providers/rules/{name}", f.handleUpdateRuleProvider)
+	mux.HandleFunc("GET /traffic", f.handleTraffic)
+	mux.HandleFunc("GET /memory", f.handleMemory)
+	mux.HandleFunc("GET /connections", f.handleConnections)
+	mux.HandleFunc("DELETE /connections", f.handleCloseConnections)
+	mux.HandleFunc("DELETE /connections/{id}", f.handleCloseConnections)
+	f.Server = httptest.NewServer(f.authenticate(mux))
+	return f
+}
+
+// Client returns an api.Client for the server
+func (f *FakeServer) Client() *api.Client {
+	return api.NewClient(f.URL, f.Secret, nil)
+}
+
+// AddProxy adds an outbound; delay is returned by delay tests (0 makes them time out)
+func (f *FakeServer) AddProxy(name, proxyType string, delay int64) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.proxies[name] = &api.Proxy{Name: name, Type: proxyType, UDP: true, History: []api.DelayHistory{}}
+	f.delays[name] = delay
+}
+
+// AddGroup adds a group of members; the first member is active
+func (f *FakeServer) AddGroup(name, groupType string, members ...string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	group := &api.Proxy{Name: name, Type: groupType, All: append([]string{}, members...), History: []api.DelayHistory{}}
+	if len(members) > 0 {
+		group.Now = members[0]
+	}
+	f.proxies[name] = group
+}
+
+// Mode returns the routing mode
+func (f *FakeServer) Mode() string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.configs.Mode
+}
+
+// AddRuleProvider adds a rule provider; its UpdatedAt is set to now when it is updated
+func (f *FakeServer) AddRuleProvider(p api.RuleProvider) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.providers == nil {
+		f.providers = make(map[string]*api.RuleProvider)
+	}
+	f.providers[p.Name] = &p
+}
+
+// RuleProviderUpdatedAt returns the last update time of a rule provider
+func (f *FakeServer) RuleProviderUpdatedAt(name string) time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if p, ok := f.providers[name]; ok {
+		return p.UpdatedAt
+	}
+	return time.Time{}
+}
+
+// SetTraffic sets the speed sent by the /traffic stream
+func (f *FakeServer) SetTraffic(up, down int64) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.traffic = api.Traffic{Up: up, Down: down}
+}
+
+// SetMemory sets the memory usage sent by the /memory stream
+func (f *FakeServer) SetMemory(inUse uint64) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.memory = api.Memory{InUse: inUse}
+}
+
+// AddConnection adds an active connection
+func (f *FakeServer) AddConnection(c api.Connection) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.connections = append(f.connections, c)
+}
+
+// ConnectionIDs returns IDs of connections that are not closed
+func (f *FakeServer) ConnectionIDs() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	ids := make([]string, 0, len(f.connections))
+	for _, c := range f.connections {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+// Now returns the active member of a group
+func (f *FakeServer) Now(group string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if p, ok := f.proxies[group]; ok {
+		return p.Now
+	}
+	return ""
+}
+
+// DisableGroupDelay makes /group/{name}/delay answer 404 like cores without the endpoint
+func (f *FakeServer) DisableGroupDelay() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.noGroup = true
+}
+
+// SetDelayTestTime sets how long a delay test of a single proxy takes
+func (f *FakeServer) SetDelayTestTime(d time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.testTime = d
+}
+
+// MaxConcurrentDelayTests returns the largest number of single proxy delay tests that ran at the same time
+func (f *FakeServer) MaxConcurrentDelayTests() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.maxInFlight
+}
+
+// Requests returns received requests as "METHOD escaped-path"
+func (f *FakeServer) Requests() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+func (f *FakeServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mutex.Lock()
+		f.requests = append(f.requests, r.Method+" "+r.URL.EscapedPath())
+		f.mutex.Unlock()
+		if f.Secret != "" && r.Header.Get("Authorization") != "Bearer "+f.Secret {
+			writeMessage(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (f *FakeServer) handleVersion(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, api.Version{Version: "sing-box 1.11.0 (fake)", Meta: true})
+}
+
+func (f *FakeServer) handleProxies(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"proxies": f.proxies})
+}
+
+func (f *FakeServer) handleProxy(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	p, ok := f.proxies[r.PathValue("name")]
+	if !ok {
+		writeMessage(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (f *FakeServer) handleSwitch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeMessage(w, http.StatusBadRequest, "Body invalid")
+		return
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	group, ok := f.proxies[r.PathValue("name")]
+	if !ok {
+		writeMessage(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+	if group.Type != "Selector" {
+		writeMessage(w, http.StatusBadRequest, "Must be a Selector")
+		return
+	}
+	for _, member := range group.All {
+		if member == req.Name {
+			group.Now = req.Name
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeMessage(w, http.StatusBadRequest, "Selector update error: not found")
+}
+
+func (f *FakeServer) handleDelay(w http.ResponseWriter, r *http.Request) {
+	if !validDelayQuery(r) {
+		writeMessage(w, http.StatusBadRequest, "Body invalid")
+		return
+	}
+	f.mutex.Lock()
+	f.inFlight++
+	f.maxInFlight = max(f.maxInFlight, f.inFlight)
+	testTime := f.testTime
+	f.mutex.Unlock()
+	time.Sleep(testTime)
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.inFlight--
+	name := r.PathValue("name")
+	if _, ok := f.proxies[name]; !ok {
+		writeMessage(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+	delay, ok := f.testDelay(name)
+	if !ok {
+		writeMessage(w, http.StatusGatewayTimeout, "Timeout")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int64{"delay": delay})
+}
+
+// handleGroupDelay tests all members of a group; like sing-box, failed members are left out of the result
+func (f *FakeServer) handleGroupDelay(w http.ResponseWriter, r *http.Request) {
+	if !validDelayQuery(r) {
+		writeMessage(w, http.StatusBadRequest, "Body invalid")
+		return
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.noGroup {
+		http.NotFound(w, r)
+		return
+	}
+	group, ok := f.proxies[r.PathValue("name")]
+	if !ok || group.All == nil {
+		writeMessage(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+	result := make(map[string]int64)
+	for _, name := range group.All {
+		if delay, ok := f.testDelay(name); ok {
+			result[name] = delay
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// testDelay records a delay test of a proxy in its history; must be called with the mutex held
+func (f *FakeServer) testDelay(name string) (int64, bool) {
+	p, ok := f.proxies[name]
+	delay := f.delays[name]
+	if !ok || delay <= 0 {
+		return 0, false
+	}
+	p.History = append(p.History, api.DelayHistory{Time: time.Now(), Delay: delay})
+	return delay, true
+}
+
+// validDelayQuery checks the url and timeout parameters of a delay test
+func validDelayQuery(r *http.Request) bool {
+	if r.URL.Query().Get("url") == "" {
+		return false
+	}
+	_, err := strconv.Atoi(r.URL.Query().Get("timeout"))
+	return err == nil
+}
+
+func (f *FakeServer) handleConfigs(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	writeJSON(w, http.StatusOK, f.configs)
+}
+
+// handlePatchConfigs changes the mode; like sing-box, the name is matched case-insensitively
+// and unknown modes are ignored
+func (f *FakeServer) handlePatchConfigs(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Mode string `json:"mode"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeMessage(w, http.StatusBadRequest, "Body invalid")
+		return
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for _, mode := range f.configs.ModeList {
+		if strings.EqualFold(mode, req.Mode) {
+			f.configs.Mode = mode
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *FakeServer) handleRuleProviders(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.providers == nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"providers": []string{}})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"providers": f.providers})
+}
+
+func (f *FakeServer) handleUpdateRuleProvider(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	p, ok := f.providers[r.PathValue("name")]
+	if !ok {
+		writeMessage(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+	p.UpdatedAt = time.Now()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *FakeServer) handleTraffic(w http.ResponseWriter, r *http.Request) {
+	f.stream(w, r, func() interface{} { return f.traffic })
+}
+
+func (f *FakeServer) handleMemory(w http.ResponseWriter, r *http.Request) {
+	f.stream(w, r, func() interface{} { return f.memory })
+}
+
+func (f *FakeServer) handleConnections(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	res := api.Connections{Connections: append([]api.Connection{}, f.connections...), Memory: f.memory.InUse}
+	for _, c := range f.connections {
+		res.UploadTotal += c.Upload
+		res.DownloadTotal += c.Download
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// handleCloseConnections closes one connection (like sing-box, unknown IDs are ignored) or all of them
+func (f *FakeServer) handleCloseConnections(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	id := r.PathValue("id")
+	kept := f.connections[:0]
+	for _, c := range f.connections {
+		if id != "" && c.ID != id {
+			kept = append(kept, c)
+		}
+	}
+	f.connections = kept
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// stream writes a sample every StreamInterval until the client disconnects.
+// Break streams with CloseClientConnections to simulate a core restart.
+func (f *FakeServer) stream(w http.ResponseWriter, r *http.Request, sample func() interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(w)
+	ticker := time.NewTicker(f.StreamInterval)
+	defer ticker.Stop()
+	for {
+		f.mutex.Lock()
+		value := sample()
+		f.mutex.Unlock()
+		if err := enc.Encode(value); err != nil {
+			return
+		}
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeMessage(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
@@ -1,13 +1,16 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"regexp"
 	"sort"
@@ -65,337 +68,361 @@ func LoadClashAPIConfig(configPath string) (baseURL, token string, err error) {
 	baseURL = "http://" + host
 	token = secret
 
-	log.Printf("Clash API loaded from config: %s / %s", baseURL, token)
+	log.Printf("Clash API loaded from config: %s", baseURL)
 	return baseURL, token, nil
 }
 
 const (
-	httpDialTimeoutSeconds    = 5
-	httpRequestTimeoutSeconds = 20 // Increased to 20 seconds for better reliability
+	// DefaultRequestTimeout limits requests whose context has no deadline
+	DefaultRequestTimeout = 20 * time.Second
+	// DefaultDelayTestURL and DefaultDelayTimeout are used by Delay when not specified
+	DefaultDelayTestURL = "http://www.gstatic.com/generate_204"
+	DefaultDelayTimeout = 5 * time.Second
+
+	httpDialTimeout    = 5 * time.Second
+	maxErrorBodyLength = 4096
+	logTimestampFormat = "2006-01-02 15:04:05"
 )
 
-// Global HTTP client with timeout for all HTTP requests
-var httpClient = &http.Client{
-	Timeout: time.Duration(httpRequestTimeoutSeconds) * time.Second,
+// defaultHTTPClient is shared by clients created with NewClient so connections are reused.
+// Timeouts come from request contexts.
+var defaultHTTPClient = &http.Client{
 	Transport: &http.Transport{
 		DialContext: (&net.Dialer{
-			Timeout: time.Duration(httpDialTimeoutSeconds) * time.Second,
+			Timeout: httpDialTimeout,
 		}).DialContext,
+		MaxIdleConnsPerHost: 4,
+		IdleConnTimeout:     90 * time.Second,
 	},
 }
 
-// TestAPIConnection attempts to connect to the Clash API.
-func TestAPIConnection(baseURL, token string, logFile *os.File) error {
-	logMessage := fmt.Sprintf("[%s] GET /version request started for API test.\n", time.Now().Format("2006-01-02 15:04:05"))
-	if logFile != nil {
-		fmt.Fprint(logFile, logMessage)
-	}
+// Client talks to the Clash API of a running sing-box
+type Client struct {
+	baseURL    string
+	secret     string
+	httpClient *http.Client
+	logger     io.Writer // API log (may be nil)
+	timeout    time.Duration
+}
 
-	url := fmt.Sprintf("%s/version", baseURL)
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(httpRequestTimeoutSeconds)*time.Second)
-	defer cancel()
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		if logFile != nil {
-			fmt.Fprint(logFile, fmt.Sprintf("[%s] Error creating API test request: %v\n", time.Now().Format("2006-01-02 15:04:05"), err))
-		}
-		return fmt.Errorf("failed to create API test request: %w", err)
+// NewClient creates a client for baseURL (e.g. "http://127.0.0.1:9090") authenticated with secret.
+// Requests and responses are logged to logger if it is not nil.
+func NewClient(baseURL, secret string, logger io.Writer) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		secret:     secret,
+		httpClient: defaultHTTPClient,
+		logger:     logger,
+		timeout:    DefaultRequestTimeout,
 	}
-	req.Header.Set("Authorization", "Bearer "+token)
+}
 
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		if logFile != nil {
-			fmt.Fprint(logFile, fmt.Sprintf("[%s] Error executing API test request: %v\n", time.Now().Format("2006-01-02 15:04:05"), err))
-		}
-		// Проверяем тип ошибки для более понятного сообщения
-		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-			return fmt.Errorf("network timeout: connection timed out")
-		}
-		if opErr, ok := err.(*net.OpError); ok && opErr.Op == "dial" {
-			return fmt.Errorf("network error: cannot connect to server")
-		}
-		return fmt.Errorf("failed to execute API test request: %w", err)
-	}
-	defer resp.Body.Close()
+// WithHTTPClient returns a copy of the client that uses httpClient for requests
+func (c *Client) WithHTTPClient(httpClient *http.Client) *Client {
+	clone := *c
+	clone.httpClient = httpClient
+	return &clone
+}
 
-	if logFile != nil {
-		fmt.Fprint(logFile, fmt.Sprintf("[%s] GET /version response status for API test: %d\n", time.Now().Format("2006-01-02 15:04:05"), resp.StatusCode))
-	}
+// BaseURL returns the Clash API address
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		if logFile != nil {
-			fmt.Fprint(logFile, fmt.Sprintf("[%s] Unexpected status code for API test: %d, body: %s\n", time.Now().Format("2006-01-02 15:04:05"), resp.StatusCode, string(bodyBytes)))
-		}
-		return fmt.Errorf("unexpected status code for API test: %d, body: %s", resp.StatusCode, string(bodyBytes))
-	}
-	if logFile != nil {
-		fmt.Fprint(logFile, fmt.Sprintf("[%s] Clash API connection successful.\n", time.Now().Format("2006-01-02 15:04:05")))
+// APIError is a non-successful response of the Clash API
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string // "message" field of the response or the raw body
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("Clash API %s %s: HTTP %d", e.Method, e.Path, e.StatusCode)
 	}
-	return nil
+	return fmt.Sprintf("Clash API %s %s: HTTP %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
 }
 
-// ProxyInfo holds the proxy name and traffic usage.
-type ProxyInfo struct {
-	Name    string
-	Traffic [2]int64 // [up, down]
-	Delay   int64    // Last known delay in ms
+// IsNotFound reports whether err is a 404 response (unknown proxy or group)
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
 }
 
-// GetProxiesInGroup retrieves proxies from a group, their traffic stats, and last delay from the Clash API.
-func GetProxiesInGroup(baseURL, token, groupName string, logFile *os.File) ([]ProxyInfo, string, error) {
-	// --- Helper function for logging ---
-	logMsg := func(format string, a ...interface{}) {
-		if logFile != nil {
-			timestamp := time.Now().Format("2006-01-02 15:04:05")
-			fmt.Fprintf(logFile, "[%s] "+format+"\n", append([]interface{}{timestamp}, a...)...)
-		}
-	}
+// Version is the response of GET /version
+type Version struct {
+	Version string `json:"version"`
+	Meta    bool   `json:"meta"`
+	Premium bool   `json:"premium"`
+}
 
-	logMsg("GetProxiesInGroup: Starting request for group '%s'", groupName)
+// DelayHistory is a delay test result of a proxy
+type DelayHistory struct {
+	Time  time.Time `json:"time"`
+	Delay int64     `json:"delay"`
+}
 
-	url := fmt.Sprintf("%s/proxies", baseURL)
-	logMsg("GetProxiesInGroup: Request URL: %s", url)
+// Proxy is an outbound or a group in the response of GET /proxies
+type Proxy struct {
+	Name    string         `json:"name"`
+	Type    string         `json:"type"` // "Selector", "URLTest", "VLESS", "Direct", ...
+	UDP     bool           `json:"udp"`
+	Now     string         `json:"now,omitempty"` // Active member of a group
+	All     []string       `json:"all,omitempty"` // Members of a group
+	History []DelayHistory `json:"history"`
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(httpRequestTimeoutSeconds)*time.Second)
-	defer cancel()
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		logMsg("GetProxiesInGroup: ERROR: Failed to create request: %v", err)
-		return nil, "", fmt.Errorf("failed to create /proxies request: %w", err)
-	}
-	req.Header.Set("Authorization", "Bearer "+token)
+// IsGroup reports whether the proxy is a group (it has members)
+func (p *Proxy) IsGroup() bool {
+	return p.All != nil
+}
 
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		logMsg("GetProxiesInGroup: ERROR: Failed to execute request: %v", err)
-		// Проверяем тип ошибки для более понятного сообщения
-		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-			return nil, "", fmt.Errorf("network timeout: connection timed out")
-		}
-		if opErr, ok := err.(*net.OpError); ok && opErr.Op == "dial" {
-			return nil, "", fmt.Errorf("network error: cannot connect to server")
-		}
-		return nil, "", fmt.Errorf("failed to execute /proxies request: %w", err)
+// LastDelay returns the delay of the latest test in ms (0 if there is none)
+func (p *Proxy) LastDelay() int64 {
+	if len(p.History) == 0 {
+		return 0
 	}
-	defer resp.Body.Close()
+	return p.History[len(p.History)-1].Delay
+}
 
-	logMsg("GetProxiesInGroup: Response status: %s", resp.Status)
+// DelayFailed is ProxyInfo.Delay of a proxy whose last delay test failed
+const DelayFailed int64 = -1
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		logMsg("GetProxiesInGroup: ERROR: Failed to read response body: %v", err)
-		return nil, "", fmt.Errorf("failed to read /proxies response: %w", err)
-	}
+// ProxyInfo holds a member of a proxy group with its last known delay.
+type ProxyInfo struct {
+	Name  string
+	Type  string
+	Delay int64 // Last known delay in ms (0 = not tested, DelayFailed = the last test failed)
+}
 
-	logMsg("GetProxiesInGroup: Raw response body:\n%s", string(body))
+// logf writes a line to the API log
+func (c *Client) logf(format string, a ...interface{}) {
+	if c.logger == nil {
+		return
+	}
+	fmt.Fprintf(c.logger, "[%s] "+format+"\n", append([]interface{}{time.Now().Format(logTimestampFormat)}, a...)...)
+}
 
-	var raw map[string]map[string]interface{}
-	if err := json.Unmarshal(body, &raw); err != nil {
-		logMsg("GetProxiesInGroup: ERROR: Failed to unmarshal JSON: %v", err)
-		return nil, "", fmt.Errorf("failed to unmarshal /proxies response: %w", err)
+// do sends a request to path (with escaped segments) and decodes a JSON response into out (if not nil)
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	if _, ok := ctx.Deadline(); !ok && c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
 	}
 
-	proxiesMap, ok := raw["proxies"]
-	if !ok {
-		logMsg("GetProxiesInGroup: ERROR: 'proxies' key not found in the response.")
-		return nil, "", fmt.Errorf("'proxies' key not found in the response")
+	resp, err := c.send(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		c.logf("Error decoding %s %s response: %v", method, path, err)
+		return fmt.Errorf("failed to decode %s %s response: %w", method, path, err)
 	}
+	return nil
+}
 
-	group, ok := proxiesMap[groupName].(map[string]interface{})
-	if !ok {
-		var availableGroups []string
-		for name := range proxiesMap {
-			if _, isGroup := proxiesMap[name].(map[string]interface{}); isGroup {
-				availableGroups = append(availableGroups, name)
-			}
+// send sends a request and returns a successful response; the caller must close its body.
+// Other status codes are returned as *APIError.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Response, error) {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s %s request: %w", method, path, err)
 		}
-		logMsg("GetProxiesInGroup: ERROR: Proxy group '%s' not found. Available groups: %v", groupName, availableGroups)
-		return nil, "", fmt.Errorf("proxy group '%s' not found", groupName)
+		reader = bytes.NewReader(data)
+		c.logf("%s %s request started with payload: %s", method, path, data)
+	} else {
+		c.logf("%s %s request started.", method, path)
 	}
 
-	rawList, ok := group["all"].([]interface{})
-	if !ok {
-		logMsg("GetProxiesInGroup: ERROR: Invalid or missing 'all' field for group '%s'", groupName)
-		return nil, "", fmt.Errorf("invalid or missing 'all' field for group %s", groupName)
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		c.logf("Error creating %s %s request: %v", method, path, err)
+		return nil, fmt.Errorf("failed to create %s %s request: %w", method, path, err)
+	}
+	if c.secret != "" {
+		req.Header.Set("Authorization", "Bearer "+c.secret)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
 
-	nowProxy, _ := group["now"].(string)
-	logMsg("GetProxiesInGroup: Current active proxy in group '%s' is '%s'", groupName, nowProxy)
+	started := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logf("Error executing %s %s request: %v", method, path, err)
+		return nil, networkError(err)
+	}
+	c.logf("%s %s response status: %d (%d ms)", method, path, resp.StatusCode, time.Since(started).Milliseconds())
 
-	var proxies []ProxyInfo
-	for _, v := range rawList {
-		name, ok := v.(string)
-		if !ok {
-			continue
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+		apiErr := &APIError{Method: method, Path: path, StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+		var msg struct {
+			Message string `json:"message"`
 		}
-		pi := ProxyInfo{Name: name}
-		if node, ok := proxiesMap[name].(map[string]interface{}); ok {
-			// Парсим трафик (остается на случай, если он появится)
-			if f, ok := node["up"].(float64); ok {
-				pi.Traffic[0] = int64(f)
-			}
-			if f, ok := node["down"].(float64); ok {
-				pi.Traffic[1] = int64(f)
-			}
-
-			// ИЗМЕНЕНО: Парсим последний известный пинг из истории
-			if history, ok := node["history"].([]interface{}); ok && len(history) > 0 {
-				if lastCheck, ok := history[0].(map[string]interface{}); ok {
-					if delay, ok := lastCheck["delay"].(float64); ok {
-						pi.Delay = int64(delay)
-					}
-				}
-			}
+		if json.Unmarshal(data, &msg) == nil && msg.Message != "" {
+			apiErr.Message = msg.Message
 		}
-		proxies = append(proxies, pi)
+		c.logf("Unexpected status code for %s %s: %d, body: %s", method, path, resp.StatusCode, data)
+		return nil, apiErr
 	}
-
-	sort.Slice(proxies, func(i, j int) bool {
-		return proxies[i].Name < proxies[j].Name
-	})
-
-	logMsg("GetProxiesInGroup: Successfully parsed %d proxies from group '%s'.", len(proxies), groupName)
-	return proxies, nowProxy, nil
+	return resp, nil
 }
 
-// SwitchProxy switches the active proxy within the specified group.
-func SwitchProxy(baseURL, token, group, proxy string, logFile *os.File) error {
-	payloadStr := fmt.Sprintf("{\"name\":\"%s\"}", proxy)
-	logMessage := fmt.Sprintf("[%s] PUT /proxies/%s request started with payload: %s\n", time.Now().Format("2006-01-02 15:04:05"), group, payloadStr)
-	if logFile != nil {
-		fmt.Fprint(logFile, logMessage)
+// networkError converts transport errors into short messages for the user.
+// Cancellation by the caller is returned as is (check with errors.Is(err, context.Canceled)).
+func networkError(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return err
 	}
-
-	url := fmt.Sprintf("%s/proxies/%s", baseURL, group)
-	payload := strings.NewReader(payloadStr)
-
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(httpRequestTimeoutSeconds)*time.Second)
-	defer cancel()
-	req, err := http.NewRequestWithContext(ctx, "PUT", url, payload)
-	if err != nil {
-		if logFile != nil {
-			fmt.Fprint(logFile, fmt.Sprintf("[%s] Error creating switch request for %s/%s: %v\n", time.Now().Format("2006-01-02 15:04:05"), group, proxy, err))
-		}
-		return fmt.Errorf("failed to create switch request: %w", err)
+	// Проверяем тип ошибки для более понятного сообщения
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("network timeout: connection timed out")
 	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return fmt.Errorf("network error: cannot connect to server")
+	}
+	return fmt.Errorf("Clash API request failed: %w", err)
+}
 
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
+// proxyPath returns /proxies/<name> with the name escaped (tags may contain spaces, "/" or emoji)
+func proxyPath(name string, rest ...string) string {
+	return "/proxies/" + url.PathEscape(name) + strings.Join(rest, "")
+}
 
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		if logFile != nil {
-			fmt.Fprint(logFile, fmt.Sprintf("[%s] Error executing switch request for %s/%s: %v\n", time.Now().Format("2006-01-02 15:04:05"), group, proxy, err))
-		}
-		// Проверяем тип ошибки для более понятного сообщения
-		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-			return fmt.Errorf("network timeout: connection timed out")
-		}
-		if opErr, ok := err.(*net.OpError); ok && opErr.Op == "dial" {
-			return fmt.Errorf("network error: cannot connect to server")
-		}
-		return fmt.Errorf("failed to execute switch request: %w", err)
+// Version requests the sing-box version; used to check that the API is reachable.
+func (c *Client) Version(ctx context.Context) (*Version, error) {
+	var v Version
+	if err := c.do(ctx, http.MethodGet, "/version", nil, nil, &v); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return &v, nil
+}
 
-	if logFile != nil {
-		fmt.Fprint(logFile, fmt.Sprintf("[%s] PUT /proxies/%s response status: %d\n", time.Now().Format("2006-01-02 15:04:05"), group, resp.StatusCode))
+// Proxies returns all outbounds and groups by name
+func (c *Client) Proxies(ctx context.Context) (map[string]*Proxy, error) {
+	var res struct {
+		Proxies map[string]*Proxy `json:"proxies"`
 	}
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		if logFile != nil {
-			fmt.Fprint(logFile, fmt.Sprintf("[%s] Unexpected status code for switch %s/%s: %d, body: %s\n", time.Now().Format("2006-01-02 15:04:05"), group, proxy, resp.StatusCode, string(bodyBytes)))
-		}
-		return fmt.Errorf("unexpected status code for switch: %d, body: %s", resp.StatusCode, string(bodyBytes))
+	if err := c.do(ctx, http.MethodGet, "/proxies", nil, nil, &res); err != nil {
+		return nil, err
 	}
-	if logFile != nil {
-		fmt.Fprint(logFile, fmt.Sprintf("[%s] Successfully switched group '%s' to '%s'.\n", time.Now().Format("2006-01-02 15:04:05"), group, proxy))
+	if res.Proxies == nil {
+		return nil, fmt.Errorf("'proxies' key not found in the response")
 	}
-	return nil
+	for name, p := range res.Proxies {
+		if p.Name == "" {
+			p.Name = name
+		}
+	}
+	return res.Proxies, nil
 }
 
-// GetDelay gets the delay for the specified proxy node.
-func GetDelay(baseURL, token, proxyName string, logFile *os.File) (int64, error) {
-	logMessage := fmt.Sprintf("[%s] GET /proxies/%s/delay request started.\n", time.Now().Format("2006-01-02 15:04:05"), proxyName)
-	if logFile != nil {
-		fmt.Fprint(logFile, logMessage)
+// Proxy returns a single outbound or group
+func (c *Client) Proxy(ctx context.Context, name string) (*Proxy, error) {
+	var p Proxy
+	if err := c.do(ctx, http.MethodGet, proxyPath(name), nil, nil, &p); err != nil {
+		return nil, err
 	}
+	return &p, nil
+}
 
-	url := fmt.Sprintf("%s/proxies/%s/delay?timeout=5000&url=http://www.gstatic.com/generate_204", baseURL, proxyName)
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(httpRequestTimeoutSeconds)*time.Second)
-	defer cancel()
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+// ProxiesInGroup returns members of a group sorted by name with their last delay, and the active member.
+func (c *Client) ProxiesInGroup(ctx context.Context, group string) ([]ProxyInfo, string, error) {
+	all, err := c.Proxies(ctx)
 	if err != nil {
-		if logFile != nil {
-			fmt.Fprint(logFile, fmt.Sprintf("[%s] Error creating delay request for %s: %v\n", time.Now().Format("2006-01-02 15:04:05"), proxyName, err))
+		return nil, "", err
+	}
+	g, ok := all[group]
+	if !ok || !g.IsGroup() {
+		var groups []string
+		for name, p := range all {
+			if p.IsGroup() {
+				groups = append(groups, name)
+			}
 		}
-		return 0, fmt.Errorf("failed to create delay request: %w", err)
+		sort.Strings(groups)
+		c.logf("ProxiesInGroup: Proxy group '%s' not found. Available groups: %v", group, groups)
+		return nil, "", fmt.Errorf("proxy group '%s' not found", group)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+token)
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		if logFile != nil {
-			fmt.Fprint(logFile, fmt.Sprintf("[%s] Error executing delay request for %s: %v\n", time.Now().Format("2006-01-02 15:04:05"), proxyName, err))
-		}
-		// Проверяем тип ошибки для более понятного сообщения
-		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-			return 0, fmt.Errorf("network timeout: connection timed out")
-		}
-		if opErr, ok := err.(*net.OpError); ok && opErr.Op == "dial" {
-			return 0, fmt.Errorf("network error: cannot connect to server")
+	proxies := make([]ProxyInfo, 0, len(g.All))
+	for _, name := range g.All {
+		info := ProxyInfo{Name: name}
+		if p, ok := all[name]; ok {
+			info.Type = p.Type
+			info.Delay = p.LastDelay()
 		}
-		return 0, fmt.Errorf("failed to execute delay request: %w", err)
+		proxies = append(proxies, info)
 	}
-	defer resp.Body.Close()
+	sort.Slice(proxies, func(i, j int) bool {
+		return proxies[i].Name < proxies[j].Name
+	})
+	c.logf("ProxiesInGroup: %d proxies in group '%s', active '%s'.", len(proxies), group, g.Now)
+	return proxies, g.Now, nil
+}
 
-	if logFile != nil {
-		fmt.Fprint(logFile, fmt.Sprintf("[%s] GET /proxies/%s/delay response status: %d\n", time.Now().Format("2006-01-02 15:04:05"), proxyName, resp.StatusCode))
-	}
+// SwitchProxy switches the active proxy within a selector group.
+func (c *Client) SwitchProxy(ctx context.Context, group, proxy string) error {
+	return c.do(ctx, http.MethodPut, proxyPath(group), nil, map[string]string{"name": proxy}, nil)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		if logFile != nil {
-			fmt.Fprint(logFile, fmt.Sprintf("[%s] Unexpected status code for delay %s: %d, body: %s\n", time.Now().Format("2006-01-02 15:04:05"), proxyName, resp.StatusCode, string(bodyBytes)))
-		}
-		return 0, fmt.Errorf("unexpected status code for delay: %d, body: %s", resp.StatusCode, string(bodyBytes))
+// Delay tests a proxy against testURL (DefaultDelayTestURL if empty) and returns the delay in ms.
+// timeout is the test timeout passed to sing-box (DefaultDelayTimeout if 0).
+func (c *Client) Delay(ctx context.Context, proxy, testURL string, timeout time.Duration) (int64, error) {
+	if testURL == "" {
+		testURL = DefaultDelayTestURL
 	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		if logFile != nil {
-			fmt.Fprint(logFile, fmt.Sprintf("[%s] Error reading response body for delay %s: %v\n", time.Now().Format("2006-01-02 15:04:05"), proxyName, err))
-		}
-		return 0, fmt.Errorf("failed to read response body for delay: %w", err)
+	if timeout <= 0 {
+		timeout = DefaultDelayTimeout
 	}
+	query := url.Values{}
+	query.Set("timeout", fmt.Sprint(timeout.Milliseconds()))
+	query.Set("url", testURL)
 
-	if logFile != nil {
-		fmt.Fprint(logFile, fmt.Sprintf("[%s] GET /proxies/%s/delay response body: %s\n", time.Now().Format("2006-01-02 15:04:05"), proxyName, string(body)))
+	var res struct {
+		Delay *int64 `json:"delay"`
 	}
-
-	var data map[string]interface{}
-	if err := json.Unmarshal(body, &data); err != nil {
-		if logFile != nil {
-			fmt.Fprint(logFile, fmt.Sprintf("[%s] Error unmarshalling JSON for delay %s: %v\n", time.Now().Format("2006-01-02 15:04:05"), proxyName, err))
-		}
-		return 0, fmt.Errorf("failed to unmarshal JSON for delay: %w", err)
+	if err := c.do(ctx, http.MethodGet, proxyPath(proxy, "/delay"), query, nil, &res); err != nil {
+		return 0, err
 	}
-
-	delay, ok := data["delay"].(float64)
-	if !ok {
-		if logFile != nil {
-			fmt.Fprint(logFile, fmt.Sprintf("[%s] Unexpected response structure for delay %s, 'delay' field missing or wrong type\n", time.Now().Format("2006-01-02 15:04:05"), proxyName))
-		}
-		return 0, fmt.Errorf("unexpected response structure, 'delay' field missing or wrong type")
+	if res.Delay == nil {
+		return 0, fmt.Errorf("unexpected response structure, 'delay' field missing")
 	}
+	return *res.Delay, nil
+}
 
-	if logFile != nil {
-		fmt.Fprint(logFile, fmt.Sprintf("[%s] Successfully got delay for %s: %d ms.\n", time.Now().Format("2006-01-02 15:04:05"), proxyName, int64(delay)))
+// GroupDelay tests all members of a group at once (GET /group/{name}/delay) and returns delays in ms by member.
+// Members that failed the test are missing or have delay 0. Old cores without the endpoint answer 404 (see IsNotFound).
+func (c *Client) GroupDelay(ctx context.Context, group, testURL string, timeout time.Duration) (map[string]int64, error) {
+	if testURL == "" {
+		testURL = DefaultDelayTestURL
+	}
+	if timeout <= 0 {
+		timeout = DefaultDelayTimeout
 	}
+	query := url.Values{}
+	query.Set("timeout", fmt.Sprint(timeout.Milliseconds()))
+	query.Set("url", testURL)
 
-	return int64(delay), nil
+	res := make(map[string]int64)
+	if err := c.do(ctx, http.MethodGet, "/group/"+url.PathEscape(group)+"/delay", query, nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
 }
@@ -0,0 +1,197 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"singbox-launcher/api"
+	"singbox-launcher/api/apitest"
+)
+
+// newFakeServer creates a fake Clash API with a selector group of proxies with unusual names
+func newFakeServer(t *testing.T) *apitest.FakeServer {
+	t.Helper()
+	fake := apitest.NewFakeServer("test-secret")
+	t.Cleanup(fake.Close)
+	fake.AddProxy("de 1", "VLESS", 42)
+	fake.AddProxy("nl/2", "Trojan", 0)
+	fake.AddProxy("🇯🇵 Tokyo", "Shadowsocks", 120)
+	fake.AddGroup("proxy out", "Selector", "de 1", "nl/2", "🇯🇵 Tokyo")
+	fake.AddGroup("auto", "URLTest", "de 1")
+	return fake
+}
+
+// TestClientProxies tests group listing, switching and delay tests with escaped names
+func TestClientProxies(t *testing.T) {
+	fake := newFakeServer(t)
+	var logBuf bytes.Buffer
+	client := api.NewClient(fake.URL, fake.Secret, &logBuf).WithHTTPClient(fake.Server.Client())
+	ctx := context.Background()
+
+	if v, err := client.Version(ctx); err != nil || !strings.HasPrefix(v.Version, "sing-box") {
+		t.Fatalf("Version = %+v, %v", v, err)
+	}
+
+	delay, err := client.Delay(ctx, "🇯🇵 Tokyo", "", 0)
+	if err != nil || delay != 120 {
+		t.Fatalf("Delay = %d, %v", delay, err)
+	}
+	if _, err := client.Delay(ctx, "nl/2", "", time.Second); err == nil {
+		t.Error("Expected a timeout error for nl/2")
+	} else if !strings.Contains(err.Error(), "Timeout") {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	delays, err := client.GroupDelay(ctx, "proxy out", "", time.Second)
+	if err != nil || len(delays) != 2 || delays["de 1"] != 42 || delays["nl/2"] != 0 {
+		t.Fatalf("GroupDelay = %v, %v", delays, err)
+	}
+	fake.DisableGroupDelay()
+	if _, err := client.GroupDelay(ctx, "proxy out", "", 0); !api.IsNotFound(err) {
+		t.Errorf("GroupDelay without the endpoint = %v, want not found", err)
+	}
+
+	if err := client.SwitchProxy(ctx, "proxy out", "nl/2"); err != nil {
+		t.Fatalf("SwitchProxy: %v", err)
+	}
+	if now := fake.Now("proxy out"); now != "nl/2" {
+		t.Errorf("Active proxy = %q", now)
+	}
+
+	proxies, now, err := client.ProxiesInGroup(ctx, "proxy out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if now != "nl/2" || len(proxies) != 3 {
+		t.Fatalf("ProxiesInGroup = %+v, %q", proxies, now)
+	}
+	// Sorted by name; the delay comes from the history of the delay test
+	if proxies[0].Name != "de 1" || proxies[2].Name != "🇯🇵 Tokyo" || proxies[2].Delay != 120 || proxies[2].Type != "Shadowsocks" {
+		t.Errorf("Unexpected proxies %+v", proxies)
+	}
+
+	if _, _, err := client.ProxiesInGroup(ctx, "de 1"); err == nil {
+		t.Error("Expected an error for a proxy that is not a group")
+	}
+	if _, err := client.Proxy(ctx, "missing"); !api.IsNotFound(err) {
+		t.Errorf("Proxy(missing) = %v, want not found", err)
+	}
+
+	// Names must reach the server as a single escaped path segment
+	want := []string{"GET /proxies/%F0%9F%87%AF%F0%9F%87%B5%20Tokyo/delay", "GET /proxies/nl%2F2/delay", "GET /group/proxy%20out/delay", "PUT /proxies/proxy%20out"}
+	requests := strings.Join(fake.Requests(), "\n")
+	for _, w := range want {
+		if !strings.Contains(requests, w) {
+			t.Errorf("Request %q not found in:\n%s", w, requests)
+		}
+	}
+	if strings.Contains(logBuf.String(), fake.Secret) {
+		t.Error("The secret must not be logged")
+	}
+	if !strings.Contains(logBuf.String(), "PUT /proxies/proxy%20out request started") {
+		t.Errorf("Unexpected API log:\n%s", logBuf.String())
+	}
+}
+
+// TestClientErrors tests authentication errors, cancellation and unreachable servers
+func TestClientErrors(t *testing.T) {
+	fake := newFakeServer(t)
+
+	var apiErr *api.APIError
+	_, err := api.NewClient(fake.URL, "wrong", nil).Version(context.Background())
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 401 || apiErr.Message != "Unauthorized" {
+		t.Errorf("Wrong secret: got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := fake.Client().Proxies(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Cancelled context: got %v", err)
+	}
+
+	url := fake.URL
+	fake.Close()
+	if _, err := api.NewClient(url, "", nil).Version(context.Background()); err == nil || !strings.Contains(err.Error(), "cannot connect") {
+		t.Errorf("Closed server: got %v", err)
+	}
+}
+
+// TestClientConfigs tests reading and switching the routing mode
+func TestClientConfigs(t *testing.T) {
+	fake := newFakeServer(t)
+	client := fake.Client()
+	ctx := context.Background()
+
+	configs, err := client.Configs(ctx)
+	if err != nil || configs.Mode != "Rule" || len(configs.ModeList) != 3 {
+		t.Fatalf("Configs = %+v, %v", configs, err)
+	}
+	if err := client.SetMode(ctx, "global"); err != nil {
+		t.Fatalf("SetMode: %v", err)
+	}
+	if fake.Mode() != "Global" {
+		t.Errorf("Mode = %q, want Global", fake.Mode())
+	}
+	// Unknown modes are ignored by sing-box
+	if err := client.SetMode(ctx, "Fastest"); err != nil || fake.Mode() != "Global" {
+		t.Errorf("Unknown mode: %v, mode = %q", err, fake.Mode())
+	}
+}
+
+// TestClientRuleProviders tests listing and refreshing rule providers
+func TestClientRuleProviders(t *testing.T) {
+	fake := newFakeServer(t)
+	client := fake.Client()
+	ctx := context.Background()
+
+	// sing-box answers with an empty array
+	if providers, err := client.RuleProviders(ctx); err != nil || len(providers) != 0 {
+		t.Fatalf("RuleProviders = %v, %v", providers, err)
+	}
+
+	updated := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	fake.AddRuleProvider(api.RuleProvider{Name: "geosite ads", Type: "Rule", VehicleType: "HTTP", Behavior: "domain", RuleCount: 120, UpdatedAt: updated})
+	providers, err := client.RuleProviders(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := providers["geosite ads"]; p.RuleCount != 120 || !p.UpdatedAt.Equal(updated) || p.VehicleType != "HTTP" {
+		t.Errorf("Unexpected provider %+v", p)
+	}
+	if err := client.UpdateRuleProvider(ctx, "geosite ads"); err != nil {
+		t.Fatalf("UpdateRuleProvider: %v", err)
+	}
+	if !fake.RuleProviderUpdatedAt("geosite ads").After(updated) {
+		t.Error("The provider was not updated")
+	}
+	if err := client.UpdateRuleProvider(ctx, "missing"); !api.IsNotFound(err) {
+		t.Errorf("Missing provider: got %v", err)
+	}
+}
+
+// TestLoadClashAPIConfig tests reading the Clash API settings from a JSONC config
+func TestLoadClashAPIConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	config := `{
+  // comment
+  "experimental": {"clash_api": {"external_controller": "127.0.0.1:9090", "secret": "abc",},},
+}`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	baseURL, token, err := api.LoadClashAPIConfig(path)
+	if err != nil || baseURL != "http://127.0.0.1:9090" || token != "abc" {
+		t.Errorf("LoadClashAPIConfig = %q, %q, %v", baseURL, token, err)
+	}
+
+	os.WriteFile(path, []byte(`{"experimental": {}}`), 0644)
+	if _, _, err := api.LoadClashAPIConfig(path); err == nil {
+		t.Error("Expected an error without clash_api")
+	}
+}
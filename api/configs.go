@@ -0,0 +1,26 @@
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// Configs is the part of GET /configs used by the launcher
+type Configs struct {
+	Mode     string   `json:"mode"`                // Routing mode: "Rule", "Global", "Direct" or a custom clash_mode
+	ModeList []string `json:"mode-list,omitempty"` // Modes known to the core
+}
+
+// Configs returns the running configuration of the core
+func (c *Client) Configs(ctx context.Context) (*Configs, error) {
+	var res Configs
+	if err := c.do(ctx, http.MethodGet, "/configs", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// SetMode switches the routing mode (PATCH /configs); rules with clash_mode match the active mode
+func (c *Client) SetMode(ctx context.Context, mode string) error {
+	return c.do(ctx, http.MethodPatch, "/configs", nil, map[string]string{"mode": mode}, nil)
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ConnectionMetadata describes where a connection comes from and goes to
+type ConnectionMetadata struct {
+	Network         string `json:"network"` // "tcp" or "udp"
+	Type            string `json:"type"`    // Inbound, e.g. "tun/tun-in"
+	SourceIP        string `json:"sourceIP"`
+	SourcePort      string `json:"sourcePort"`
+	DestinationIP   string `json:"destinationIP"`
+	DestinationPort string `json:"destinationPort"`
+	Host            string `json:"host"` // Domain from sniffing or the request, may be empty
+	DNSMode         string `json:"dnsMode"`
+	ProcessPath     string `json:"processPath"`
+}
+
+// Connection is an active connection of GET /connections
+type Connection struct {
+	ID          string             `json:"id"`
+	Metadata    ConnectionMetadata `json:"metadata"`
+	Upload      int64              `json:"upload"`   // Bytes sent so far
+	Download    int64              `json:"download"` // Bytes received so far
+	Start       time.Time          `json:"start"`
+	Chains      []string           `json:"chains"` // Outbounds from the final one to the first group
+	Rule        string             `json:"rule"`
+	RulePayload string             `json:"rulePayload"`
+}
+
+// Host returns the domain of the connection or the destination IP if there is none
+func (c *Connection) Host() string {
+	if c.Metadata.Host != "" {
+		return c.Metadata.Host
+	}
+	return c.Metadata.DestinationIP
+}
+
+// Destination returns the destination IP and port
+func (c *Connection) Destination() string {
+	host := c.Metadata.DestinationIP
+	if host == "" {
+		host = c.Metadata.Host
+	}
+	return net.JoinHostPort(host, c.Metadata.DestinationPort)
+}
+
+// Process returns the file name of the process that opened the connection ("" if unknown)
+func (c *Connection) Process() string {
+	path := c.Metadata.ProcessPath
+	if i := strings.LastIndexAny(path, `/\`); i >= 0 {
+		path = path[i+1:]
+	}
+	return path
+}
+
+// Chain returns the outbounds in routing order: "proxy-out → de-1"
+func (c *Connection) Chain() string {
+	chain := make([]string, len(c.Chains))
+	for i, outbound := range c.Chains {
+		chain[len(c.Chains)-1-i] = outbound
+	}
+	return strings.Join(chain, " → ")
+}
+
+// Connections is the response of GET /connections
+type Connections struct {
+	DownloadTotal int64        `json:"downloadTotal"`
+	UploadTotal   int64        `json:"uploadTotal"`
+	Connections   []Connection `json:"connections"`
+	Memory        uint64       `json:"memory"`
+}
+
+// Connections returns active connections and total traffic
+func (c *Client) Connections(ctx context.Context) (*Connections, error) {
+	var res Connections
+	if err := c.do(ctx, http.MethodGet, "/connections", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// CloseConnection closes a connection by ID
+func (c *Client) CloseConnection(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/connections/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// CloseAllConnections closes all active connections
+func (c *Client) CloseAllConnections(ctx context.Context) error {
+	return c.do(ctx, http.MethodDelete, "/connections", nil, nil, nil)
+}
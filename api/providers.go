@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RuleProvider is a rule provider (rule-set) from GET /providers/rules
+type RuleProvider struct {
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`        // "Rule"
+	VehicleType string    `json:"vehicleType"` // "HTTP", "File", "Inline"
+	Behavior    string    `json:"behavior"`    // "domain", "ipcidr", "classical"
+	Format      string    `json:"format,omitempty"`
+	RuleCount   int       `json:"ruleCount"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// RuleProviders returns rule providers of the core by name.
+// sing-box answers with an empty list: its rule-sets are not exposed as providers.
+func (c *Client) RuleProviders(ctx context.Context) (map[string]RuleProvider, error) {
+	var res struct {
+		Providers json.RawMessage `json:"providers"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/providers/rules", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	providers := make(map[string]RuleProvider)
+	// Clash cores return an object by name, sing-box returns an empty array
+	if len(res.Providers) == 0 || res.Providers[0] != '{' {
+		return providers, nil
+	}
+	if err := json.Unmarshal(res.Providers, &providers); err != nil {
+		c.logf("Error decoding rule providers: %v", err)
+		return nil, fmt.Errorf("failed to decode rule providers: %w", err)
+	}
+	for name, p := range providers {
+		if p.Name == "" {
+			p.Name = name
+			providers[name] = p
+		}
+	}
+	return providers, nil
+}
+
+// UpdateRuleProvider makes the core download a rule provider again (PUT /providers/rules/{name})
+func (c *Client) UpdateRuleProvider(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodPut, "/providers/rules/"+url.PathEscape(name), nil, nil, nil)
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Traffic is a sample of the /traffic stream: bytes per second through all connections
+type Traffic struct {
+	Up   int64 `json:"up"`
+	Down int64 `json:"down"`
+}
+
+// Memory is a sample of the /memory stream: memory used by sing-box in bytes
+type Memory struct {
+	InUse   uint64 `json:"inuse"`
+	OSLimit uint64 `json:"oslimit"`
+}
+
+// StreamTraffic calls fn for every sample of the /traffic stream (one per second)
+// until ctx is cancelled or the connection breaks. It never returns nil.
+func (c *Client) StreamTraffic(ctx context.Context, fn func(Traffic)) error {
+	return c.stream(ctx, "/traffic", func(dec *json.Decoder) error {
+		var t Traffic
+		if err := dec.Decode(&t); err != nil {
+			return err
+		}
+		fn(t)
+		return nil
+	})
+}
+
+// StreamMemory calls fn for every sample of the /memory stream (one per second)
+// until ctx is cancelled or the connection breaks. It never returns nil.
+func (c *Client) StreamMemory(ctx context.Context, fn func(Memory)) error {
+	return c.stream(ctx, "/memory", func(dec *json.Decoder) error {
+		var m Memory
+		if err := dec.Decode(&m); err != nil {
+			return err
+		}
+		fn(m)
+		return nil
+	})
+}
+
+// stream reads a stream of JSON objects from path; next decodes one object.
+// Streaming requests have no timeout, they end with ctx.
+func (c *Client) stream(ctx context.Context, path string, next func(*json.Decoder) error) error {
+	resp, err := c.send(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		if err := next(dec); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			c.logf("GET %s stream closed: %v", path, err)
+			return fmt.Errorf("Clash API %s stream closed: %w", path, err)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Package cli implements headless subcommands of the launcher (no Fyne window is created).
+// It is used on servers and in scripts/cron:
+//
+//	singbox-launcher [-json] [-profile name] <command> [args]
+//
+// Commands share core services with the GUI and report results either as text or as JSON
+// (with -json). The exit code tells the outcome, see Exit* constants.
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"singbox-launcher/core"
+)
+
+// Exit codes of CLI commands
+const (
+	ExitOK            = 0 // Success (for status: sing-box is running)
+	ExitError         = 1 // General error
+	ExitUsage         = 2 // Invalid command line
+	ExitNotRunning    = 3 // sing-box is not running (status, stop, proxies, rulesets refresh)
+	ExitNetwork       = 4 // Network error while updating subscriptions
+	ExitAuth          = 5 // Subscription rejected the request (expired / unauthorized)
+	ExitInvalidConfig = 6 // Config or @ParserConfig is invalid
+)
+
+// command describes a CLI subcommand
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(env *environment, args []string) int
+}
+
+// commands lists all subcommands in the order shown in usage
+var commands = []command{
+	{"update", "update", "download subscriptions and regenerate config.json (hot-reloads a running sing-box)", runUpdate},
+	{"start", "start", "validate config and start sing-box in the background", runStart},
+	{"stop", "stop", "stop a running sing-box", runStop},
+	{"status", "status", "show sing-box and config status (exit code 3 if not running)", runStatus},
+	{"check-config", "check-config [path]", "validate config.json with sing-box check", runCheckConfig},
+	{"core", "core install <version|latest>", "download and install the sing-box core", runCore},
+	{"proxies", "proxies list [-group name] | proxies switch [-group name] <proxy>", "list or switch proxies via Clash API", runProxies},
+	{"rulesets", "rulesets list | rulesets refresh [-reload] [tag...]", "list rule-sets or make the running core download them again (-reload: sing-box reloads its whole config)", runRuleSets},
+}
+
+// internalCommands are started by the launcher itself and not shown in usage
+var internalCommands = []command{
+	{core.LogPumpCommand, core.LogPumpCommand + " <config>", "write output of a detached sing-box (stdin) to its log", runLogPump},
+}
+
+// options are flags accepted both before and after the command name
+type options struct {
+	json    bool
+	profile string
+}
+
+// environment is passed to commands
+type environment struct {
+	opts   options
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// addCommonFlags registers flags shared by all commands
+func addCommonFlags(fs *flag.FlagSet, opts *options) {
+	fs.BoolVar(&opts.json, "json", opts.json, "print results as JSON")
+	fs.StringVar(&opts.profile, "profile", opts.profile, "use this profile instead of the active one")
+}
+
+// findCommand returns the command with the given name
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	for i := range internalCommands {
+		if internalCommands[i].name == name {
+			return &internalCommands[i]
+		}
+	}
+	return nil
+}
+
+// IsCommand reports whether args (without program name) invoke a CLI subcommand
+// rather than the GUI (GUI flags like -start and -tray are not CLI flags).
+func IsCommand(args []string) bool {
+	var opts options
+	fs := flag.NewFlagSet("singbox-launcher", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	addCommonFlags(fs, &opts)
+	if err := fs.Parse(args); err != nil {
+		return false
+	}
+	return fs.NArg() > 0 && (findCommand(fs.Arg(0)) != nil || fs.Arg(0) == "help")
+}
+
+// Run executes a CLI subcommand and returns the process exit code
+func Run(args []string) int {
+	return run(args, os.Stdout, os.Stderr)
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	env := &environment{stdout: stdout, stderr: stderr}
+
+	fs := flag.NewFlagSet("singbox-launcher", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { printUsage(stderr) }
+	addCommonFlags(fs, &env.opts)
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if fs.NArg() == 0 || fs.Arg(0) == "help" {
+		printUsage(stdout)
+		if fs.NArg() == 0 {
+			return ExitUsage
+		}
+		return ExitOK
+	}
+
+	cmd := findCommand(fs.Arg(0))
+	if cmd == nil {
+		fmt.Fprintf(stderr, "Unknown command: %s\n\n", fs.Arg(0))
+		printUsage(stderr)
+		return ExitUsage
+	}
+	return cmd.run(env, fs.Args()[1:])
+}
+
+// printUsage prints the list of commands
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: singbox-launcher [-json] [-profile name] <command> [args]")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %s\n      %s\n", cmd.usage, cmd.summary)
+	}
+	fmt.Fprintln(w, "\nWithout a command the GUI is started (flags: -start, -tray).")
+	fmt.Fprintf(w, "\nExit codes: %d ok, %d error, %d usage, %d not running, %d network error, %d subscription auth error, %d invalid config\n",
+		ExitOK, ExitError, ExitUsage, ExitNotRunning, ExitNetwork, ExitAuth, ExitInvalidConfig)
+}
+
+// parseFlags parses command flags (common flags are accepted after the command name too)
+func (env *environment) parseFlags(name string, args []string, setup func(fs *flag.FlagSet)) (*flag.FlagSet, bool) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	addCommonFlags(fs, &env.opts)
+	if setup != nil {
+		setup(fs)
+	}
+	if err := fs.Parse(args); err != nil {
+		return fs, false
+	}
+	return fs, true
+}
+
+// usageError reports invalid arguments of a command
+func (env *environment) usageError(format string, a ...interface{}) int {
+	return env.fail(ExitUsage, fmt.Errorf(format, a...))
+}
+
+// result prints a successful result: JSON object or text lines
+func (env *environment) result(code int, value interface{}, text string) int {
+	if env.opts.json {
+		enc := json.NewEncoder(env.stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(value); err != nil {
+			fmt.Fprintf(env.stderr, "Error: failed to encode result: %v\n", err)
+			return ExitError
+		}
+		return code
+	}
+	if text != "" {
+		fmt.Fprintln(env.stdout, strings.TrimRight(text, "\n"))
+	}
+	return code
+}
+
+// errorResult is the JSON output of a failed command
+type errorResult struct {
+	OK       bool   `json:"ok"`
+	Error    string `json:"error"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// fail prints an error and returns the exit code
+func (env *environment) fail(code int, err error) int {
+	if env.opts.json {
+		return env.result(code, errorResult{OK: false, Error: err.Error(), ExitCode: code}, "")
+	}
+	fmt.Fprintf(env.stderr, "Error: %v\n", err)
+	return code
+}
+
+// progress prints progress messages to stderr in text mode (JSON output stays clean)
+func (env *environment) progress(format string, a ...interface{}) {
+	if !env.opts.json {
+		fmt.Fprintf(env.stderr, format+"\n", a...)
+	}
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"singbox-launcher/core"
+)
+
+// TestIsCommand tests detection of CLI mode vs GUI flags
+func TestIsCommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"-start"}, false},
+		{[]string{"-tray", "-start"}, false},
+		{[]string{"status"}, true},
+		{[]string{"-json", "status"}, true},
+		{[]string{"-profile", "work", "update"}, true},
+		{[]string{"proxies", "list", "-group", "proxy-out"}, true},
+		{[]string{"rulesets", "refresh", "ads-all"}, true},
+		{[]string{"help"}, true},
+		{[]string{"unknown"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsCommand(tt.args); got != tt.want {
+			t.Errorf("IsCommand(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+// TestRunUsageErrors tests exit codes of invalid command lines (no controller is created)
+func TestRunUsageErrors(t *testing.T) {
+	tests := [][]string{
+		{},
+		{"unknown"},
+		{"core"},
+		{"core", "remove", "1.0.0"},
+		{"core", "install"},
+		{"proxies"},
+		{"proxies", "switch"},
+		{"rulesets"},
+		{"rulesets", "list", "extra"},
+		{"status", "extra"},
+		{"-badflag", "status"},
+	}
+
+	for _, args := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, &stdout, &stderr); code != ExitUsage {
+			t.Errorf("run(%v) = %d, want %d (stderr: %s)", args, code, ExitUsage, stderr.String())
+		}
+	}
+}
+
+// TestRunJSONError tests JSON error output
+func TestRunJSONError(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-json", "proxies", "list", "extra"}, &stdout, &stderr)
+	if code != ExitUsage {
+		t.Fatalf("Expected exit code %d, got %d", ExitUsage, code)
+	}
+
+	var res errorResult
+	if err := json.Unmarshal(stdout.Bytes(), &res); err != nil {
+		t.Fatalf("Output is not JSON: %v\n%s", err, stdout.String())
+	}
+	if res.OK || res.ExitCode != ExitUsage || !strings.Contains(res.Error, "usage") {
+		t.Errorf("Unexpected error result: %+v", res)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("Expected empty stderr in JSON mode, got: %s", stderr.String())
+	}
+}
+
+// TestExitCodes tests mapping of errors to exit codes
+func TestExitCodes(t *testing.T) {
+	networkErr := fmt.Errorf("fetch: %w", &core.SubscriptionHTTPError{StatusCode: 503})
+	authErr := fmt.Errorf("fetch: %w", &core.SubscriptionHTTPError{StatusCode: 403})
+
+	if code := exitCodeForUpdateError(networkErr); code != ExitNetwork {
+		t.Errorf("Network error: got %d, want %d", code, ExitNetwork)
+	}
+	if code := exitCodeForUpdateError(authErr); code != ExitAuth {
+		t.Errorf("Auth error: got %d, want %d", code, ExitAuth)
+	}
+	if code := exitCodeForUpdateError(errors.New("disk full")); code != ExitError {
+		t.Errorf("Unknown error: got %d, want %d", code, ExitError)
+	}
+
+	// Failures of an update forwarded to the running launcher keep their exit codes
+	if code := exitCodeForUpdateKind(core.UpdateFailureAuth.String()); code != ExitAuth {
+		t.Errorf("Forwarded auth error: got %d, want %d", code, ExitAuth)
+	}
+	if code := exitCodeForUpdateKind(""); code != ExitError {
+		t.Errorf("Forwarded error without kind: got %d, want %d", code, ExitError)
+	}
+
+	checkErr := fmt.Errorf("start: %w", &core.ConfigCheckError{Output: "unknown field"})
+	if code := exitCodeForCheckError(checkErr); code != ExitInvalidConfig {
+		t.Errorf("Config check error: got %d, want %d", code, ExitInvalidConfig)
+	}
+	if code := exitCodeForCheckError(errors.New("sing-box not found")); code != ExitError {
+		t.Errorf("Other error: got %d, want %d", code, ExitError)
+	}
+}
@@ -0,0 +1,658 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"singbox-launcher/control"
+	"singbox-launcher/core"
+	"singbox-launcher/internal/platform"
+)
+
+// controller creates a headless controller for the selected profile
+func (env *environment) controller() (*core.AppController, int) {
+	ac, err := core.NewHeadlessAppController(env.opts.profile)
+	if err != nil {
+		return nil, env.fail(ExitError, fmt.Errorf("failed to initialize: %w", err))
+	}
+	return ac, ExitOK
+}
+
+// exitCodeForUpdateError maps a subscription update error to an exit code
+func exitCodeForUpdateError(err error) int {
+	switch core.ClassifyUpdateError(err) {
+	case core.UpdateFailureNetwork:
+		return ExitNetwork
+	case core.UpdateFailureAuth:
+		return ExitAuth
+	case core.UpdateFailureParse, core.UpdateFailureValidation:
+		return ExitInvalidConfig
+	default:
+		return ExitError
+	}
+}
+
+// exitCodeForCheckError maps a config check error to an exit code
+func exitCodeForCheckError(err error) int {
+	var checkErr *core.ConfigCheckError
+	if errors.As(err, &checkErr) {
+		return ExitInvalidConfig
+	}
+	return ExitError
+}
+
+// updateResult is the output of the update command
+type updateResult struct {
+	OK          bool   `json:"ok"`
+	Profile     string `json:"profile"`
+	ConfigPath  string `json:"config_path"`
+	Running     bool   `json:"running"`
+	Reloaded    bool   `json:"reloaded"`
+	ReloadError string `json:"reload_error,omitempty"`
+	Forwarded   bool   `json:"forwarded,omitempty"` // Updated by the running launcher
+}
+
+// forwardedUpdateTimeout limits an update done by the running launcher (all subscriptions are downloaded)
+const forwardedUpdateTimeout = 10 * time.Minute
+
+// runUpdate downloads all subscriptions and regenerates config.json. The command holds the instance lock
+// while it writes config.json and logs; if a launcher is running, the update is done by it
+// (control API /v1/update), so it does not interleave with its auto-update and log rotation.
+func runUpdate(env *environment, args []string) int {
+	fs, ok := env.parseFlags("update", args, nil)
+	if !ok {
+		return ExitUsage
+	}
+	if fs.NArg() > 0 {
+		return env.usageError("update takes no arguments")
+	}
+	execDir, err := core.ExecutableDir()
+	if err != nil {
+		return env.fail(ExitError, err)
+	}
+	if err := platform.EnsureDirectories(execDir); err != nil {
+		return env.fail(ExitError, err)
+	}
+	lock, err := platform.LockInstance(platform.GetInstanceLockPath(execDir))
+	if errors.Is(err, platform.ErrInstanceLocked) {
+		return forwardUpdate(env, execDir)
+	}
+	if err != nil {
+		return env.fail(ExitError, err)
+	}
+	defer lock.Close()
+
+	ac, code := env.controller()
+	if ac == nil {
+		return code
+	}
+	if _, err := os.Stat(ac.GetConfigPath()); err != nil {
+		return env.fail(ExitInvalidConfig, fmt.Errorf("config not found at %s", ac.GetConfigPath()))
+	}
+
+	unsubscribe := ac.Events.Subscribe(func(e core.Event) {
+		if e.Type == core.EventParserProgress && e.Progress >= 0 {
+			env.progress("[%3.0f%%] %s", e.Progress, e.Message)
+		}
+	})
+	defer unsubscribe()
+	if err := ac.ConfigService.UpdateConfigFromSubscriptions(); err != nil {
+		return env.fail(exitCodeForUpdateError(err), err)
+	}
+
+	res := updateResult{OK: true, Profile: ac.GetActiveProfile(), ConfigPath: ac.GetConfigPath()}
+	text := fmt.Sprintf("Config updated: %s", ac.GetConfigPath())
+	code = ExitOK
+
+	// Apply the new config to a running sing-box (started by the GUI or by `start`)
+	if running, pid := ac.ProcessService.FindRunning(); running {
+		res.Running = true
+		if err := ac.ProcessService.ReloadPID(pid); err != nil {
+			res.OK = false
+			res.ReloadError = err.Error()
+			text += fmt.Sprintf("\nSing-Box (PID %d) was not reloaded: %v", pid, err)
+			code = exitCodeForCheckError(err)
+		} else {
+			res.Reloaded = true
+			text += fmt.Sprintf("\nSing-Box (PID %d) reloaded", pid)
+		}
+	}
+	return env.result(code, res, text)
+}
+
+// forwardUpdate asks the launcher running in execDir to update its config
+func forwardUpdate(env *environment, execDir string) int {
+	client, err := control.NewLauncherClient(execDir)
+	if err != nil {
+		return env.fail(ExitError, fmt.Errorf("another launcher instance is running: %w", err))
+	}
+	var status struct {
+		Profile    string `json:"profile"`
+		ConfigPath string `json:"config_path"`
+	}
+	if err := client.Do(http.MethodGet, "/v1/status", nil, &status); err != nil {
+		return env.fail(ExitError, fmt.Errorf("another launcher instance or update is running and can't be reached: %w", err))
+	}
+	if env.opts.profile != "" && env.opts.profile != status.Profile {
+		return env.fail(ExitError, fmt.Errorf("the running launcher uses profile '%s': switch it to '%s' or stop it to update that profile", status.Profile, env.opts.profile))
+	}
+
+	env.progress("Updating in the running launcher (profile %s)...", status.Profile)
+	err = client.WithTimeout(forwardedUpdateTimeout).Do(http.MethodPost, "/v1/update", nil, nil)
+	var apiErr *control.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		// The update failed or another one is in progress (HTTP 409)
+		return env.fail(exitCodeForUpdateKind(apiErr.Kind), errors.New(apiErr.Message))
+	case err != nil:
+		return env.fail(ExitError, err)
+	}
+	res := updateResult{OK: true, Profile: status.Profile, ConfigPath: status.ConfigPath, Forwarded: true}
+	return env.result(ExitOK, res, fmt.Sprintf("Config updated by the running launcher: %s", status.ConfigPath))
+}
+
+// exitCodeForUpdateKind maps an update failure kind reported by the control API to an exit code
+func exitCodeForUpdateKind(kind string) int {
+	for _, k := range []core.UpdateFailureKind{core.UpdateFailureNetwork, core.UpdateFailureAuth, core.UpdateFailureParse, core.UpdateFailureValidation} {
+		if k.String() == kind {
+			return exitCodeForUpdateError(&core.UpdateError{Kind: k, Err: errors.New(kind)})
+		}
+	}
+	return ExitError
+}
+
+// processResult is the output of start and stop commands
+type processResult struct {
+	OK      bool   `json:"ok"`
+	Running bool   `json:"running"`
+	PID     int    `json:"pid,omitempty"`
+	Message string `json:"message"`
+}
+
+// runStart validates config and starts sing-box in the background
+func runStart(env *environment, args []string) int {
+	fs, ok := env.parseFlags("start", args, nil)
+	if !ok {
+		return ExitUsage
+	}
+	if fs.NArg() > 0 {
+		return env.usageError("start takes no arguments")
+	}
+	ac, code := env.controller()
+	if ac == nil {
+		return code
+	}
+
+	pid, err := ac.ProcessService.StartDetached()
+	if err != nil {
+		return env.fail(exitCodeForCheckError(err), err)
+	}
+	msg := fmt.Sprintf("Sing-Box started (PID %d, profile %s)", pid, ac.GetActiveProfile())
+	return env.result(ExitOK, processResult{OK: true, Running: true, PID: pid, Message: msg}, msg)
+}
+
+// runStop stops a running sing-box
+func runStop(env *environment, args []string) int {
+	fs, ok := env.parseFlags("stop", args, nil)
+	if !ok {
+		return ExitUsage
+	}
+	if fs.NArg() > 0 {
+		return env.usageError("stop takes no arguments")
+	}
+	ac, code := env.controller()
+	if ac == nil {
+		return code
+	}
+
+	running, pid := ac.ProcessService.FindRunning()
+	if !running {
+		msg := "Sing-Box is not running"
+		return env.result(ExitNotRunning, processResult{OK: false, Message: msg}, msg)
+	}
+	if err := ac.ProcessService.StopPID(pid); err != nil {
+		return env.fail(ExitError, err)
+	}
+	msg := fmt.Sprintf("Sing-Box stopped (PID %d)", pid)
+	return env.result(ExitOK, processResult{OK: true, PID: pid, Message: msg}, msg)
+}
+
+// statusResult is the output of the status command
+type statusResult struct {
+	Running          bool   `json:"running"`
+	PID              int    `json:"pid,omitempty"`
+	Profile          string `json:"profile"`
+	ConfigPath       string `json:"config_path"`
+	ConfigExists     bool   `json:"config_exists"`
+	SingboxPath      string `json:"singbox_path"`
+	SingboxInstalled bool   `json:"singbox_installed"`
+	SingboxVersion   string `json:"singbox_version,omitempty"`
+	ClashAPIEnabled  bool   `json:"clash_api_enabled"`
+	ClashAPIURL      string `json:"clash_api_url,omitempty"`
+	SelectedGroup    string `json:"selected_group,omitempty"`
+}
+
+// runStatus reports sing-box and config status; exit code 3 if sing-box is not running
+func runStatus(env *environment, args []string) int {
+	fs, ok := env.parseFlags("status", args, nil)
+	if !ok {
+		return ExitUsage
+	}
+	if fs.NArg() > 0 {
+		return env.usageError("status takes no arguments")
+	}
+	ac, code := env.controller()
+	if ac == nil {
+		return code
+	}
+
+	res := statusResult{
+		Profile:         ac.GetActiveProfile(),
+		ConfigPath:      ac.GetConfigPath(),
+		SingboxPath:     ac.SingboxPath,
+		ClashAPIEnabled: ac.ClashAPIEnabled,
+	}
+	res.Running, res.PID = ac.ProcessService.FindRunning()
+	if !res.Running {
+		res.PID = 0
+	}
+	if _, err := os.Stat(ac.GetConfigPath()); err == nil {
+		res.ConfigExists = true
+	}
+	if version, err := ac.GetInstalledCoreVersion(); err == nil {
+		res.SingboxInstalled = true
+		res.SingboxVersion = version
+	} else if _, statErr := os.Stat(ac.SingboxPath); statErr == nil {
+		res.SingboxInstalled = true
+	}
+	if ac.ClashAPIEnabled {
+		res.ClashAPIURL = ac.ClashAPIBaseURL
+		res.SelectedGroup = ac.SelectedClashGroup
+	}
+
+	var text strings.Builder
+	if res.Running {
+		fmt.Fprintf(&text, "Sing-Box: running (PID %d)\n", res.PID)
+	} else {
+		fmt.Fprintln(&text, "Sing-Box: stopped")
+	}
+	switch {
+	case res.SingboxVersion != "":
+		fmt.Fprintf(&text, "Core: %s (%s)\n", res.SingboxVersion, res.SingboxPath)
+	case res.SingboxInstalled:
+		fmt.Fprintf(&text, "Core: unknown version (%s)\n", res.SingboxPath)
+	default:
+		fmt.Fprintf(&text, "Core: not installed (%s)\n", res.SingboxPath)
+	}
+	fmt.Fprintf(&text, "Profile: %s\n", res.Profile)
+	if res.ConfigExists {
+		fmt.Fprintf(&text, "Config: %s\n", res.ConfigPath)
+	} else {
+		fmt.Fprintf(&text, "Config: %s (not found)\n", res.ConfigPath)
+	}
+	if res.ClashAPIEnabled {
+		fmt.Fprintf(&text, "Clash API: %s (group %s)\n", res.ClashAPIURL, res.SelectedGroup)
+	} else {
+		fmt.Fprintln(&text, "Clash API: disabled")
+	}
+
+	code = ExitOK
+	if !res.Running {
+		code = ExitNotRunning
+	}
+	return env.result(code, res, text.String())
+}
+
+// checkConfigResult is the output of the check-config command
+type checkConfigResult struct {
+	Valid               bool   `json:"valid"`
+	ConfigPath          string `json:"config_path"`
+	ParserConfigVersion int    `json:"parser_config_version,omitempty"`
+	Error               string `json:"error,omitempty"`
+}
+
+// runCheckConfig validates config.json (of the profile or the given path) with sing-box check
+func runCheckConfig(env *environment, args []string) int {
+	fs, ok := env.parseFlags("check-config", args, nil)
+	if !ok {
+		return ExitUsage
+	}
+	if fs.NArg() > 1 {
+		return env.usageError("usage: check-config [path]")
+	}
+	ac, code := env.controller()
+	if ac == nil {
+		return code
+	}
+
+	configPath := ac.GetConfigPath()
+	if fs.NArg() == 1 {
+		abs, err := filepath.Abs(fs.Arg(0))
+		if err != nil {
+			return env.usageError("invalid path %s: %v", fs.Arg(0), err)
+		}
+		configPath = abs
+	}
+	if _, err := os.Stat(configPath); err != nil {
+		return env.fail(ExitInvalidConfig, fmt.Errorf("config not found at %s", configPath))
+	}
+	if _, err := os.Stat(ac.SingboxPath); err != nil {
+		return env.fail(ExitError, fmt.Errorf("sing-box not found at %s, install it with `core install latest`", ac.SingboxPath))
+	}
+
+	res := checkConfigResult{ConfigPath: configPath}
+	// @ParserConfig is optional, report its version if present
+	if parserConfig, err := core.ExtractParserConfig(configPath); err == nil {
+		res.ParserConfigVersion = parserConfig.ParserConfig.Version
+	}
+
+	if err := ac.ProcessService.CheckConfig(configPath); err != nil {
+		code := exitCodeForCheckError(err)
+		if code != ExitInvalidConfig {
+			return env.fail(code, err)
+		}
+		res.Error = err.Error()
+		return env.result(code, res, fmt.Sprintf("Config is invalid: %s\n%v", configPath, err))
+	}
+	res.Valid = true
+	return env.result(ExitOK, res, fmt.Sprintf("Config is valid: %s", configPath))
+}
+
+// coreInstallResult is the output of the core install command
+type coreInstallResult struct {
+	OK      bool   `json:"ok"`
+	Version string `json:"version"`
+	Path    string `json:"path"`
+}
+
+// runCore handles `core install <version|latest>`
+func runCore(env *environment, args []string) int {
+	if len(args) == 0 || args[0] != "install" {
+		return env.usageError("usage: core install <version|latest>")
+	}
+	fs, ok := env.parseFlags("core install", args[1:], nil)
+	if !ok {
+		return ExitUsage
+	}
+	if fs.NArg() != 1 {
+		return env.usageError("usage: core install <version|latest>")
+	}
+	ac, code := env.controller()
+	if ac == nil {
+		return code
+	}
+
+	if running, pid := ac.ProcessService.FindRunning(); running {
+		return env.fail(ExitError, fmt.Errorf("sing-box is running (PID %d), stop it before installing the core", pid))
+	}
+
+	version := strings.TrimPrefix(fs.Arg(0), "v")
+	if version == "latest" {
+		env.progress("Checking latest version...")
+		latest, err := ac.GetLatestCoreVersion()
+		if err != nil {
+			return env.fail(ExitNetwork, fmt.Errorf("failed to get latest version: %w", err))
+		}
+		version = strings.TrimPrefix(latest, "v")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	progressChan := make(chan core.DownloadProgress, 10)
+	go ac.DownloadCore(ctx, version, progressChan)
+
+	var lastErr error
+	for p := range progressChan {
+		if p.Status == "error" {
+			lastErr = p.Error
+			if lastErr == nil {
+				lastErr = errors.New(p.Message)
+			}
+			continue
+		}
+		env.progress("[%3d%%] %s", p.Progress, p.Message)
+	}
+	if lastErr != nil {
+		return env.fail(ExitError, fmt.Errorf("failed to install sing-box %s: %w", version, lastErr))
+	}
+
+	res := coreInstallResult{OK: true, Version: version, Path: ac.SingboxPath}
+	return env.result(ExitOK, res, fmt.Sprintf("sing-box %s installed to %s", version, ac.SingboxPath))
+}
+
+// proxyEntry is a proxy in the output of proxies list
+type proxyEntry struct {
+	Name   string `json:"name"`
+	Delay  int64  `json:"delay_ms,omitempty"`
+	Active bool   `json:"active"`
+}
+
+// proxiesListResult is the output of proxies list
+type proxiesListResult struct {
+	Group   string       `json:"group"`
+	Now     string       `json:"now"`
+	Proxies []proxyEntry `json:"proxies"`
+}
+
+// proxySwitchResult is the output of proxies switch
+type proxySwitchResult struct {
+	OK    bool   `json:"ok"`
+	Group string `json:"group"`
+	Proxy string `json:"proxy"`
+}
+
+// runProxies handles `proxies list` and `proxies switch <proxy>` via Clash API
+func runProxies(env *environment, args []string) int {
+	const usage = "usage: proxies list [-group name] | proxies switch [-group name] <proxy>"
+	if len(args) == 0 || (args[0] != "list" && args[0] != "switch") {
+		return env.usageError(usage)
+	}
+	subcommand := args[0]
+
+	group := ""
+	fs, ok := env.parseFlags("proxies "+subcommand, args[1:], func(fs *flag.FlagSet) {
+		fs.StringVar(&group, "group", "", "selector group (default: the default selector from config)")
+	})
+	if !ok {
+		return ExitUsage
+	}
+	if (subcommand == "list" && fs.NArg() != 0) || (subcommand == "switch" && fs.NArg() != 1) {
+		return env.usageError(usage)
+	}
+
+	ac, code := env.controller()
+	if ac == nil {
+		return code
+	}
+	if !ac.ClashAPIEnabled {
+		return env.fail(ExitError, fmt.Errorf("Clash API is not enabled in %s", ac.GetConfigPath()))
+	}
+	if running, _ := ac.ProcessService.FindRunning(); !running {
+		return env.fail(ExitNotRunning, fmt.Errorf("sing-box is not running"))
+	}
+	if group == "" {
+		group = ac.SelectedClashGroup
+	}
+
+	if subcommand == "switch" {
+		proxy := fs.Arg(0)
+		if err := ac.ClashClient().SwitchProxy(context.Background(), group, proxy); err != nil {
+			return env.fail(ExitError, err)
+		}
+		res := proxySwitchResult{OK: true, Group: group, Proxy: proxy}
+		return env.result(ExitOK, res, fmt.Sprintf("%s: switched to %s", group, proxy))
+	}
+
+	proxies, now, err := ac.ClashClient().ProxiesInGroup(context.Background(), group)
+	if err != nil {
+		return env.fail(ExitError, err)
+	}
+	res := proxiesListResult{Group: group, Now: now, Proxies: make([]proxyEntry, 0, len(proxies))}
+	var text strings.Builder
+	fmt.Fprintf(&text, "Group: %s\n", group)
+	for _, p := range proxies {
+		entry := proxyEntry{Name: p.Name, Delay: p.Delay, Active: p.Name == now}
+		res.Proxies = append(res.Proxies, entry)
+
+		marker := " "
+		if entry.Active {
+			marker = "*"
+		}
+		if entry.Delay > 0 {
+			fmt.Fprintf(&text, "%s %s (%d ms)\n", marker, entry.Name, entry.Delay)
+		} else {
+			fmt.Fprintf(&text, "%s %s\n", marker, entry.Name)
+		}
+	}
+	return env.result(ExitOK, res, text.String())
+}
+
+// ruleSetEntry is a rule-set in the output of rulesets list
+type ruleSetEntry struct {
+	Tag            string `json:"tag"`
+	Type           string `json:"type,omitempty"`
+	Format         string `json:"format,omitempty"`
+	URL            string `json:"url,omitempty"`
+	UpdateInterval string `json:"update_interval,omitempty"`
+	Refreshable    bool   `json:"refreshable"`
+	NeedsReload    bool   `json:"needs_reload,omitempty"`
+	RuleCount      int    `json:"rule_count,omitempty"`
+	UpdatedAt      string `json:"updated_at,omitempty"`
+}
+
+// ruleSetsListResult is the output of rulesets list
+type ruleSetsListResult struct {
+	RuleSets []ruleSetEntry `json:"rule_sets"`
+}
+
+// ruleSetsRefreshResult is the output of rulesets refresh
+type ruleSetsRefreshResult struct {
+	OK        bool     `json:"ok"`
+	Refreshed []string `json:"refreshed"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// runRuleSets handles `rulesets list` and `rulesets refresh [-reload] [tag...]` (see core.RefreshRuleSets).
+// Rule-sets of sing-box are refreshed by reloading its whole config, which -reload confirms.
+func runRuleSets(env *environment, args []string) int {
+	const usage = "usage: rulesets list | rulesets refresh [-reload] [tag...]"
+	if len(args) == 0 || (args[0] != "list" && args[0] != "refresh") {
+		return env.usageError(usage)
+	}
+	subcommand := args[0]
+
+	var allowReload bool
+	fs, ok := env.parseFlags("rulesets "+subcommand, args[1:], func(fs *flag.FlagSet) {
+		if subcommand == "refresh" {
+			fs.BoolVar(&allowReload, "reload", false, "reload the whole sing-box config if the core cannot refresh rule-sets in place (drops all connections)")
+		}
+	})
+	if !ok {
+		return ExitUsage
+	}
+	if subcommand == "list" && fs.NArg() != 0 {
+		return env.usageError(usage)
+	}
+
+	ac, code := env.controller()
+	if ac == nil {
+		return code
+	}
+
+	if subcommand == "refresh" {
+		if running, _ := ac.ProcessService.FindRunning(); !running {
+			return env.fail(ExitNotRunning, fmt.Errorf("sing-box is not running"))
+		}
+		refreshed, err := ac.RefreshRuleSets(context.Background(), allowReload, fs.Args()...)
+		if errors.Is(err, core.ErrRuleSetReloadRequired) {
+			return env.fail(ExitUsage, fmt.Errorf("%w; pass -reload to reload it", err))
+		}
+		if err != nil && len(refreshed) == 0 {
+			return env.fail(ExitError, err)
+		}
+		res := ruleSetsRefreshResult{OK: err == nil, Refreshed: refreshed}
+		text := fmt.Sprintf("Refreshed: %s", strings.Join(refreshed, ", "))
+		code := ExitOK
+		if err != nil {
+			// Some rule-sets were refreshed
+			res.Error = err.Error()
+			text += "\nError: " + err.Error()
+			code = ExitError
+		}
+		return env.result(code, res, text)
+	}
+
+	sets, err := ac.RuleSets(context.Background())
+	if err != nil {
+		return env.fail(ExitInvalidConfig, err)
+	}
+	res := ruleSetsListResult{RuleSets: make([]ruleSetEntry, 0, len(sets))}
+	var text strings.Builder
+	for _, rs := range sets {
+		entry := ruleSetEntry{
+			Tag:            rs.Tag,
+			Type:           rs.Type,
+			Format:         rs.Format,
+			URL:            rs.URL,
+			UpdateInterval: rs.UpdateInterval,
+			Refreshable:    rs.Refreshable,
+			NeedsReload:    rs.NeedsReload,
+			RuleCount:      rs.RuleCount,
+		}
+		if !rs.UpdatedAt.IsZero() {
+			entry.UpdatedAt = rs.UpdatedAt.Format(time.RFC3339)
+		}
+		res.RuleSets = append(res.RuleSets, entry)
+
+		fmt.Fprintf(&text, "%s (%s %s)", rs.Tag, rs.Type, rs.Format)
+		var details []string
+		if rs.Provider || rs.RuleCount > 0 {
+			details = append(details, fmt.Sprintf("%d rules", rs.RuleCount))
+		}
+		if !rs.UpdatedAt.IsZero() {
+			details = append(details, "updated "+rs.UpdatedAt.Local().Format("2006-01-02 15:04"))
+		}
+		if !rs.Provider && rs.UpdateInterval != "" {
+			details = append(details, "updated by sing-box every "+rs.UpdateInterval)
+		}
+		if rs.Cached {
+			details = append(details, "kept in cache_file")
+		}
+		if rs.NeedsReload {
+			details = append(details, "refreshed by reloading sing-box")
+		}
+		if len(details) > 0 {
+			fmt.Fprintf(&text, ": %s", strings.Join(details, ", "))
+		}
+		fmt.Fprintln(&text)
+	}
+	if len(sets) == 0 {
+		fmt.Fprintln(&text, "No rule-sets in config.json")
+	}
+	return env.result(ExitOK, res, text.String())
+}
+
+// runLogPump writes output of a detached sing-box from stdin to the sing-box log (started by
+// the start command, see core.RunLogPump); it exits when sing-box closes its output
+func runLogPump(env *environment, args []string) int {
+	if len(args) != 1 {
+		return env.usageError("%s takes the config path", core.LogPumpCommand)
+	}
+	execDir, err := core.ExecutableDir()
+	if err != nil {
+		return env.fail(ExitError, err)
+	}
+	if err := core.RunLogPump(execDir, args[0], os.Stdin); err != nil {
+		return env.fail(ExitError, err)
+	}
+	return ExitOK
+}
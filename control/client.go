@@ -0,0 +1,105 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+const clientTimeout = 30 * time.Second // /v1/start and /v1/stop wait for sing-box
+
+// Client calls the control API of a running launcher
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// APIError is an error response of the control API
+type APIError struct {
+	StatusCode int
+	Message    string
+	Kind       string // Update failure kind of /v1/update (see core.UpdateFailureKind)
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("control API: %s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// NewClient creates a client for endpoint
+func NewClient(endpoint Endpoint) *Client {
+	transport := &http.Transport{}
+	baseURL := "http://" + endpoint.Address
+	if endpoint.Network == "unix" {
+		path := endpoint.Address
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		}
+		baseURL = "http://launcher" // Host is not used for Unix sockets
+	}
+	return &Client{
+		baseURL: baseURL,
+		token:   endpoint.Token,
+		http:    &http.Client{Transport: transport, Timeout: clientTimeout},
+	}
+}
+
+// WithTimeout returns a copy of the client whose requests time out after timeout (long actions like /v1/update)
+func (c *Client) WithTimeout(timeout time.Duration) *Client {
+	httpClient := *c.http
+	httpClient.Timeout = timeout
+	return &Client{baseURL: c.baseURL, token: c.token, http: &httpClient}
+}
+
+// Do sends a request with a JSON body (if not nil) and decodes the JSON response into out (if not nil).
+// Non-2xx responses are returned as *APIError.
+func (c *Client) Do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var res errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil || res.Error == "" {
+			res.Error = http.StatusText(resp.StatusCode)
+		}
+		return &APIError{StatusCode: resp.StatusCode, Message: res.Error, Kind: res.Kind}
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+// IsStatus reports whether err is an APIError with the status code
+func IsStatus(err error, statusCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
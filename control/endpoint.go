@@ -0,0 +1,85 @@
+// Package control implements the local control API of a running launcher.
+// Scripts, browser extensions and other tools use it to start/stop sing-box,
+// update subscriptions, switch profiles and proxies, and follow state changes.
+package control
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"singbox-launcher/core"
+	"singbox-launcher/internal/constants"
+	"singbox-launcher/internal/platform"
+)
+
+// maxSocketPathLength is the portable limit of a Unix socket path (sun_path is 104 bytes on macOS)
+const maxSocketPathLength = 100
+
+// Endpoint describes where the control API listens
+type Endpoint struct {
+	Network string // "unix" or "tcp"
+	Address string // Socket path or host:port
+	Token   string // Bearer token, required for "tcp"
+}
+
+// ResolveEndpoint returns the control API endpoint for the launcher directory.
+// Linux uses a Unix socket in bin/ unless settings.Listen is set; other platforms use
+// loopback TCP. Non-loopback TCP addresses are rejected.
+func ResolveEndpoint(execDir string, settings core.ControlAPISettings) (Endpoint, error) {
+	if runtime.GOOS == "linux" && settings.Listen == "" {
+		return Endpoint{Network: "unix", Address: socketPath(execDir)}, nil
+	}
+
+	address := settings.Listen
+	if address == "" {
+		address = constants.DefaultControlAPIAddress
+	}
+	if !isLoopbackAddress(address) {
+		return Endpoint{}, fmt.Errorf("control API address %q is not a loopback address", address)
+	}
+	return Endpoint{Network: "tcp", Address: address, Token: settings.Token}, nil
+}
+
+// socketPath returns bin/control.sock, or a socket in a private directory of the temp directory
+// if the path is too long (the directory is created with 0700 permissions by the server)
+func socketPath(execDir string) string {
+	path := filepath.Join(platform.GetBinDir(execDir), constants.ControlSocketFileName)
+	if len(path) <= maxSocketPathLength {
+		return path
+	}
+	sum := sha1.Sum([]byte(execDir))
+	return filepath.Join(os.TempDir(), fmt.Sprintf("singbox-launcher-%d-%x", os.Getuid(), sum[:6]), constants.ControlSocketFileName)
+}
+
+// isTempSocketPath reports whether the socket is in the temp directory (see socketPath)
+func isTempSocketPath(path string) bool {
+	return filepath.Dir(filepath.Dir(path)) == filepath.Clean(os.TempDir())
+}
+
+// isLoopbackAddress reports whether host:port refers to the local machine only
+func isLoopbackAddress(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// generateToken returns a random hex token
+func generateToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,98 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"singbox-launcher/core"
+)
+
+const (
+	forwardConnectTimeout = 10 * time.Second // The running launcher may still be starting its control API
+	forwardSwitchTimeout  = 15 * time.Second // Clash API of a just started sing-box needs a few seconds
+	forwardRetryInterval  = 250 * time.Millisecond
+)
+
+// ForwardRequest holds command-line actions of a second launcher invocation
+type ForwardRequest struct {
+	Start  bool   // -start: start sing-box
+	Switch string // --switch: proxy for the selected group
+	Link   string // sing-box://import-remote-profile deep link
+}
+
+// Forward sends the actions to the launcher running in execDir and brings its window to front.
+// The window is shown even if an action fails; errors of failed actions are returned joined.
+func Forward(execDir string, req ForwardRequest) error {
+	client, err := NewLauncherClient(execDir)
+	if err != nil {
+		return err
+	}
+
+	if err := retry(forwardConnectTimeout, func() error {
+		return client.Do(http.MethodPost, "/v1/window/show", nil, nil)
+	}, isConnectionError); err != nil {
+		return fmt.Errorf("failed to reach the running launcher: %w", err)
+	}
+
+	var errs []error
+	if req.Link != "" {
+		log.Printf("Forward: Importing %s", req.Link)
+		errs = append(errs, client.Do(http.MethodPost, "/v1/profiles/import", profileImportRequest{Link: req.Link}, nil))
+	}
+	if req.Start {
+		log.Println("Forward: Starting sing-box")
+		if err := client.Do(http.MethodPost, "/v1/start", nil, nil); err != nil && !IsStatus(err, http.StatusConflict) {
+			errs = append(errs, err)
+		}
+	}
+	if req.Switch != "" {
+		log.Printf("Forward: Switching to %s", req.Switch)
+		switchProxy := func() error {
+			return client.Do(http.MethodPost, "/v1/proxies/switch", proxySwitchRequest{Proxy: req.Switch}, nil)
+		}
+		err := switchProxy()
+		if err != nil && req.Start {
+			// sing-box has just been started, wait for its Clash API
+			err = retry(forwardSwitchTimeout, switchProxy, func(err error) bool {
+				return IsStatus(err, http.StatusBadGateway)
+			})
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// NewLauncherClient creates a client for the control API of the launcher running in execDir
+func NewLauncherClient(execDir string) (*Client, error) {
+	var settings core.ControlAPISettings
+	core.LoadLauncherSettings(execDir).Get(func(s *core.LauncherSettings) { settings = s.ControlAPI })
+	if settings.Disabled {
+		return nil, errors.New("control API is disabled in launcher settings, the running launcher can't be reached")
+	}
+	endpoint, err := ResolveEndpoint(execDir, settings)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(endpoint), nil
+}
+
+// retry calls fn until it succeeds, fails with an error that is not retryable, or timeout expires
+func retry(timeout time.Duration, fn func() error, retryable func(error) bool) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := fn()
+		if err == nil || !retryable(err) || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(forwardRetryInterval)
+	}
+}
+
+// isConnectionError reports whether err is a transport error (no response from the server)
+func isConnectionError(err error) bool {
+	var apiErr *APIError
+	return !errors.As(err, &apiErr)
+}
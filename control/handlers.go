@@ -0,0 +1,501 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"singbox-launcher/core"
+	"singbox-launcher/internal/constants"
+)
+
+const (
+	stopWaitTimeout   = 5 * time.Second  // How long /v1/stop waits for sing-box to exit
+	eventBufferSize   = 64               // Events buffered per stream; a slow client loses events
+	eventPingInterval = 30 * time.Second // Keep-alive comment interval of event streams
+)
+
+// routes registers API handlers
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/status", s.handleStatus)
+	mux.HandleFunc("POST /v1/start", s.handleStart)
+	mux.HandleFunc("POST /v1/stop", s.handleStop)
+	mux.HandleFunc("POST /v1/restart", s.handleRestart)
+	mux.HandleFunc("POST /v1/update", s.handleUpdate)
+	mux.HandleFunc("GET /v1/profiles", s.handleProfiles)
+	mux.HandleFunc("POST /v1/profiles/switch", s.handleProfileSwitch)
+	mux.HandleFunc("POST /v1/profiles/import", s.handleProfileImport)
+	mux.HandleFunc("GET /v1/proxies/groups", s.handleProxyGroups)
+	mux.HandleFunc("GET /v1/proxies", s.handleProxies)
+	mux.HandleFunc("POST /v1/proxies/switch", s.handleProxySwitch)
+	mux.HandleFunc("GET /v1/logs", s.handleLogs)
+	mux.HandleFunc("GET /v1/events", s.handleEvents)
+	mux.HandleFunc("POST /v1/window/show", s.handleWindowShow)
+	return s.authenticate(mux)
+}
+
+// okResponse is returned by actions without other output
+type okResponse struct {
+	OK bool `json:"ok"`
+}
+
+// errorResponse is returned on failure
+type errorResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+	Kind  string `json:"kind,omitempty"` // Update failure kind for /v1/update
+}
+
+// autoUpdateResponse is the auto-update part of the status
+type autoUpdateResponse struct {
+	Enabled        bool      `json:"enabled"`
+	FailedAttempts int       `json:"failed_attempts"`
+	NextAttempt    time.Time `json:"next_attempt,omitzero"`
+	LastError      string    `json:"last_error,omitempty"`
+	FailedSources  int       `json:"failed_sources,omitempty"` // Subscriptions backing off or stopped after failed downloads
+}
+
+// statusResponse is the output of /v1/status
+type statusResponse struct {
+	Running          bool               `json:"running"`
+	PID              int                `json:"pid,omitempty"`
+	LauncherVersion  string             `json:"launcher_version"`
+	Profile          string             `json:"profile"`
+	ConfigPath       string             `json:"config_path"`
+	ConfigExists     bool               `json:"config_exists"`
+	ClashAPIEnabled  bool               `json:"clash_api_enabled"`
+	SelectedGroup    string             `json:"selected_group,omitempty"`
+	ActiveProxy      string             `json:"active_proxy,omitempty"`
+	UpdateInProgress bool               `json:"update_in_progress"`
+	AutoUpdate       autoUpdateResponse `json:"auto_update"`
+}
+
+// profilesResponse is the output of /v1/profiles
+type profilesResponse struct {
+	Active   string   `json:"active"`
+	Profiles []string `json:"profiles"`
+}
+
+// groupsResponse is the output of /v1/proxies/groups
+type groupsResponse struct {
+	Groups   []string     `json:"groups"`
+	Default  string       `json:"default,omitempty"`
+	Selected string       `json:"selected,omitempty"`
+	Tree     []groupEntry `json:"tree,omitempty"` // All groups from the Clash API while sing-box runs
+}
+
+// groupEntry is a proxy group from the Clash API
+type groupEntry struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Now        string   `json:"now,omitempty"`
+	Selectable bool     `json:"selectable"` // false for groups that choose automatically (URLTest)
+	Root       bool     `json:"root"`       // Not a member of another group
+	Members    []string `json:"members"`
+}
+
+// proxyEntry is a proxy of a selector group
+type proxyEntry struct {
+	Name   string `json:"name"`
+	Delay  int64  `json:"delay_ms,omitempty"`
+	Active bool   `json:"active"`
+}
+
+// proxiesResponse is the output of /v1/proxies
+type proxiesResponse struct {
+	Group   string       `json:"group"`
+	Now     string       `json:"now"`
+	Proxies []proxyEntry `json:"proxies"`
+}
+
+// logsResponse is the output of /v1/logs
+type logsResponse struct {
+	LastSeq uint64          `json:"last_seq"` // Pass as ?since= to get only newer entries
+	Entries []core.LogEntry `json:"entries"`
+}
+
+// profileSwitchRequest is the body of /v1/profiles/switch
+type profileSwitchRequest struct {
+	Name string `json:"name"`
+}
+
+// profileImportRequest is the body of /v1/profiles/import
+type profileImportRequest struct {
+	Link string `json:"link"` // sing-box://import-remote-profile?url=...#name
+}
+
+// proxySwitchRequest is the body of /v1/proxies/switch (empty group = selected group)
+type proxySwitchRequest struct {
+	Group string `json:"group"`
+	Proxy string `json:"proxy"`
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.status())
+}
+
+// status collects the current launcher state
+func (s *Server) status() statusResponse {
+	ac := s.ac
+	res := statusResponse{
+		Running:         ac.RunningState.IsRunning(),
+		LauncherVersion: constants.AppVersion,
+		Profile:         ac.GetActiveProfile(),
+		ConfigPath:      ac.GetConfigPath(),
+		ClashAPIEnabled: ac.ClashAPIEnabled,
+		ActiveProxy:     ac.GetActiveProxyName(),
+	}
+	if res.Running {
+		ac.CmdMutex.Lock()
+		if ac.SingboxCmd != nil && ac.SingboxCmd.Process != nil {
+			res.PID = ac.SingboxCmd.Process.Pid
+		}
+		ac.CmdMutex.Unlock()
+	}
+	_, err := os.Stat(ac.GetConfigPath())
+	res.ConfigExists = err == nil
+
+	ac.APIStateMutex.RLock()
+	res.SelectedGroup = ac.SelectedClashGroup
+	ac.APIStateMutex.RUnlock()
+
+	ac.ParserMutex.Lock()
+	res.UpdateInProgress = ac.ParserRunning
+	ac.ParserMutex.Unlock()
+
+	autoUpdate := ac.GetAutoUpdateStatus()
+	res.AutoUpdate = autoUpdateResponse{
+		Enabled:        autoUpdate.Enabled,
+		FailedAttempts: autoUpdate.FailedAttempts,
+		NextAttempt:    autoUpdate.NextAttempt,
+		LastError:      autoUpdate.LastError,
+		FailedSources:  autoUpdate.FailedSources,
+	}
+	return res
+}
+
+func (s *Server) handleStart(w http.ResponseWriter, r *http.Request) {
+	if s.ac.RunningState.IsRunning() {
+		writeError(w, http.StatusConflict, errors.New("sing-box is already running"))
+		return
+	}
+	core.StartSingBoxProcess(s.ac)
+	if !s.ac.RunningState.IsRunning() {
+		writeError(w, http.StatusInternalServerError, errors.New("failed to start sing-box, see logs/"+constants.ChildLogFileName))
+		return
+	}
+	writeJSON(w, http.StatusOK, s.status())
+}
+
+func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
+	core.StopSingBoxProcess(s.ac)
+
+	deadline := time.Now().Add(stopWaitTimeout)
+	for s.ac.RunningState.IsRunning() && time.Now().Before(deadline) {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	if s.ac.RunningState.IsRunning() {
+		writeError(w, http.StatusInternalServerError, errors.New("sing-box did not stop in time"))
+		return
+	}
+	writeJSON(w, http.StatusOK, s.status())
+}
+
+func (s *Server) handleRestart(w http.ResponseWriter, r *http.Request) {
+	s.ac.ProcessService.Restart()
+	if !s.ac.RunningState.IsRunning() {
+		writeError(w, http.StatusInternalServerError, errors.New("failed to restart sing-box, see logs/"+constants.ChildLogFileName))
+		return
+	}
+	writeJSON(w, http.StatusOK, s.status())
+}
+
+// handleUpdate updates subscriptions synchronously; progress is streamed by /v1/events
+func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	err := s.ac.ConfigService.UpdateNow()
+	switch {
+	case errors.Is(err, core.ErrUpdateInProgress):
+		writeError(w, http.StatusConflict, err)
+	case err != nil:
+		writeJSON(w, http.StatusBadGateway, errorResponse{
+			Error: err.Error(),
+			Kind:  core.ClassifyUpdateError(err).String(),
+		})
+	default:
+		writeJSON(w, http.StatusOK, okResponse{OK: true})
+	}
+}
+
+func (s *Server) handleProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := s.ac.Profiles.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, profilesResponse{Active: s.ac.GetActiveProfile(), Profiles: profiles})
+}
+
+func (s *Server) handleProfileSwitch(w http.ResponseWriter, r *http.Request) {
+	var req profileSwitchRequest
+	if err := decodeBody(r, &req); err != nil || req.Name == "" {
+		writeError(w, http.StatusBadRequest, errors.New(`expected {"name": "<profile>"}`))
+		return
+	}
+	if err := core.CheckProfileName(req.Name); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !s.ac.Profiles.Exists(req.Name) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("profile '%s' not found", req.Name))
+		return
+	}
+	if err := s.ac.SwitchProfile(req.Name); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.status())
+}
+
+// handleProfileImport asks the user to confirm a remote profile import.
+// The import runs after confirmation, so the response only means the request was accepted.
+func (s *Server) handleProfileImport(w http.ResponseWriter, r *http.Request) {
+	var req profileImportRequest
+	if err := decodeBody(r, &req); err != nil || req.Link == "" {
+		writeError(w, http.StatusBadRequest, errors.New(`expected {"link": "sing-box://import-remote-profile?url=..."}`))
+		return
+	}
+	link, err := core.ParseRemoteProfileLink(req.Link)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.ac.RequestRemoteProfileImport(link)
+	writeJSON(w, http.StatusAccepted, okResponse{OK: true})
+}
+
+func (s *Server) handleProxyGroups(w http.ResponseWriter, r *http.Request) {
+	groups, defaultGroup, err := core.GetSelectorGroupsFromConfig(s.ac.GetConfigPath())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.ac.APIStateMutex.RLock()
+	selected := s.ac.SelectedClashGroup
+	s.ac.APIStateMutex.RUnlock()
+	res := groupsResponse{Groups: groups, Default: defaultGroup, Selected: selected}
+
+	if s.ac.ClashAPIEnabled && s.ac.RunningState.IsRunning() {
+		tree, err := s.ac.ProxyGroups(r.Context())
+		if err != nil {
+			log.Printf("ControlAPI: Failed to load proxy groups: %v", err)
+		}
+		roots := make(map[string]bool)
+		for _, name := range core.ProxyGroupRoots(tree) {
+			roots[name] = true
+		}
+		for i := range tree {
+			g := &tree[i]
+			entry := groupEntry{Name: g.Name, Type: g.Type, Now: g.Now, Selectable: g.Selectable(), Root: roots[g.Name], Members: make([]string, 0, len(g.Members))}
+			for _, m := range g.Members {
+				entry.Members = append(entry.Members, m.Name)
+			}
+			res.Tree = append(res.Tree, entry)
+		}
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) handleProxies(w http.ResponseWriter, r *http.Request) {
+	if !s.checkClashAPI(w) {
+		return
+	}
+	group := s.groupOrSelected(r.URL.Query().Get("group"))
+	proxies, now, err := s.ac.ClashClient().ProxiesInGroup(r.Context(), group)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	res := proxiesResponse{Group: group, Now: now, Proxies: make([]proxyEntry, 0, len(proxies))}
+	for _, p := range proxies {
+		res.Proxies = append(res.Proxies, proxyEntry{Name: p.Name, Delay: p.Delay, Active: p.Name == now})
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) handleProxySwitch(w http.ResponseWriter, r *http.Request) {
+	var req proxySwitchRequest
+	if err := decodeBody(r, &req); err != nil || req.Proxy == "" {
+		writeError(w, http.StatusBadRequest, errors.New(`expected {"group": "<group>", "proxy": "<proxy>"}`))
+		return
+	}
+	if !s.checkClashAPI(w) {
+		return
+	}
+	group := s.groupOrSelected(req.Group)
+	if err := s.ac.SelectProxy(group, req.Proxy); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, okResponse{OK: true})
+}
+
+// handleLogs returns parsed sing-box log entries from memory.
+// Optional ?since=<seq>, ?level=warn (minimum level) and ?limit=<n> (newest n entries).
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var since uint64
+	if v := query.Get("since"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid since %q", v))
+			return
+		}
+		since = n
+	}
+	var level core.LogLevel
+	if v := query.Get("level"); v != "" {
+		l, ok := core.ParseLogLevel(v)
+		if !ok {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid level %q", v))
+			return
+		}
+		level = l
+	}
+	limit := 0
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", v))
+			return
+		}
+		limit = n
+	}
+
+	// LastSeq is read first, so a following ?since= request doesn't miss entries added meanwhile
+	res := logsResponse{LastSeq: s.ac.CoreLog.LastSeq()}
+	res.Entries = s.ac.CoreLog.Entries(since, level, limit)
+	if res.Entries == nil {
+		res.Entries = []core.LogEntry{}
+	} else if last := res.Entries[len(res.Entries)-1].Seq; last > res.LastSeq {
+		res.LastSeq = last
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// handleEvents streams core events as Server-Sent Events.
+// Optional ?types=core_status,proxies limits the stream to the listed event types.
+// core_log and traffic events (every line of sing-box output, every second of traffic)
+// are sent only if listed explicitly.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	var types map[core.EventType]bool
+	if filter := r.URL.Query().Get("types"); filter != "" {
+		types = make(map[core.EventType]bool)
+		for _, t := range strings.Split(filter, ",") {
+			types[core.EventType(strings.TrimSpace(t))] = true
+		}
+	}
+
+	events := make(chan core.Event, eventBufferSize)
+	unsubscribe := s.ac.Events.Subscribe(func(e core.Event) {
+		if (types != nil && !types[e.Type]) || (types == nil && (e.Type == core.EventCoreLog || e.Type == core.EventTraffic)) {
+			return
+		}
+		select {
+		case events <- e:
+		default: // Slow client, drop the event
+		}
+	})
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	ping := time.NewTicker(eventPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case e := <-events:
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+		case <-ping.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// handleWindowShow brings the main window to front (no-op without GUI)
+func (s *Server) handleWindowShow(w http.ResponseWriter, r *http.Request) {
+	s.ac.RequestShowWindow()
+	writeJSON(w, http.StatusOK, okResponse{OK: true})
+}
+
+// checkClashAPI writes an error if proxies can't be managed now
+func (s *Server) checkClashAPI(w http.ResponseWriter) bool {
+	if !s.ac.ClashAPIEnabled {
+		writeError(w, http.StatusConflict, fmt.Errorf("Clash API is disabled in %s", s.ac.GetConfigPath()))
+		return false
+	}
+	if !s.ac.RunningState.IsRunning() {
+		writeError(w, http.StatusConflict, errors.New("sing-box is not running"))
+		return false
+	}
+	return true
+}
+
+// groupOrSelected returns group, or the group selected in the launcher if empty
+func (s *Server) groupOrSelected(group string) string {
+	if group != "" {
+		return group
+	}
+	s.ac.APIStateMutex.RLock()
+	defer s.ac.APIStateMutex.RUnlock()
+	return s.ac.SelectedClashGroup
+}
+
+// decodeBody decodes a JSON request body
+func decodeBody(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 64*1024))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an errorResponse
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
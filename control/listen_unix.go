@@ -0,0 +1,41 @@
+//go:build !windows
+// +build !windows
+
+package control
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+)
+
+// listenUnix creates the socket with 0600 permissions from the start: the umask is restricted
+// while listening, so there is no window in which other users can connect
+func listenUnix(path string) (net.Listener, error) {
+	old := syscall.Umask(0077)
+	defer syscall.Umask(old)
+	return net.Listen("unix", path)
+}
+
+// ensurePrivateDir creates dir with 0700 permissions or checks that an existing one
+// is a directory of the current user that nobody else can access
+func ensurePrivateDir(dir string) error {
+	if err := os.Mkdir(dir, 0700); err != nil && !os.IsExist(err) {
+		return err
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != os.Getuid() {
+		return fmt.Errorf("%s is owned by another user", dir)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("%s is accessible by other users (%v)", dir, info.Mode().Perm())
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build windows
+// +build windows
+
+package control
+
+import (
+	"net"
+	"os"
+)
+
+// listenUnix listens on a Unix socket (the control API uses loopback TCP on Windows)
+func listenUnix(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
+
+// ensurePrivateDir creates dir if it does not exist
+func ensurePrivateDir(dir string) error {
+	return os.MkdirAll(dir, 0700)
+}
@@ -0,0 +1,190 @@
+package control
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"singbox-launcher/core"
+)
+
+const (
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 2 * time.Second
+)
+
+// Server serves the control API for an AppController
+type Server struct {
+	ac       *core.AppController
+	endpoint Endpoint
+	handler  http.Handler
+
+	mutex    sync.Mutex
+	listener net.Listener
+	server   *http.Server
+	done     chan struct{} // Closed on Stop, ends event streams
+}
+
+// NewServer creates a control API server using the launcher settings of ac.
+// A token is generated and saved to the launcher settings on first use of TCP.
+func NewServer(ac *core.AppController) (*Server, error) {
+	var settings core.ControlAPISettings
+	ac.Settings.Get(func(s *core.LauncherSettings) { settings = s.ControlAPI })
+
+	endpoint, err := ResolveEndpoint(ac.ExecDir, settings)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Network == "tcp" && endpoint.Token == "" {
+		token, err := generateToken()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate control API token: %w", err)
+		}
+		if err := ac.Settings.Update(func(s *core.LauncherSettings) { s.ControlAPI.Token = token }); err != nil {
+			return nil, err
+		}
+		endpoint.Token = token
+	}
+	core.RegisterSecret(endpoint.Token)
+
+	s := &Server{ac: ac, endpoint: endpoint, done: make(chan struct{})}
+	s.handler = s.routes()
+	return s, nil
+}
+
+// Endpoint returns where the server listens
+func (s *Server) Endpoint() Endpoint {
+	return s.endpoint
+}
+
+// Handler returns the HTTP handler with authentication
+func (s *Server) Handler() http.Handler {
+	return s.handler
+}
+
+// Start starts listening in the background
+func (s *Server) Start() error {
+	listener, err := s.listen()
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Handler:           s.handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+		// No write timeout: event streams and subscription updates are long-running
+	}
+
+	s.mutex.Lock()
+	s.listener = listener
+	s.server = server
+	s.mutex.Unlock()
+
+	log.Printf("ControlAPI: Listening on %s %s", s.endpoint.Network, s.endpoint.Address)
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("ControlAPI: Server stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// listen opens the listener; a stale Unix socket left by a crashed launcher is removed
+func (s *Server) listen() (net.Listener, error) {
+	if s.endpoint.Network != "unix" {
+		return net.Listen(s.endpoint.Network, s.endpoint.Address)
+	}
+
+	path := s.endpoint.Address
+	// The temp directory is shared with other users: the socket lives in a private directory there
+	if isTempSocketPath(path) {
+		if err := ensurePrivateDir(filepath.Dir(path)); err != nil {
+			return nil, fmt.Errorf("failed to prepare control socket directory: %w", err)
+		}
+	}
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("control socket %s is in use by another launcher instance", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale control socket: %w", err)
+		}
+	}
+	// Access to the socket is the authentication on Linux
+	listener, err := listenUnix(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set control socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// Stop closes event streams and shuts the server down
+func (s *Server) Stop() {
+	s.mutex.Lock()
+	server := s.server
+	s.server = nil
+	s.mutex.Unlock()
+	if server == nil {
+		return
+	}
+
+	close(s.done)
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("ControlAPI: Shutdown: %v", err)
+		server.Close()
+	}
+	if s.endpoint.Network == "unix" {
+		_ = os.Remove(s.endpoint.Address)
+		if isTempSocketPath(s.endpoint.Address) {
+			_ = os.Remove(filepath.Dir(s.endpoint.Address))
+		}
+	}
+	log.Println("ControlAPI: Stopped")
+}
+
+// authenticate checks the bearer token (TCP only) and rejects foreign Host headers (DNS rebinding)
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	if s.endpoint.Network == "unix" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopbackHost(r.Host) {
+			writeError(w, http.StatusForbidden, errors.New("forbidden host"))
+			return
+		}
+
+		token := r.URL.Query().Get("token") // EventSource in browsers can't set headers
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			token = strings.TrimPrefix(auth, "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.endpoint.Token)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("invalid or missing token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLoopbackHost reports whether the Host header names the local machine
+func isLoopbackHost(host string) bool {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), "0")
+	}
+	return isLoopbackAddress(host)
+}
@@ -0,0 +1,410 @@
+package control
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"singbox-launcher/api/apitest"
+	"singbox-launcher/core"
+)
+
+// newTestController creates a headless AppController in a temporary directory
+func newTestController(t *testing.T, settings core.ControlAPISettings) *core.AppController {
+	t.Helper()
+	ac, err := core.NewAppControllerInDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewAppControllerInDir failed: %v", err)
+	}
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		for _, f := range []*core.RotatingLogFile{ac.MainLogFile, ac.ChildLogFile, ac.ApiLogFile} {
+			if f != nil {
+				f.Close()
+			}
+		}
+	})
+	if err := ac.Settings.Update(func(s *core.LauncherSettings) { s.ControlAPI = settings }); err != nil {
+		t.Fatal(err)
+	}
+	return ac
+}
+
+// newTCPTestServer creates a server with token authentication served by httptest
+func newTCPTestServer(t *testing.T) (*Server, *httptest.Server, *core.AppController) {
+	t.Helper()
+	ac := newTestController(t, core.ControlAPISettings{Listen: "127.0.0.1:0"})
+	server, err := NewServer(ac)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
+	return server, ts, ac
+}
+
+// request sends an authenticated request and decodes the JSON response into v (if not nil)
+func request(t *testing.T, ts *httptest.Server, token, method, path, body string, v interface{}) int {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("%s %s: invalid JSON: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// TestResolveEndpoint tests endpoint selection and loopback validation
+func TestResolveEndpoint(t *testing.T) {
+	if _, err := ResolveEndpoint("/app", core.ControlAPISettings{Listen: "0.0.0.0:19090"}); err == nil {
+		t.Error("Expected error for non-loopback address")
+	}
+	if _, err := ResolveEndpoint("/app", core.ControlAPISettings{Listen: "192.168.1.2:19090"}); err == nil {
+		t.Error("Expected error for LAN address")
+	}
+
+	ep, err := ResolveEndpoint("/app", core.ControlAPISettings{Listen: "localhost:1234", Token: "t"})
+	if err != nil || ep.Network != "tcp" || ep.Address != "localhost:1234" || ep.Token != "t" {
+		t.Errorf("Unexpected endpoint %+v, err %v", ep, err)
+	}
+
+	ep, err = ResolveEndpoint("/app", core.ControlAPISettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS == "linux" {
+		if ep.Network != "unix" || !strings.HasSuffix(ep.Address, "control.sock") {
+			t.Errorf("Expected Unix socket on Linux, got %+v", ep)
+		}
+	} else if ep.Network != "tcp" {
+		t.Errorf("Expected TCP, got %+v", ep)
+	}
+
+	long := "/" + strings.Repeat("a", 120)
+	if path := socketPath(long); len(path) > maxSocketPathLength || !isTempSocketPath(path) {
+		t.Errorf("Expected a short socket path in a directory of the temp dir, got %s", path)
+	}
+}
+
+// TestEnsurePrivateDir tests that the socket directory in the temp dir is not shared with other users
+func TestEnsurePrivateDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permissions")
+	}
+	dir := filepath.Join(t.TempDir(), "sock")
+	if err := ensurePrivateDir(dir); err != nil {
+		t.Fatalf("ensurePrivateDir failed: %v", err)
+	}
+	if info, err := os.Stat(dir); err != nil || info.Mode().Perm() != 0700 {
+		t.Fatalf("Expected a 0700 directory, got %v (err: %v)", info.Mode().Perm(), err)
+	}
+	if err := ensurePrivateDir(dir); err != nil {
+		t.Errorf("Existing private directory rejected: %v", err)
+	}
+
+	if err := os.Chmod(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ensurePrivateDir(dir); err == nil {
+		t.Error("Expected error for a directory readable by other users")
+	}
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(dir, link); err != nil {
+		t.Fatal(err)
+	}
+	if err := ensurePrivateDir(link); err == nil {
+		t.Error("Expected error for a symlink")
+	}
+}
+
+// TestAuthentication tests token and Host checks of the TCP endpoint
+func TestAuthentication(t *testing.T) {
+	server, ts, ac := newTCPTestServer(t)
+	token := server.Endpoint().Token
+	if token == "" {
+		t.Fatal("Expected generated token")
+	}
+	var saved string
+	ac.Settings.Get(func(s *core.LauncherSettings) { saved = s.ControlAPI.Token })
+	if saved != token {
+		t.Errorf("Token not saved to launcher settings: %q", saved)
+	}
+
+	if code := request(t, ts, "", "GET", "/v1/status", "", nil); code != http.StatusUnauthorized {
+		t.Errorf("No token: got %d", code)
+	}
+	if code := request(t, ts, "wrong", "GET", "/v1/status", "", nil); code != http.StatusUnauthorized {
+		t.Errorf("Wrong token: got %d", code)
+	}
+	if code := request(t, ts, token, "GET", "/v1/status", "", nil); code != http.StatusOK {
+		t.Errorf("Valid token: got %d", code)
+	}
+	if code := request(t, ts, "", "GET", "/v1/status?token="+token, "", nil); code != http.StatusOK {
+		t.Errorf("Token in query: got %d", code)
+	}
+
+	req, _ := http.NewRequest("GET", ts.URL+"/v1/status", nil)
+	req.Host = "attacker.example:80"
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Foreign Host: got %d", resp.StatusCode)
+	}
+}
+
+// TestStatusAndProfiles tests read endpoints and request validation
+func TestStatusAndProfiles(t *testing.T) {
+	server, ts, _ := newTCPTestServer(t)
+	token := server.Endpoint().Token
+
+	var status statusResponse
+	if code := request(t, ts, token, "GET", "/v1/status", "", &status); code != http.StatusOK {
+		t.Fatalf("status: got %d", code)
+	}
+	if status.Running || status.Profile != core.DefaultProfileName || status.ConfigExists {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+
+	var profiles profilesResponse
+	if code := request(t, ts, token, "GET", "/v1/profiles", "", &profiles); code != http.StatusOK {
+		t.Fatalf("profiles: got %d", code)
+	}
+	if profiles.Active != core.DefaultProfileName {
+		t.Errorf("Unexpected profiles: %+v", profiles)
+	}
+
+	var errRes errorResponse
+	if code := request(t, ts, token, "POST", "/v1/profiles/switch", `{"name":"missing"}`, &errRes); code != http.StatusNotFound {
+		t.Errorf("Switch to missing profile: got %d", code)
+	}
+	if code := request(t, ts, token, "POST", "/v1/profiles/switch", `{"profile":"x"}`, &errRes); code != http.StatusBadRequest {
+		t.Errorf("Invalid body: got %d", code)
+	}
+	for _, name := range []string{"..", "../x", "/tmp"} {
+		if code := request(t, ts, token, "POST", "/v1/profiles/switch", `{"name":"`+name+`"}`, &errRes); code != http.StatusBadRequest {
+			t.Errorf("Switch to %q: got %d", name, code)
+		}
+	}
+	if status := server.status(); status.Profile != core.DefaultProfileName {
+		t.Errorf("Active profile changed to %q", status.Profile)
+	}
+	if code := request(t, ts, token, "GET", "/v1/proxies", "", &errRes); code != http.StatusConflict {
+		t.Errorf("Proxies without Clash API: got %d", code)
+	}
+	if code := request(t, ts, token, "GET", "/v1/start", "", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /v1/start: got %d", code)
+	}
+}
+
+// TestEventStream tests Server-Sent Events delivery with a type filter
+func TestEventStream(t *testing.T) {
+	server, ts, ac := newTCPTestServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL+"/v1/events?types=config", nil)
+	req.Header.Set("Authorization", "Bearer "+server.Endpoint().Token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Unexpected content type %q", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	if line, _ := reader.ReadString('\n'); !strings.HasPrefix(line, ": connected") {
+		t.Fatalf("Unexpected first line %q", line)
+	}
+
+	ac.Events.Publish(core.Event{Type: core.EventParserProgress, Progress: 10}) // Filtered out
+	ac.NotifyConfigChanged()
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Stream ended: %v", err)
+		}
+		if strings.HasPrefix(line, "event: ") {
+			if line != "event: config\n" {
+				t.Errorf("Unexpected event line %q", line)
+			}
+			return
+		}
+	}
+}
+
+// TestLogs tests the in-memory sing-box log endpoint and its filters
+func TestLogs(t *testing.T) {
+	server, ts, ac := newTCPTestServer(t)
+	token := server.Endpoint().Token
+
+	now := time.Now()
+	for _, line := range []string{"INFO[0000] started", "WARN[0001] dns: slow", "ERROR[0002] router: failed", "INFO[0003] done"} {
+		ac.CoreLog.Add(core.ParseSingboxLogLine(line, now))
+	}
+
+	var res logsResponse
+	if code := request(t, ts, token, "GET", "/v1/logs?level=warn", "", &res); code != http.StatusOK {
+		t.Fatalf("logs: got %d", code)
+	}
+	if len(res.Entries) != 2 || res.Entries[0].Component != "dns" || res.LastSeq != 4 {
+		t.Errorf("Unexpected logs %+v", res)
+	}
+	if code := request(t, ts, token, "GET", "/v1/logs?since=3&limit=10", "", &res); code != http.StatusOK {
+		t.Fatalf("logs since: got %d", code)
+	}
+	if len(res.Entries) != 1 || res.Entries[0].Message != "done" {
+		t.Errorf("Unexpected logs since 3: %+v", res.Entries)
+	}
+	if code := request(t, ts, token, "GET", "/v1/logs?level=loud", "", nil); code != http.StatusBadRequest {
+		t.Errorf("Invalid level: got %d", code)
+	}
+}
+
+// TestProxies tests proxy listing and switching through a fake Clash API
+func TestProxies(t *testing.T) {
+	server, ts, ac := newTCPTestServer(t)
+	token := server.Endpoint().Token
+
+	fake := apitest.NewFakeServer("clash-secret")
+	defer fake.Close()
+	fake.AddProxy("de 1", "VLESS", 0)
+	fake.AddProxy("nl/2", "Trojan", 0)
+	fake.AddGroup("proxy out", "Selector", "de 1", "nl/2")
+	ac.ClashAPIBaseURL, ac.ClashAPIToken, ac.ClashAPIEnabled = fake.URL, fake.Secret, true
+	ac.SelectedClashGroup = "proxy out"
+	ac.RunningState.Set(true)
+
+	if code := request(t, ts, token, "POST", "/v1/proxies/switch", `{"proxy":"nl/2"}`, nil); code != http.StatusOK {
+		t.Fatalf("switch: got %d", code)
+	}
+	var res proxiesResponse
+	if code := request(t, ts, token, "GET", "/v1/proxies", "", &res); code != http.StatusOK {
+		t.Fatalf("proxies: got %d", code)
+	}
+	if res.Group != "proxy out" || res.Now != "nl/2" || len(res.Proxies) != 2 || !res.Proxies[1].Active {
+		t.Errorf("Unexpected proxies %+v", res)
+	}
+	if code := request(t, ts, token, "GET", "/v1/proxies?group=missing", "", nil); code != http.StatusBadGateway {
+		t.Errorf("Missing group: got %d", code)
+	}
+}
+
+// TestUnixSocketServer tests the Unix socket endpoint without token
+func TestUnixSocketServer(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Unix socket endpoint is used on Linux")
+	}
+	ac := newTestController(t, core.ControlAPISettings{})
+	server, err := NewServer(ac)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	path := server.Endpoint().Address
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Socket permissions %v, want 0600", info.Mode().Perm())
+	}
+
+	// Second instance must not steal the socket
+	second, _ := NewServer(ac)
+	if err := second.Start(); err == nil {
+		t.Error("Expected error when socket is in use")
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://launcher/v1/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status over socket: got %d", resp.StatusCode)
+	}
+	client.CloseIdleConnections()
+
+	server.Stop()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected socket to be removed, stat err: %v", err)
+	}
+}
+
+// TestForward tests forwarding of a second invocation's command line to the running launcher
+func TestForward(t *testing.T) {
+	ac := newTestController(t, core.ControlAPISettings{Listen: "127.0.0.1:0"})
+	server, err := NewServer(ac)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
+	// Forward reads the endpoint from launcher settings like a second launcher process
+	if err := ac.Settings.Update(func(s *core.LauncherSettings) {
+		s.ControlAPI.Listen = strings.TrimPrefix(ts.URL, "http://")
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	events := make(chan core.EventType, 16)
+	ac.Events.Subscribe(func(e core.Event) { events <- e.Type })
+
+	link := "sing-box://import-remote-profile?url=https%3A%2F%2Fexample.com%2Fc.json#Work"
+	if err := Forward(ac.ExecDir, ForwardRequest{Link: link}); err != nil {
+		t.Fatalf("Forward failed: %v", err)
+	}
+	if got := <-events; got != core.EventShowWindowRequested {
+		t.Errorf("Expected %q event, got %q", core.EventShowWindowRequested, got)
+	}
+
+	// Switching without running sing-box fails, the window is still shown
+	err = Forward(ac.ExecDir, ForwardRequest{Switch: "NL-1"})
+	if !IsStatus(err, http.StatusConflict) {
+		t.Errorf("Expected 409 for switch without sing-box, got %v", err)
+	}
+	if got := <-events; got != core.EventShowWindowRequested {
+		t.Errorf("Expected %q event, got %q", core.EventShowWindowRequested, got)
+	}
+
+	if err := Forward(ac.ExecDir, ForwardRequest{Link: "sing-box://import-remote-profile"}); !IsStatus(err, http.StatusBadRequest) {
+		t.Errorf("Expected 400 for invalid link, got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/muhammadmuzzammil1998/jsonc"
+
+	"singbox-launcher/api"
+)
+
+// DefaultClashModes are offered when the core does not report its mode list
+var DefaultClashModes = []string{"Rule", "Global", "Direct"}
+
+// ClashMode returns the routing mode last read from or set in the Clash API ("" if unknown)
+// and the modes of the running config
+func (ac *AppController) ClashMode() (string, []string) {
+	ac.APIStateMutex.RLock()
+	defer ac.APIStateMutex.RUnlock()
+	return ac.clashMode, ac.clashModes
+}
+
+// setClashMode caches the routing mode and publishes EventClashModeChanged if it changed
+func (ac *AppController) setClashMode(mode string, modes []string) {
+	if len(modes) == 0 && mode != "" {
+		modes = DefaultClashModes
+	}
+	ac.APIStateMutex.Lock()
+	changed := ac.clashMode != mode
+	ac.clashMode, ac.clashModes = mode, modes
+	ac.APIStateMutex.Unlock()
+	if changed {
+		ac.Events.Publish(Event{Type: EventClashModeChanged, Message: mode})
+	}
+}
+
+// LoadClashMode reads the routing mode and the known modes from the Clash API (GET /configs)
+func (ac *AppController) LoadClashMode(ctx context.Context) (*api.Configs, error) {
+	if !ac.ClashAPIEnabled {
+		return nil, fmt.Errorf("Clash API is disabled in %s", ac.GetConfigPath())
+	}
+	configs, err := ac.ClashClient().Configs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(configs.ModeList) == 0 {
+		configs.ModeList = DefaultClashModes
+	}
+	ac.setClashMode(configs.Mode, configs.ModeList)
+	return configs, nil
+}
+
+// SetClashMode switches the routing mode via Clash API and saves it in launcher settings,
+// so it is re-applied after sing-box restarts. sing-box ignores unknown modes, so the mode
+// is read back to check that it was applied.
+func (ac *AppController) SetClashMode(ctx context.Context, mode string) error {
+	if !ac.ClashAPIEnabled {
+		return fmt.Errorf("Clash API is disabled in %s", ac.GetConfigPath())
+	}
+	client := ac.ClashClient()
+	if err := client.SetMode(ctx, mode); err != nil {
+		return err
+	}
+	configs, err := client.Configs(ctx)
+	if err != nil {
+		return err
+	}
+	ac.setClashMode(configs.Mode, configs.ModeList)
+	if !strings.EqualFold(configs.Mode, mode) {
+		return fmt.Errorf("mode '%s' is not supported by the running config (modes: %s)", mode, strings.Join(configs.ModeList, ", "))
+	}
+
+	if err := ac.Settings.Update(func(s *LauncherSettings) { s.ClashMode = configs.Mode }); err != nil {
+		log.Printf("SetClashMode: Failed to save mode: %v", err)
+	}
+	log.Printf("SetClashMode: Routing mode is %s", configs.Mode)
+	return nil
+}
+
+// RestoreClashMode applies the mode saved by SetClashMode after sing-box starts
+// (the core starts in the default mode of config.json). Modes unknown to the config are skipped.
+func (ac *AppController) RestoreClashMode(ctx context.Context) error {
+	var saved string
+	ac.Settings.Get(func(s *LauncherSettings) { saved = s.ClashMode })
+
+	configs, err := ac.LoadClashMode(ctx)
+	if err != nil {
+		return err
+	}
+	if saved == "" || strings.EqualFold(configs.Mode, saved) {
+		return nil
+	}
+	known := false
+	for _, mode := range configs.ModeList {
+		known = known || strings.EqualFold(mode, saved)
+	}
+	if !known {
+		log.Printf("RestoreClashMode: Mode '%s' is not in the config (modes: %s), keeping %s", saved, strings.Join(configs.ModeList, ", "), configs.Mode)
+		return nil
+	}
+	log.Printf("RestoreClashMode: Restoring mode %s", saved)
+	return ac.SetClashMode(ctx, saved)
+}
+
+// ConfigHasClashModeRules reports whether route or DNS rules of config.json use clash_mode.
+// Without such rules switching the mode changes nothing.
+func ConfigHasClashModeRules(configPath string) (bool, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read config: %w", err)
+	}
+	var config struct {
+		Route json.RawMessage `json:"route"`
+		DNS   json.RawMessage `json:"dns"`
+	}
+	if err := json.Unmarshal(jsonc.ToJSON(data), &config); err != nil {
+		return false, fmt.Errorf("failed to parse config: %w", err)
+	}
+	for _, section := range []json.RawMessage{config.Route, config.DNS} {
+		var value interface{}
+		if len(section) > 0 && json.Unmarshal(section, &value) == nil && hasKey(value, "clash_mode") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hasKey reports whether a decoded JSON value contains an object with key at any depth
+// (rules can be nested in logical rules)
+func hasKey(value interface{}, key string) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if _, ok := v[key]; ok {
+			return true
+		}
+		for _, child := range v {
+			if hasKey(child, key) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if hasKey(child, key) {
+				return true
+			}
+		}
+	}
+	return false
+}
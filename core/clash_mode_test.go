@@ -0,0 +1,86 @@
+package core
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"singbox-launcher/api/apitest"
+)
+
+// TestClashMode tests switching the routing mode and restoring it after a restart
+func TestClashMode(t *testing.T) {
+	ac, _ := newTestController(t)
+	fake := apitest.NewFakeServer("secret")
+	defer fake.Close()
+	ac.ClashAPIBaseURL, ac.ClashAPIToken, ac.ClashAPIEnabled = fake.URL, fake.Secret, true
+
+	var events []Event
+	unsubscribe := ac.Events.Subscribe(func(e Event) {
+		if e.Type == EventClashModeChanged {
+			events = append(events, e)
+		}
+	})
+	defer unsubscribe()
+
+	if _, err := ac.LoadClashMode(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if mode, modes := ac.ClashMode(); mode != "Rule" || len(modes) != 3 {
+		t.Fatalf("ClashMode = %q, %v", mode, modes)
+	}
+	if err := ac.SetClashMode(context.Background(), "Global"); err != nil {
+		t.Fatal(err)
+	}
+	var saved string
+	ac.Settings.Get(func(s *LauncherSettings) { saved = s.ClashMode })
+	if saved != "Global" || fake.Mode() != "Global" {
+		t.Errorf("Saved mode = %q, core mode = %q", saved, fake.Mode())
+	}
+	if err := ac.SetClashMode(context.Background(), "Fastest"); err == nil {
+		t.Error("Expected an error for a mode unknown to the config")
+	}
+	if len(events) != 2 || events[0].Message != "Rule" || events[1].Message != "Global" {
+		t.Errorf("Unexpected events %+v", events)
+	}
+
+	// sing-box restarted in the default mode of config.json
+	ac.setClashMode("", nil)
+	if err := fake.Client().SetMode(context.Background(), "Rule"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ac.RestoreClashMode(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if mode, _ := ac.ClashMode(); mode != "Global" || fake.Mode() != "Global" {
+		t.Errorf("Restored mode = %q, core mode = %q", mode, fake.Mode())
+	}
+}
+
+// TestConfigHasClashModeRules tests detection of clash_mode in route and DNS rules
+func TestConfigHasClashModeRules(t *testing.T) {
+	ac, _ := newTestController(t)
+	tests := []struct {
+		name   string
+		config string
+		want   bool
+	}{
+		{"no rules", `{"route": {"rules": [{"domain_suffix": [".ru"], "outbound": "direct-out"}]}}`, false},
+		{"route rule", `{"route": {"rules": [{"clash_mode": "Direct", "outbound": "direct-out"}]}}`, true},
+		{"logical rule", `{
+			// JSONC
+			"route": {"rules": [{"type": "logical", "mode": "and", "rules": [{"clash_mode": "Global"}, {"network": "tcp"}], "outbound": "proxy-out"}]}
+		}`, true},
+		{"dns rule", `{"dns": {"rules": [{"clash_mode": "Direct", "server": "local"}]}}`, true},
+		{"outside rules", `{"experimental": {"clash_api": {"default_mode": "Rule", "clash_mode": "x"}}}`, false},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(ac.ConfigPath, []byte(tt.config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ConfigHasClashModeRules(ac.ConfigPath)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %v, %v, want %v", tt.name, got, err, tt.want)
+		}
+	}
+}
@@ -55,15 +55,21 @@ type v2ParserConfig struct {
 // Takes JSON string as input, returns migrated JSON string
 type MigrationFunc func(jsonContent string) (string, error)
 
+// DowngradeFunc is a function that converts JSON content from version N to version N-1
+// Returns converted JSON string and warnings about settings that cannot be represented in the older version
+type DowngradeFunc func(jsonContent string) (string, []string, error)
+
 // ConfigMigrator handles automatic migration of ParserConfig between versions
 type ConfigMigrator struct {
 	migrations map[int]MigrationFunc // For migrations working with JSON strings
+	downgrades map[int]DowngradeFunc // Downgrades keyed by source version (N → N-1)
 }
 
 // NewConfigMigrator creates a new migrator with registered migrations
 func NewConfigMigrator() *ConfigMigrator {
 	migrator := &ConfigMigrator{
 		migrations: make(map[int]MigrationFunc),
+		downgrades: make(map[int]DowngradeFunc),
 	}
 
 	// Register all migrations
@@ -71,6 +77,11 @@ func NewConfigMigrator() *ConfigMigrator {
 	migrator.RegisterMigration(2, migrateV2ToV3)
 	migrator.RegisterMigration(3, migrateV3ToV4)
 
+	// Register all downgrades (used to share config with older launcher versions)
+	migrator.RegisterDowngrade(4, downgradeV4ToV3)
+	migrator.RegisterDowngrade(3, downgradeV3ToV2)
+	migrator.RegisterDowngrade(2, downgradeV2ToV1)
+
 	return migrator
 }
 
@@ -79,6 +90,11 @@ func (m *ConfigMigrator) RegisterMigration(fromVersion int, fn MigrationFunc) {
 	m.migrations[fromVersion] = fn
 }
 
+// RegisterDowngrade registers a downgrade function from fromVersion to fromVersion-1
+func (m *ConfigMigrator) RegisterDowngrade(fromVersion int, fn DowngradeFunc) {
+	m.downgrades[fromVersion] = fn
+}
+
 // extractVersion extracts version from JSON content string
 // Returns version from ParserConfig.version, or from top-level version (legacy v1), or 0 if not found
 func extractVersion(jsonContent string) int {
@@ -134,11 +150,27 @@ func (m *ConfigMigrator) MigrateRaw(jsonContent string, currentVersion int, targ
 	}
 
 	// Apply migrations sequentially (string → string)
+	currentJSON, err := m.migrateUp(jsonContent, currentVersion, targetVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse final JSON into clean ParserConfig (version 3)
+	var parserConfig *ParserConfig
+	if err := json.Unmarshal([]byte(currentJSON), &parserConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse migrated @ParserConfig JSON: %w", err)
+	}
+
+	return parserConfig, nil
+}
+
+// migrateUp applies migrations sequentially from currentVersion to targetVersion
+func (m *ConfigMigrator) migrateUp(jsonContent string, currentVersion, targetVersion int) (string, error) {
 	currentJSON := jsonContent
 	for version := currentVersion; version < targetVersion; version++ {
 		migration, exists := m.migrations[version]
 		if !exists {
-			return nil, fmt.Errorf("migration from version %d to %d not found", version, version+1)
+			return "", fmt.Errorf("migration from version %d to %d not found", version, version+1)
 		}
 
 		log.Printf("ConfigMigrator: Migrating from version %d to version %d", version, version+1)
@@ -146,19 +178,87 @@ func (m *ConfigMigrator) MigrateRaw(jsonContent string, currentVersion int, targ
 		var err error
 		currentJSON, err = migration(currentJSON)
 		if err != nil {
-			return nil, fmt.Errorf("failed to migrate from version %d to %d: %w", version, version+1, err)
+			return "", fmt.Errorf("failed to migrate from version %d to %d: %w", version, version+1, err)
 		}
 
 		log.Printf("ConfigMigrator: Successfully migrated to version %d", version+1)
 	}
+	return currentJSON, nil
+}
 
-	// Parse final JSON into clean ParserConfig (version 3)
-	var parserConfig *ParserConfig
-	if err := json.Unmarshal([]byte(currentJSON), &parserConfig); err != nil {
-		return nil, fmt.Errorf("failed to parse migrated @ParserConfig JSON: %w", err)
+// migrateDown applies downgrades sequentially from currentVersion to targetVersion
+// Returns converted JSON and accumulated loss warnings
+func (m *ConfigMigrator) migrateDown(jsonContent string, currentVersion, targetVersion int) (string, []string, error) {
+	currentJSON := jsonContent
+	var warnings []string
+	for version := currentVersion; version > targetVersion; version-- {
+		downgrade, exists := m.downgrades[version]
+		if !exists {
+			return "", nil, fmt.Errorf("downgrade from version %d to %d not found", version, version-1)
+		}
+
+		log.Printf("ConfigMigrator: Downgrading from version %d to version %d", version, version-1)
+
+		stepJSON, stepWarnings, err := downgrade(currentJSON)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to downgrade from version %d to %d: %w", version, version-1, err)
+		}
+		for _, w := range stepWarnings {
+			log.Printf("ConfigMigrator: Warning (v%d → v%d): %s", version, version-1, w)
+			warnings = append(warnings, fmt.Sprintf("v%d → v%d: %s", version, version-1, w))
+		}
+		currentJSON = stepJSON
 	}
+	return currentJSON, warnings, nil
+}
 
-	return parserConfig, nil
+// MigrationPreview describes the result of converting @ParserConfig between versions without writing it
+type MigrationPreview struct {
+	FromVersion  int
+	ToVersion    int
+	OriginalJSON string   // @ParserConfig block content before conversion
+	MigratedJSON string   // @ParserConfig block content after conversion
+	Warnings     []string // Settings that are lost by a downgrade
+}
+
+// Preview converts JSON content to targetVersion (upgrade or downgrade) and returns the result
+// without writing anything. The source must not be newer than ParserConfigVersion.
+func (m *ConfigMigrator) Preview(jsonContent string, targetVersion int) (*MigrationPreview, error) {
+	if jsonContent == "" {
+		return nil, fmt.Errorf("json content is empty")
+	}
+	if targetVersion < 1 || targetVersion > ParserConfigVersion {
+		return nil, fmt.Errorf("unsupported target version %d (supported: 1-%d)", targetVersion, ParserConfigVersion)
+	}
+
+	currentVersion := extractVersion(jsonContent)
+	if currentVersion == 0 {
+		currentVersion = 1
+	}
+	if currentVersion > ParserConfigVersion {
+		return nil, fmt.Errorf("config version %d is newer than supported version %d. Please update the application",
+			currentVersion, ParserConfigVersion)
+	}
+
+	preview := &MigrationPreview{
+		FromVersion:  currentVersion,
+		ToVersion:    targetVersion,
+		OriginalJSON: jsonContent,
+	}
+
+	var err error
+	switch {
+	case currentVersion < targetVersion:
+		preview.MigratedJSON, err = m.migrateUp(jsonContent, currentVersion, targetVersion)
+	case currentVersion > targetVersion:
+		preview.MigratedJSON, preview.Warnings, err = m.migrateDown(jsonContent, currentVersion, targetVersion)
+	default:
+		preview.MigratedJSON = jsonContent
+	}
+	if err != nil {
+		return nil, err
+	}
+	return preview, nil
 }
 
 // migrateV1ToV2 migrates JSON content from version 1 to version 2
@@ -235,22 +335,12 @@ func migrateV2ToV3(jsonContent string) (string, error) {
 	}
 
 	// Convert to version 3 structure (clean ParserConfig)
-	v3 := ParserConfig{
-		ParserConfig: struct {
-			Version   int              `json:"version,omitempty"`
-			Proxies   []ProxySource    `json:"proxies"`
-			Outbounds []OutboundConfig `json:"outbounds"`
-			Parser    struct {
-				Reload      string `json:"reload,omitempty"`
-				LastUpdated string `json:"last_updated,omitempty"`
-			} `json:"parser,omitempty"`
-		}{
-			Version:   3,
-			Proxies:   v2.ParserConfig.Proxies,
-			Outbounds: convertV2OutboundsToV3(v2.ParserConfig.Outbounds),
-			Parser:    v2.ParserConfig.Parser,
-		},
-	}
+	var v3 ParserConfig
+	v3.ParserConfig.Version = 3
+	v3.ParserConfig.Proxies = v2.ParserConfig.Proxies
+	v3.ParserConfig.Outbounds = convertV2OutboundsToV3(v2.ParserConfig.Outbounds)
+	v3.ParserConfig.Parser.Reload = v2.ParserConfig.Parser.Reload
+	v3.ParserConfig.Parser.LastUpdated = v2.ParserConfig.Parser.LastUpdated
 
 	// Serialize to JSON
 	resultJSON, err := json.MarshalIndent(v3, "", "  ")
@@ -324,3 +414,121 @@ func migrateV3ToV4(jsonContent string) (string, error) {
 	log.Printf("migrateV3ToV4: Successfully migrated from version 3 to version 4")
 	return string(resultJSON), nil
 }
+
+// downgradeV4ToV3 converts JSON content from version 4 to version 3
+// Version 3 has no per-source local outbounds, tag prefix/postfix/mask and per-source
+// reload/last_updated, they are removed
+func downgradeV4ToV3(jsonContent string) (string, []string, error) {
+	var v4 ParserConfig
+	if err := json.Unmarshal([]byte(jsonContent), &v4); err != nil {
+		return "", nil, fmt.Errorf("failed to parse version 4 config: %w", err)
+	}
+
+	var warnings []string
+	for i := range v4.ParserConfig.Proxies {
+		proxy := &v4.ParserConfig.Proxies[i]
+		if len(proxy.Outbounds) > 0 {
+			warnings = append(warnings, fmt.Sprintf("proxies[%d]: %d local outbound(s) removed", i, len(proxy.Outbounds)))
+			proxy.Outbounds = nil
+		}
+		if proxy.TagPrefix != "" || proxy.TagPostfix != "" || proxy.TagMask != "" {
+			warnings = append(warnings, fmt.Sprintf("proxies[%d]: tag_prefix/tag_postfix/tag_mask removed", i))
+			proxy.TagPrefix, proxy.TagPostfix, proxy.TagMask = "", "", ""
+		}
+		if proxy.Reload != "" || proxy.LastUpdated != "" || proxy.LastFailed != "" {
+			warnings = append(warnings, fmt.Sprintf("proxies[%d]: per-source reload/last_updated removed (parser.reload and parser.last_updated are used)", i))
+			proxy.Reload, proxy.LastUpdated, proxy.LastFailed = "", "", ""
+		}
+	}
+	v4.ParserConfig.Version = 3
+
+	resultJSON, err := json.MarshalIndent(v4, "", "  ")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal version 3 config: %w", err)
+	}
+	return string(resultJSON), warnings, nil
+}
+
+// downgradeV3ToV2 converts JSON content from version 3 to version 2
+// Moves filters/addOutbounds/preferredDefault back into the nested "outbounds" object
+func downgradeV3ToV2(jsonContent string) (string, []string, error) {
+	var v3 ParserConfig
+	if err := json.Unmarshal([]byte(jsonContent), &v3); err != nil {
+		return "", nil, fmt.Errorf("failed to parse version 3 config: %w", err)
+	}
+
+	var warnings []string
+	var v2 v2ParserConfig
+	v2.ParserConfig.Version = 2
+	v2.ParserConfig.Proxies = v3.ParserConfig.Proxies
+	v2.ParserConfig.Parser.Reload = v3.ParserConfig.Parser.Reload
+	v2.ParserConfig.Parser.LastUpdated = v3.ParserConfig.Parser.LastUpdated
+	if v3.ParserConfig.Parser.Jitter != "" || v3.ParserConfig.Parser.QuietHours != nil {
+		warnings = append(warnings, "parser.jitter/parser.quiet_hours removed")
+	}
+
+	v2.ParserConfig.Outbounds = make([]v2OutboundConfig, 0, len(v3.ParserConfig.Outbounds))
+	for _, outbound := range v3.ParserConfig.Outbounds {
+		converted := v2OutboundConfig{
+			Tag:     outbound.Tag,
+			Type:    outbound.Type,
+			Options: outbound.Options,
+			Comment: outbound.Comment,
+		}
+		converted.Outbounds.Proxies = outbound.Filters
+		converted.Outbounds.AddOutbounds = outbound.AddOutbounds
+		converted.Outbounds.PreferredDefault = outbound.PreferredDefault
+		if outbound.Wizard != "" {
+			warnings = append(warnings, fmt.Sprintf("outbound '%s': wizard setting removed", outbound.Tag))
+		}
+		v2.ParserConfig.Outbounds = append(v2.ParserConfig.Outbounds, converted)
+	}
+
+	resultJSON, err := json.MarshalIndent(v2, "", "  ")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal version 2 config: %w", err)
+	}
+	return string(resultJSON), warnings, nil
+}
+
+// downgradeV2ToV1 converts JSON content from version 2 to version 1
+// Moves version to the top level and flattens the nested "outbounds" object
+func downgradeV2ToV1(jsonContent string) (string, []string, error) {
+	var v2 v2ParserConfig
+	if err := json.Unmarshal([]byte(jsonContent), &v2); err != nil {
+		return "", nil, fmt.Errorf("failed to parse version 2 config: %w", err)
+	}
+
+	var v1 v1ParserConfig
+	v1.Version = 1
+	v1.ParserConfig.Proxies = v2.ParserConfig.Proxies
+	v1.ParserConfig.Parser = v2.ParserConfig.Parser
+	v1.ParserConfig.Outbounds = make([]OutboundConfig, 0, len(v2.ParserConfig.Outbounds))
+	for _, outbound := range v2.ParserConfig.Outbounds {
+		converted := OutboundConfig{
+			Tag:              outbound.Tag,
+			Type:             outbound.Type,
+			Options:          outbound.Options,
+			Filters:          outbound.Filters,
+			AddOutbounds:     outbound.AddOutbounds,
+			PreferredDefault: outbound.PreferredDefault,
+			Comment:          outbound.Comment,
+		}
+		if len(outbound.Outbounds.Proxies) > 0 {
+			converted.Filters = outbound.Outbounds.Proxies
+		}
+		if len(outbound.Outbounds.AddOutbounds) > 0 {
+			converted.AddOutbounds = outbound.Outbounds.AddOutbounds
+		}
+		if len(outbound.Outbounds.PreferredDefault) > 0 {
+			converted.PreferredDefault = outbound.Outbounds.PreferredDefault
+		}
+		v1.ParserConfig.Outbounds = append(v1.ParserConfig.Outbounds, converted)
+	}
+
+	resultJSON, err := json.MarshalIndent(v1, "", "  ")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal version 1 config: %w", err)
+	}
+	return string(resultJSON), nil, nil
+}
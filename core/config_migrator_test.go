@@ -0,0 +1,187 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"singbox-launcher/internal/constants"
+)
+
+const testV4ParserConfig = `{
+  "ParserConfig": {
+    "version": 4,
+    "proxies": [
+      {
+        "source": "https://example.com/sub",
+        "tag_prefix": "A-",
+        "reload": "1h",
+        "last_updated": "2024-05-10T12:00:00Z",
+        "outbounds": [{"tag": "local-out", "type": "selector"}]
+      }
+    ],
+    "outbounds": [
+      {
+        "tag": "proxy-out",
+        "type": "selector",
+        "filters": {"tag": "!/(🇷🇺)/i"},
+        "addOutbounds": ["direct-out"],
+        "preferredDefault": {"tag": "/🇳🇱/i"},
+        "wizard": "hide"
+      }
+    ],
+    "parser": {"reload": "2h", "jitter": "5m"}
+  }
+}`
+
+// TestConfigMigrator_Downgrade tests downgrade path with loss warnings
+func TestConfigMigrator_Downgrade(t *testing.T) {
+	migrator := NewConfigMigrator()
+
+	t.Run("Version 4 to version 3", func(t *testing.T) {
+		preview, err := migrator.Preview(testV4ParserConfig, 3)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if preview.FromVersion != 4 || preview.ToVersion != 3 {
+			t.Errorf("Expected 4 → 3, got %d → %d", preview.FromVersion, preview.ToVersion)
+		}
+		if extractVersion(preview.MigratedJSON) != 3 {
+			t.Errorf("Expected migrated version 3, got %d", extractVersion(preview.MigratedJSON))
+		}
+		for _, field := range []string{"local-out", "tag_prefix", `"1h"`, "last_updated"} {
+			if strings.Contains(preview.MigratedJSON, field) {
+				t.Errorf("Expected version 4 field %s to be removed, got:\n%s", field, preview.MigratedJSON)
+			}
+		}
+		if len(preview.Warnings) != 3 {
+			t.Errorf("Expected 3 warnings, got %v", preview.Warnings)
+		}
+	})
+
+	t.Run("Version 4 to version 1 and back", func(t *testing.T) {
+		preview, err := migrator.Preview(testV4ParserConfig, 1)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var v1 v1ParserConfig
+		if err := json.Unmarshal([]byte(preview.MigratedJSON), &v1); err != nil {
+			t.Fatalf("Failed to parse version 1 JSON: %v", err)
+		}
+		if v1.Version != 1 {
+			t.Errorf("Expected top-level version 1, got %d", v1.Version)
+		}
+		if len(v1.ParserConfig.Outbounds) != 1 || v1.ParserConfig.Outbounds[0].Filters["tag"] != "!/(🇷🇺)/i" {
+			t.Errorf("Expected filters to survive downgrade, got %+v", v1.ParserConfig.Outbounds)
+		}
+
+		// Upgrade back: selector settings must be preserved
+		config, err := migrator.MigrateRaw(preview.MigratedJSON, 0, ParserConfigVersion)
+		if err != nil {
+			t.Fatalf("Failed to migrate back: %v", err)
+		}
+		outbound := config.ParserConfig.Outbounds[0]
+		if outbound.Filters["tag"] != "!/(🇷🇺)/i" || len(outbound.AddOutbounds) != 1 || outbound.PreferredDefault["tag"] != "/🇳🇱/i" {
+			t.Errorf("Selector settings lost in round trip: %+v", outbound)
+		}
+		if config.ParserConfig.Parser.Reload != "2h" {
+			t.Errorf("Expected reload '2h', got '%s'", config.ParserConfig.Parser.Reload)
+		}
+	})
+
+	t.Run("Unsupported target version", func(t *testing.T) {
+		if _, err := migrator.Preview(testV4ParserConfig, ParserConfigVersion+1); err == nil {
+			t.Error("Expected error for unsupported target version")
+		}
+	})
+}
+
+// TestApplyParserConfigMigration tests writing a previewed migration with backup
+func TestApplyParserConfigMigration(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	original := "{\n  /** @ParserConfig\n" + testV4ParserConfig + "\n  */\n  \"outbounds\": []\n}\n"
+	if err := os.WriteFile(configPath, []byte(original), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	preview, err := PreviewParserConfigMigration(configPath, 3)
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+
+	// Preview must not modify the file
+	data, _ := os.ReadFile(configPath)
+	if string(data) != original {
+		t.Fatal("Preview modified config.json")
+	}
+
+	backupPath, err := ApplyParserConfigMigration(configPath, preview)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if filepath.Base(filepath.Dir(backupPath)) != constants.ParserConfigBackupDirName {
+		t.Errorf("Unexpected backup location: %s", backupPath)
+	}
+	backup, err := os.ReadFile(backupPath)
+	if err != nil || strings.TrimSpace(string(backup)) != strings.TrimSpace(testV4ParserConfig) {
+		t.Errorf("Backup does not contain the original block (err: %v)", err)
+	}
+
+	data, _ = os.ReadFile(configPath)
+	block, err := extractParserConfigBlock(data)
+	if err != nil {
+		t.Fatalf("Block not found after apply: %v", err)
+	}
+	if extractVersion(block) != 3 {
+		t.Errorf("Expected version 3 on disk, got %d", extractVersion(block))
+	}
+	if !strings.Contains(string(data), `"outbounds": []`) {
+		t.Error("Content outside @ParserConfig block was modified")
+	}
+
+	// A stale preview must be rejected
+	if _, err := ApplyParserConfigMigration(configPath, preview); err == nil {
+		t.Error("Expected error when applying stale preview")
+	}
+}
+
+// TestUpdateConfig_MigrationIsBackedUpAndReported tests that an update converting an older
+// @ParserConfig block backs the block up and tells the user
+func TestUpdateConfig_MigrationIsBackedUpAndReported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "vless://4a3ece53-6000-4ba3-a9fa-fd0d7ba61cf3@example.com:443?security=none&type=tcp#ok")
+	}))
+	defer server.Close()
+
+	ac, notifier := newTestController(t)
+	block := `{"ParserConfig": {"version": 3, "proxies": [{"source": "` + server.URL + `/sub"}], "outbounds": [{"tag": "proxy-out", "type": "selector"}]}}`
+	content := "{\n/** @ParserConfig\n" + block + "\n*/\n\"outbounds\": [\n/** @ParserSTART */\n/** @ParserEND */\n{\"type\": \"direct\", \"tag\": \"direct\"}\n]\n}\n"
+	if err := os.WriteFile(ac.ConfigPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewConfigService(ac).UpdateConfigFromSubscriptions(); err != nil {
+		t.Fatalf("UpdateConfigFromSubscriptions failed: %v", err)
+	}
+	backups, _ := filepath.Glob(filepath.Join(filepath.Dir(ac.ConfigPath), constants.ParserConfigBackupDirName, "parser_config_v3_*.json"))
+	if len(backups) != 1 {
+		t.Errorf("Expected a backup of the version 3 block, got %v", backups)
+	}
+	if msgs := notifier.Messages(); len(msgs) != 1 || msgs[0] != "info: ParserConfig converted" {
+		t.Errorf("Expected the conversion to be reported, got %v", msgs)
+	}
+
+	// The block is current now: the next update neither converts nor reports it
+	if err := NewConfigService(ac).UpdateConfigFromSubscriptions(); err != nil {
+		t.Fatalf("UpdateConfigFromSubscriptions failed: %v", err)
+	}
+	if msgs := notifier.Messages(); len(msgs) != 1 {
+		t.Errorf("Expected no new notifications, got %v", msgs)
+	}
+}
@@ -1,10 +1,13 @@
 package core
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"regexp"
+	"strings"
 
 	"github.com/muhammadmuzzammil1998/jsonc"
 )
@@ -101,3 +104,56 @@ func GetSelectorGroupsFromConfig(configPath string) ([]string, string, error) {
 
 	return selectorGroups, defaultSelector, nil
 }
+
+// ConfigOutbound is an outbound of config.json
+type ConfigOutbound struct {
+	Tag      string
+	Type     string
+	Default  string // Initial member of a selector (set from preferredDefault of @ParserConfig)
+	Identity string // Server of a proxy independent of its tag, see outboundIdentity ("" for groups)
+}
+
+// GetOutboundsFromConfig returns outbounds of config.json by tag
+func GetOutboundsFromConfig(configPath string) (map[string]ConfigOutbound, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var config struct {
+		Outbounds []map[string]interface{} `json:"outbounds"`
+	}
+	if err := json.Unmarshal(jsonc.ToJSON(data), &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	outbounds := make(map[string]ConfigOutbound, len(config.Outbounds))
+	for _, o := range config.Outbounds {
+		tag, _ := o["tag"].(string)
+		if tag == "" {
+			continue
+		}
+		outbound := ConfigOutbound{Tag: tag, Identity: outboundIdentity(o)}
+		outbound.Type, _ = o["type"].(string)
+		outbound.Default, _ = o["default"].(string)
+		outbounds[tag] = outbound
+	}
+	return outbounds, nil
+}
+
+// outboundIdentity identifies the server of a proxy outbound: tags of subscription nodes may change
+// on update while the server stays the same. Credentials are hashed, so the identity can be stored.
+func outboundIdentity(o map[string]interface{}) string {
+	server, _ := o["server"].(string)
+	if server == "" {
+		return ""
+	}
+	parts := []string{fmt.Sprint(o["type"]), server, fmt.Sprint(o["server_port"])}
+	for _, key := range []string{"uuid", "password", "method"} {
+		if v, ok := o[key].(string); ok {
+			parts = append(parts, v)
+		}
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:8])
+}
@@ -3,10 +3,9 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"log"
-
-	"singbox-launcher/internal/dialogs"
 )
 
 // ConfigService encapsulates configuration parsing and update routines.
@@ -23,16 +22,38 @@ func NewConfigService(ac *AppController) *ConfigService {
 	return &ConfigService{ac: ac}
 }
 
-// RunParserProcess starts the internal configuration update process.
-// Logic migrated from controller-level function without behavior changes.
+// ErrUpdateInProgress is returned by UpdateNow when another configuration update is running
+var ErrUpdateInProgress = errors.New("configuration update is already in progress")
+
+// RunParserProcess starts the internal configuration update process and reports the result to the user.
 func (svc *ConfigService) RunParserProcess() {
+	ac := svc.ac
+	err := svc.UpdateNow()
+
+	// Обрабатываем результат
+	switch {
+	case errors.Is(err, ErrUpdateInProgress):
+		ac.Notifier.ShowAutoHideInfo("Parser Info", "Configuration update is already in progress.")
+	case err != nil:
+		log.Printf("RunParser: Failed to update config: %v", err)
+		// Progress already updated in UpdateConfigFromSubscriptions with error status
+		ac.ShowParserError(fmt.Errorf("failed to update config: %w", err))
+	default:
+		log.Println("RunParser: Config updated successfully.")
+		// Progress already updated in UpdateConfigFromSubscriptions with success status
+		ac.Notifier.ShowAutoHideInfo("Parser", "Config updated successfully!")
+	}
+}
+
+// UpdateNow runs the configuration update unless another one is in progress (ErrUpdateInProgress).
+// Progress is published as EventParserProgress.
+func (svc *ConfigService) UpdateNow() error {
 	ac := svc.ac
 	// Проверяем, не запущен ли уже парсинг
 	ac.ParserMutex.Lock()
 	if ac.ParserRunning {
 		ac.ParserMutex.Unlock()
-		dialogs.ShowAutoHideInfo(ac.Application, ac.MainWindow, "Parser Info", "Configuration update is already in progress.")
-		return
+		return ErrUpdateInProgress
 	}
 	ac.ParserRunning = true
 	ac.ParserMutex.Unlock()
@@ -46,16 +67,5 @@ func (svc *ConfigService) RunParserProcess() {
 	}()
 
 	// Call internal parser to update configuration
-	err := svc.UpdateConfigFromSubscriptions()
-
-	// Обрабатываем результат
-	if err != nil {
-		log.Printf("RunParser: Failed to update config: %v", err)
-		// Progress already updated in UpdateConfigFromSubscriptions with error status
-		ac.ShowParserError(fmt.Errorf("failed to update config: %w", err))
-	} else {
-		log.Println("RunParser: Config updated successfully.")
-		// Progress already updated in UpdateConfigFromSubscriptions with success status
-		dialogs.ShowAutoHideInfo(ac.Application, ac.MainWindow, "Parser", "Config updated successfully!")
-	}
+	return svc.UpdateConfigFromSubscriptions()
 }
@@ -2,6 +2,7 @@ package core
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -23,6 +24,9 @@ type OutboundGenerationResult struct {
 	NodesCount           int      // Number of generated nodes
 	LocalSelectorsCount  int      // Number of local selectors
 	GlobalSelectorsCount int      // Number of global selectors
+
+	RefreshedSources []int         // Indexes of sources whose subscriptions were downloaded
+	SourceErrors     map[int]error // Download errors by source index
 }
 
 // applyTagPrefixPostfix applies prefix and postfix to a node tag if specified in ProxySource.
@@ -115,11 +119,9 @@ func IsSubscriptionURL(input string) bool {
 		strings.HasPrefix(trimmed, "https://")
 }
 
-// updateParserProgress safely calls UpdateParserProgressFunc if it's not nil
+// updateParserProgress publishes EventParserProgress (progress -1 means error)
 func updateParserProgress(ac *AppController, progress float64, status string) {
-	if ac.UpdateParserProgressFunc != nil {
-		ac.UpdateParserProgressFunc(progress, status)
-	}
+	ac.Events.Publish(Event{Type: EventParserProgress, Progress: progress, Message: status})
 }
 
 // LogDuplicateTagStatistics logs statistics about duplicate tags found in tagCounts.
@@ -142,6 +144,53 @@ func LogDuplicateTagStatistics(tagCounts map[string]int, logPrefix string) {
 // ProcessProxySource delegates to the internal parser logic
 // This method is moved from parser.go to ConfigService to encapsulate logic
 func (svc *ConfigService) ProcessProxySource(proxySource ProxySource, tagCounts map[string]int, progressCallback func(float64, string), subscriptionIndex, totalSubscriptions int) ([]*parsers.ParsedNode, error) {
+	result := svc.processProxySource(proxySource, false, tagCounts, progressCallback, subscriptionIndex, totalSubscriptions)
+	return result.nodes, nil
+}
+
+// proxySourceResult contains parsed nodes of a single source and the outcome of its subscription download
+type proxySourceResult struct {
+	nodes     []*parsers.ParsedNode
+	refreshed bool  // Subscription content was downloaded (not taken from cache)
+	fetchErr  error // Download error (nodes may still come from cache)
+}
+
+// loadSubscriptionContent returns subscription content either from the cache (preferCache)
+// or from the network. Successfully downloaded content is stored in the cache;
+// if the download fails, cached content is used as a fallback.
+func (svc *ConfigService) loadSubscriptionContent(url string, preferCache bool) ([]byte, bool, error) {
+	var cache *SubscriptionCache
+	if svc.ac != nil {
+		cache = NewSubscriptionCache(svc.ac.GetConfigPath())
+	}
+
+	if preferCache {
+		if content, err := cache.Load(url); err == nil {
+			log.Printf("Parser: Source is not due for reload, using cached content for %s", url)
+			return content, false, nil
+		}
+		log.Printf("Parser: No cached content for %s, downloading", url)
+	}
+
+	content, err := FetchSubscription(url)
+	if err != nil {
+		if cached, cacheErr := cache.Load(url); cacheErr == nil {
+			log.Printf("Parser: Warning: Failed to fetch %s, using cached content: %v", url, err)
+			return cached, false, err
+		}
+		return nil, false, err
+	}
+
+	if err := cache.Store(url, content); err != nil {
+		log.Printf("Parser: Warning: Failed to cache subscription %s: %v", url, err)
+	}
+	return content, true, nil
+}
+
+// processProxySource parses a single source. If preferCache is true, the subscription
+// is taken from the cache instead of being downloaded (used for sources that are not due).
+func (svc *ConfigService) processProxySource(proxySource ProxySource, preferCache bool, tagCounts map[string]int, progressCallback func(float64, string), subscriptionIndex, totalSubscriptions int) proxySourceResult {
+	var result proxySourceResult
 	startTime := time.Now()
 	log.Printf("[DEBUG] ProcessProxySource: START source %d/%d at %s",
 		subscriptionIndex+1, totalSubscriptions, startTime.Format("15:04:05.000"))
@@ -163,13 +212,16 @@ func (svc *ConfigService) ProcessProxySource(proxySource ProxySource, tagCounts
 			fetchStartTime := time.Now()
 			log.Printf("[DEBUG] ProcessProxySource: Fetching subscription %d/%d: %s",
 				subscriptionIndex+1, totalSubscriptions, proxySource.Source)
-			content, err := FetchSubscription(proxySource.Source)
+			content, refreshed, err := svc.loadSubscriptionContent(proxySource.Source, preferCache)
 			fetchDuration := time.Since(fetchStartTime)
+			result.refreshed = refreshed
+			result.fetchErr = err
 			if err != nil {
 				log.Printf("[DEBUG] ProcessProxySource: Failed to fetch subscription %d/%d (took %v): %v",
 					subscriptionIndex+1, totalSubscriptions, fetchDuration, err)
 				log.Printf("Parser: Error: Failed to fetch subscription from %s: %v", proxySource.Source, err)
-			} else if len(content) > 0 {
+			}
+			if len(content) > 0 {
 				log.Printf("[DEBUG] ProcessProxySource: Fetched subscription %d/%d: %d bytes in %v",
 					subscriptionIndex+1, totalSubscriptions, len(content), fetchDuration)
 
@@ -314,7 +366,8 @@ func (svc *ConfigService) ProcessProxySource(proxySource ProxySource, tagCounts
 	totalDuration := time.Since(startTime)
 	log.Printf("[DEBUG] ProcessProxySource: END source %d/%d (total duration: %v, nodes: %d)",
 		subscriptionIndex+1, totalSubscriptions, totalDuration, len(nodes))
-	return nodes, nil
+	result.nodes = nodes
+	return result
 }
 
 // GenerateSelector generates JSON string for a selector from filtered nodes.
@@ -720,10 +773,23 @@ func (svc *ConfigService) GenerateOutboundsFromParserConfig(
 	config *ParserConfig,
 	tagCounts map[string]int,
 	progressCallback func(float64, string),
+) (*OutboundGenerationResult, error) {
+	return svc.generateOutbounds(config, tagCounts, progressCallback, nil)
+}
+
+// generateOutbounds implements GenerateOutboundsFromParserConfig.
+// Sources listed in cachedSources reuse cached subscription content instead of downloading it.
+func (svc *ConfigService) generateOutbounds(
+	config *ParserConfig,
+	tagCounts map[string]int,
+	progressCallback func(float64, string),
+	cachedSources map[int]bool,
 ) (*OutboundGenerationResult, error) {
 	// Step 1: Process all proxy sources and collect nodes
 	allNodes := make([]*parsers.ParsedNode, 0)
 	nodesBySource := make(map[int][]*parsers.ParsedNode) // Map source index to its nodes
+	refreshedSources := make([]int, 0)
+	sourceErrors := make(map[int]error)
 
 	totalSources := len(config.ParserConfig.Proxies)
 	if progressCallback != nil {
@@ -736,11 +802,15 @@ func (svc *ConfigService) GenerateOutboundsFromParserConfig(
 				fmt.Sprintf("Processing source %d/%d...", i+1, totalSources))
 		}
 
-		nodesFromSource, err := svc.ProcessProxySource(proxySource, tagCounts, progressCallback, i, totalSources)
-		if err != nil {
-			log.Printf("GenerateOutboundsFromParserConfig: Error processing source %d/%d: %v", i+1, totalSources, err)
-			continue
+		sourceResult := svc.processProxySource(proxySource, cachedSources[i], tagCounts, progressCallback, i, totalSources)
+		if sourceResult.refreshed {
+			refreshedSources = append(refreshedSources, i)
+		}
+		if sourceResult.fetchErr != nil {
+			log.Printf("GenerateOutboundsFromParserConfig: Error processing source %d/%d: %v", i+1, totalSources, sourceResult.fetchErr)
+			sourceErrors[i] = sourceResult.fetchErr
 		}
+		nodesFromSource := sourceResult.nodes
 
 		if len(nodesFromSource) > 0 {
 			allNodes = append(allNodes, nodesFromSource...)
@@ -828,6 +898,8 @@ func (svc *ConfigService) GenerateOutboundsFromParserConfig(
 		NodesCount:           nodesCount,
 		LocalSelectorsCount:  localSelectorsCount,
 		GlobalSelectorsCount: globalSelectorsCount,
+		RefreshedSources:     refreshedSources,
+		SourceErrors:         sourceErrors,
 	}, nil
 }
 
@@ -835,15 +907,49 @@ func (svc *ConfigService) GenerateOutboundsFromParserConfig(
 // This is the main entry point for configuration updates.
 // It extracts parser configuration, processes all proxy sources, generates outbound JSON,
 // and writes the result to config.json between @ParserSTART and @ParserEND markers.
+// All subscriptions are downloaded regardless of their reload intervals.
 func (svc *ConfigService) UpdateConfigFromSubscriptions() error {
+	return svc.updateConfig(false)
+}
+
+// UpdateDueSubscriptions regenerates config.json downloading only the subscriptions whose
+// reload interval has elapsed; other sources reuse cached content.
+// Does nothing if no source is due. Used by the auto-update loop.
+func (svc *ConfigService) UpdateDueSubscriptions() error {
+	return svc.updateConfig(true)
+}
+
+// updateConfig implements UpdateConfigFromSubscriptions and UpdateDueSubscriptions
+func (svc *ConfigService) updateConfig(onlyDue bool) error {
 	ac := svc.ac
 	log.Println("Parser: Starting configuration update...")
 
 	// Step 1: Extract configuration
-	config, err := ExtractParserConfig(ac.ConfigPath)
+	config, err := ExtractParserConfig(ac.GetConfigPath())
 	if err != nil {
 		updateParserProgress(ac, -1, fmt.Sprintf("Error: %v", err))
-		return fmt.Errorf("failed to extract parser config: %w", err)
+		return newUpdateError(UpdateFailureValidation, fmt.Errorf("failed to extract parser config: %w", err))
+	}
+
+	// Determine which sources may be taken from cache (partial regeneration)
+	var cachedSources map[int]bool
+	if onlyDue {
+		due := ac.dueSources(config, time.Now())
+		if len(due) == 0 {
+			log.Println("Parser: No sources are due for reload, skipping update")
+			return nil
+		}
+		dueSet := make(map[int]bool, len(due))
+		for _, i := range due {
+			dueSet[i] = true
+		}
+		cachedSources = make(map[int]bool)
+		for i := range config.ParserConfig.Proxies {
+			if !dueSet[i] {
+				cachedSources[i] = true
+			}
+		}
+		log.Printf("Parser: %d of %d sources are due for reload", len(due), len(config.ParserConfig.Proxies))
 	}
 
 	// Update progress: Step 1 completed
@@ -867,12 +973,49 @@ func (svc *ConfigService) UpdateConfigFromSubscriptions() error {
 		updateParserProgress(ac, p, s)
 	}
 
-	result, err := svc.GenerateOutboundsFromParserConfig(config, tagCounts, progressCallback)
+	result, err := svc.generateOutbounds(config, tagCounts, progressCallback, cachedSources)
 	if err != nil {
 		updateParserProgress(ac, -1, fmt.Sprintf("Error: %v", err))
 		return fmt.Errorf("failed to generate outbounds: %w", err)
 	}
 
+	// Failed subscriptions back off on their own, the others keep their schedule
+	ac.recordSourceResults(config, result, onlyDue)
+
+	// If every attempted download failed, the result would only contain stale cached nodes
+	sourceErr := primarySourceError(config, result.SourceErrors)
+	if sourceErr != nil && len(result.RefreshedSources) == 0 {
+		updateParserProgress(ac, -1, fmt.Sprintf("Error: %v", sourceErr))
+		err := fmt.Errorf("failed to fetch %d subscription(s): %w", len(result.SourceErrors), sourceErr)
+		if onlyDue {
+			return fmt.Errorf("%w, %w", err, errSourcesFailed)
+		}
+		return err
+	}
+
+	// Record per-source update time for downloaded subscriptions
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, i := range result.RefreshedSources {
+		config.ParserConfig.Proxies[i].LastUpdated = now
+		config.ParserConfig.Proxies[i].LastFailed = ""
+	}
+	// Failed sources keep the time of their last successful download: last_failed keeps them due
+	// (also on the first update, when they would inherit the new parser.last_updated)
+	for i := range result.SourceErrors {
+		config.ParserConfig.Proxies[i].LastFailed = now
+	}
+	// Other sources keep their own update time: without it they would inherit the new
+	// parser.last_updated, and a slow-interval source would never become due
+	for i := range config.ParserConfig.Proxies {
+		source := &config.ParserConfig.Proxies[i]
+		if source.LastUpdated != "" {
+			continue
+		}
+		if lastUpdated := SourceLastUpdated(config, *source); !lastUpdated.IsZero() {
+			source.LastUpdated = lastUpdated.Format(time.RFC3339)
+		}
+	}
+
 	// Log statistics about duplicates
 	LogDuplicateTagStatistics(tagCounts, "Parser")
 
@@ -884,36 +1027,122 @@ func (svc *ConfigService) UpdateConfigFromSubscriptions() error {
 	// Final check: ensure we have content to write
 	if len(selectorsJSON) == 0 {
 		updateParserProgress(ac, -1, "Error: nothing to write to configuration")
-		return fmt.Errorf("no content generated - cannot write empty result to config")
+		return newUpdateError(UpdateFailureValidation, fmt.Errorf("no content generated - cannot write empty result to config"))
 	}
 
 	// Step 4: Write to file
 	updateParserProgress(ac, 90, "Writing to config file...")
 
 	content := strings.Join(selectorsJSON, "\n")
-	if err := writeToConfig(ac.ConfigPath, content, config); err != nil {
+	migrationBackup, err := writeToConfig(ac.GetConfigPath(), content, config, svc.checkGeneratedConfig)
+	if err != nil {
+		var checkErr *ConfigCheckError
+		if errors.As(err, &checkErr) {
+			updateParserProgress(ac, -1, "Error: generated config is invalid, config.json is unchanged")
+			if !onlyDue {
+				// Scheduled updates report repeated failures in handleAutoUpdateFailure
+				ac.ShowConfigValidationError(err)
+			}
+			return newUpdateError(UpdateFailureValidation, fmt.Errorf("generated config is invalid: %w", err))
+		}
 		updateParserProgress(ac, -1, fmt.Sprintf("Write error: %v", err))
 		return fmt.Errorf("failed to write to config: %w", err)
 	}
 
-	log.Printf("Parser: Done! File %s successfully updated.", ac.ConfigPath)
+	log.Printf("Parser: Done! File %s successfully updated.", ac.GetConfigPath())
 	log.Printf("Parser: Successfully updated last_updated timestamp")
+	if migrationBackup != "" {
+		ac.Notifier.ShowInfo("ParserConfig converted", fmt.Sprintf(
+			"@ParserConfig of %s was converted to version %d, older launchers may not read it.\n\n"+
+				"The previous block is backed up to %s. Use Diagnostics → ParserConfig Version to convert it back.",
+			ac.GetConfigPath(), ParserConfigVersion, migrationBackup))
+	}
 
 	updateParserProgress(ac, 100, "Configuration updated successfully!")
 
+	// Apply the new config to the running core without dropping TUN
+	svc.reloadRunningCore()
+
+	// Sources that failed to download stay due and back off on their own (see recordSourceResults),
+	// config.json has been written with their cached nodes
+	if sourceErr != nil {
+		log.Printf("Parser: Configuration updated, but %d subscription(s) failed: %v", len(result.SourceErrors), sourceErr)
+	}
+
 	// Resume auto-update after successful update
 	ac.resumeAutoUpdate()
 
 	return nil
 }
 
+// checkGeneratedConfig validates a generated config with `sing-box check` before it replaces
+// config.json. Only a config rejected by sing-box is an error: the check is skipped if sing-box
+// is not installed yet or cannot be run.
+func (svc *ConfigService) checkGeneratedConfig(path string) error {
+	ac := svc.ac
+	if ac.ProcessService == nil {
+		return nil
+	}
+	if _, err := os.Stat(ac.SingboxPath); err != nil {
+		return nil
+	}
+	err := ac.ProcessService.CheckConfig(path)
+	var checkErr *ConfigCheckError
+	if err != nil && !errors.As(err, &checkErr) {
+		log.Printf("Parser: Warning: Failed to check the generated config: %v", err)
+		return nil
+	}
+	return err
+}
+
+// reloadRunningCore hot-reloads sing-box after config.json was regenerated.
+// An invalid config is reported to the user and the core keeps running the previous one.
+func (svc *ConfigService) reloadRunningCore() {
+	ac := svc.ac
+	if ac.ProcessService == nil || !ac.RunningState.IsRunning() {
+		return
+	}
+	log.Println("Parser: Sing-Box is running, reloading configuration...")
+	if err := ac.ProcessService.Reload(); err != nil {
+		log.Printf("Parser: Failed to reload Sing-Box, it keeps the previous configuration: %v", err)
+		ac.ShowConfigValidationError(err)
+	}
+}
+
+// errSourcesFailed marks update errors caused only by failed subscription downloads.
+// The auto-update loop does not count them: each subscription backs off on its own.
+var errSourcesFailed = errors.New("failed subscriptions are retried on their own schedule")
+
+// primarySourceError picks the most relevant download error to report.
+// Auth/expired errors take precedence because they require user action.
+func primarySourceError(config *ParserConfig, sourceErrors map[int]error) error {
+	var firstErr error
+	for i := range config.ParserConfig.Proxies {
+		err, ok := sourceErrors[i]
+		if !ok {
+			continue
+		}
+		if ClassifyUpdateError(err) == UpdateFailureAuth {
+			return err
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
 // writeToConfig writes content between @ParserSTART and @ParserEND markers
-// Also updates @ParserConfig block with last_updated timestamp in a single file write
-func writeToConfig(configPath string, content string, parserConfig *ParserConfig) error {
+// Also updates @ParserConfig block with last_updated timestamp in a single file write.
+// The new config is written to a temporary file first; if check is set, config.json is
+// replaced only when check accepts it, otherwise the check error is returned.
+// If the @ParserConfig block is converted to the current version, the old block is backed up
+// first and the backup path is returned (empty if the block was not converted).
+func writeToConfig(configPath string, content string, parserConfig *ParserConfig, check func(path string) error) (string, error) {
 	// Read config file
 	data, err := os.ReadFile(configPath)
 	if err != nil {
-		return fmt.Errorf("failed to read config file: %w", err)
+		return "", fmt.Errorf("failed to read config file: %w", err)
 	}
 
 	configStr := string(data)
@@ -926,18 +1155,28 @@ func writeToConfig(configPath string, content string, parserConfig *ParserConfig
 	endIdx := strings.Index(configStr, endMarker)
 
 	if startIdx == -1 || endIdx == -1 {
-		return fmt.Errorf("markers @ParserSTART or @ParserEND not found in config.json")
+		return "", fmt.Errorf("markers @ParserSTART or @ParserEND not found in config.json")
 	}
 
 	if endIdx <= startIdx {
-		return fmt.Errorf("invalid marker positions")
+		return "", fmt.Errorf("invalid marker positions")
 	}
 
 	// Build new content with updated @ParserSTART/@ParserEND section
 	newContent := configStr[:startIdx+len(startMarker)] + "\n" + content + "\n" + configStr[endIdx:]
 
 	// Also update @ParserConfig block if parserConfig is provided
+	var migrationBackup string
 	if parserConfig != nil {
+		// Keep the pre-migration block: older launchers sharing this config may need it
+		migrationBackup, err = backupParserConfigBeforeMigration(configPath, data, ParserConfigVersion)
+		if err != nil {
+			return "", fmt.Errorf("failed to back up @ParserConfig before converting it to version %d: %w", ParserConfigVersion, err)
+		}
+		if migrationBackup != "" {
+			log.Printf("Parser: Converting @ParserConfig to version %d, the previous block is backed up to %s", ParserConfigVersion, migrationBackup)
+		}
+
 		// Update last_updated timestamp
 		parserConfig.ParserConfig.Parser.LastUpdated = time.Now().UTC().Format(time.RFC3339)
 
@@ -945,7 +1184,7 @@ func writeToConfig(configPath string, content string, parserConfig *ParserConfig
 		NormalizeParserConfig(parserConfig, false)
 
 		// Find the @ParserConfig block using regex
-		pattern := regexp.MustCompile(`(/\*\*\s*@ParserConfig\s*\n)([\s\S]*?)(\*/)`)
+		pattern := parserConfigBlockPattern
 		matches := pattern.FindSubmatch([]byte(newContent))
 
 		if len(matches) >= 4 {
@@ -955,7 +1194,7 @@ func writeToConfig(configPath string, content string, parserConfig *ParserConfig
 			}
 			finalJSON, err := json.MarshalIndent(outerJSON, "", "  ")
 			if err != nil {
-				return fmt.Errorf("failed to marshal outer @ParserConfig: %w", err)
+				return "", fmt.Errorf("failed to marshal outer @ParserConfig: %w", err)
 			}
 
 			parserConfigBlock := string(matches[1]) + string(finalJSON) + "\n" + string(matches[3])
@@ -963,10 +1202,23 @@ func writeToConfig(configPath string, content string, parserConfig *ParserConfig
 		}
 	}
 
-	// Write to file (single write operation)
-	if err := os.WriteFile(configPath, []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("failed to write config file: %w", err)
+	// Write to a temporary file next to config.json, so that a rejected config never replaces it
+	tmpPath := configPath + ".new"
+	if err := os.WriteFile(tmpPath, []byte(newContent), 0644); err != nil {
+		_ = os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to write config file: %w", err)
+	}
+	if check != nil {
+		if err := check(tmpPath); err != nil {
+			_ = os.Remove(tmpPath)
+			return "", err
+		}
 	}
 
-	return nil
+	if err := os.Rename(tmpPath, configPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to replace config file: %w", err)
+	}
+
+	return migrationBackup, nil
 }
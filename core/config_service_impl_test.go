@@ -1,13 +1,17 @@
 package core
 
 import (
-	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
-
-	"singbox-launcher/core/parsers"
+	"time"
 )
 
 // TestProcessProxySource_Subscription tests processing subscription URLs
@@ -22,10 +26,6 @@ func TestProcessProxySource_Subscription(t *testing.T) {
 	}
 	svc := NewConfigService(ac)
 
-	// Create a test subscription content (base64 encoded)
-	subscriptionContent := "vless://test-uuid@example.com:443#Test Server\nvmess://test-base64"
-	encodedContent := base64.URLEncoding.EncodeToString([]byte(subscriptionContent))
-
 	// Note: This test would require mocking HTTP requests or using a test HTTP server
 	// For now, we'll test the logic that doesn't require network access
 
@@ -274,3 +274,204 @@ func TestProcessProxySource_RealWorldExamples(t *testing.T) {
 	}
 }
 
+
+// writeScheduleTestConfig writes config.json with the given @ParserConfig sources and parser settings
+func writeScheduleTestConfig(t *testing.T, configPath string, parser ParserSettings, sources []ProxySource) {
+	t.Helper()
+	config := &ParserConfig{}
+	config.ParserConfig.Version = ParserConfigVersion
+	config.ParserConfig.Proxies = sources
+	config.ParserConfig.Outbounds = []OutboundConfig{{Tag: "proxy-out", Type: "selector"}}
+	config.ParserConfig.Parser = parser
+	block, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to marshal ParserConfig: %v", err)
+	}
+	content := "{\n/** @ParserConfig\n" + string(block) + "\n*/\n" +
+		"\"outbounds\": [\n/** @ParserSTART */\n/** @ParserEND */\n{\"type\": \"direct\", \"tag\": \"direct\"}\n]\n}\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+}
+
+// TestUpdateDueSubscriptions_KeepsSourceTimestamps tests that a source which is not due keeps its own
+// update time instead of inheriting the new parser.last_updated
+func TestUpdateDueSubscriptions_KeepsSourceTimestamps(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "vless://4a3ece53-6000-4ba3-a9fa-fd0d7ba61cf3@example.com:443?security=none&type=tcp#%s", strings.Trim(r.URL.Path, "/"))
+	}))
+	defer server.Close()
+
+	ac, _ := newTestController(t)
+	svc := NewConfigService(ac)
+	fastURL, slowURL := server.URL+"/fast", server.URL+"/slow"
+	// Legacy config: no per-source timestamps, the last update was 2 hours ago
+	lastUpdated := time.Now().UTC().Add(-2 * time.Hour).Truncate(time.Second)
+	writeScheduleTestConfig(t, ac.ConfigPath,
+		ParserSettings{Reload: "4h", LastUpdated: lastUpdated.Format(time.RFC3339)},
+		[]ProxySource{{Source: fastURL, Reload: "1h"}, {Source: slowURL, Reload: "24h"}})
+	if err := NewSubscriptionCache(ac.ConfigPath).Store(slowURL, []byte("vless://53fff6cc-b4ec-43e8-ade5-e0c42972fc33@example.org:80?security=none&type=tcp#slow")); err != nil {
+		t.Fatalf("Failed to cache subscription: %v", err)
+	}
+
+	if err := svc.UpdateDueSubscriptions(); err != nil {
+		t.Fatalf("UpdateDueSubscriptions failed: %v", err)
+	}
+	config, err := ExtractParserConfig(ac.ConfigPath)
+	if err != nil {
+		t.Fatalf("ExtractParserConfig failed: %v", err)
+	}
+	fast, slow := config.ParserConfig.Proxies[0], config.ParserConfig.Proxies[1]
+	if got := SourceLastUpdated(config, fast); !got.After(lastUpdated) {
+		t.Errorf("Fast source was refreshed, but its last_updated is %v", got)
+	}
+	if got := SourceLastUpdated(config, slow); !got.Equal(lastUpdated) {
+		t.Errorf("Slow source must keep last_updated %v, got %v", lastUpdated, got)
+	}
+
+	// The slow source becomes due 24h after its own update, not after the fast source's refreshes
+	due := DueSources(config, lastUpdated.Add(24*time.Hour))
+	if len(due) != 2 {
+		t.Errorf("Expected both sources to be due after 24h, got %v", due)
+	}
+	if due := DueSources(config, time.Now().Add(90*time.Minute)); len(due) != 1 || due[0] != 0 {
+		t.Errorf("Expected only the fast source to be due in 90m, got %v", due)
+	}
+}
+
+// TestUpdateConfig_FailedSourceStaysDue tests that a source which failed on the first update
+// does not inherit the new parser.last_updated and is retried
+func TestUpdateConfig_FailedSourceStaysDue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, "vless://4a3ece53-6000-4ba3-a9fa-fd0d7ba61cf3@example.com:443?security=none&type=tcp#ok")
+	}))
+	defer server.Close()
+
+	ac, _ := newTestController(t)
+	// First update: no parser.last_updated yet
+	writeScheduleTestConfig(t, ac.ConfigPath, ParserSettings{Reload: "4h"},
+		[]ProxySource{{Source: server.URL + "/ok"}, {Source: server.URL + "/broken"}})
+
+	if err := NewConfigService(ac).UpdateConfigFromSubscriptions(); err != nil {
+		t.Fatalf("UpdateConfigFromSubscriptions failed: %v", err)
+	}
+	config, err := ExtractParserConfig(ac.ConfigPath)
+	if err != nil {
+		t.Fatalf("ExtractParserConfig failed: %v", err)
+	}
+	if config.ParserConfig.Parser.LastUpdated == "" {
+		t.Fatal("Expected parser.last_updated to be set")
+	}
+	if got := SourceLastUpdated(config, config.ParserConfig.Proxies[1]); !got.IsZero() {
+		t.Errorf("Failed source must have no update time, got %v", got)
+	}
+	if due := DueSources(config, time.Now()); len(due) != 1 || due[0] != 1 {
+		t.Errorf("Expected only the failed source to be due, got %v", due)
+	}
+}
+
+// TestUpdateConfig_FailedSourceKeepsLastUpdated tests that a failed download keeps the time of the
+// source's last successful download and only marks it with last_failed
+func TestUpdateConfig_FailedSourceKeepsLastUpdated(t *testing.T) {
+	var broken atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if broken.Load() && r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprintf(w, "vless://4a3ece53-6000-4ba3-a9fa-fd0d7ba61cf3@example.com:443?security=none&type=tcp#%s", strings.Trim(r.URL.Path, "/"))
+	}))
+	defer server.Close()
+
+	ac, _ := newTestController(t)
+	svc := NewConfigService(ac)
+	writeScheduleTestConfig(t, ac.ConfigPath, ParserSettings{Reload: "4h"},
+		[]ProxySource{{Source: server.URL + "/ok"}, {Source: server.URL + "/broken"}})
+	if err := svc.UpdateConfigFromSubscriptions(); err != nil {
+		t.Fatalf("UpdateConfigFromSubscriptions failed: %v", err)
+	}
+	config, err := ExtractParserConfig(ac.ConfigPath)
+	if err != nil {
+		t.Fatalf("ExtractParserConfig failed: %v", err)
+	}
+	lastSuccess := config.ParserConfig.Proxies[1].LastUpdated
+	if lastSuccess == "" {
+		t.Fatal("Expected last_updated of the downloaded source")
+	}
+
+	broken.Store(true)
+	time.Sleep(1100 * time.Millisecond) // RFC3339 timestamps have a resolution of one second
+	if err := svc.UpdateConfigFromSubscriptions(); err != nil {
+		t.Fatalf("UpdateConfigFromSubscriptions failed: %v", err)
+	}
+	config, err = ExtractParserConfig(ac.ConfigPath)
+	if err != nil {
+		t.Fatalf("ExtractParserConfig failed: %v", err)
+	}
+	source := config.ParserConfig.Proxies[1]
+	if source.LastUpdated != lastSuccess {
+		t.Errorf("Failed source must keep last_updated %s, got %s", lastSuccess, source.LastUpdated)
+	}
+	if source.LastFailed == "" || !SourceFailed(config, source) {
+		t.Errorf("Expected the failed source to be marked with last_failed, got %q", source.LastFailed)
+	}
+	if due := DueSources(config, time.Now()); len(due) != 1 || due[0] != 1 {
+		t.Errorf("Expected only the failed source to be due, got %v", due)
+	}
+
+	// A successful download clears last_failed
+	broken.Store(false)
+	if err := svc.UpdateConfigFromSubscriptions(); err != nil {
+		t.Fatalf("UpdateConfigFromSubscriptions failed: %v", err)
+	}
+	config, err = ExtractParserConfig(ac.ConfigPath)
+	if err != nil {
+		t.Fatalf("ExtractParserConfig failed: %v", err)
+	}
+	if source := config.ParserConfig.Proxies[1]; source.LastFailed != "" || SourceFailed(config, source) {
+		t.Errorf("Expected last_failed to be cleared, got %q", source.LastFailed)
+	}
+}
+
+// TestUpdateConfig_RejectedConfigIsNotWritten tests that a config rejected by `sing-box check`
+// does not replace config.json
+func TestUpdateConfig_RejectedConfigIsNotWritten(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "vless://4a3ece53-6000-4ba3-a9fa-fd0d7ba61cf3@example.com:443?security=none&type=tcp#ok")
+	}))
+	defer server.Close()
+
+	ac, notifier := newTestController(t)
+	script := "#!/bin/sh\nif [ \"$1\" = check ]; then echo 'FATAL[0000] decode config: unknown field' >&2; exit 1; fi\n"
+	if err := os.WriteFile(ac.SingboxPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeScheduleTestConfig(t, ac.ConfigPath, ParserSettings{Reload: "4h"}, []ProxySource{{Source: server.URL + "/ok"}})
+	before, err := os.ReadFile(ac.ConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewConfigService(ac).UpdateConfigFromSubscriptions()
+	var checkErr *ConfigCheckError
+	if !errors.As(err, &checkErr) || ClassifyUpdateError(err) != UpdateFailureValidation {
+		t.Fatalf("Expected a config check validation error, got %v", err)
+	}
+	after, err := os.ReadFile(ac.ConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Error("config.json was replaced by a rejected config")
+	}
+	if _, err := os.Stat(ac.ConfigPath + ".new"); !os.IsNotExist(err) {
+		t.Errorf("Temporary config was not removed: %v", err)
+	}
+	if msgs := notifier.Messages(); len(msgs) != 1 {
+		t.Errorf("Expected the validation error to be shown, got %v", msgs)
+	}
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"singbox-launcher/api"
+)
+
+// ConnectionSort is the order of the connection list
+type ConnectionSort string
+
+const (
+	ConnectionSortDuration ConnectionSort = "duration" // Newest first
+	ConnectionSortDownload ConnectionSort = "download" // Largest first
+	ConnectionSortUpload   ConnectionSort = "upload"   // Largest first
+	ConnectionSortHost     ConnectionSort = "host"     // Alphabetical
+)
+
+// ConnectionGrouping groups the connection list
+type ConnectionGrouping string
+
+const (
+	ConnectionGroupNone    ConnectionGrouping = ""
+	ConnectionGroupProcess ConnectionGrouping = "process"
+	ConnectionGroupHost    ConnectionGrouping = "host"
+)
+
+// ConnectionGroup is connections of one process or host with their total traffic
+type ConnectionGroup struct {
+	Name        string
+	Connections []api.Connection
+	Upload      int64
+	Download    int64
+}
+
+// MatchConnection reports whether host, destination, process, rule, chain or network
+// of the connection contain query (case-insensitive)
+func MatchConnection(c *api.Connection, query string) bool {
+	if query == "" {
+		return true
+	}
+	query = strings.ToLower(query)
+	for _, field := range []string{c.Host(), c.Destination(), c.Process(), c.Rule, c.RulePayload, c.Chain(), c.Metadata.Network} {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterConnections returns connections matching query
+func FilterConnections(connections []api.Connection, query string) []api.Connection {
+	result := make([]api.Connection, 0, len(connections))
+	for i := range connections {
+		if MatchConnection(&connections[i], query) {
+			result = append(result, connections[i])
+		}
+	}
+	return result
+}
+
+// SortConnections sorts connections in place; ties keep a stable order by ID
+func SortConnections(connections []api.Connection, by ConnectionSort) {
+	sort.SliceStable(connections, func(i, j int) bool {
+		a, b := &connections[i], &connections[j]
+		switch by {
+		case ConnectionSortDownload:
+			if a.Download != b.Download {
+				return a.Download > b.Download
+			}
+		case ConnectionSortUpload:
+			if a.Upload != b.Upload {
+				return a.Upload > b.Upload
+			}
+		case ConnectionSortHost:
+			if a.Host() != b.Host() {
+				return a.Host() < b.Host()
+			}
+		default:
+			if !a.Start.Equal(b.Start) {
+				return a.Start.After(b.Start)
+			}
+		}
+		return a.ID < b.ID
+	})
+}
+
+// GroupConnections groups connections by process or host, keeping their order inside groups.
+// Groups with more traffic come first. Without grouping there is one group with an empty name.
+func GroupConnections(connections []api.Connection, by ConnectionGrouping) []ConnectionGroup {
+	if by == ConnectionGroupNone {
+		group := ConnectionGroup{Connections: connections}
+		for _, c := range connections {
+			group.Upload += c.Upload
+			group.Download += c.Download
+		}
+		return []ConnectionGroup{group}
+	}
+
+	index := make(map[string]int)
+	var groups []ConnectionGroup
+	for _, c := range connections {
+		name := c.Host()
+		if by == ConnectionGroupProcess {
+			name = c.Process()
+			if name == "" {
+				name = "(unknown process)"
+			}
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, ConnectionGroup{Name: name})
+		}
+		groups[i].Connections = append(groups[i].Connections, c)
+		groups[i].Upload += c.Upload
+		groups[i].Download += c.Download
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		ti, tj := groups[i].Upload+groups[i].Download, groups[j].Upload+groups[j].Download
+		if ti != tj {
+			return ti > tj
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// CloseConnections closes connections by ID via Clash API (e.g. all connections of a host).
+// It tries every ID and returns the first error.
+func (ac *AppController) CloseConnections(ctx context.Context, ids []string) error {
+	if !ac.ClashAPIEnabled {
+		return fmt.Errorf("Clash API is disabled in %s", ac.GetConfigPath())
+	}
+	client := ac.ClashClient()
+	var firstErr error
+	for _, id := range ids {
+		if err := client.CloseConnection(ctx, id); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	log.Printf("CloseConnections: Closed %d connection(s)", len(ids))
+	return firstErr
+}
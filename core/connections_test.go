@@ -0,0 +1,109 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"singbox-launcher/api"
+	"singbox-launcher/api/apitest"
+)
+
+// testConnections returns connections of two processes to two hosts
+func testConnections() []api.Connection {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	conn := func(id, host, process string, down int64, age time.Duration) api.Connection {
+		return api.Connection{
+			ID:       id,
+			Metadata: api.ConnectionMetadata{Network: "tcp", Host: host, DestinationIP: "1.2.3.4", DestinationPort: "443", ProcessPath: process},
+			Download: down,
+			Start:    start.Add(-age),
+			Chains:   []string{"de-1", "proxy-out"},
+			Rule:     "rule_set=geosite-youtube",
+		}
+	}
+	return []api.Connection{
+		conn("1", "www.youtube.com", `C:\Program Files\Browser\browser.exe`, 100, time.Minute),
+		conn("2", "api.github.com", "/usr/bin/git", 5000, time.Second),
+		conn("3", "www.youtube.com", `C:\Program Files\Browser\browser.exe`, 200, time.Hour),
+	}
+}
+
+// TestConnectionListHelpers tests search, sorting and grouping
+func TestConnectionListHelpers(t *testing.T) {
+	conns := testConnections()
+	if c := conns[0]; c.Process() != "browser.exe" || c.Chain() != "proxy-out → de-1" || c.Destination() != "1.2.3.4:443" {
+		t.Errorf("Unexpected connection fields: %q, %q, %q", c.Process(), c.Chain(), c.Destination())
+	}
+
+	if got := FilterConnections(conns, "WWW.YouTube"); len(got) != 2 {
+		t.Errorf("Search by host: %d connections", len(got))
+	}
+	if got := FilterConnections(conns, "git"); len(got) != 1 || got[0].ID != "2" {
+		t.Errorf("Search by process: %+v", got)
+	}
+	if got := FilterConnections(conns, "de-1"); len(got) != 3 {
+		t.Errorf("Search by chain: %d connections", len(got))
+	}
+
+	sorted := append([]api.Connection(nil), conns...)
+	for by, want := range map[ConnectionSort]string{
+		ConnectionSortDuration: "213",
+		ConnectionSortDownload: "231",
+		ConnectionSortHost:     "213",
+	} {
+		SortConnections(sorted, by)
+		order := ""
+		for _, c := range sorted {
+			order += c.ID
+		}
+		if order != want {
+			t.Errorf("Sort by %s: %s, want %s", by, order, want)
+		}
+	}
+
+	groups := GroupConnections(conns, ConnectionGroupProcess)
+	if len(groups) != 2 || groups[0].Name != "git" || groups[1].Name != "browser.exe" || groups[1].Download != 300 || len(groups[1].Connections) != 2 {
+		t.Errorf("Unexpected process groups %+v", groups)
+	}
+	groups = GroupConnections(conns, ConnectionGroupHost)
+	if len(groups) != 2 || groups[1].Name != "www.youtube.com" {
+		t.Errorf("Unexpected host groups %+v", groups)
+	}
+	if groups := GroupConnections(conns, ConnectionGroupNone); len(groups) != 1 || groups[0].Download != 5300 {
+		t.Errorf("Unexpected single group %+v", groups)
+	}
+}
+
+// TestCloseConnections tests closing the connections of a host through a fake Clash API
+func TestCloseConnections(t *testing.T) {
+	ac, _ := newTestController(t)
+	fake := apitest.NewFakeServer("secret")
+	defer fake.Close()
+	for _, c := range testConnections() {
+		fake.AddConnection(c)
+	}
+	ac.ClashAPIBaseURL, ac.ClashAPIToken, ac.ClashAPIEnabled = fake.URL, fake.Secret, true
+
+	res, err := ac.ClashClient().Connections(context.Background())
+	if err != nil || len(res.Connections) != 3 || res.DownloadTotal != 5300 {
+		t.Fatalf("Connections = %+v, %v", res, err)
+	}
+	var ids []string
+	for _, g := range GroupConnections(res.Connections, ConnectionGroupHost) {
+		if g.Name == "www.youtube.com" {
+			for _, c := range g.Connections {
+				ids = append(ids, c.ID)
+			}
+		}
+	}
+	if err := ac.CloseConnections(context.Background(), ids); err != nil {
+		t.Fatal(err)
+	}
+	if left := fake.ConnectionIDs(); len(left) != 1 || left[0] != "2" {
+		t.Errorf("Connections left: %v", left)
+	}
+	if err := ac.ClashClient().CloseAllConnections(context.Background()); err != nil || len(fake.ConnectionIDs()) != 0 {
+		t.Errorf("CloseAllConnections: %v, left %v", err, fake.ConnectionIDs())
+	}
+}
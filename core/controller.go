@@ -3,7 +3,9 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
@@ -14,16 +16,8 @@ import (
 	"sync"
 	"time"
 
-	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/app"
-	"fyne.io/fyne/v2/container"
-	"fyne.io/fyne/v2/dialog"
-	"fyne.io/fyne/v2/driver/desktop"
-	"fyne.io/fyne/v2/widget"
-
 	"singbox-launcher/api"
 	"singbox-launcher/internal/constants"
-	"singbox-launcher/internal/dialogs"
 	"singbox-launcher/internal/platform"
 
 	ps "github.com/mitchellh/go-ps"
@@ -41,38 +35,31 @@ const (
 
 // Constants for auto-update configuration
 const (
-	autoUpdateMinInterval   = 10 * time.Minute // Minimum check interval (constant)
-	autoUpdateRetryInterval = 10 * time.Second // Interval between retry attempts
-	autoUpdateMaxRetries    = 10               // Maximum consecutive failed attempts
-	autoUpdateDefaultReload = "4h"             // Default reload interval if not specified
+	autoUpdateMinInterval     = 10 * time.Minute // Minimum reload interval and maximum delay between checks
+	autoUpdateRecheckInterval = 1 * time.Minute  // Minimum delay between checks
+	autoUpdateRetryInterval   = 10 * time.Second // Initial retry delay, doubled after each consecutive failure
+	autoUpdateMaxBackoff      = 30 * time.Minute // Maximum retry delay
+	autoUpdateMaxRetries      = 10               // Maximum consecutive failed attempts (network errors are retried forever)
+	autoUpdateDefaultReload   = "4h"             // Default reload interval if not specified
 )
 
 // AppController - the main structure encapsulating all application state and logic.
 // AppController is the central controller coordinating all application components.
-// It manages UI state, process lifecycle, configuration, API interactions, and logging.
+// It manages process lifecycle, configuration, API interactions, and logging.
 // The controller delegates specific responsibilities to specialized services:
 // - ProcessService: sing-box process management
 // - ConfigService: configuration parsing and updates
-// The controller maintains application-wide state and provides callbacks for UI updates.
+// The controller does not depend on Fyne: user-facing messages go to Notifier and
+// state changes are published to Events, the UI (package ui) subscribes to them.
 type AppController struct {
-	// --- Fyne Components ---
-	Application    fyne.App
-	MainWindow     fyne.Window
-	TrayIcon       fyne.Resource
-	ApiStatusLabel *widget.Label
-
-	// --- UI State Fields ---
-	ProxiesListWidget *widget.List
-	ActiveProxyName   string
-	SelectedIndex     int
-	ProxiesList       []api.ProxyInfo
-	ListStatusLabel   *widget.Label
-
-	// --- Icon Resources ---
-	AppIconData   fyne.Resource
-	GreenIconData fyne.Resource
-	GreyIconData  fyne.Resource
-	RedIconData   fyne.Resource // Icon for error state
+	// --- UI integration ---
+	Notifier Notifier  // User-facing messages (LogNotifier until the UI installs its own)
+	Events   *EventBus // State change events
+
+	// --- Clash API State Fields ---
+	ActiveProxyName string
+	SelectedIndex   int
+	ProxiesList     []api.ProxyInfo
 
 	// --- Process State ---
 	SingboxCmd               *exec.Cmd
@@ -80,15 +67,23 @@ type AppController struct {
 	ParserMutex              sync.Mutex // Mutex for ParserRunning
 	ParserRunning            bool
 	StoppedByUser            bool
+	ReloadInProgress         bool // SIGHUP sent, an exit during the settle period triggers a full restart instead of crash handling
 	ConsecutiveCrashAttempts int
 	APIStateMutex            sync.RWMutex // Mutex for API-related fields (ProxiesList, ActiveProxyName, SelectedIndex)
 
 	// --- File Paths ---
 	ExecDir     string
-	ConfigPath  string
+	ConfigPath  string // config.json of the active profile; read via GetConfigPath, guarded by ProfileStateMutex
 	SingboxPath string
 	WintunPath  string
 
+	// --- Profiles and launcher settings ---
+	Settings          *LauncherSettings
+	Profiles          *ProfileManager
+	ActiveProfile     string       // Name of the active profile; read via GetActiveProfile, guarded by ProfileStateMutex
+	ProfileMutex      sync.Mutex   // Serializes profile switches
+	ProfileStateMutex sync.RWMutex // Mutex for ConfigPath and ActiveProfile (changed by SwitchProfile and RenameProfile)
+
 	// --- VPN Operation State ---
 	RunningState *RunningState
 
@@ -97,24 +92,28 @@ type AppController struct {
 	ProcessService *ProcessService
 	// ConfigService handles configuration parsing, subscription fetching, and JSON generation
 	ConfigService *ConfigService
+	// TrafficMonitor keeps live traffic and memory samples from the Clash API (GUI only)
+	TrafficMonitor *TrafficMonitor
 
 	// --- Logging ---
-	MainLogFile  *os.File
-	ChildLogFile *os.File
-	ApiLogFile   *os.File
+	MainLogFile  *RotatingLogFile
+	ChildLogFile *RotatingLogFile
+	ApiLogFile   *RotatingLogFile
+	CoreLog      *LogBuffer // Parsed sing-box output (last coreLogCapacity lines)
+
+	connectivityMutex  sync.Mutex
+	connectivityChecks []ConnectivityCheck // Recent connectivity tests for support bundles
 
 	// --- Clash API configuration ---
 	ClashAPIBaseURL    string
 	ClashAPIToken      string
 	ClashAPIEnabled    bool
 	SelectedClashGroup string
+	clashMode          string     // Routing mode from the Clash API, see ClashMode; guarded by APIStateMutex
+	clashModes         []string   // Modes of the running config; guarded by APIStateMutex
 	AutoLoadInProgress bool       // Flag to prevent multiple auto-load attempts
 	AutoLoadMutex      sync.Mutex // Mutex for AutoLoadInProgress
-
-	// --- Tray menu update protection ---
-	TrayMenuUpdateInProgress bool        // Flag to prevent multiple simultaneous menu updates
-	TrayMenuUpdateMutex      sync.Mutex  // Mutex for TrayMenuUpdateInProgress
-	TrayMenuUpdateTimer      *time.Timer // Timer for debouncing menu updates
+	restorePending     bool       // Saved proxy selections must be re-applied (sing-box started or reloaded); guarded by AutoLoadMutex
 
 	// --- Version check caching ---
 	VersionCheckCache      string       // Cached latest version
@@ -132,22 +131,25 @@ type AppController struct {
 	ctx        context.Context    // Context for cancellation
 	cancelFunc context.CancelFunc // Cancel function for stopping goroutines
 
-	// --- Callbacks for UI logic ---
-	RefreshAPIFunc         func()
-	ResetAPIStateFunc      func()
-	UpdateCoreStatusFunc   func() // Callback to update status in Core Dashboard
-	UpdateConfigStatusFunc func() // Callback to update config status in Core Dashboard
-	UpdateTrayMenuFunc     func() // Callback to update tray menu
-
-	// --- Parser progress UI ---
-	ParserProgressBar        *widget.ProgressBar
-	ParserStatusLabel        *widget.Label
-	UpdateParserProgressFunc func(progress float64, status string) // Callback to update parser progress
-
 	// --- Auto-update configuration ---
-	AutoUpdateEnabled        bool       // Flag to enable/disable auto-updates (false after 10 failed attempts)
-	AutoUpdateFailedAttempts int        // Counter for consecutive failed attempts (reset on success)
-	AutoUpdateMutex          sync.Mutex // Mutex for auto-update state
+	AutoUpdateEnabled        bool              // Flag to enable/disable auto-updates (false on auth errors or after 10 non-network failures)
+	AutoUpdateFailedAttempts int               // Counter for consecutive failed attempts (reset on success)
+	AutoUpdateNextAttempt    time.Time         // Time of the next scheduled auto-update check (zero if stopped)
+	AutoUpdateLastFailure    UpdateFailureKind // Kind of the last failure (valid if AutoUpdateLastError is not empty)
+	AutoUpdateLastError      string            // Last auto-update error message (empty after success)
+	AutoUpdateMutex          sync.Mutex        // Mutex for auto-update state
+
+	sourceFailures map[string]SourceFailure // Subscriptions whose last download failed, by URL (guarded by AutoUpdateMutex)
+}
+
+// AutoUpdateStatus is a snapshot of the auto-update state for the UI
+type AutoUpdateStatus struct {
+	Enabled        bool
+	FailedAttempts int
+	NextAttempt    time.Time
+	LastFailure    UpdateFailureKind
+	LastError      string
+	FailedSources  int // Subscriptions backing off or stopped after failed downloads
 }
 
 // RunningState - structure for tracking the VPN's running state.
@@ -157,35 +159,101 @@ type RunningState struct {
 	controller *AppController
 }
 
-// NewAppController creates and initializes a new AppController instance.
-func NewAppController(appIconData, greyIconData, greenIconData, redIconData []byte) (*AppController, error) {
-	ac := &AppController{}
+// NewAppController creates and initializes a new AppController instance and starts
+// the subscription auto-update loop, the traffic monitor and background latency tests.
+// The GUI wraps it in ui.Controller.
+func NewAppController() (*AppController, error) {
+	ac, err := newAppController()
+	if err != nil {
+		return nil, err
+	}
+	go ac.startAutoUpdateLoop()
+	go ac.TrafficMonitor.Run(ac.ctx)
+	go ac.runLatencyTestLoop()
+	return ac, nil
+}
+
+// NewHeadlessAppController creates an AppController without the auto-update loop.
+// Used by CLI subcommands; messages go to the log (LogNotifier).
+// profile selects a profile for this run only (empty = active profile from launcher settings).
+func NewHeadlessAppController(profile string) (*AppController, error) {
+	ac, err := newAppController()
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckProfileName(profile); err != nil {
+		return nil, err
+	}
+	if profile != "" && profile != ac.GetActiveProfile() {
+		if !ac.Profiles.Exists(profile) {
+			return nil, fmt.Errorf("profile '%s' does not exist", profile)
+		}
+		ac.setActiveProfile(profile)
+		ac.ProcessService.reloadClashAPIConfig("NewHeadlessAppController")
+	}
+	return ac, nil
+}
 
+// ExecutableDir returns the launcher directory (the directory of the executable)
+func ExecutableDir() (string, error) {
 	ex, err := os.Executable()
 	if err != nil {
-		return nil, fmt.Errorf("NewAppController: cannot determine executable path: %w", err)
+		return "", fmt.Errorf("cannot determine executable path: %w", err)
+	}
+	return filepath.Dir(ex), nil
+}
+
+// newAppController initializes the controller for the directory of the executable
+func newAppController() (*AppController, error) {
+	execDir, err := ExecutableDir()
+	if err != nil {
+		return nil, fmt.Errorf("NewAppController: %w", err)
+	}
+	return NewAppControllerInDir(execDir)
+}
+
+// NewAppControllerInDir initializes paths, logs, services and state shared by GUI and CLI modes
+// for the launcher directory execDir. It does not start the auto-update loop (used by tests).
+func NewAppControllerInDir(execDir string) (*AppController, error) {
+	ac := &AppController{
+		Notifier: LogNotifier{},
+		Events:   NewEventBus(),
+		CoreLog:  NewLogBuffer(coreLogCapacity),
 	}
-	ac.ExecDir = filepath.Dir(ex)
+	ac.ExecDir = execDir
 
 	// Use platform-specific functions
 	if err := platform.EnsureDirectories(ac.ExecDir); err != nil {
 		return nil, fmt.Errorf("NewAppController: cannot create directories: %w", err)
 	}
 
-	ac.ConfigPath = platform.GetConfigPath(ac.ExecDir)
+	// Resolve config.json of the active profile
+	ac.Settings = LoadLauncherSettings(ac.ExecDir)
+	ac.Profiles = NewProfileManager(ac.ExecDir)
+	ac.Settings.Get(func(s *LauncherSettings) { ac.ActiveProfile = s.ActiveProfile })
+	if ac.ActiveProfile == "" || !ac.Profiles.Exists(ac.ActiveProfile) {
+		ac.ActiveProfile = DefaultProfileName
+	}
+	ac.ConfigPath = ac.Profiles.ConfigPath(ac.ActiveProfile)
 	singboxName := platform.GetExecutableNames()
 	ac.SingboxPath = filepath.Join(ac.ExecDir, "bin", singboxName)
 	ac.WintunPath = platform.GetWintunPath(ac.ExecDir)
 
+	// Secrets are masked in all logs unless the user opted in to unredacted debug logs
+	var unredacted bool
+	ac.Settings.Get(func(s *LauncherSettings) { unredacted = s.DebugUnredactedLogs })
+	SetLogRedaction(!unredacted)
+
 	// Open log files with rotation support
-	logFile, err := openLogFileWithRotation(filepath.Join(ac.ExecDir, logFileName))
+	rotation := ac.LogRotationPolicy()
+	logFile, err := OpenRotatingLogFile(filepath.Join(ac.ExecDir, logFileName), rotation)
 	if err != nil {
 		return nil, fmt.Errorf("NewAppController: cannot open main log file: %w", err)
 	}
 	log.SetOutput(logFile)
 	ac.MainLogFile = logFile
 
-	childLogFile, err := openLogFileWithRotation(filepath.Join(ac.ExecDir, childLogFileName))
+	childLogFile, err := OpenSharedLogFile(filepath.Join(ac.ExecDir, childLogFileName), rotation, ac.detachedCoreRunning)
 	if err != nil {
 		log.Printf("NewAppController: failed to open sing-box child log file: %v", err)
 		ac.ChildLogFile = nil
@@ -193,7 +261,7 @@ func NewAppController(appIconData, greyIconData, greenIconData, redIconData []by
 		ac.ChildLogFile = childLogFile
 	}
 
-	apiLogFile, err := openLogFileWithRotation(filepath.Join(ac.ExecDir, apiLogFileName))
+	apiLogFile, err := OpenRotatingLogFile(filepath.Join(ac.ExecDir, apiLogFileName), rotation)
 	if err != nil {
 		log.Printf("NewAppController: failed to open API log file: %v", err)
 		ac.ApiLogFile = nil
@@ -201,21 +269,18 @@ func NewAppController(appIconData, greyIconData, greenIconData, redIconData []by
 		ac.ApiLogFile = apiLogFile
 	}
 
-	ac.AppIconData = fyne.NewStaticResource("appIcon", appIconData)
-	ac.GreyIconData = fyne.NewStaticResource("trayIcon", greyIconData)
-	ac.GreenIconData = fyne.NewStaticResource("runningIcon", greenIconData)
-	ac.RedIconData = fyne.NewStaticResource("errorIcon", redIconData)
-
 	log.Println("Application initializing...")
-	ac.Application = app.NewWithID("com.singbox.launcher")
-	ac.Application.SetIcon(ac.AppIconData)
+	if unredacted {
+		log.Println("NewAppController: Warning: debug_unredacted_logs is enabled, logs contain passwords and subscription tokens. Do not share them.")
+	}
 	ac.RunningState = &RunningState{controller: ac}
 	ac.RunningState.Set(false) // Use Set() method instead of direct assignment
 	ac.ConsecutiveCrashAttempts = 0
 	ac.ProcessService = NewProcessService(ac)
 	ac.ConfigService = NewConfigService(ac)
+	ac.TrafficMonitor = NewTrafficMonitor(ac)
 
-	if base, tok, err := api.LoadClashAPIConfig(ac.ConfigPath); err != nil {
+	if base, tok, err := api.LoadClashAPIConfig(ac.GetConfigPath()); err != nil {
 		log.Printf("NewAppController: Clash API config error: %v", err)
 		ac.ClashAPIBaseURL = ""
 		ac.ClashAPIToken = ""
@@ -224,11 +289,12 @@ func NewAppController(appIconData, greyIconData, greenIconData, redIconData []by
 		ac.ClashAPIBaseURL = base
 		ac.ClashAPIToken = tok
 		ac.ClashAPIEnabled = true
+		RegisterSecret(tok)
 	}
 
 	// Initialize SelectedClashGroup from config (needed for auto-loading proxies)
 	if ac.ClashAPIEnabled {
-		_, defaultSelector, err := GetSelectorGroupsFromConfig(ac.ConfigPath)
+		_, defaultSelector, err := GetSelectorGroupsFromConfig(ac.GetConfigPath())
 		if err != nil {
 			log.Printf("NewAppController: Failed to get selector groups: %v", err)
 			ac.SelectedClashGroup = "proxy-out" // Default fallback
@@ -243,15 +309,6 @@ func NewAppController(appIconData, greyIconData, greenIconData, redIconData []by
 	ac.SetSelectedIndex(-1)
 	ac.SetActiveProxyName("")
 
-	ac.RefreshAPIFunc = func() { log.Println("RefreshAPIFunc handler is not set yet.") }
-	ac.ResetAPIStateFunc = func() { log.Println("ResetAPIStateFunc handler is not set yet.") }
-	ac.UpdateCoreStatusFunc = func() { log.Println("UpdateCoreStatusFunc handler is not set yet.") }
-	ac.UpdateConfigStatusFunc = func() { log.Println("UpdateConfigStatusFunc handler is not set yet.") }
-	ac.UpdateTrayMenuFunc = func() { log.Println("UpdateTrayMenuFunc handler is not set yet.") }
-	ac.UpdateParserProgressFunc = func(progress float64, status string) {
-		log.Printf("UpdateParserProgressFunc handler is not set yet. Progress: %.0f%%, Status: %s", progress, status)
-	}
-
 	// Initialize context for goroutine cancellation
 	ac.ctx, ac.cancelFunc = context.WithCancel(context.Background())
 
@@ -260,63 +317,15 @@ func NewAppController(appIconData, greyIconData, greenIconData, redIconData []by
 	ac.AutoUpdateFailedAttempts = 0
 
 	// Check if config file exists before starting auto-update
-	if _, err := os.Stat(ac.ConfigPath); os.IsNotExist(err) {
-		log.Printf("Auto-update: Config file does not exist (%s), auto-update disabled", ac.ConfigPath)
+	if _, err := os.Stat(ac.GetConfigPath()); os.IsNotExist(err) {
+		log.Printf("Auto-update: Config file does not exist (%s), auto-update disabled", ac.GetConfigPath())
 		ac.AutoUpdateEnabled = false
 	}
-	go ac.startAutoUpdateLoop()
 	return ac, nil
 }
 
-// UpdateUI updates all UI elements based on the current application state.
-func (ac *AppController) UpdateUI() {
-	fyne.Do(func() {
-		// Update tray icon (this is a system function, not a UI widget)
-		if desk, ok := ac.Application.(desktop.App); ok {
-			// Check that icons are initialized
-			if ac.GreenIconData == nil || ac.GreyIconData == nil || ac.RedIconData == nil {
-				log.Printf("UpdateUI: Icons not initialized, skipping icon update")
-				return
-			}
-
-			var iconToSet fyne.Resource
-
-			if ac.RunningState.IsRunning() {
-				// Green icon - if running
-				iconToSet = ac.GreenIconData
-			} else {
-				// Check for binary to determine error state (simple file check)
-				if _, err := os.Stat(ac.SingboxPath); os.IsNotExist(err) {
-					// Red icon - on error (binary not found)
-					iconToSet = ac.RedIconData
-				} else {
-					// Grey icon - on normal stop
-					iconToSet = ac.GreyIconData
-				}
-			}
-
-			desk.SetSystemTrayIcon(iconToSet)
-		}
-
-		// Если состояние Down, сбрасываем API состояние
-		if !ac.RunningState.IsRunning() && ac.ResetAPIStateFunc != nil {
-			log.Println("UpdateUI: Triggering API state reset because state is 'Down'.")
-			ac.ResetAPIStateFunc()
-		}
-
-		// Update tray menu when state changes (same as Core Dashboard)
-		if ac.UpdateTrayMenuFunc != nil {
-			ac.UpdateTrayMenuFunc()
-		}
-
-		// Update Core Dashboard status when state changes (synchronize with tray)
-		if ac.UpdateCoreStatusFunc != nil {
-			ac.UpdateCoreStatusFunc()
-		}
-	})
-}
-
-// GracefulExit performs a graceful shutdown of the application.
+// GracefulExit stops sing-box and background goroutines and closes log files.
+// The GUI quits the Fyne application afterwards (see ui.Controller.Quit).
 func (ac *AppController) GracefulExit() {
 	// Cancel context to signal all goroutines to stop
 	if ac.cancelFunc != nil {
@@ -324,14 +333,6 @@ func (ac *AppController) GracefulExit() {
 		log.Println("GracefulExit: Context cancelled, signalling goroutines to stop")
 	}
 
-	// Stop any pending menu update timer
-	ac.TrayMenuUpdateMutex.Lock()
-	if ac.TrayMenuUpdateTimer != nil {
-		ac.TrayMenuUpdateTimer.Stop()
-		ac.TrayMenuUpdateTimer = nil
-	}
-	ac.TrayMenuUpdateMutex.Unlock()
-
 	StopSingBoxProcess(ac)
 
 	log.Println("GracefulExit: Waiting for sing-box to stop...")
@@ -366,8 +367,6 @@ end_loop:
 	if ac.ApiLogFile != nil {
 		ac.ApiLogFile.Close()
 	}
-
-	ac.Application.Quit()
 }
 
 // RunHidden launches an external command in a hidden window.
@@ -380,12 +379,9 @@ func (ac *AppController) RunHidden(name string, args []string, logPath string, d
 
 	if logPath != "" {
 		if logPath == filepath.Join(ac.ExecDir, childLogFileName) && ac.ChildLogFile != nil {
-			// For sing-box logs, check and rotate if needed before writing
-			checkAndRotateLogFile(logPath)
-			logFile := ac.ChildLogFile
 			// Don't truncate - append to preserve logs, rotation handles size limits
-			cmd.Stdout = logFile
-			cmd.Stderr = logFile
+			cmd.Stdout = ac.ChildLogFile
+			cmd.Stderr = ac.ChildLogFile
 		} else {
 			// For other logs (parser), use truncate mode for clean start
 			logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
@@ -406,11 +402,11 @@ func CheckLinuxCapabilities(ac *AppController) {
 	if suggestion := platform.CheckAndSuggestCapabilities(ac.SingboxPath); suggestion != "" {
 		log.Printf("CheckLinuxCapabilities: %s", suggestion)
 		// Show info dialog (not error) - capabilities can be set later
-		dialogs.ShowInfo(ac.MainWindow, "Linux Capabilities", suggestion)
+		ac.Notifier.ShowInfo("Linux Capabilities", suggestion)
 	}
 }
 
-// Set sets the new value for the 'running' state and triggers a UI update.
+// Set sets the new value for the 'running' state and publishes EventCoreStatusChanged.
 func (r *RunningState) Set(value bool) {
 	r.Lock()
 	if r.running == value {
@@ -420,13 +416,8 @@ func (r *RunningState) Set(value bool) {
 	r.running = value
 	r.Unlock()
 
-	r.controller.UpdateUI()
-
-	// Call callback to update status in Core Dashboard
-	if r.controller.UpdateCoreStatusFunc != nil {
-		r.controller.UpdateCoreStatusFunc()
-	}
-
+	// UI updates tray icon, tray menu and Core Dashboard; resets API state when stopped
+	r.controller.publish(EventCoreStatusChanged)
 }
 
 // IsRunning checks if the VPN is running.
@@ -437,7 +428,9 @@ func (r *RunningState) IsRunning() bool {
 }
 
 // SetProxiesList safely sets the proxies list with mutex protection.
+// The list is sorted by name or delay according to ProxySortByLatency.
 func (ac *AppController) SetProxiesList(proxies []api.ProxyInfo) {
+	SortProxies(proxies, ac.ProxySortByLatency())
 	ac.APIStateMutex.Lock()
 	defer ac.APIStateMutex.Unlock()
 	ac.ProxiesList = proxies
@@ -768,8 +761,8 @@ func CheckIfSingBoxRunningAtStartUtil(ac *AppController) {
 
 // CheckConfigFileExists checks if config.json exists and shows a warning if it doesn't
 func CheckConfigFileExists(ac *AppController) {
-	if _, err := os.Stat(ac.ConfigPath); os.IsNotExist(err) {
-		log.Printf("CheckConfigFileExists: config.json not found at %s", ac.ConfigPath)
+	if _, err := os.Stat(ac.GetConfigPath()); os.IsNotExist(err) {
+		log.Printf("CheckConfigFileExists: config.json not found at %s", ac.GetConfigPath())
 
 		message := fmt.Sprintf(
 			"⚠️ Configuration file not found!\n\n"+
@@ -781,33 +774,7 @@ func CheckConfigFileExists(ac *AppController) {
 			constants.ConfigFileName,
 		)
 
-		dialogs.ShowInfo(ac.MainWindow, "Configuration Not Found", message)
-	}
-}
-
-func CheckIfLauncherAlreadyRunningUtil(ac *AppController) {
-	execPath, err := os.Executable()
-	if err != nil {
-		log.Printf("CheckIfLauncherAlreadyRunning: cannot detect executable path: %v", err)
-		return
-	}
-	execName := strings.ToLower(filepath.Base(execPath))
-	currentPID := os.Getpid()
-
-	processes, err := ps.Processes()
-	if err != nil {
-		log.Printf("CheckIfLauncherAlreadyRunning: error listing processes: %v", err)
-		return
-	}
-
-	for _, p := range processes {
-		if p.Pid() == currentPID {
-			continue
-		}
-		if strings.EqualFold(p.Executable(), execName) {
-			dialogs.ShowInfo(ac.MainWindow, "Information", "The application is already running. Use the existing instance or close it before starting a new one.")
-			return
-		}
+		ac.Notifier.ShowInfo("Configuration Not Found", message)
 	}
 }
 
@@ -830,7 +797,7 @@ func CheckFilesUtil(ac *AppController) {
 	} else {
 		msg += "\nSome files missing. ❌"
 	}
-	dialogs.ShowInfo(ac.MainWindow, "File Check", msg)
+	ac.Notifier.ShowInfo("File Check", msg)
 }
 
 func FormatBytesUtil(b int64) string {
@@ -846,23 +813,18 @@ func FormatBytesUtil(b int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "KMGTPE"[exp])
 }
 
+// ShowSingBoxAlreadyRunningWarningUtil offers to kill a sing-box process not started by this launcher
 func ShowSingBoxAlreadyRunningWarningUtil(ac *AppController) {
-	label := widget.NewLabel("Sing-Box appears to be already running.\nWould you like to kill the existing process?")
-	killButton := widget.NewButton("Kill Process", nil)
-	closeButton := widget.NewButton("Close This Warning", nil)
-	content := container.NewVBox(label, killButton, closeButton)
-	var d dialog.Dialog
-	d = dialog.NewCustomWithoutButtons("Warning", content, ac.MainWindow)
-	killButton.OnTapped = func() {
-		go func() {
-			processName := platform.GetProcessNameForCheck()
-			_ = platform.KillProcess(processName)
-			ac.RunningState.Set(false)
-		}()
-		fyne.Do(func() { d.Hide() })
-	}
-	closeButton.OnTapped = func() { fyne.Do(func() { d.Hide() }) }
-	fyne.Do(func() { d.Show() })
+	ac.Notifier.ShowConfirm("Warning",
+		"Sing-Box appears to be already running.\nWould you like to kill the existing process?",
+		"Kill Process",
+		func() {
+			go func() {
+				processName := platform.GetProcessNameForCheck()
+				_ = platform.KillProcess(processName)
+				ac.RunningState.Set(false)
+			}()
+		})
 }
 
 // AutoLoadProxies attempts to load proxies with retry intervals (1, 3, 7, 13, 17 seconds)
@@ -938,8 +900,6 @@ func (ac *AppController) AutoLoadProxies() {
 			// Get current group (it might have changed)
 			ac.APIStateMutex.RLock()
 			currentGroup := ac.SelectedClashGroup
-			baseURL := ac.ClashAPIBaseURL
-			token := ac.ClashAPIToken
 			ac.APIStateMutex.RUnlock()
 
 			if currentGroup == "" {
@@ -947,34 +907,32 @@ func (ac *AppController) AutoLoadProxies() {
 				return
 			}
 
+			// Re-apply manual proxy selections and the routing mode once per sing-box start or reload
+			if ac.takeRestorePending() {
+				if _, err := ac.RestoreProxySelections(ac.ctx); err != nil {
+					log.Printf("AutoLoadProxies: Attempt %d failed to restore selections: %v", attempt+1, err)
+					ac.setRestorePending()
+					continue
+				}
+				if err := ac.RestoreClashMode(ac.ctx); err != nil {
+					log.Printf("AutoLoadProxies: Failed to restore routing mode: %v", err)
+				}
+			}
+
 			// Try to load proxies
-			proxies, now, err := api.GetProxiesInGroup(baseURL, token, currentGroup, ac.ApiLogFile)
+			proxies, now, err := ac.ClashClient().ProxiesInGroup(ac.ctx, currentGroup)
 			if err != nil {
 				log.Printf("AutoLoadProxies: Attempt %d failed: %v", attempt+1, err)
 				// Continue to next attempt
 				continue
 			}
 
-			// Success - update proxies list
-			fyne.Do(func() {
-				ac.SetProxiesList(proxies)
-				ac.SetActiveProxyName(now)
-
-				// Update UI if callbacks are set
-				if ac.ProxiesListWidget != nil {
-					ac.ProxiesListWidget.Refresh()
-				}
-				if ac.ListStatusLabel != nil {
-					ac.ListStatusLabel.SetText(fmt.Sprintf("Proxies loaded for '%s'. Active: %s", currentGroup, now))
-				}
-				if ac.RefreshAPIFunc != nil {
-					ac.RefreshAPIFunc()
-				}
-
-				// Update tray menu AFTER UI updates (important: this must be last)
-				if ac.UpdateTrayMenuFunc != nil {
-					ac.UpdateTrayMenuFunc()
-				}
+			// Success - update proxies list; UI refreshes Clash API tab and tray menu
+			ac.SetProxiesList(proxies)
+			ac.SetActiveProxyName(now)
+			ac.Events.Publish(Event{
+				Type:    EventProxiesChanged,
+				Message: fmt.Sprintf("Proxies loaded for '%s'. Active: %s", currentGroup, now),
 			})
 
 			log.Printf("AutoLoadProxies: Successfully loaded %d proxies for group '%s' on attempt %d", len(proxies), currentGroup, attempt+1)
@@ -992,6 +950,64 @@ func (ac *AppController) AutoLoadProxies() {
 	}()
 }
 
+// setRestorePending makes the next AutoLoadProxies re-apply saved proxy selections
+func (ac *AppController) setRestorePending() {
+	ac.AutoLoadMutex.Lock()
+	defer ac.AutoLoadMutex.Unlock()
+	ac.restorePending = true
+}
+
+// takeRestorePending reports and clears the pending restore of proxy selections
+func (ac *AppController) takeRestorePending() bool {
+	ac.AutoLoadMutex.Lock()
+	defer ac.AutoLoadMutex.Unlock()
+	pending := ac.restorePending
+	ac.restorePending = false
+	return pending
+}
+
+// ClashClient returns a Clash API client for the address and secret from the current config.
+// Requests are logged to the API log.
+func (ac *AppController) ClashClient() *api.Client {
+	ac.APIStateMutex.RLock()
+	defer ac.APIStateMutex.RUnlock()
+	return api.NewClient(ac.ClashAPIBaseURL, ac.ClashAPIToken, ac.apiLogWriter())
+}
+
+// apiLogWriter returns the API log as io.Writer (nil if it could not be opened)
+func (ac *AppController) apiLogWriter() io.Writer {
+	if ac.ApiLogFile == nil {
+		return nil
+	}
+	return ac.ApiLogFile
+}
+
+// SelectProxy switches a selector group to the proxy via Clash API and saves the choice
+// (see RestoreProxySelections). If the group is the selected one, the active proxy is updated
+// and EventProxiesChanged is published.
+func (ac *AppController) SelectProxy(group, proxy string) error {
+	if !ac.ClashAPIEnabled {
+		return fmt.Errorf("Clash API is disabled in %s", ac.GetConfigPath())
+	}
+	if err := ac.ClashClient().SwitchProxy(context.Background(), group, proxy); err != nil {
+		return err
+	}
+	ac.saveProxySelection(group, proxy)
+	ac.addRecentProxy(proxy)
+
+	ac.APIStateMutex.RLock()
+	selectedGroup := ac.SelectedClashGroup
+	ac.APIStateMutex.RUnlock()
+	if group == selectedGroup {
+		ac.SetActiveProxyName(proxy)
+		ac.Events.Publish(Event{
+			Type:    EventProxiesChanged,
+			Message: fmt.Sprintf("Switched '%s' to %s", group, proxy),
+		})
+	}
+	return nil
+}
+
 // VPNButtonState represents the state of Start/Stop VPN buttons
 type VPNButtonState struct {
 	BinaryExists bool
@@ -1008,7 +1024,7 @@ func (ac *AppController) GetVPNButtonState() VPNButtonState {
 
 	// Check if config.json exists
 	configExists := false
-	if _, err := os.Stat(ac.ConfigPath); err == nil {
+	if _, err := os.Stat(ac.GetConfigPath()); err == nil {
 		configExists = true
 	}
 
@@ -1059,128 +1075,12 @@ func (ac *AppController) GetVPNButtonState() VPNButtonState {
 	return state
 }
 
-// CreateTrayMenu creates the system tray menu with proxy selection submenu
-func (ac *AppController) CreateTrayMenu() *fyne.Menu {
-	// Get proxies from current group
-	ac.APIStateMutex.RLock()
-	proxies := ac.ProxiesList
-	activeProxy := ac.ActiveProxyName
-	selectedGroup := ac.SelectedClashGroup
-	clashAPIEnabled := ac.ClashAPIEnabled
-	ac.APIStateMutex.RUnlock()
-
-	// Auto-load proxies if list is empty and API is enabled
-	// Note: AutoLoadProxies has internal guard to prevent multiple simultaneous loads
-	if clashAPIEnabled && selectedGroup != "" && len(proxies) == 0 {
-		// Only auto-load if sing-box is running
-		if ac.RunningState.IsRunning() {
-			// Check if auto-load is already in progress to avoid duplicate calls
-			ac.AutoLoadMutex.Lock()
-			alreadyInProgress := ac.AutoLoadInProgress
-			ac.AutoLoadMutex.Unlock()
-
-			if !alreadyInProgress {
-				// Start auto-loading in background (non-blocking)
-				go ac.AutoLoadProxies()
-			}
-		}
-	}
-
-	// Create proxy submenu items
-	var proxyMenuItems []*fyne.MenuItem
-	if clashAPIEnabled && selectedGroup != "" && len(proxies) > 0 {
-		for i := range proxies {
-			proxy := proxies[i]
-			proxyName := proxy.Name
-			isActive := proxyName == activeProxy
-
-			// Create local copy for closure
-			pName := proxyName
-			menuItem := fyne.NewMenuItem(proxyName, func() {
-				// Switch to selected proxy
-				go func() {
-					err := api.SwitchProxy(ac.ClashAPIBaseURL, ac.ClashAPIToken, selectedGroup, pName, ac.ApiLogFile)
-					fyne.Do(func() {
-						if err != nil {
-							log.Printf("CreateTrayMenu: Failed to switch proxy: %v", err)
-							dialogs.ShowError(ac.MainWindow, fmt.Errorf("failed to switch proxy: %w", err))
-						} else {
-							ac.SetActiveProxyName(pName)
-							// Update tray menu after switch
-							if ac.UpdateTrayMenuFunc != nil {
-								ac.UpdateTrayMenuFunc()
-							}
-							// Refresh UI if callback is set
-							if ac.RefreshAPIFunc != nil {
-								ac.RefreshAPIFunc()
-							}
-						}
-					})
-				}()
-			})
-
-			// Mark active proxy with checkmark
-			if isActive {
-				menuItem.Label = "✓ " + proxyName
-			}
-
-			proxyMenuItems = append(proxyMenuItems, menuItem)
-		}
-	} else {
-		// Show disabled item if no proxies available
-		disabledItem := fyne.NewMenuItem("No proxies available", nil)
-		disabledItem.Disabled = true
-		proxyMenuItems = append(proxyMenuItems, disabledItem)
-	}
-
-	// Create proxy submenu
-	proxySubmenu := fyne.NewMenu("Select Proxy", proxyMenuItems...)
-
-	// Get button state from centralized function
-	buttonState := ac.GetVPNButtonState()
-
-	// Create main menu items
-	menuItems := []*fyne.MenuItem{
-		fyne.NewMenuItem("Open", func() { ac.MainWindow.Show() }),
-		fyne.NewMenuItemSeparator(),
-	}
-
-	// Add Start/Stop VPN buttons based on centralized state
-	if buttonState.StartEnabled {
-		menuItems = append(menuItems, fyne.NewMenuItem("Start VPN", func() { StartSingBoxProcess(ac) }))
-	} else {
-		startItem := fyne.NewMenuItem("Start VPN", nil)
-		startItem.Disabled = true
-		menuItems = append(menuItems, startItem)
-	}
-
-	if buttonState.StopEnabled {
-		menuItems = append(menuItems, fyne.NewMenuItem("Stop VPN", func() { StopSingBoxProcess(ac) }))
-	} else {
-		stopItem := fyne.NewMenuItem("Stop VPN", nil)
-		stopItem.Disabled = true
-		menuItems = append(menuItems, stopItem)
-	}
-
-	menuItems = append(menuItems, fyne.NewMenuItemSeparator())
-
-	// Add proxy submenu if Clash API is enabled
-	if clashAPIEnabled && selectedGroup != "" {
-		selectProxyItem := fyne.NewMenuItem("Select Proxy", nil)
-		selectProxyItem.ChildMenu = proxySubmenu
-		menuItems = append(menuItems, selectProxyItem)
-		menuItems = append(menuItems, fyne.NewMenuItemSeparator())
-	}
-
-	// Add Quit item
-	menuItems = append(menuItems, fyne.NewMenuItem("Quit", ac.GracefulExit))
-
-	return fyne.NewMenu("Singbox Launcher", menuItems...)
-}
-
 // startAutoUpdateLoop runs a background goroutine that periodically checks and updates configuration
-// Uses dynamic interval: max(10 minutes, parser.reload from config)
-// Handles errors with retries (10 attempts, 10 seconds between retries)
+// Each proxy source has its own reload interval (source.reload, falling back to parser.reload);
+// only due sources are downloaded, the others reuse cached content.
+// Updates are deferred during parser.quiet_hours and delayed by a random parser.jitter.
+// Failures are retried with exponential backoff (see handleAutoUpdateFailure); failed subscription
+// downloads back off per subscription (see recordSourceResults)
 // Resumes after successful manual update
 func (ac *AppController) startAutoUpdateLoop() {
 	log.Println("Auto-update: Starting auto-update loop")
@@ -1195,7 +1095,11 @@ func (ac *AppController) startAutoUpdateLoop() {
 		}
 
 		// Check if auto-update is enabled
-		if !ac.AutoUpdateEnabled {
+		ac.AutoUpdateMutex.Lock()
+		enabled := ac.AutoUpdateEnabled
+		ac.AutoUpdateMutex.Unlock()
+		if !enabled {
+			ac.setAutoUpdateNextAttempt(time.Time{})
 			// Auto-update is stopped, wait and check again
 			select {
 			case <-ac.ctx.Done():
@@ -1205,64 +1109,9 @@ func (ac *AppController) startAutoUpdateLoop() {
 			}
 		}
 
-		// Calculate check interval from config
-		checkInterval, err := ac.calculateAutoUpdateInterval()
-		if err != nil {
-			log.Printf("Auto-update: Failed to calculate interval: %v, using default", err)
-			checkInterval = autoUpdateMinInterval
-		}
-
-		log.Printf("Auto-update: Calculated interval: %v (min: %v)", checkInterval, autoUpdateMinInterval)
-
-		// Check if update is needed immediately (before waiting)
-		requiredInterval, err := ac.calculateAutoUpdateInterval()
-		if err != nil {
-			log.Printf("Auto-update: Failed to calculate required interval: %v, using default", err)
-			requiredInterval = autoUpdateMinInterval
-		}
-
-		needsUpdate, err := ac.shouldAutoUpdate(requiredInterval)
-		if err != nil {
-			log.Printf("Auto-update: Failed to check if update needed: %v, skipping this check", err)
-			// Don't stop auto-update on check errors, just skip this check and wait
-		} else if needsUpdate {
-			// Update is needed - check if already in progress
-			ac.ParserMutex.Lock()
-			updateInProgress := ac.ParserRunning
-			ac.ParserMutex.Unlock()
-
-			if !updateInProgress {
-				log.Println("Auto-update: Update needed, attempting update...")
-				success := ac.attemptAutoUpdateWithRetries(autoUpdateRetryInterval, autoUpdateMaxRetries)
-				if success {
-					// Success - error counter already reset in attemptAutoUpdateWithRetries
-					ac.AutoUpdateMutex.Lock()
-					if !ac.AutoUpdateEnabled {
-						ac.AutoUpdateEnabled = true
-						log.Println("Auto-update: Resumed after successful update")
-					}
-					ac.AutoUpdateMutex.Unlock()
-					log.Println("Auto-update: Completed successfully, error counter reset")
-				} else {
-					// Failed after all retries - check if we reached max consecutive failures
-					ac.AutoUpdateMutex.Lock()
-					if ac.AutoUpdateFailedAttempts >= autoUpdateMaxRetries {
-						ac.AutoUpdateEnabled = false
-						ac.AutoUpdateMutex.Unlock()
-						log.Printf("Auto-update: Stopped after %d consecutive failed attempts", ac.AutoUpdateFailedAttempts)
-						fyne.Do(func() {
-							dialogs.ShowAutoHideInfo(ac.Application, ac.MainWindow, "Auto-update", "Automatic configuration update stopped after 10 failed attempts. Use manual update.")
-						})
-					} else {
-						ac.AutoUpdateMutex.Unlock()
-					}
-				}
-			} else {
-				log.Println("Auto-update: Update already in progress, skipping")
-			}
-		} else {
-			log.Printf("Auto-update: Update not needed yet, will check again in %v", checkInterval)
-		}
+		checkInterval := ac.runAutoUpdateCheck()
+		log.Printf("Auto-update: Will check again in %v", checkInterval)
+		ac.setAutoUpdateNextAttempt(time.Now().Add(checkInterval))
 
 		// Wait for check interval before next check
 		select {
@@ -1274,128 +1123,166 @@ func (ac *AppController) startAutoUpdateLoop() {
 	}
 }
 
-// calculateAutoUpdateInterval calculates the check interval: max(10 minutes, parser.reload)
-// Returns the interval to use for checking if update is needed
-func (ac *AppController) calculateAutoUpdateInterval() (time.Duration, error) {
-
-	// Read ParserConfig from file
-	config, err := ExtractParserConfig(ac.ConfigPath)
+// runAutoUpdateCheck performs a single auto-update check and returns the delay before the next one
+func (ac *AppController) runAutoUpdateCheck() time.Duration {
+	config, err := ExtractParserConfig(ac.GetConfigPath())
 	if err != nil {
-		// If config doesn't exist or can't be read, use default
-		defaultDuration, _ := time.ParseDuration(autoUpdateDefaultReload)
-		return maxDuration(autoUpdateMinInterval, defaultDuration), nil
+		log.Printf("Auto-update: Failed to read config: %v, skipping this check", err)
+		return autoUpdateMinInterval
 	}
 
-	// Get reload value from config
-	reloadStr := config.ParserConfig.Parser.Reload
-	if reloadStr == "" {
-		// Use default if not specified
-		defaultDuration, _ := time.ParseDuration(autoUpdateDefaultReload)
-		return maxDuration(autoUpdateMinInterval, defaultDuration), nil
+	now := time.Now()
+	quietHours := config.ParserConfig.Parser.QuietHours
+	if quietHours.Contains(now) {
+		quietEnd := quietHours.EndAfter(now)
+		log.Printf("Auto-update: Quiet hours (%s-%s), deferring updates until %s", quietHours.Start, quietHours.End, quietEnd.Format("15:04"))
+		return clampAutoUpdateCheckInterval(quietEnd.Sub(now))
 	}
 
-	// Parse reload string to duration
-	reloadDuration, err := time.ParseDuration(reloadStr)
-	if err != nil {
-		log.Printf("Auto-update: Failed to parse reload duration '%s': %v, using default", reloadStr, err)
-		defaultDuration, _ := time.ParseDuration(autoUpdateDefaultReload)
-		return maxDuration(autoUpdateMinInterval, defaultDuration), nil
+	due := ac.dueSources(config, now)
+	if len(due) == 0 {
+		next := ac.nextAutoUpdateTime(config)
+		if next.IsZero() {
+			log.Println("Auto-update: No subscription sources to update automatically")
+			return autoUpdateMinInterval
+		}
+		log.Printf("Auto-update: Update not needed yet, next source is due at %s", next.Local().Format("2006-01-02 15:04:05"))
+		return clampAutoUpdateCheckInterval(next.Sub(now))
 	}
 
-	// Return max(10 minutes, reload)
-	return maxDuration(autoUpdateMinInterval, reloadDuration), nil
-}
-
-// maxDuration returns the maximum of two durations
-func maxDuration(a, b time.Duration) time.Duration {
-	if a > b {
-		return a
+	// Update is needed - check if already in progress
+	ac.ParserMutex.Lock()
+	updateInProgress := ac.ParserRunning
+	ac.ParserMutex.Unlock()
+	if updateInProgress {
+		log.Println("Auto-update: Update already in progress, skipping")
+		return autoUpdateRecheckInterval
 	}
-	return b
-}
 
-// shouldAutoUpdate checks if configuration update is needed
-// Returns true if elapsed time since last_updated >= required interval
-func (ac *AppController) shouldAutoUpdate(requiredInterval time.Duration) (bool, error) {
-	// Read ParserConfig from file
-	config, err := ExtractParserConfig(ac.ConfigPath)
-	if err != nil {
-		// If config doesn't exist, update is needed
-		return true, nil
+	if jitter := autoUpdateJitter(config); jitter > 0 {
+		log.Printf("Auto-update: Delaying update by %v (jitter)", jitter)
+		select {
+		case <-ac.ctx.Done():
+			return autoUpdateRecheckInterval
+		case <-time.After(jitter):
+		}
 	}
 
-	// Check last_updated
-	lastUpdatedStr := config.ParserConfig.Parser.LastUpdated
-	if lastUpdatedStr == "" {
-		// No last_updated - update is needed
-		return true, nil
+	log.Printf("Auto-update: %d source(s) due, attempting update...", len(due))
+	// Call UpdateDueSubscriptions synchronously (only due sources are downloaded)
+	err = ac.ConfigService.UpdateDueSubscriptions()
+	if errors.Is(err, errSourcesFailed) {
+		// Every due subscription failed: they back off on their own, the config is unchanged
+		log.Printf("Auto-update: %v", err)
+		return autoUpdateRecheckInterval
 	}
-
-	// Parse last_updated timestamp
-	lastUpdated, err := time.Parse(time.RFC3339, lastUpdatedStr)
 	if err != nil {
-		log.Printf("Auto-update: Failed to parse last_updated '%s': %v", lastUpdatedStr, err)
-		// If parsing fails, assume update is needed
-		return true, nil
+		return ac.handleAutoUpdateFailure(err)
 	}
 
-	// Calculate elapsed time
-	elapsed := time.Since(lastUpdated.UTC())
-	log.Printf("Auto-update: Checking if update needed (last_updated: %s, elapsed: %v, required: %v)", lastUpdatedStr, elapsed, requiredInterval)
-
-	// Check if elapsed >= required interval
-	return elapsed >= requiredInterval, nil
+	// Success - error counter is reset by resumeAutoUpdate
+	log.Println("Auto-update: Completed successfully, error counter reset")
+	return autoUpdateRecheckInterval
 }
 
-// attemptAutoUpdateWithRetries attempts to update configuration with retries
-// Returns true if update succeeded, false if all retries failed
-func (ac *AppController) attemptAutoUpdateWithRetries(retryInterval time.Duration, maxRetries int) bool {
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		log.Printf("Auto-update: Attempting update (attempt %d/%d)", attempt, maxRetries)
+// handleAutoUpdateFailure records a failed auto-update attempt and returns the delay before the next one.
+// Network errors are retried forever with exponential backoff; auth/expired errors stop
+// auto-update immediately; other errors stop it after autoUpdateMaxRetries consecutive failures.
+func (ac *AppController) handleAutoUpdateFailure(err error) time.Duration {
+	kind := ClassifyUpdateError(err)
 
-		// Call UpdateConfigFromSubscriptions synchronously
-		err := ac.ConfigService.UpdateConfigFromSubscriptions()
-		if err == nil {
-			// Success - reset error counter
-			ac.AutoUpdateMutex.Lock()
-			ac.AutoUpdateFailedAttempts = 0
-			ac.AutoUpdateMutex.Unlock()
-			return true
+	ac.AutoUpdateMutex.Lock()
+	ac.AutoUpdateFailedAttempts++
+	failures := ac.AutoUpdateFailedAttempts
+	ac.AutoUpdateLastFailure = kind
+	ac.AutoUpdateLastError = err.Error()
+
+	var notification string
+	switch {
+	case kind == UpdateFailureAuth:
+		ac.AutoUpdateEnabled = false
+		notification = fmt.Sprintf("Subscription server rejected the request:\n%v\n\nThe subscription may have expired. Automatic updates are stopped, check the subscription URL and use manual update.", err)
+	case kind != UpdateFailureNetwork && failures >= autoUpdateMaxRetries:
+		ac.AutoUpdateEnabled = false
+		notification = fmt.Sprintf("Automatic configuration update stopped after %d failed attempts (%s error). Use manual update.", failures, kind)
+	}
+	ac.AutoUpdateMutex.Unlock()
+
+	log.Printf("Auto-update: Failed (%s error, consecutive failures: %d): %v", kind, failures, err)
+
+	if notification != "" {
+		log.Printf("Auto-update: Stopped after %s error", kind)
+		if kind == UpdateFailureAuth {
+			// Requires user action - keep the dialog until it is dismissed
+			ac.Notifier.ShowErrorText("Auto-update stopped", notification)
+		} else {
+			ac.Notifier.ShowAutoHideInfo("Auto-update", notification)
 		}
+		return autoUpdateMinInterval
+	}
 
-		// Error occurred - increment error counter
-		ac.AutoUpdateMutex.Lock()
-		ac.AutoUpdateFailedAttempts++
-		currentAttempts := ac.AutoUpdateFailedAttempts
-		ac.AutoUpdateMutex.Unlock()
+	delay := autoUpdateBackoff(failures, autoUpdateRetryInterval, autoUpdateMaxBackoff)
+	log.Printf("Auto-update: Retrying in %v...", delay)
+	return delay
+}
 
-		log.Printf("Auto-update: Failed (attempt %d/%d, total consecutive failures: %d): %v", attempt, maxRetries, currentAttempts, err)
+// setAutoUpdateNextAttempt stores the time of the next auto-update check and notifies the UI
+func (ac *AppController) setAutoUpdateNextAttempt(next time.Time) {
+	ac.AutoUpdateMutex.Lock()
+	changed := !ac.AutoUpdateNextAttempt.Equal(next)
+	ac.AutoUpdateNextAttempt = next
+	ac.AutoUpdateMutex.Unlock()
 
-		if attempt < maxRetries {
-			// Wait before retry (except for last attempt)
-			log.Printf("Auto-update: Retrying in %v...", retryInterval)
-			select {
-			case <-ac.ctx.Done():
-				return false
-			case <-time.After(retryInterval):
-				// Continue to next attempt
-			}
-		}
+	if changed {
+		ac.publish(EventAutoUpdateStatusChanged)
 	}
+}
 
-	// All retries failed
-	return false
+// GetAutoUpdateStatus returns a snapshot of the auto-update state
+func (ac *AppController) GetAutoUpdateStatus() AutoUpdateStatus {
+	ac.AutoUpdateMutex.Lock()
+	defer ac.AutoUpdateMutex.Unlock()
+	return AutoUpdateStatus{
+		Enabled:        ac.AutoUpdateEnabled,
+		FailedAttempts: ac.AutoUpdateFailedAttempts,
+		NextAttempt:    ac.AutoUpdateNextAttempt,
+		LastFailure:    ac.AutoUpdateLastFailure,
+		LastError:      ac.AutoUpdateLastError,
+		FailedSources:  len(ac.sourceFailures),
+	}
+}
+
+// clampAutoUpdateCheckInterval keeps the delay until the next check within
+// [autoUpdateRecheckInterval, autoUpdateMinInterval], so config changes are picked up regularly
+func clampAutoUpdateCheckInterval(d time.Duration) time.Duration {
+	if d < autoUpdateRecheckInterval {
+		return autoUpdateRecheckInterval
+	}
+	if d > autoUpdateMinInterval {
+		return autoUpdateMinInterval
+	}
+	return d
+}
+
+// maxDuration returns the maximum of two durations
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
 }
 
 // resumeAutoUpdate resumes automatic updates after successful manual update
 // Should be called after successful UpdateConfigFromSubscriptions
 func (ac *AppController) resumeAutoUpdate() {
 	ac.AutoUpdateMutex.Lock()
-	defer ac.AutoUpdateMutex.Unlock()
-
 	ac.AutoUpdateFailedAttempts = 0
+	ac.AutoUpdateLastError = ""
 	if !ac.AutoUpdateEnabled {
 		ac.AutoUpdateEnabled = true
 		log.Println("Auto-update: Resumed after successful manual update")
 	}
+	ac.AutoUpdateMutex.Unlock()
+
+	ac.publish(EventAutoUpdateStatusChanged)
 }
@@ -0,0 +1,161 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"singbox-launcher/api"
+)
+
+// SwitchProfile makes the named profile active: config.json, ParserConfig and subscription
+// cache of the profile are used from now on. If sing-box is running, it is restarted
+// with the new profile's config.
+func (ac *AppController) SwitchProfile(name string) error {
+	ac.ProfileMutex.Lock()
+	defer ac.ProfileMutex.Unlock()
+
+	if err := CheckProfileName(name); err != nil {
+		return err
+	}
+	if name == ac.GetActiveProfile() {
+		return nil
+	}
+	if !ac.Profiles.Exists(name) {
+		return fmt.Errorf("profile '%s' does not exist", name)
+	}
+
+	ac.ParserMutex.Lock()
+	parserRunning := ac.ParserRunning
+	ac.ParserMutex.Unlock()
+	if parserRunning {
+		return fmt.Errorf("configuration update is in progress, try again later")
+	}
+
+	wasRunning := ac.RunningState.IsRunning()
+	if wasRunning {
+		log.Printf("SwitchProfile: Stopping Sing-Box before switching to profile '%s'", name)
+	}
+
+	previous := ac.GetActiveProfile()
+	ac.setActiveProfile(name)
+	if err := ac.Settings.Update(func(s *LauncherSettings) { s.ActiveProfile = name }); err != nil {
+		log.Printf("SwitchProfile: Failed to save active profile: %v", err)
+	}
+	log.Printf("SwitchProfile: Switched from profile '%s' to '%s' (%s)", previous, name, ac.GetConfigPath())
+
+	ac.reloadProfileState()
+
+	if wasRunning {
+		ac.ProcessService.Restart()
+	}
+
+	ac.publish(EventConfigChanged)
+	return nil
+}
+
+// GetConfigPath returns config.json of the active profile (thread-safe)
+func (ac *AppController) GetConfigPath() string {
+	ac.ProfileStateMutex.RLock()
+	defer ac.ProfileStateMutex.RUnlock()
+	return ac.ConfigPath
+}
+
+// GetActiveProfile returns the name of the active profile (thread-safe)
+func (ac *AppController) GetActiveProfile() string {
+	ac.ProfileStateMutex.RLock()
+	defer ac.ProfileStateMutex.RUnlock()
+	return ac.ActiveProfile
+}
+
+// setActiveProfile makes name the active profile and its config.json the current config
+func (ac *AppController) setActiveProfile(name string) {
+	configPath := ac.Profiles.ConfigPath(name)
+	ac.ProfileStateMutex.Lock()
+	ac.ActiveProfile = name
+	ac.ConfigPath = configPath
+	ac.ProfileStateMutex.Unlock()
+}
+
+// reloadProfileState re-reads state derived from config.json after the active profile changes
+func (ac *AppController) reloadProfileState() {
+	ac.ProcessService.reloadClashAPIConfig("SwitchProfile")
+
+	ac.SetProxiesList([]api.ProxyInfo{})
+	ac.SetSelectedIndex(-1)
+	ac.SetActiveProxyName("")
+	ac.publish(EventAPIStateReset)
+
+	// Auto-update state belongs to the previous profile
+	ac.AutoUpdateMutex.Lock()
+	_, statErr := os.Stat(ac.GetConfigPath())
+	ac.AutoUpdateEnabled = statErr == nil
+	ac.AutoUpdateFailedAttempts = 0
+	ac.AutoUpdateLastError = ""
+	ac.sourceFailures = nil
+	ac.AutoUpdateMutex.Unlock()
+	ac.publish(EventAutoUpdateStatusChanged)
+}
+
+// CreateProfile creates an empty profile; its config.json is created by the Config Wizard from the template
+func (ac *AppController) CreateProfile(name string) error {
+	if err := ac.Profiles.Create(name); err != nil {
+		return err
+	}
+	log.Printf("Profiles: Created profile '%s'", name)
+	ac.notifyProfilesChanged()
+	return nil
+}
+
+// CloneProfile copies config.json and subscription cache of source into a new profile
+func (ac *AppController) CloneProfile(source, name string) error {
+	if err := ac.Profiles.Clone(source, name); err != nil {
+		return err
+	}
+	log.Printf("Profiles: Cloned profile '%s' to '%s'", source, name)
+	ac.notifyProfilesChanged()
+	return nil
+}
+
+// RenameProfile renames a profile, keeping it active if it was active
+func (ac *AppController) RenameProfile(oldName, newName string) error {
+	ac.ProfileMutex.Lock()
+	defer ac.ProfileMutex.Unlock()
+
+	if oldName == ac.GetActiveProfile() && ac.RunningState.IsRunning() {
+		return fmt.Errorf("stop VPN before renaming the active profile")
+	}
+	if err := ac.Profiles.Rename(oldName, newName); err != nil {
+		return err
+	}
+	if oldName == ac.GetActiveProfile() {
+		ac.setActiveProfile(newName)
+		if err := ac.Settings.Update(func(s *LauncherSettings) { s.ActiveProfile = newName }); err != nil {
+			log.Printf("Profiles: Failed to save active profile: %v", err)
+		}
+	}
+	log.Printf("Profiles: Renamed profile '%s' to '%s'", oldName, newName)
+	ac.notifyProfilesChanged()
+	return nil
+}
+
+// DeleteProfile deletes a profile. The active profile cannot be deleted.
+func (ac *AppController) DeleteProfile(name string) error {
+	ac.ProfileMutex.Lock()
+	defer ac.ProfileMutex.Unlock()
+
+	if name == ac.GetActiveProfile() {
+		return fmt.Errorf("the active profile cannot be deleted, switch to another profile first")
+	}
+	if err := ac.Profiles.Delete(name); err != nil {
+		return err
+	}
+	log.Printf("Profiles: Deleted profile '%s'", name)
+	ac.notifyProfilesChanged()
+	return nil
+}
+
+// notifyProfilesChanged notifies UI parts that show the profile list
+func (ac *AppController) notifyProfilesChanged() {
+	ac.publish(EventProfilesChanged)
+}
@@ -0,0 +1,192 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"singbox-launcher/internal/constants"
+)
+
+// recordingNotifier records messages instead of showing dialogs
+type recordingNotifier struct {
+	mutex    sync.Mutex
+	messages []string
+}
+
+func (n *recordingNotifier) record(kind, text string) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.messages = append(n.messages, kind+": "+text)
+}
+
+func (n *recordingNotifier) ShowError(err error)                { n.record("error", err.Error()) }
+func (n *recordingNotifier) ShowErrorText(title, msg string)    { n.record("error", title) }
+func (n *recordingNotifier) ShowInfo(title, msg string)         { n.record("info", title) }
+func (n *recordingNotifier) ShowAutoHideInfo(title, msg string) { n.record("autohide", title) }
+func (n *recordingNotifier) ShowConfirm(title, msg, confirmText string, onConfirm func()) {
+	n.record("confirm", title)
+}
+
+func (n *recordingNotifier) Messages() []string {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return append([]string(nil), n.messages...)
+}
+
+// newTestController creates a headless AppController in a temporary directory
+func newTestController(t *testing.T) (*AppController, *recordingNotifier) {
+	t.Helper()
+	ac, err := NewAppControllerInDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewAppControllerInDir failed: %v", err)
+	}
+	// NewAppControllerInDir redirects the log to logs/ of the temporary directory
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		for _, f := range []*RotatingLogFile{ac.MainLogFile, ac.ChildLogFile, ac.ApiLogFile} {
+			if f != nil {
+				f.Close()
+			}
+		}
+	})
+
+	notifier := &recordingNotifier{}
+	ac.Notifier = notifier
+	return ac, notifier
+}
+
+// TestEventBus tests subscription, unsubscription and panic isolation
+func TestEventBus(t *testing.T) {
+	bus := NewEventBus()
+
+	var got []Event
+	unsubscribe := bus.Subscribe(func(e Event) { got = append(got, e) })
+	bus.Subscribe(func(Event) { panic("broken handler") })
+
+	bus.Publish(Event{Type: EventParserProgress, Progress: 50, Message: "half"})
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(got))
+	}
+	if got[0].Type != EventParserProgress || got[0].Progress != 50 || got[0].Message != "half" {
+		t.Errorf("Unexpected event: %+v", got[0])
+	}
+	if got[0].Time.IsZero() {
+		t.Error("Expected event time to be set")
+	}
+
+	unsubscribe()
+	bus.Publish(Event{Type: EventConfigChanged})
+	if len(got) != 1 {
+		t.Errorf("Expected no events after unsubscribe, got %d", len(got))
+	}
+}
+
+// TestProcessLifecycleEvents tests sing-box start/stop without UI using a fake sing-box binary
+func TestProcessLifecycleEvents(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake sing-box is a shell script")
+	}
+
+	ac, notifier := newTestController(t)
+
+	script := "#!/bin/sh\nif [ \"$1\" = run ]; then exec sleep 30; fi\n"
+	if err := os.WriteFile(ac.SingboxPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(ac.ConfigPath, []byte(`{"outbounds": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	events := make(chan EventType, 16)
+	ac.Events.Subscribe(func(e Event) { events <- e.Type })
+
+	waitFor := func(want EventType) {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case got := <-events:
+				if got == want {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("Timeout waiting for event %q", want)
+			}
+		}
+	}
+
+	ac.ProcessService.Start(true)
+	waitFor(EventCoreStatusChanged)
+	if !ac.RunningState.IsRunning() {
+		t.Fatal("Expected sing-box to be running")
+	}
+
+	ac.ProcessService.Stop()
+	waitFor(EventCoreStatusChanged)
+	if ac.RunningState.IsRunning() {
+		t.Error("Expected sing-box to be stopped")
+	}
+
+	if msgs := notifier.Messages(); len(msgs) != 0 {
+		t.Errorf("Expected no user messages, got %v", msgs)
+	}
+}
+
+// TestAutoUpdateFailureNotifies tests that an auth error stops auto-update and is reported to the notifier
+func TestAutoUpdateFailureNotifies(t *testing.T) {
+	ac, notifier := newTestController(t)
+
+	var statusEvents int
+	ac.Events.Subscribe(func(e Event) {
+		if e.Type == EventAutoUpdateStatusChanged {
+			statusEvents++
+		}
+	})
+
+	ac.AutoUpdateEnabled = true
+	authErr := fmt.Errorf("fetch: %w", &SubscriptionHTTPError{StatusCode: 403})
+	ac.handleAutoUpdateFailure(authErr)
+
+	if ac.GetAutoUpdateStatus().Enabled {
+		t.Error("Expected auto-update to be stopped after auth error")
+	}
+	msgs := notifier.Messages()
+	if len(msgs) != 1 || msgs[0] != "error: Auto-update stopped" {
+		t.Errorf("Unexpected notifications: %v", msgs)
+	}
+
+	ac.resumeAutoUpdate()
+	if !ac.GetAutoUpdateStatus().Enabled || statusEvents != 1 {
+		t.Errorf("Expected auto-update resumed with 1 status event, got enabled=%v events=%d",
+			ac.GetAutoUpdateStatus().Enabled, statusEvents)
+	}
+
+	// Network errors are retried silently
+	ac.handleAutoUpdateFailure(errors.New("dial tcp: connection refused"))
+	if len(notifier.Messages()) != 1 {
+		t.Errorf("Expected no new notifications for network error, got %v", notifier.Messages())
+	}
+}
+
+// TestNewAppControllerInDir tests headless initialization paths
+func TestNewAppControllerInDir(t *testing.T) {
+	ac, _ := newTestController(t)
+
+	wantConfig := filepath.Join(ac.ExecDir, constants.BinDirName, constants.ConfigFileName)
+	if ac.ConfigPath != wantConfig {
+		t.Errorf("ConfigPath = %q, want %q", ac.ConfigPath, wantConfig)
+	}
+	if ac.ActiveProfile != DefaultProfileName {
+		t.Errorf("ActiveProfile = %q, want %q", ac.ActiveProfile, DefaultProfileName)
+	}
+	if ac.AutoUpdateEnabled {
+		t.Error("Expected auto-update disabled without config.json")
+	}
+}
@@ -14,9 +14,6 @@ import (
 	"strings"
 	"time"
 
-	"fyne.io/fyne/v2"
-
-	"singbox-launcher/internal/dialogs"
 	"singbox-launcher/internal/platform"
 )
 
@@ -372,7 +369,7 @@ func (ac *AppController) getLatestVersionFromURLWithPrefix(url string, keepPrefi
 // Запускает синхронную проверку версии и отображает диалог с информацией.
 func (ac *AppController) CheckForUpdates() {
 	// Показываем информационное сообщение о начале проверки
-	dialogs.ShowInfo(ac.MainWindow, "Checking for Updates", "Checking for updates...")
+	ac.Notifier.ShowInfo("Checking for Updates", "Checking for updates...")
 
 	// Запускаем проверку версии в фоне
 	go func() {
@@ -386,9 +383,7 @@ func (ac *AppController) CheckForUpdates() {
 		latest, err := ac.GetLatestCoreVersion()
 		if err != nil {
 			log.Printf("CheckForUpdates: Failed to get latest version: %v", err)
-			fyne.Do(func() {
-				dialogs.ShowError(ac.MainWindow, fmt.Errorf("Failed to check for updates: %v", err))
-			})
+			ac.Notifier.ShowError(fmt.Errorf("Failed to check for updates: %v", err))
 			return
 		}
 
@@ -398,9 +393,7 @@ func (ac *AppController) CheckForUpdates() {
 		// Получаем информацию о версиях
 		info := ac.GetCoreVersionInfo()
 		if info.Error != "" {
-			fyne.Do(func() {
-				dialogs.ShowError(ac.MainWindow, fmt.Errorf("Error checking version: %s", info.Error))
-			})
+			ac.Notifier.ShowError(fmt.Errorf("Error checking version: %s", info.Error))
 			return
 		}
 
@@ -409,15 +402,11 @@ func (ac *AppController) CheckForUpdates() {
 		if info.UpdateAvailable {
 			message = fmt.Sprintf("Update available!\n\nInstalled: %s\nLatest: %s\n\nYou can download the update from the Core tab.",
 				info.InstalledVersion, info.LatestVersion)
-			fyne.Do(func() {
-				dialogs.ShowInfo(ac.MainWindow, "Update Available", message)
-			})
+			ac.Notifier.ShowInfo("Update Available", message)
 		} else {
 			message = fmt.Sprintf("You are using the latest version.\n\nInstalled: %s\nLatest: %s",
 				info.InstalledVersion, info.LatestVersion)
-			fyne.Do(func() {
-				dialogs.ShowInfo(ac.MainWindow, "No Updates", message)
-			})
+			ac.Notifier.ShowInfo("No Updates", message)
 		}
 	}()
 }
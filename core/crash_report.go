@@ -0,0 +1,311 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"singbox-launcher/internal/constants"
+	"singbox-launcher/internal/platform"
+)
+
+const (
+	crashOutputLines    = 50   // Lines of core output kept in a crash record
+	crashRecordsKept    = 20   // Crash record files kept in logs/crashes
+	maxOutputLineLength = 4096 // Longer lines are cut (protects memory from output without newlines)
+)
+
+// CrashRecord describes one crash of sing-box
+type CrashRecord struct {
+	Time          time.Time `json:"time"`
+	Profile       string    `json:"profile"`
+	ConfigPath    string    `json:"config_path"`
+	PID           int       `json:"pid"`
+	ExitCode      int       `json:"exit_code"`        // -1 if killed by a signal
+	Signal        string    `json:"signal,omitempty"` // Signal that killed the process (Linux/macOS)
+	UptimeSeconds float64   `json:"uptime_seconds"`
+	Attempt       int       `json:"attempt"`      // Consecutive crash number
+	WillRestart   bool      `json:"will_restart"` // Auto-restart is scheduled
+	FatalLine     string    `json:"fatal_line,omitempty"`
+	FatalKind     string    `json:"fatal_kind,omitempty"` // Known cause, see FatalHint
+	Output        []string  `json:"output"`               // Last lines of core output
+
+	Path string `json:"-"` // File the record was saved to
+}
+
+// ExitDescription returns a short description like "exit code 1" or "signal: killed"
+func (r *CrashRecord) ExitDescription() string {
+	if r.Signal != "" {
+		return "signal: " + r.Signal
+	}
+	return fmt.Sprintf("exit code %d", r.ExitCode)
+}
+
+// newCrashRecord collects crash details from the error returned by cmd.Wait and the process output
+func newCrashRecord(waitErr error, pid int, uptime time.Duration, output *outputTail) *CrashRecord {
+	record := &CrashRecord{
+		Time:          time.Now(),
+		PID:           pid,
+		ExitCode:      -1,
+		UptimeSeconds: uptime.Round(time.Second).Seconds(),
+		Output:        output.Lines(),
+	}
+	if fatal := output.Fatal(); fatal != nil {
+		record.FatalLine = fatal.Raw
+		record.FatalKind = fatal.FatalKind
+	} else {
+		record.FatalLine = extractFatalLine(record.Output)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(waitErr, &exitErr) {
+		record.ExitCode = exitErr.ExitCode()
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			record.Signal = status.Signal().String()
+		}
+	}
+	return record
+}
+
+// ansiEscapePattern matches terminal color codes in core output
+var ansiEscapePattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// extractFatalLine returns the most relevant error line of sing-box output:
+// the last FATAL line, otherwise the first line of a Go panic, otherwise the last ERROR line
+func extractFatalLine(lines []string) string {
+	for _, marker := range []string{"FATAL", "panic:", "ERROR"} {
+		for i := len(lines) - 1; i >= 0; i-- {
+			line := strings.TrimSpace(ansiEscapePattern.ReplaceAllString(lines[i], ""))
+			if strings.Contains(line, marker) {
+				return line
+			}
+		}
+	}
+	return ""
+}
+
+// saveCrashRecord writes the record to logs/crashes and removes the oldest records
+func saveCrashRecord(execDir string, record *CrashRecord) error {
+	dir := filepath.Join(platform.GetLogsDir(execDir), constants.CrashReportsDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	// Crash records are sent with bug reports, so the output is redacted like the logs
+	saved := *record
+	saved.FatalLine = Redact(record.FatalLine)
+	saved.Output = make([]string, len(record.Output))
+	for i, line := range record.Output {
+		saved.Output[i] = Redact(line)
+	}
+	data, err := json.MarshalIndent(&saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("crash_%s_%d.json", record.Time.Format("20060102-150405"), record.PID))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	record.Path = path
+
+	files, _ := filepath.Glob(filepath.Join(dir, "crash_*.json"))
+	sort.Strings(files) // Timestamp in the name sorts chronologically
+	for len(files) > crashRecordsKept {
+		_ = os.Remove(files[0])
+		files = files[1:]
+	}
+	return nil
+}
+
+// lineWriter is an io.Writer that splits process output into lines for onLine
+type lineWriter struct {
+	mutex   sync.Mutex
+	partial []byte
+	onLine  func(line string)
+	split   bool // Pass over-long lines on in pieces of maxOutputLineLength instead of cutting them
+}
+
+func newLineWriter(onLine func(line string)) *lineWriter {
+	return &lineWriter{onLine: onLine}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	data := p
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			w.partial = append(w.partial, data...)
+			if len(w.partial) > maxOutputLineLength {
+				w.emit()
+			}
+			break
+		}
+		w.partial = append(w.partial, data[:i]...)
+		w.emit()
+		data = data[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush passes an unfinished last line to onLine (called after the process exited)
+func (w *lineWriter) Flush() {
+	if w == nil {
+		return
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if len(w.partial) > 0 {
+		w.emit()
+	}
+}
+
+func (w *lineWriter) emit() {
+	line := strings.TrimRight(string(w.partial), "\r")
+	w.partial = nil
+	for w.split && len(line) > maxOutputLineLength {
+		w.onLine(line[:maxOutputLineLength])
+		line = line[maxOutputLineLength:]
+	}
+	if len(line) > maxOutputLineLength {
+		line = line[:maxOutputLineLength]
+	}
+	w.onLine(line)
+}
+
+// outputTail keeps the last lines and the last fatal log entry of a sing-box process
+type outputTail struct {
+	mutex sync.Mutex
+	lines []string
+	max   int
+	fatal *LogEntry
+}
+
+func newOutputTail(max int) *outputTail {
+	return &outputTail{max: max}
+}
+
+// Add appends a line, dropping the oldest one when full
+func (t *outputTail) Add(line string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.lines = append(t.lines, line)
+	if len(t.lines) > t.max {
+		t.lines = append(t.lines[:0], t.lines[len(t.lines)-t.max:]...)
+	}
+}
+
+// Lines returns the kept lines
+func (t *outputTail) Lines() []string {
+	if t == nil {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return append([]string(nil), t.lines...)
+}
+
+// SetFatal remembers the last FATAL/PANIC entry of the process
+func (t *outputTail) SetFatal(entry LogEntry) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.fatal = &entry
+}
+
+// Fatal returns the last FATAL/PANIC entry of the process, or nil
+func (t *outputTail) Fatal() *LogEntry {
+	if t == nil {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.fatal
+}
+
+// PreviousConfigPath returns where the last working version of configPath is kept (see saveWorkingConfig)
+func PreviousConfigPath(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), constants.PreviousConfigFileName)
+}
+
+// saveWorkingConfig keeps content of configPath that sing-box has run successfully as
+// config.prev.json, so that a later config that makes sing-box crash can be rolled back
+// from the crash report
+func saveWorkingConfig(configPath string, content []byte) error {
+	prevPath := PreviousConfigPath(configPath)
+	tmpPath := prevPath + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0644); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, prevPath)
+}
+
+// HasPreviousConfig reports whether config.prev.json exists for the active config
+func (ac *AppController) HasPreviousConfig() bool {
+	_, err := os.Stat(PreviousConfigPath(ac.GetConfigPath()))
+	return err == nil
+}
+
+// RestorePreviousConfigAndRetry swaps config.json with config.prev.json (the failing config
+// becomes the previous one, so the restore can be undone) and starts sing-box again.
+// Auto-update is paused: it would regenerate the failing config from @ParserConfig.
+func (ac *AppController) RestorePreviousConfigAndRetry() error {
+	if ac.RunningState.IsRunning() {
+		return fmt.Errorf("sing-box is running")
+	}
+	prevPath := PreviousConfigPath(ac.GetConfigPath())
+	if _, err := os.Stat(prevPath); err != nil {
+		return fmt.Errorf("no previous config to restore")
+	}
+
+	tmpPath := ac.GetConfigPath() + ".restore"
+	if err := os.Rename(ac.GetConfigPath(), tmpPath); err != nil {
+		return fmt.Errorf("failed to restore previous config: %w", err)
+	}
+	if err := os.Rename(prevPath, ac.GetConfigPath()); err != nil {
+		_ = os.Rename(tmpPath, ac.GetConfigPath())
+		return fmt.Errorf("failed to restore previous config: %w", err)
+	}
+	if err := os.Rename(tmpPath, prevPath); err != nil {
+		log.Printf("CrashReport: Failed to keep the failed config as %s: %v", prevPath, err)
+	}
+	log.Printf("CrashReport: Restored previous config %s", ac.GetConfigPath())
+	ac.pauseAutoUpdateAfterRestore()
+	ac.NotifyConfigChanged()
+
+	ac.CmdMutex.Lock()
+	ac.ConsecutiveCrashAttempts = 0
+	ac.CmdMutex.Unlock()
+	ac.ProcessService.Start()
+	if !ac.RunningState.IsRunning() {
+		return fmt.Errorf("sing-box did not start with the previous config, see logs/%s", constants.ChildLogFileName)
+	}
+	return nil
+}
+
+// pauseAutoUpdateAfterRestore stops auto-update after the previous config was restored, so that
+// the next scheduled update does not overwrite it with the failing config again
+func (ac *AppController) pauseAutoUpdateAfterRestore() {
+	ac.AutoUpdateMutex.Lock()
+	ac.AutoUpdateEnabled = false
+	ac.AutoUpdateLastFailure = UpdateFailureValidation
+	ac.AutoUpdateLastError = "previous config restored after a crash"
+	ac.AutoUpdateMutex.Unlock()
+	ac.publish(EventAutoUpdateStatusChanged)
+
+	log.Println("CrashReport: Auto-update paused after restoring the previous config")
+	ac.Notifier.ShowInfo("Auto-update paused",
+		"The previous config was restored. Automatic updates are paused, because they would generate the failing config again from @ParserConfig.\n\n"+
+			"Fix the subscriptions or settings and use manual update to resume them.")
+}
@@ -0,0 +1,204 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"singbox-launcher/internal/constants"
+)
+
+// TestRestartPolicy tests settings conversion and the backoff curve
+func TestRestartPolicy(t *testing.T) {
+	p := CrashRestartSettings{}.Policy()
+	if p != DefaultRestartPolicy() {
+		t.Errorf("Empty settings: got %+v, want defaults", p)
+	}
+
+	p = CrashRestartSettings{
+		MaxAttempts:        5,
+		InitialDelay:       "1s",
+		MaxDelay:           "10s",
+		Multiplier:         3,
+		StabilityThreshold: "1m",
+	}.Policy()
+	want := []time.Duration{time.Second, 3 * time.Second, 9 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, w := range want {
+		if got := p.Delay(i + 1); got != w {
+			t.Errorf("Delay(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+	if p.MaxAttempts != 5 || p.StabilityThreshold != time.Minute || !p.Enabled {
+		t.Errorf("Unexpected policy %+v", p)
+	}
+
+	// Invalid values fall back to defaults
+	p = CrashRestartSettings{Disabled: true, MaxAttempts: -1, InitialDelay: "soon", Multiplier: 0.5, StabilityThreshold: "1ms"}.Policy()
+	def := DefaultRestartPolicy()
+	if p.Enabled || p.MaxAttempts != def.MaxAttempts || p.InitialDelay != def.InitialDelay ||
+		p.Multiplier != def.Multiplier || p.StabilityThreshold != def.StabilityThreshold {
+		t.Errorf("Invalid settings: got %+v", p)
+	}
+}
+
+// TestLineWriter tests splitting of process output into lines and the bounded tail
+func TestLineWriter(t *testing.T) {
+	tail := newOutputTail(3)
+	writer := newLineWriter(tail.Add)
+	writer.Write([]byte("one\ntwo\r\nthr"))
+	writer.Write([]byte("ee\nfour\nfive"))
+	if got := strings.Join(tail.Lines(), "|"); got != "two|three|four" {
+		t.Errorf("Unexpected lines %q", got)
+	}
+	writer.Flush()
+	if got := strings.Join(tail.Lines(), "|"); got != "three|four|five" {
+		t.Errorf("Unexpected lines after flush %q", got)
+	}
+
+	writer.Write([]byte(strings.Repeat("x", maxOutputLineLength+10)))
+	if lines := tail.Lines(); len(lines[len(lines)-1]) > maxOutputLineLength {
+		t.Error("Long line without newline must be cut")
+	}
+
+	var pieces []string
+	splitter := &lineWriter{onLine: func(line string) { pieces = append(pieces, line) }, split: true}
+	splitter.Write([]byte(strings.Repeat("y", maxOutputLineLength+10) + "\n"))
+	if len(pieces) != 2 || len(pieces[0]) != maxOutputLineLength || pieces[1] != strings.Repeat("y", 10) {
+		t.Errorf("Long line must be split into pieces, got %d", len(pieces))
+	}
+
+	var nilTail *outputTail
+	if nilTail.Lines() != nil || nilTail.Fatal() != nil {
+		t.Error("Expected no data for nil tail")
+	}
+}
+
+// TestExtractFatalLine tests selection of the error line from sing-box output
+func TestExtractFatalLine(t *testing.T) {
+	tests := []struct {
+		lines []string
+		want  string
+	}{
+		{[]string{"INFO[0000] started", "ERROR[0001] dns: timeout", "\x1b[31mFATAL\x1b[0m[0001] start service: bind: address already in use", "INFO exiting"},
+			"FATAL[0001] start service: bind: address already in use"},
+		{[]string{"ERROR[0000] first", "panic: runtime error: nil pointer", "goroutine 1 [running]:"}, "panic: runtime error: nil pointer"},
+		{[]string{"ERROR[0000] first", "ERROR[0001] second"}, "ERROR[0001] second"},
+		{[]string{"INFO ok"}, ""},
+	}
+	for _, tt := range tests {
+		if got := extractFatalLine(tt.lines); got != tt.want {
+			t.Errorf("extractFatalLine(%q) = %q, want %q", tt.lines, got, tt.want)
+		}
+	}
+}
+
+// runCrashingCore starts a fake sing-box that prints fatalLine and exits with code 3,
+// and returns the crash report event published when auto-restart gives up
+func runCrashingCore(t *testing.T, fatalLine string) (*AppController, Event) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake sing-box is a shell script")
+	}
+
+	ac, _ := newTestController(t)
+	script := "#!/bin/sh\nif [ \"$1\" = run ]; then echo 'INFO[0000] starting'; echo '" + fatalLine + "' >&2; exit 3; fi\n"
+	if err := os.WriteFile(ac.SingboxPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(ac.ConfigPath, []byte(`{"outbounds": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ac.Settings.Update(func(s *LauncherSettings) {
+		s.CrashRestart = CrashRestartSettings{MaxAttempts: 1, InitialDelay: "10ms"}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	reports := make(chan Event, 4)
+	ac.Events.Subscribe(func(e Event) {
+		if e.Type == EventCrashReport {
+			reports <- e
+		}
+	})
+
+	ac.ProcessService.Start(true)
+	select {
+	case report := <-reports:
+		return ac, report
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timeout waiting for crash report")
+	}
+	return nil, Event{}
+}
+
+// TestCrashRestartGivesUp tests backoff restarts, crash records and the crash report event
+func TestCrashRestartGivesUp(t *testing.T) {
+	ac, report := runCrashingCore(t, "FATAL[0000] start service: something broke")
+	if report.Message != "FATAL[0000] start service: something broke" {
+		t.Errorf("Unexpected crash report message %q", report.Message)
+	}
+
+	record := ac.ProcessService.LastCrash()
+	if record == nil {
+		t.Fatal("Expected crash record")
+	}
+	if record.ExitCode != 3 || record.Attempt != 2 || record.WillRestart || len(record.Output) != 2 ||
+		record.FatalKind != FatalUnknown {
+		t.Errorf("Unexpected crash record %+v", record)
+	}
+	if fatal := ac.CoreLog.Entries(0, LogLevelFatal, 0); len(fatal) != 2 {
+		t.Errorf("Expected 2 parsed FATAL entries in CoreLog, got %+v", fatal)
+	}
+	files, _ := filepath.Glob(filepath.Join(ac.ExecDir, constants.LogsDirName, constants.CrashReportsDirName, "crash_*.json"))
+	if len(files) != 2 {
+		t.Errorf("Expected 2 crash record files (restart + give up), got %d", len(files))
+	}
+	if ac.RunningState.IsRunning() {
+		t.Error("Expected sing-box to stay stopped")
+	}
+}
+
+// TestCrashNoRestartOnInvalidConfig tests that a crash that would repeat is not restarted
+func TestCrashNoRestartOnInvalidConfig(t *testing.T) {
+	ac, _ := runCrashingCore(t, "FATAL[0000] decode config at config.json: unknown field")
+	record := ac.ProcessService.LastCrash()
+	if record == nil || record.Attempt != 1 || record.WillRestart || record.FatalKind != FatalInvalidConfig {
+		t.Errorf("Unexpected crash record %+v", record)
+	}
+}
+
+// TestRestorePreviousConfig tests the config swap of "restore previous config and retry"
+func TestRestorePreviousConfig(t *testing.T) {
+	ac, _ := newTestController(t)
+	if ac.HasPreviousConfig() {
+		t.Fatal("Expected no previous config")
+	}
+	if err := ac.RestorePreviousConfigAndRetry(); err == nil {
+		t.Error("Expected error without previous config")
+	}
+
+	if err := saveWorkingConfig(ac.ConfigPath, []byte("good")); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(ac.ConfigPath, []byte("bad"), 0644)
+	ac.AutoUpdateEnabled = true
+
+	// sing-box is missing in the test directory, so only the swap succeeds
+	err := ac.RestorePreviousConfigAndRetry()
+	if err == nil {
+		t.Errorf("Expected start error, got %v", err)
+	}
+	if data, _ := os.ReadFile(ac.ConfigPath); string(data) != "good" {
+		t.Errorf("config.json = %q, want restored content", data)
+	}
+	if data, _ := os.ReadFile(PreviousConfigPath(ac.ConfigPath)); string(data) != "bad" {
+		t.Errorf("config.prev.json = %q, want the failed config", data)
+	}
+	// The next scheduled update would regenerate the failing config
+	if status := ac.GetAutoUpdateStatus(); status.Enabled || status.LastError == "" {
+		t.Errorf("Expected auto-update to be paused after restore, got %+v", status)
+	}
+}
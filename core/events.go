@@ -0,0 +1,112 @@
+package core
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// EventType identifies a state change published by core services
+type EventType string
+
+// Events published by AppController and services.
+// The UI subscribes to them instead of being called directly by core.
+const (
+	EventCoreStatusChanged       EventType = "core_status"        // sing-box running state or crash counter changed
+	EventConfigChanged           EventType = "config"             // config.json or the active profile changed
+	EventProfilesChanged         EventType = "profiles"           // profile list changed (created/cloned/renamed/deleted)
+	EventParserProgress          EventType = "parser_progress"    // subscription update progress (Progress, Message)
+	EventAutoUpdateStatusChanged EventType = "auto_update_status" // next auto-update attempt or last failure changed
+	EventProxiesChanged          EventType = "proxies"            // proxies list or active proxy of the selected group changed
+	EventAPIStateReset           EventType = "api_reset"          // Clash API state was reset (sing-box stopped/restarted, profile switched)
+	EventShowWindowRequested     EventType = "show_window"        // another launcher invocation asked to bring the main window to front
+	EventCrashReport             EventType = "crash"              // sing-box crashed and is not restarted (Message; details in ProcessService.LastCrash)
+	EventCoreLog                 EventType = "core_log"           // sing-box printed a log line (Log; also kept in AppController.CoreLog)
+	EventCoreFatal               EventType = "core_fatal"         // sing-box printed a FATAL/PANIC line (Log; Message is a hint for known causes)
+	EventTraffic                 EventType = "traffic"            // a second of traffic from the Clash API stream (Traffic; see TrafficMonitor)
+	EventLatencyTested           EventType = "latency"            // delays of the selected group were tested (Message; see TestGroupLatency)
+	EventClashModeChanged        EventType = "clash_mode"         // routing mode changed (Message is the mode, "" when sing-box stopped)
+)
+
+// Event is a state change notification.
+// Progress and Message are set for EventParserProgress (Progress is -1 on error),
+// Log is set for EventCoreLog and EventCoreFatal, Traffic for EventTraffic.
+type Event struct {
+	Type     EventType      `json:"type"`
+	Time     time.Time      `json:"time"`
+	Progress float64        `json:"progress,omitempty"`
+	Message  string         `json:"message,omitempty"`
+	Log      *LogEntry      `json:"log,omitempty"`
+	Traffic  *TrafficSample `json:"traffic,omitempty"`
+}
+
+// EventBus delivers events to subscribers.
+// Handlers are called synchronously in the publisher's goroutine and must not block
+// (UI handlers switch to the UI thread with fyne.Do).
+type EventBus struct {
+	mutex    sync.RWMutex
+	handlers map[int]func(Event)
+	nextID   int
+}
+
+// NewEventBus creates an empty event bus
+func NewEventBus() *EventBus {
+	return &EventBus{handlers: make(map[int]func(Event))}
+}
+
+// Subscribe registers a handler for all events and returns a function that removes it
+func (b *EventBus) Subscribe(handler func(Event)) (unsubscribe func()) {
+	b.mutex.Lock()
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+	b.mutex.Unlock()
+
+	return func() {
+		b.mutex.Lock()
+		delete(b.handlers, id)
+		b.mutex.Unlock()
+	}
+}
+
+// Publish sends an event to all subscribers. A panicking handler does not affect the others.
+func (b *EventBus) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mutex.RLock()
+	handlers := make([]func(Event), 0, len(b.handlers))
+	for _, handler := range b.handlers {
+		handlers = append(handlers, handler)
+	}
+	b.mutex.RUnlock()
+
+	for _, handler := range handlers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("EventBus: Recovered from panic in '%s' handler: %v", event.Type, r)
+				}
+			}()
+			handler(event)
+		}()
+	}
+}
+
+// publish sends an event of the given type without payload
+func (ac *AppController) publish(eventType EventType) {
+	ac.Events.Publish(Event{Type: eventType})
+}
+
+// NotifyConfigChanged publishes EventConfigChanged after config.json was written
+// outside of core services (Config Wizard, ParserConfig migration)
+func (ac *AppController) NotifyConfigChanged() {
+	ac.publish(EventConfigChanged)
+}
+
+// RequestShowWindow asks the GUI to show and focus the main window
+// (a second launcher invocation forwarded its command line to this instance)
+func (ac *AppController) RequestShowWindow() {
+	ac.publish(EventShowWindowRequested)
+}
@@ -213,10 +213,7 @@ func TestIntegration_ParserConfigFlow(t *testing.T) {
 				Version   int              `json:"version,omitempty"`
 				Proxies   []ProxySource    `json:"proxies"`
 				Outbounds []OutboundConfig `json:"outbounds"`
-				Parser    struct {
-					Reload      string `json:"reload,omitempty"`
-					LastUpdated string `json:"last_updated,omitempty"`
-				} `json:"parser,omitempty"`
+				Parser    ParserSettings   `json:"parser,omitempty"`
 			}{
 				Version: 3,
 				Proxies: []ProxySource{
@@ -0,0 +1,276 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"singbox-launcher/api"
+)
+
+// Default latency test policy
+const (
+	defaultLatencyConcurrency = 8
+	maxLatencyConcurrency     = 64
+	minLatencyInterval        = time.Minute
+	minLatencyTimeout         = 100 * time.Millisecond
+	maxLatencyTimeout         = time.Minute
+	latencyRequestSlack       = 5 * time.Second // Added to the test timeout for the HTTP request to the core
+)
+
+// LatencyTestPolicy describes how delays of proxies are tested
+type LatencyTestPolicy struct {
+	URL         string        // URL requested through the proxy
+	Timeout     time.Duration // Timeout of a single test
+	Concurrency int           // Parallel single proxy tests if the core cannot test a whole group
+	Interval    time.Duration // Background re-test of the selected group (0 = never, the default)
+}
+
+// DefaultLatencyTestPolicy returns the policy used without launcher settings
+func DefaultLatencyTestPolicy() LatencyTestPolicy {
+	return LatencyTestPolicy{
+		URL:         api.DefaultDelayTestURL,
+		Timeout:     api.DefaultDelayTimeout,
+		Concurrency: defaultLatencyConcurrency,
+	}
+}
+
+// Policy converts settings to a LatencyTestPolicy; invalid values are logged and replaced by defaults
+func (s LatencyTestSettings) Policy() LatencyTestPolicy {
+	p := DefaultLatencyTestPolicy()
+
+	if s.URL != "" {
+		if u, err := url.Parse(s.URL); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+			p.URL = s.URL
+		} else {
+			log.Printf("LatencyTest: Invalid latency_test.url %q, using %s", s.URL, p.URL)
+		}
+	}
+	if s.Timeout != "" {
+		d, err := time.ParseDuration(s.Timeout)
+		if err != nil || d < minLatencyTimeout || d > maxLatencyTimeout {
+			log.Printf("LatencyTest: Invalid latency_test.timeout %q, using %v", s.Timeout, p.Timeout)
+		} else {
+			p.Timeout = d
+		}
+	}
+	if s.Concurrency > 0 && s.Concurrency <= maxLatencyConcurrency {
+		p.Concurrency = s.Concurrency
+	} else if s.Concurrency != 0 {
+		log.Printf("LatencyTest: Invalid latency_test.concurrency %d, using %d", s.Concurrency, p.Concurrency)
+	}
+	switch s.Interval {
+	case "":
+	case "0", "off":
+		p.Interval = 0
+	default:
+		d, err := time.ParseDuration(s.Interval)
+		if err != nil || d < minLatencyInterval {
+			log.Printf("LatencyTest: Invalid latency_test.interval %q, using %v", s.Interval, p.Interval)
+		} else {
+			p.Interval = d
+		}
+	}
+	return p
+}
+
+// LatencyTestPolicy returns the latency test policy from launcher settings
+func (ac *AppController) LatencyTestPolicy() LatencyTestPolicy {
+	var settings LatencyTestSettings
+	ac.Settings.Get(func(s *LauncherSettings) { settings = s.LatencyTest })
+	return settings.Policy()
+}
+
+// ProxySortByLatency reports whether the proxy list is sorted by delay instead of name
+func (ac *AppController) ProxySortByLatency() bool {
+	var byLatency bool
+	ac.Settings.Get(func(s *LauncherSettings) { byLatency = s.LatencyTest.SortByLatency })
+	return byLatency
+}
+
+// SetProxySortByLatency saves the sort order of the proxy list and re-sorts ProxiesList
+func (ac *AppController) SetProxySortByLatency(byLatency bool) error {
+	err := ac.Settings.Update(func(s *LauncherSettings) { s.LatencyTest.SortByLatency = byLatency })
+	ac.APIStateMutex.Lock()
+	SortProxies(ac.ProxiesList, byLatency)
+	ac.APIStateMutex.Unlock()
+	return err
+}
+
+// SetProxyDelay updates the delay of a proxy in ProxiesList after a single test; the order is kept
+func (ac *AppController) SetProxyDelay(name string, delay int64) {
+	ac.APIStateMutex.Lock()
+	defer ac.APIStateMutex.Unlock()
+	for i := range ac.ProxiesList {
+		if ac.ProxiesList[i].Name == name {
+			ac.ProxiesList[i].Delay = delay
+		}
+	}
+}
+
+// SortProxies sorts proxies in place by name, or by delay: tested proxies fastest first,
+// then untested ones, then failed ones (each by name)
+func SortProxies(proxies []api.ProxyInfo, byLatency bool) {
+	rank := func(delay int64) int {
+		switch {
+		case delay > 0:
+			return 0
+		case delay == 0:
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(proxies, func(i, j int) bool {
+		a, b := proxies[i], proxies[j]
+		if byLatency {
+			if ra, rb := rank(a.Delay), rank(b.Delay); ra != rb {
+				return ra < rb
+			}
+			if a.Delay > 0 && a.Delay != b.Delay {
+				return a.Delay < b.Delay
+			}
+		}
+		return a.Name < b.Name
+	})
+}
+
+// TestGroupLatency tests every member of a group and returns delays in ms by member
+// (api.DelayFailed for members that did not respond). The whole group is tested by the core
+// (GET /group/{name}/delay); cores without that endpoint get single proxy tests,
+// at most LatencyTestPolicy.Concurrency at a time.
+// If group is the selected group, delays in ProxiesList are updated and EventLatencyTested is published.
+func (ac *AppController) TestGroupLatency(ctx context.Context, group string) (map[string]int64, error) {
+	if !ac.ClashAPIEnabled {
+		return nil, fmt.Errorf("Clash API is disabled in %s", ac.GetConfigPath())
+	}
+	policy := ac.LatencyTestPolicy()
+	client := ac.ClashClient()
+
+	g, err := client.Proxy(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+	if !g.IsGroup() {
+		return nil, fmt.Errorf("'%s' is not a proxy group", group)
+	}
+
+	// The core tests members in parallel, so the request takes about one test timeout
+	reqCtx, cancel := context.WithTimeout(ctx, policy.Timeout+latencyRequestSlack)
+	delays, err := client.GroupDelay(reqCtx, group, policy.URL, policy.Timeout)
+	cancel()
+	if api.IsNotFound(err) {
+		log.Printf("TestGroupLatency: The core cannot test groups, testing %d proxies of '%s' one by one", len(g.All), group)
+		delays, err = testProxiesLatency(ctx, client, g.All, policy)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]int64, len(g.All))
+	reachable := 0
+	for _, name := range g.All {
+		if delay := delays[name]; delay > 0 {
+			results[name] = delay
+			reachable++
+		} else {
+			results[name] = api.DelayFailed
+		}
+	}
+	summary := fmt.Sprintf("%d of %d proxies reachable", reachable, len(g.All))
+	log.Printf("TestGroupLatency: Tested '%s': %s", group, summary)
+	ac.RecordConnectivityCheck(ConnectivityCheck{Kind: "group_delay", Target: group, OK: reachable > 0, Detail: summary})
+
+	byLatency := ac.ProxySortByLatency()
+	ac.APIStateMutex.Lock()
+	selected := group == ac.SelectedClashGroup
+	if selected {
+		for i := range ac.ProxiesList {
+			if delay, ok := results[ac.ProxiesList[i].Name]; ok {
+				ac.ProxiesList[i].Delay = delay
+			}
+		}
+		SortProxies(ac.ProxiesList, byLatency)
+	}
+	ac.APIStateMutex.Unlock()
+	if selected {
+		ac.Events.Publish(Event{
+			Type:    EventLatencyTested,
+			Message: fmt.Sprintf("Tested '%s': %s", group, summary),
+		})
+	}
+	return results, nil
+}
+
+// testProxiesLatency tests proxies one by one, at most policy.Concurrency at a time.
+// Failed tests are left out of the result; only cancellation of ctx is returned as error.
+func testProxiesLatency(ctx context.Context, client *api.Client, proxies []string, policy LatencyTestPolicy) (map[string]int64, error) {
+	var (
+		mutex sync.Mutex
+		wg    sync.WaitGroup
+	)
+	results := make(map[string]int64, len(proxies))
+	slots := make(chan struct{}, max(policy.Concurrency, 1))
+	for _, name := range proxies {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			reqCtx, cancel := context.WithTimeout(ctx, policy.Timeout+latencyRequestSlack)
+			defer cancel()
+			delay, err := client.Delay(reqCtx, name, policy.URL, policy.Timeout)
+			if err != nil {
+				return
+			}
+			mutex.Lock()
+			results[name] = delay
+			mutex.Unlock()
+		}(name)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// runLatencyTestLoop re-tests the selected group every LatencyTestPolicy.Interval
+// while sing-box is running (GUI only). Settings are re-read on every iteration.
+func (ac *AppController) runLatencyTestLoop() {
+	for {
+		interval := ac.LatencyTestPolicy().Interval
+		wait := interval
+		if wait == 0 {
+			wait = minLatencyInterval // Disabled: check the settings again later
+		}
+		select {
+		case <-ac.ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		if interval == 0 || !ac.ClashAPIEnabled || !ac.RunningState.IsRunning() {
+			continue
+		}
+		ac.APIStateMutex.RLock()
+		group := ac.SelectedClashGroup
+		ac.APIStateMutex.RUnlock()
+		if group == "" {
+			continue
+		}
+		if _, err := ac.TestGroupLatency(ac.ctx, group); err != nil {
+			log.Printf("LatencyTest: Background test of '%s' failed: %v", group, err)
+		}
+	}
+}
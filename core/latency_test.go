@@ -0,0 +1,138 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"singbox-launcher/api"
+	"singbox-launcher/api/apitest"
+)
+
+// TestLatencyTestPolicy tests defaults and fallback of invalid settings
+func TestLatencyTestPolicy(t *testing.T) {
+	if p := (LatencyTestSettings{}).Policy(); p != DefaultLatencyTestPolicy() {
+		t.Errorf("Empty settings: %+v", p)
+	}
+
+	p := LatencyTestSettings{URL: "https://cp.cloudflare.com/", Timeout: "2s", Concurrency: 3, Interval: "off"}.Policy()
+	want := LatencyTestPolicy{URL: "https://cp.cloudflare.com/", Timeout: 2 * time.Second, Concurrency: 3}
+	if p != want {
+		t.Errorf("Policy = %+v, want %+v", p, want)
+	}
+
+	p = LatencyTestSettings{URL: "ftp://example.com", Timeout: "1ms", Concurrency: 1000, Interval: "5s"}.Policy()
+	if p != DefaultLatencyTestPolicy() {
+		t.Errorf("Invalid settings must fall back to defaults: %+v", p)
+	}
+
+	// Background re-tests are opt-in
+	if p := DefaultLatencyTestPolicy(); p.Interval != 0 {
+		t.Errorf("Background re-test must be off by default, got %v", p.Interval)
+	}
+	if p := (LatencyTestSettings{Interval: "10m"}).Policy(); p.Interval != 10*time.Minute {
+		t.Errorf("Interval = %v, want 10m", p.Interval)
+	}
+}
+
+// TestSortProxies tests sorting by name and by delay
+func TestSortProxies(t *testing.T) {
+	proxies := []api.ProxyInfo{
+		{Name: "d", Delay: api.DelayFailed},
+		{Name: "c", Delay: 300},
+		{Name: "b"},
+		{Name: "a", Delay: 120},
+		{Name: "e", Delay: 120},
+	}
+	order := func() string {
+		s := ""
+		for _, p := range proxies {
+			s += p.Name
+		}
+		return s
+	}
+	SortProxies(proxies, true)
+	if got := order(); got != "aecbd" {
+		t.Errorf("By latency: %s", got)
+	}
+	SortProxies(proxies, false)
+	if got := order(); got != "abcde" {
+		t.Errorf("By name: %s", got)
+	}
+}
+
+// TestGroupLatency tests a group test through the core endpoint and the concurrent fallback
+func TestGroupLatency(t *testing.T) {
+	ac, _ := newTestController(t)
+	fake := apitest.NewFakeServer("secret")
+	defer fake.Close()
+	var members []string
+	for i := 1; i <= 6; i++ {
+		name := fmt.Sprintf("node %d", i)
+		delay := int64(700 - i*100)
+		if i == 3 {
+			delay = 0 // Times out
+		}
+		fake.AddProxy(name, "VLESS", delay)
+		members = append(members, name)
+	}
+	fake.AddGroup("proxy-out", "Selector", members...)
+	ac.ClashAPIBaseURL, ac.ClashAPIToken, ac.ClashAPIEnabled = fake.URL, fake.Secret, true
+	ac.SelectedClashGroup = "proxy-out"
+	if err := ac.Settings.Update(func(s *LauncherSettings) {
+		s.LatencyTest = LatencyTestSettings{Timeout: "1s", Concurrency: 2, SortByLatency: true}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	proxies, _, err := ac.ClashClient().ProxiesInGroup(context.Background(), "proxy-out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ac.SetProxiesList(proxies)
+
+	var events []Event
+	unsubscribe := ac.Events.Subscribe(func(e Event) {
+		if e.Type == EventLatencyTested {
+			events = append(events, e)
+		}
+	})
+	defer unsubscribe()
+
+	results, err := ac.TestGroupLatency(context.Background(), "proxy-out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 6 || results["node 1"] != 600 || results["node 3"] != api.DelayFailed {
+		t.Errorf("Unexpected results %v", results)
+	}
+	list := ac.GetProxiesList()
+	if list[0].Name != "node 6" || list[0].Delay != 100 || list[5].Name != "node 3" || list[5].Delay != api.DelayFailed {
+		t.Errorf("ProxiesList is not updated and sorted by latency: %+v", list)
+	}
+	if len(events) != 1 || events[0].Message != "Tested 'proxy-out': 5 of 6 proxies reachable" {
+		t.Errorf("Unexpected events %+v", events)
+	}
+	if n := fake.MaxConcurrentDelayTests(); n != 0 {
+		t.Errorf("%d single proxy tests with the group endpoint available", n)
+	}
+
+	// Without the group endpoint proxies are tested one by one, at most 2 at a time
+	fake.DisableGroupDelay()
+	fake.SetDelayTestTime(20 * time.Millisecond)
+	results, err = ac.TestGroupLatency(context.Background(), "proxy-out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 6 || results["node 6"] != 100 || results["node 3"] != api.DelayFailed {
+		t.Errorf("Unexpected fallback results %v", results)
+	}
+	if n := fake.MaxConcurrentDelayTests(); n < 1 || n > 2 {
+		t.Errorf("%d concurrent tests, want at most 2", n)
+	}
+
+	if _, err := ac.TestGroupLatency(context.Background(), "node 1"); err == nil {
+		t.Error("Expected an error for a proxy that is not a group")
+	}
+}
@@ -0,0 +1,122 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"singbox-launcher/internal/constants"
+	"singbox-launcher/internal/platform"
+)
+
+// LauncherSettings holds launcher preferences that are not part of config.json.
+// Stored as bin/launcher_settings.json.
+type LauncherSettings struct {
+	ActiveProfile string               `json:"active_profile,omitempty"` // Name of the active profile (empty = default)
+	ControlAPI    ControlAPISettings   `json:"control_api"`              // Local control API (package control)
+	CrashRestart  CrashRestartSettings `json:"crash_restart"`            // Auto-restart of sing-box after a crash
+	LogRotation   LogRotationSettings  `json:"log_rotation"`             // Rotation of files in logs/
+	LatencyTest   LatencyTestSettings  `json:"latency_test"`             // Delay tests of proxies in the Servers tab
+	ClashMode     string               `json:"clash_mode,omitempty"`     // Routing mode chosen by the user, re-applied after sing-box starts
+
+	FavoriteProxies []string `json:"favorite_proxies,omitempty"` // Proxy tags pinned at the top of the proxy list
+	RecentProxies   []string `json:"recent_proxies,omitempty"`   // Recently switched proxy tags, the latest first
+
+	// DebugUnredactedLogs turns off masking of secrets in logs (see Redact), for debugging only
+	DebugUnredactedLogs bool `json:"debug_unredacted_logs,omitempty"`
+
+	path  string
+	mutex sync.Mutex
+}
+
+// ControlAPISettings configures the local control API of the running launcher.
+// On Linux it listens on a Unix socket in bin/ (protected by file permissions) unless Listen is set;
+// on other platforms it listens on loopback TCP and requires Token.
+type ControlAPISettings struct {
+	Disabled bool   `json:"disabled,omitempty"` // Do not start the control API
+	Listen   string `json:"listen,omitempty"`   // Loopback TCP address (default 127.0.0.1:19090 on non-Linux)
+	Token    string `json:"token,omitempty"`    // Bearer token for TCP, generated on first start
+}
+
+// CrashRestartSettings configures auto-restart of sing-box after a crash (see RestartPolicy).
+// Durations use Go syntax ("2s", "1m30s"); empty or invalid values fall back to defaults.
+type CrashRestartSettings struct {
+	Disabled           bool    `json:"disabled,omitempty"`            // Never restart automatically, only show the crash report
+	MaxAttempts        int     `json:"max_attempts,omitempty"`        // Consecutive restarts before giving up (default 3)
+	InitialDelay       string  `json:"initial_delay,omitempty"`       // Delay before the first restart (default 2s)
+	MaxDelay           string  `json:"max_delay,omitempty"`           // Upper bound of the delay (default 1m)
+	Multiplier         float64 `json:"multiplier,omitempty"`          // Delay growth factor per attempt (default 2)
+	StabilityThreshold string  `json:"stability_threshold,omitempty"` // Uptime after which the crash counter resets (default 3m)
+}
+
+// LogRotationSettings configures rotation of the launcher, sing-box and API logs (see LogRotationPolicy).
+// Zero values mean defaults; changes apply on the next launcher start.
+type LogRotationSettings struct {
+	MaxSizeMB     int    `json:"max_size_mb,omitempty"`     // Rotate a log when it exceeds this size (default 10)
+	MaxAge        string `json:"max_age,omitempty"`         // Rotate a log started this long ago, e.g. "24h" (default 168h, "off" = never)
+	Generations   int    `json:"generations,omitempty"`     // Rotated files kept per log (default 5)
+	NoCompression bool   `json:"no_compression,omitempty"`  // Keep rotated files as plain text instead of gzip
+	TotalBudgetMB int    `json:"total_budget_mb,omitempty"` // Disk budget of logs/, oldest rotated files are removed (default 100)
+}
+
+// LatencyTestSettings configures delay tests of proxies (see LatencyTestPolicy).
+// Durations use Go syntax; empty or invalid values fall back to defaults.
+type LatencyTestSettings struct {
+	URL           string `json:"url,omitempty"`             // Test URL (default http://www.gstatic.com/generate_204)
+	Timeout       string `json:"timeout,omitempty"`         // Timeout of a single test (default 5s)
+	Concurrency   int    `json:"concurrency,omitempty"`     // Parallel tests if the core cannot test a whole group (default 8)
+	Interval      string `json:"interval,omitempty"`        // Background re-test of the selected group (e.g. "10m"; default "off" = never)
+	SortByLatency bool   `json:"sort_by_latency,omitempty"` // Sort the proxy list by delay instead of name
+}
+
+// LoadLauncherSettings reads launcher settings from the bin directory.
+// Missing or invalid file results in default settings.
+func LoadLauncherSettings(execDir string) *LauncherSettings {
+	settings := &LauncherSettings{
+		path: filepath.Join(platform.GetBinDir(execDir), constants.LauncherSettingsFileName),
+	}
+
+	data, err := os.ReadFile(settings.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("LauncherSettings: Failed to read %s: %v", settings.path, err)
+		}
+		return settings
+	}
+	if err := json.Unmarshal(data, settings); err != nil {
+		log.Printf("LauncherSettings: Failed to parse %s: %v, using defaults", settings.path, err)
+	}
+	return settings
+}
+
+// Update applies fn to the settings under lock and saves them to disk
+func (s *LauncherSettings) Update(fn func(s *LauncherSettings)) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	fn(s)
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal launcher settings: %w", err)
+	}
+	// The file holds the control API token: only the owner may read it
+	// (files written by older versions with 0644 are restricted before the write)
+	if err := os.Chmod(s.path, 0600); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to restrict launcher settings permissions: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write launcher settings: %w", err)
+	}
+	return nil
+}
+
+// Get calls fn with the settings under lock (for consistent reads)
+func (s *LauncherSettings) Get(fn func(s *LauncherSettings)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	fn(s)
+}
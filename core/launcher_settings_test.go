@@ -0,0 +1,34 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// TestLauncherSettingsPermissions tests that launcher_settings.json (it holds the control API token)
+// is readable by the owner only, also if an older version created it with 0644
+func TestLauncherSettingsPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permissions")
+	}
+	settings := LoadLauncherSettings(t.TempDir())
+	if err := os.MkdirAll(filepath.Dir(settings.path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(settings.path, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := settings.Update(func(s *LauncherSettings) { s.ControlAPI.Token = "secret-token" }); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	info, err := os.Stat(settings.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("launcher_settings.json permissions %v, want 0600", info.Mode().Perm())
+	}
+}
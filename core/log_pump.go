@@ -0,0 +1,69 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"singbox-launcher/api"
+	"singbox-launcher/internal/platform"
+)
+
+// LogPumpCommand is the hidden CLI command that runs RunLogPump (see startLogPump)
+const LogPumpCommand = "log-pump"
+
+// startLogPump starts a detached launcher process that writes the output of a detached sing-box
+// to the sing-box log, redacted and rotated like the output of a sing-box started by the GUI.
+// Returns the write end of its input pipe for sing-box stdout and stderr; the pump exits
+// when the last writer closes it (sing-box exits).
+func (svc *ProcessService) startLogPump() (*os.File, error) {
+	ac := svc.ac
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine executable path: %w", err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close() // The pump has its own copy
+
+	cmd := exec.Command(exe, LogPumpCommand, ac.GetConfigPath())
+	platform.PrepareDetachedCommand(cmd)
+	cmd.Dir = ac.ExecDir
+	cmd.Stdin = r
+	if err := cmd.Start(); err != nil {
+		w.Close()
+		return nil, err
+	}
+	_ = cmd.Process.Release()
+	return w, nil
+}
+
+// RunLogPump copies the output of a detached sing-box from r to the sing-box log of the launcher
+// directory execDir until r is closed. Redaction follows the launcher settings, and the Clash API
+// secret of configPath is masked too. The pump is the only writer of the log while it runs,
+// so it rotates the log itself; it writes nothing to the launcher log (see OpenSharedLogFile).
+func RunLogPump(execDir, configPath string, r io.Reader) error {
+	settings := LoadLauncherSettings(execDir)
+	var unredacted bool
+	var rotation LogRotationSettings
+	settings.Get(func(s *LauncherSettings) {
+		unredacted = s.DebugUnredactedLogs
+		rotation = s.LogRotation
+	})
+	SetLogRedaction(!unredacted)
+	if _, tok, err := api.LoadClashAPIConfig(configPath); err == nil {
+		RegisterSecret(tok)
+	}
+
+	f, err := OpenRotatingLogFile(filepath.Join(execDir, childLogFileName), rotation.Policy())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, r)
+	return err
+}
@@ -0,0 +1,42 @@
+package core
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRunLogPumpRedactsDetachedOutput tests that output of a detached sing-box is redacted like
+// the output of a sing-box started by the GUI, including the Clash API secret and split lines
+func TestRunLogPumpRedactsDetachedOutput(t *testing.T) {
+	execDir := t.TempDir()
+	configPath := filepath.Join(execDir, "config.json")
+	os.WriteFile(configPath, []byte(`{"experimental": {"clash_api": {"external_controller": "127.0.0.1:9090", "secret": "pump-clash-secret"}}}`), 0644)
+	if err := os.MkdirAll(filepath.Join(execDir, "logs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	r, w := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- RunLogPump(execDir, configPath, r) }()
+	io.WriteString(w, "ERROR outbound/vless: user 4a3ece53-6000-4ba3-a9fa-")
+	io.WriteString(w, "fd0d7ba61cf3 rejected\nFATAL clash api secret pump-clash-secret\n")
+	w.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("RunLogPump failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(execDir, childLogFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := string(data)
+	if strings.Contains(log, "fd0d7ba61cf3") || strings.Contains(log, "pump-clash-secret") {
+		t.Errorf("Secrets must be masked in the sing-box log:\n%s", log)
+	}
+	if !strings.Contains(log, "user *** rejected") {
+		t.Errorf("Expected the line split between writes to be redacted as a whole:\n%s", log)
+	}
+}
@@ -0,0 +1,325 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"singbox-launcher/internal/constants"
+	"singbox-launcher/internal/platform"
+)
+
+const (
+	logReadChunk     = 64 * 1024       // Block size for reading log files backwards
+	maxLogPollBytes  = 4 * 1024 * 1024 // Larger appends are skipped to their end (the tail is what matters)
+	maxLogViewerLine = 16 * 1024       // Longer lines of rotated logs are cut
+)
+
+// LogSource is a log file shown in the Logs tab
+type LogSource string
+
+const (
+	LogSourceLauncher LogSource = "launcher"
+	LogSourceSingbox  LogSource = "sing-box"
+	LogSourceAPI      LogSource = "api"
+)
+
+// LogSources lists the log sources in display order
+var LogSources = []LogSource{LogSourceLauncher, LogSourceSingbox, LogSourceAPI}
+
+// LogSourcePath returns the current log file of a source
+func LogSourcePath(execDir string, source LogSource) string {
+	name := constants.MainLogFileName
+	switch source {
+	case LogSourceSingbox:
+		name = constants.ChildLogFileName
+	case LogSourceAPI:
+		name = constants.APILogFileName
+	}
+	return filepath.Join(platform.GetLogsDir(execDir), name)
+}
+
+// LogFiles returns the existing files of a log, oldest first: rotated generations
+// (name.log.N[.gz] ... name.log.1[.gz]), then the current file
+func LogFiles(logPath string) []string {
+	var files []string
+	if _, err := os.Stat(logPath); err == nil {
+		files = append(files, logPath)
+	}
+	rotated := &RotatingLogFile{path: logPath}
+	for n := 1; n <= maxLogGenerations; n++ {
+		path := rotated.existingGeneration(n)
+		if _, err := os.Stat(path); err != nil {
+			break
+		}
+		files = append([]string{path}, files...)
+	}
+	return files
+}
+
+var (
+	// launcherLogPrefix is the timestamp of the standard logger: "2006/01/02 15:04:05 "
+	launcherLogPrefix = regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}) `)
+	// apiLogPrefix is the timestamp of api.log: "[2006-01-02 15:04:05] "
+	apiLogPrefix = regexp.MustCompile(`^\[(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})\] `)
+	// launcherComponent is the "Component: " prefix of launcher log messages
+	launcherComponent = regexp.MustCompile(`^([\w.]+): `)
+)
+
+// ParseLogLine parses a line of a log file. sing-box lines are parsed with ParseSingboxLogLine;
+// launcher and API lines have no level, it is guessed from the message.
+func ParseLogLine(source LogSource, line string) LogEntry {
+	if source == LogSourceSingbox {
+		return ParseSingboxLogLine(line, time.Time{})
+	}
+
+	prefix, layout := launcherLogPrefix, "2006/01/02 15:04:05"
+	if source == LogSourceAPI {
+		prefix, layout = apiLogPrefix, "2006-01-02 15:04:05"
+	}
+	entry := LogEntry{Message: line, Raw: line}
+	if m := prefix.FindStringSubmatch(line); m != nil {
+		entry.Time, _ = time.ParseInLocation(layout, m[1], time.Local)
+		entry.Message = line[len(m[0]):]
+	}
+	if m := launcherComponent.FindStringSubmatch(entry.Message); m != nil {
+		entry.Component = m[1]
+	}
+	entry.Level = guessLogLevel(entry.Message)
+	return entry
+}
+
+// guessLogLevel derives a level from the words of a launcher log message
+func guessLogLevel(message string) LogLevel {
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "panic"):
+		return LogLevelPanic
+	case strings.Contains(lower, "error") || strings.Contains(lower, "failed") || strings.Contains(lower, "crashed"):
+		return LogLevelError
+	case strings.Contains(lower, "warning") || strings.Contains(lower, "warn:"):
+		return LogLevelWarn
+	}
+	return LogLevelInfo
+}
+
+// LogFilter selects log entries in the Logs tab
+type LogFilter struct {
+	MinLevel  LogLevel       // "" = all levels
+	Component string         // Case-insensitive substring of the component, "" = all
+	Search    *regexp.Regexp // Matched against the raw line, nil = all
+}
+
+// Match reports whether the entry passes the filter
+func (f LogFilter) Match(entry LogEntry) bool {
+	if f.MinLevel != "" && entry.Level.Rank() < f.MinLevel.Rank() {
+		return false
+	}
+	if f.Component != "" && !strings.Contains(strings.ToLower(entry.Component), strings.ToLower(f.Component)) {
+		return false
+	}
+	return f.Search == nil || f.Search.MatchString(entry.Raw)
+}
+
+// LogTail reads the end of a log file and then follows it. Only complete lines are returned;
+// replacing the file (rotation) or truncating it restarts reading at the beginning of the new file.
+type LogTail struct {
+	path     string
+	maxLines int
+	info     os.FileInfo // Current file at the last read
+	offset   int64       // Position after the last complete line read
+}
+
+// NewLogTail creates a tail of the log at path that loads up to maxLines lines
+func NewLogTail(path string, maxLines int) *LogTail {
+	return &LogTail{path: path, maxLines: maxLines}
+}
+
+// Load returns the last maxLines lines of the log, continuing into the rotated file
+// if the current one is shorter, and positions Poll after them
+func (t *LogTail) Load() ([]string, error) {
+	t.info, t.offset = nil, 0
+	files := LogFiles(t.path)
+	var lines []string
+	for i := len(files) - 1; i >= 0 && len(lines) < t.maxLines; i-- {
+		var fileLines []string
+		var end int64
+		var info os.FileInfo
+		var err error
+		if strings.HasSuffix(files[i], ".gz") {
+			fileLines, err = readLastLinesGzip(files[i], t.maxLines-len(lines))
+		} else {
+			fileLines, end, info, err = readLastLines(files[i], t.maxLines-len(lines))
+		}
+		if err != nil {
+			return nil, err
+		}
+		if files[i] == t.path {
+			t.info, t.offset = info, end
+		}
+		lines = append(fileLines, lines...)
+	}
+	return lines, nil
+}
+
+// Poll returns complete lines appended since the last Load or Poll
+func (t *LogTail) Poll() ([]string, error) {
+	info, err := os.Stat(t.path)
+	if os.IsNotExist(err) {
+		return nil, nil // Rotated right now, the new file appears on the next write
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t.info == nil || !os.SameFile(t.info, info) || info.Size() < t.offset {
+		t.offset = 0
+	}
+	t.info = info
+	if info.Size() == t.offset {
+		return nil, nil
+	}
+
+	f, err := os.Open(t.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	start := t.offset
+	skipped := info.Size()-start > maxLogPollBytes
+	if skipped {
+		start = info.Size() - maxLogPollBytes
+	}
+	data := make([]byte, info.Size()-start)
+	n, err := f.ReadAt(data, start)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("read %s: %w", t.path, err)
+	}
+	data = data[:n]
+	last := bytes.LastIndexByte(data, '\n')
+	if last < 0 {
+		t.offset = start // Wait for the end of the line
+		return nil, nil
+	}
+	t.offset = start + int64(last) + 1
+	lines := splitLogLines(data[:last])
+	if skipped {
+		lines = lines[1:] // The first line is cut by the skip
+	}
+	if len(lines) > t.maxLines {
+		lines = lines[len(lines)-t.maxLines:]
+	}
+	return lines, nil
+}
+
+// readLastLines reads up to n complete lines from the end of a file, reading backwards in chunks.
+// It returns the lines, the position after the last complete line and the file info.
+func readLastLines(path string, n int) ([]string, int64, os.FileInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	size := info.Size()
+	var data []byte
+	pos := size
+	for pos > 0 && bytes.Count(data, []byte{'\n'}) <= n {
+		chunk := int64(logReadChunk)
+		if chunk > pos {
+			chunk = pos
+		}
+		pos -= chunk
+		buf := make([]byte, chunk)
+		if _, err := f.ReadAt(buf, pos); err != nil && err != io.EOF {
+			return nil, 0, nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		data = append(buf, data...)
+	}
+
+	// Keep complete lines only: drop the unfinished last line and, if the start of the file
+	// was not reached, the first line that may be cut
+	last := bytes.LastIndexByte(data, '\n')
+	if last < 0 {
+		return nil, 0, info, nil
+	}
+	end := pos + int64(last) + 1
+	data = data[:last]
+	lines := splitLogLines(data)
+	if pos > 0 && len(lines) > 0 {
+		lines = lines[1:]
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, end, info, nil
+}
+
+// readLastLinesGzip returns up to n last lines of a gzip-compressed rotated log
+func readLastLinesGzip(path string, n int) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	defer zr.Close()
+
+	// Ring of the last n lines; lines longer than maxLogViewerLine are cut instead of failing the read
+	ring := make([]string, n)
+	count := 0
+	reader := bufio.NewReaderSize(zr, logReadChunk)
+	var line []byte
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if room := maxLogViewerLine - len(line); room > 0 {
+			line = append(line, chunk[:min(len(chunk), room)]...)
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		if err == nil || len(line) > 0 {
+			ring[count%n] = strings.TrimRight(string(line), "\r\n")
+			count++
+		}
+		if err != nil {
+			break
+		}
+		line = line[:0]
+	}
+	if count <= n {
+		return ring[:count], nil
+	}
+	start := count % n
+	return append(ring[start:], ring[:start]...), nil
+}
+
+// splitLogLines splits log data into lines without trailing \r
+func splitLogLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, "\r")
+	}
+	return lines
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// appendLog appends text to a log file
+func appendLog(t *testing.T, path, text string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestLogTail tests loading the end of a rotated log and following appends, rotation and truncation
+func TestLogTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	appendLog(t, path+".2", "older1\nolder2\n")
+	if err := gzipFile(path+".2", path+".2.gz"); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(path + ".2")
+	appendLog(t, path+".1", "old1\nold2\n")
+	appendLog(t, path, "new1\r\nnew2\npartial")
+
+	if got := LogFiles(path); len(got) != 3 || got[0] != path+".2.gz" || got[2] != path {
+		t.Errorf("LogFiles = %q", got)
+	}
+	tail := NewLogTail(path, 5)
+	lines, err := tail.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(lines, "|"); got != "older2|old1|old2|new1|new2" {
+		t.Errorf("Load = %q", got)
+	}
+
+	appendLog(t, path, " line\nnew3\n")
+	lines, _ = tail.Poll()
+	if got := strings.Join(lines, "|"); got != "partial line|new3" {
+		t.Errorf("Poll after append = %q", got)
+	}
+	if lines, _ = tail.Poll(); len(lines) != 0 {
+		t.Errorf("Poll without changes = %q", lines)
+	}
+
+	// Rotation: the file is renamed and a new one is created
+	os.Remove(path + ".1")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendLog(t, path, "rotated1\nrotated2\nrotated3\n")
+	lines, _ = tail.Poll()
+	if got := strings.Join(lines, "|"); got != "rotated1|rotated2|rotated3" {
+		t.Errorf("Poll after rotation = %q", got)
+	}
+
+	// Truncation
+	if err := os.WriteFile(path, []byte("t\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lines, _ = tail.Poll()
+	if got := strings.Join(lines, "|"); got != "t" {
+		t.Errorf("Poll after truncation = %q", got)
+	}
+}
+
+// TestLogTailLargeFile tests reading the last lines of a file larger than a read chunk
+func TestLogTailLargeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "large.log")
+	var b strings.Builder
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	appendLog(t, path, b.String())
+
+	lines, err := NewLogTail(path, 3).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(lines, "|"); got != "line 19997|line 19998|line 19999" {
+		t.Errorf("Load = %q", got)
+	}
+}
+
+// TestLogTailLongLineInGzip tests that an over-long line of a compressed generation is cut
+// instead of failing the whole Load
+func TestLogTailLongLineInGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	long := strings.Repeat("x", maxLogViewerLine*3)
+	appendLog(t, path+".1", "before\n"+long+"\nafter\n")
+	if err := gzipFile(path+".1", path+".1.gz"); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(path + ".1")
+	appendLog(t, path, "current\n")
+
+	lines, err := NewLogTail(path, 10).Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(lines) != 4 || lines[0] != "before" || lines[2] != "after" || lines[3] != "current" {
+		t.Fatalf("Unexpected lines: %d", len(lines))
+	}
+	if len(lines[1]) != maxLogViewerLine {
+		t.Errorf("Long line has %d bytes, want %d", len(lines[1]), maxLogViewerLine)
+	}
+}
+
+// TestParseLogLineAndFilter tests launcher/API line parsing and filters
+func TestParseLogLineAndFilter(t *testing.T) {
+	entry := ParseLogLine(LogSourceLauncher, "2025/01/15 12:00:00 monitorSingBox: Failed to save crash record: disk full")
+	if entry.Component != "monitorSingBox" || entry.Level != LogLevelError || entry.Time.IsZero() ||
+		entry.Message != "monitorSingBox: Failed to save crash record: disk full" {
+		t.Errorf("Unexpected launcher entry %+v", entry)
+	}
+	entry = ParseLogLine(LogSourceAPI, "[2025-01-15 12:00:00] GetProxiesInGroup: Response status 200")
+	if entry.Component != "GetProxiesInGroup" || entry.Level != LogLevelInfo || entry.Time.IsZero() {
+		t.Errorf("Unexpected API entry %+v", entry)
+	}
+	entry = ParseLogLine(LogSourceSingbox, "WARN[0001] dns: slow")
+	if entry.Component != "dns" || entry.Level != LogLevelWarn {
+		t.Errorf("Unexpected sing-box entry %+v", entry)
+	}
+
+	filter := LogFilter{MinLevel: LogLevelWarn, Component: "DN", Search: regexp.MustCompile(`sl.w`)}
+	if !filter.Match(entry) {
+		t.Error("Expected the filter to match")
+	}
+	for _, f := range []LogFilter{
+		{MinLevel: LogLevelError},
+		{Component: "router"},
+		{Search: regexp.MustCompile(`fast`)},
+	} {
+		if f.Match(entry) {
+			t.Errorf("Filter %+v must not match", f)
+		}
+	}
+}
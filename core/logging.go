@@ -3,40 +3,435 @@
 package core
 
 import (
+	"compress/gzip"
+	"fmt"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
 )
 
+// Default log rotation policy
 const (
-	// maxLogFileSize is the maximum log file size before rotation (10 MB)
-	maxLogFileSize = 10 * 1024 * 1024
+	defaultLogMaxSize     = 10 * 1024 * 1024 // 10 MB
+	defaultLogMaxAge      = 7 * 24 * time.Hour
+	defaultLogGenerations = 5
+	defaultLogBudget      = 100 * 1024 * 1024 // 100 MB for the whole logs directory
+	maxLogGenerations     = 50
+	minLogMaxAge          = time.Minute
+	rotationRetryDelay    = time.Minute // After a failed rotation (e.g. the file is held by a detached sing-box on Windows)
 )
 
-// checkAndRotateLogFile checks log file size and rotates if it exceeds maxLogFileSize.
-// Rotates by renaming current file to .old and removing old backup if exists.
-func checkAndRotateLogFile(logPath string) {
-	info, err := os.Stat(logPath)
-	if err != nil {
-		return // File doesn't exist yet, nothing to rotate
+// LogRotationPolicy decides when log files are rotated and how many rotated files are kept
+type LogRotationPolicy struct {
+	MaxSize     int64         // Rotate when a write would exceed this size
+	MaxAge      time.Duration // Rotate when the file was started this long ago (0 = never)
+	Generations int           // Rotated files kept per log: name.log.1.gz (newest) ... name.log.N.gz
+	Compress    bool          // Gzip rotated files
+	TotalBudget int64         // Rotated files are removed, oldest first, while the logs directory is larger
+}
+
+// DefaultLogRotationPolicy returns the policy used without launcher settings
+func DefaultLogRotationPolicy() LogRotationPolicy {
+	return LogRotationPolicy{
+		MaxSize:     defaultLogMaxSize,
+		MaxAge:      defaultLogMaxAge,
+		Generations: defaultLogGenerations,
+		Compress:    true,
+		TotalBudget: defaultLogBudget,
 	}
+}
 
-	if info.Size() > maxLogFileSize {
-		// Rotate: rename current file to .old
-		oldPath := logPath + ".old"
-		_ = os.Remove(oldPath) // Remove old backup if exists
-		if err := os.Rename(logPath, oldPath); err != nil {
-			log.Printf("checkAndRotateLogFile: Failed to rotate log file %s: %v", logPath, err)
+// Policy converts settings to a LogRotationPolicy; invalid values are logged and replaced by defaults
+func (s LogRotationSettings) Policy() LogRotationPolicy {
+	p := DefaultLogRotationPolicy()
+	p.Compress = !s.NoCompression
+
+	if s.MaxSizeMB > 0 {
+		p.MaxSize = int64(s.MaxSizeMB) * 1024 * 1024
+	} else if s.MaxSizeMB != 0 {
+		log.Printf("LogRotation: Invalid log_rotation.max_size_mb %d, using %d", s.MaxSizeMB, p.MaxSize/1024/1024)
+	}
+	if s.Generations > 0 && s.Generations <= maxLogGenerations {
+		p.Generations = s.Generations
+	} else if s.Generations != 0 {
+		log.Printf("LogRotation: Invalid log_rotation.generations %d, using %d", s.Generations, p.Generations)
+	}
+	if s.TotalBudgetMB > 0 {
+		p.TotalBudget = int64(s.TotalBudgetMB) * 1024 * 1024
+	} else if s.TotalBudgetMB != 0 {
+		log.Printf("LogRotation: Invalid log_rotation.total_budget_mb %d, using %d", s.TotalBudgetMB, p.TotalBudget/1024/1024)
+	}
+	switch s.MaxAge {
+	case "":
+	case "0", "off":
+		p.MaxAge = 0
+	default:
+		d, err := time.ParseDuration(s.MaxAge)
+		if err != nil || d < minLogMaxAge {
+			log.Printf("LogRotation: Invalid log_rotation.max_age %q, using %v", s.MaxAge, p.MaxAge)
 		} else {
-			log.Printf("checkAndRotateLogFile: Rotated log file %s (size: %d bytes)", logPath, info.Size())
+			p.MaxAge = d
+		}
+	}
+	return p
+}
+
+// RotatingLogFile is a log file that rotates itself on write when it grows over the size limit
+// or gets too old. Rotated files are shifted (name.log.1.gz -> name.log.2.gz ...), the oldest
+// generation is removed, and the logs directory is kept within the disk budget.
+// Methods are safe for concurrent use and for a nil receiver (writes are discarded).
+type RotatingLogFile struct {
+	mutex       sync.Mutex
+	compressing sync.WaitGroup // Background compression of generation 1, see compressGeneration
+	path        string
+	policy      LogRotationPolicy
+	file        *os.File
+	size        int64
+	startedAt   time.Time // When the current file was started (previous rotation)
+	retryAfter  time.Time
+	rotateError error
+	lines       *lineWriter // Collects complete lines for redaction, see Write
+	writeError  error       // Error of the last line written by Write
+	inUse       func() bool // Reports whether another process writes to the file, see OpenSharedLogFile
+}
+
+// OpenRotatingLogFile opens a log file for appending. A file over the limits, or a backup
+// of the previous single-file rotation (name.log.old), is rotated first.
+func OpenRotatingLogFile(path string, policy LogRotationPolicy) (*RotatingLogFile, error) {
+	return OpenSharedLogFile(path, policy, nil)
+}
+
+// OpenSharedLogFile opens a log file that another process may write to directly (detached sing-box).
+// While inUse reports true the file is not rotated: the other process would keep writing to the
+// renamed and then removed file, its output would be lost and the disk space never freed.
+func OpenSharedLogFile(path string, policy LogRotationPolicy, inUse func() bool) (*RotatingLogFile, error) {
+	f := &RotatingLogFile{path: path, policy: policy, inUse: inUse}
+	f.lines = &lineWriter{onLine: f.writeLine, split: true}
+	if policy.Generations < 1 {
+		f.policy.Generations = 1
+	}
+
+	if _, err := os.Stat(path + ".old"); err == nil {
+		if err := f.shiftGenerations(); err == nil {
+			if err := f.storeGeneration(path+".old", time.Now()); err != nil {
+				log.Printf("RotatingLogFile: Failed to convert %s.old: %v", path, err)
+			}
 		}
 	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	if f.needsRotation(0) {
+		f.rotate()
+	}
+	enforceLogBudget(filepath.Dir(path), policy.TotalBudget)
+	return f, nil
+}
+
+// open opens the current file and restores its size and start time
+func (f *RotatingLogFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	// The newest generation was written when the current file was started
+	f.startedAt = time.Now()
+	if f.size > 0 {
+		if gen, err := os.Stat(f.existingGeneration(1)); err == nil {
+			f.startedAt = gen.ModTime()
+		}
+	}
+	return nil
+}
+
+// Write appends p to the log with secrets masked (see Redact). Output is redacted by complete lines,
+// so a secret split between writes (pipe chunks) is masked too; an unfinished line is kept until
+// its end arrives or the file is closed.
+func (f *RotatingLogFile) Write(p []byte) (int, error) {
+	if f == nil {
+		return len(p), nil
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	f.writeError = nil
+	_, _ = f.lines.Write(p) // Calls writeLine for every complete line
+	if f.writeError != nil {
+		return 0, f.writeError
+	}
+	return len(p), nil
+}
+
+// writeLine appends a redacted line to the file, rotating first if needed; f.mutex must be held
+func (f *RotatingLogFile) writeLine(line string) {
+	if f.file == nil {
+		return
+	}
+	data := []byte(Redact(line) + "\n")
+	if f.needsRotation(len(data)) {
+		f.rotate()
+		if f.file == nil {
+			f.writeError = f.rotateError
+			return
+		}
+	}
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+	if err != nil {
+		f.writeError = err
+	}
+}
+
+// RotateIfNeeded rotates the log if it is over the limits (before handing File to another process)
+func (f *RotatingLogFile) RotateIfNeeded() {
+	if f == nil {
+		return
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.file != nil && f.needsRotation(0) {
+		f.rotate()
+	}
+}
+
+// File returns the current file, for a process that must write to it directly
+// (detached sing-box). Such writes are not counted until the next rotation check on open.
+func (f *RotatingLogFile) File() *os.File {
+	if f == nil {
+		return nil
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.file
+}
+
+// Path returns the path of the current file
+func (f *RotatingLogFile) Path() string {
+	if f == nil {
+		return ""
+	}
+	return f.path
+}
+
+// Close closes the current file and waits for background compression of the rotated one
+func (f *RotatingLogFile) Close() error {
+	if f == nil {
+		return nil
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.compressing.Wait()
+	if f.file == nil {
+		return nil
+	}
+	f.lines.Flush()
+	err := f.file.Close()
+	f.file = nil
+	return err
 }
 
-// openLogFileWithRotation opens a log file and rotates it if it exceeds maxLogFileSize.
-func openLogFileWithRotation(logPath string) (*os.File, error) {
-	checkAndRotateLogFile(logPath)
+func (f *RotatingLogFile) needsRotation(writeSize int) bool {
+	if f.size == 0 || time.Now().Before(f.retryAfter) {
+		return false
+	}
+	if f.size+int64(writeSize) <= f.policy.MaxSize && (f.policy.MaxAge <= 0 || time.Since(f.startedAt) <= f.policy.MaxAge) {
+		return false
+	}
+	if f.inUse != nil && f.inUse() {
+		f.retryAfter = time.Now().Add(rotationRetryDelay)
+		return false
+	}
+	return true
+}
+
+// rotate moves the current file to generation 1 and starts a new file. On failure the
+// current file is kept and rotation is retried after rotationRetryDelay.
+// It must not use the standard logger: the launcher log itself is a RotatingLogFile.
+func (f *RotatingLogFile) rotate() {
+	rotatedAt := time.Now()
+	_ = f.file.Close() // Reopened below in any case
+	err := f.shiftGenerations()
+	if err == nil {
+		err = f.storeGeneration(f.path, rotatedAt)
+	}
+	if openErr := f.open(); openErr != nil {
+		// Nowhere to write; keep the error for the next writes
+		f.file = nil
+		f.rotateFailed(openErr)
+		return
+	}
+	if err != nil {
+		f.rotateFailed(err)
+		return
+	}
+	f.startedAt = rotatedAt
+	f.rotateError = nil
+	enforceLogBudget(filepath.Dir(f.path), f.policy.TotalBudget)
+}
+
+func (f *RotatingLogFile) rotateFailed(err error) {
+	f.retryAfter = time.Now().Add(rotationRetryDelay)
+	if f.rotateError == nil || f.rotateError.Error() != err.Error() {
+		fmt.Fprintf(os.Stderr, "RotatingLogFile: Failed to rotate %s: %v\n", f.path, err)
+	}
+	f.rotateError = err
+}
+
+// generationPath returns the name of rotated file n (1 = newest)
+func (f *RotatingLogFile) generationPath(n int, compressed bool) string {
+	path := f.path + "." + strconv.Itoa(n)
+	if compressed {
+		path += ".gz"
+	}
+	return path
+}
+
+// existingGeneration returns the existing file of generation n (compressed or not)
+func (f *RotatingLogFile) existingGeneration(n int) string {
+	if _, err := os.Stat(f.generationPath(n, false)); err == nil {
+		return f.generationPath(n, false)
+	}
+	return f.generationPath(n, true)
+}
+
+// shiftGenerations removes the oldest generation and renames the others to free generation 1
+func (f *RotatingLogFile) shiftGenerations() error {
+	f.compressing.Wait() // Generation 1 must not be renamed while it is being compressed
+	last := f.policy.Generations
+	for _, compressed := range []bool{false, true} {
+		if err := os.Remove(f.generationPath(last, compressed)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	for n := last - 1; n >= 1; n-- {
+		for _, compressed := range []bool{false, true} {
+			from := f.generationPath(n, compressed)
+			if _, err := os.Stat(from); err != nil {
+				continue
+			}
+			if err := os.Rename(from, f.generationPath(n+1, compressed)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// storeGeneration moves src to generation 1; if the policy says so, it is compressed in the background
+func (f *RotatingLogFile) storeGeneration(src string, rotatedAt time.Time) error {
+	plain := f.generationPath(1, false)
+	if err := os.Rename(src, plain); err != nil {
+		return err
+	}
+	if f.policy.Compress {
+		f.compressing.Add(1)
+		go f.compressGeneration(plain, f.generationPath(1, true), rotatedAt)
+	}
+	return nil
+}
+
+// compressGeneration replaces the rotated file plain with its compressed copy. It runs without
+// f.mutex, so log writes are not blocked; readers see the plain file until the copy is complete
+// (see existingGeneration). Like rotate, it must not use the standard logger.
+func (f *RotatingLogFile) compressGeneration(plain, compressed string, rotatedAt time.Time) {
+	defer f.compressing.Done()
+	if err := gzipFile(plain, compressed); err != nil {
+		os.Remove(compressed)
+		return // The plain file is kept as the generation
+	}
+	_ = os.Chtimes(compressed, rotatedAt, rotatedAt)
+	_ = os.Remove(plain)
+	enforceLogBudget(filepath.Dir(f.path), f.policy.TotalBudget)
+}
+
+// gzipFile writes a gzip-compressed copy of src to dst
+func gzipFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	zw.Name = filepath.Base(src)
+	if _, err := io.Copy(zw, in); err != nil {
+		zw.Close()
+		out.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// rotatedLogPattern matches rotated generations of any log: name.log.3 or name.log.3.gz
+var rotatedLogPattern = regexp.MustCompile(`\.log\.\d+(\.gz)?$`)
+
+// enforceLogBudget removes rotated log files, oldest first, while the files in dir
+// take more than budget bytes. Current log files are never removed.
+func enforceLogBudget(dir string, budget int64) {
+	if budget <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	type rotated struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var total int64
+	var candidates []rotated
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		total += info.Size()
+		if rotatedLogPattern.MatchString(entry.Name()) {
+			candidates = append(candidates, rotated{filepath.Join(dir, entry.Name()), info.Size(), info.ModTime()})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].modTime.Before(candidates[j].modTime) })
+	for _, c := range candidates {
+		if total <= budget {
+			return
+		}
+		if os.Remove(c.path) == nil {
+			total -= c.size
+		}
+	}
+}
 
-	// Open file in append mode (not truncate) to preserve recent logs
-	// But if file was rotated, it will be a new file
-	return os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+// LogRotationPolicy returns the log rotation policy from launcher settings
+func (ac *AppController) LogRotationPolicy() LogRotationPolicy {
+	var settings LogRotationSettings
+	ac.Settings.Get(func(s *LauncherSettings) { settings = s.LogRotation })
+	return settings.Policy()
 }
@@ -0,0 +1,214 @@
+package core
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readGzip returns the content of a gzip file
+func readGzip(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// TestLogRotationPolicy tests settings conversion
+func TestLogRotationPolicy(t *testing.T) {
+	if p := (LogRotationSettings{}).Policy(); p != DefaultLogRotationPolicy() {
+		t.Errorf("Empty settings: got %+v, want defaults", p)
+	}
+	p := LogRotationSettings{MaxSizeMB: 2, MaxAge: "24h", Generations: 3, NoCompression: true, TotalBudgetMB: 50}.Policy()
+	if p.MaxSize != 2*1024*1024 || p.MaxAge != 24*time.Hour || p.Generations != 3 || p.Compress || p.TotalBudget != 50*1024*1024 {
+		t.Errorf("Unexpected policy %+v", p)
+	}
+	if p := (LogRotationSettings{MaxAge: "off"}).Policy(); p.MaxAge != 0 {
+		t.Errorf("max_age off: got %v", p.MaxAge)
+	}
+	def := DefaultLogRotationPolicy()
+	p = LogRotationSettings{MaxSizeMB: -1, MaxAge: "1s", Generations: 1000, TotalBudgetMB: -5}.Policy()
+	if p.MaxSize != def.MaxSize || p.MaxAge != def.MaxAge || p.Generations != def.Generations || p.TotalBudget != def.TotalBudget {
+		t.Errorf("Invalid settings: got %+v", p)
+	}
+}
+
+// TestRotatingLogFile tests size-based rotation, compression and the generation limit
+func TestRotatingLogFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.log")
+	f, err := OpenRotatingLogFile(path, LogRotationPolicy{MaxSize: 10, Generations: 2, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f.compressing.Wait()
+
+	// Every write exceeds 10 bytes with the previous one, so each line ends up in its own file;
+	// "first" was removed with generation 3
+	if got := readGzip(t, path+".1.gz"); got != "third\n" {
+		t.Errorf("Generation 1 = %q", got)
+	}
+	if got := readGzip(t, path+".2.gz"); got != "second\n" {
+		t.Errorf("Generation 2 = %q", got)
+	}
+	if _, err := os.Stat(path + ".3.gz"); !os.IsNotExist(err) {
+		t.Error("Generation 3 must be removed")
+	}
+	if data, _ := os.ReadFile(path); !strings.HasSuffix(string(data), "fourth\n") {
+		t.Errorf("Current file = %q", data)
+	}
+}
+
+// TestRotatingLogFileCompressesInBackground tests that writes are not blocked while the rotated file
+// is compressed, and that readers see the plain generation until its compressed copy is complete
+func TestRotatingLogFileCompressesInBackground(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.log")
+	f, err := OpenRotatingLogFile(path, LogRotationPolicy{MaxSize: 1024, Generations: 2, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Hold a compression "in progress": shiftGenerations must wait for it, writes must not
+	f.compressing.Add(1)
+	os.WriteFile(path+".1", []byte("rotated\n"), 0644)
+	done := make(chan struct{})
+	go func() {
+		f.Write([]byte(strings.Repeat("x", 100) + "\n"))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Write blocked by compression")
+	}
+	if got := f.existingGeneration(1); got != path+".1" {
+		t.Errorf("existingGeneration(1) = %q, want the plain file", got)
+	}
+	f.compressing.Done()
+
+	f.Write([]byte(strings.Repeat("y", 1024) + "\n"))
+	f.compressing.Wait()
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Error("Plain generation 1 must be replaced by its compressed copy")
+	}
+	if got := readGzip(t, path+".1.gz"); !strings.HasPrefix(got, "xxx") {
+		t.Errorf("Generation 1 = %q", got)
+	}
+	if data, _ := os.ReadFile(path + ".2"); string(data) != "rotated\n" {
+		t.Errorf("Generation 2 = %q", data)
+	}
+}
+
+// TestRotatingLogFileAgeAndOldBackup tests age-based rotation and conversion of name.log.old
+func TestRotatingLogFileAgeAndOldBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.log")
+	os.WriteFile(path+".old", []byte("backup\n"), 0644)
+	os.WriteFile(path, []byte("current\n"), 0644)
+
+	f, err := OpenRotatingLogFile(path, LogRotationPolicy{MaxSize: 1024, MaxAge: time.Hour, Generations: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if data, _ := os.ReadFile(path + ".1"); string(data) != "backup\n" {
+		t.Errorf("Generation 1 = %q, want the old backup", data)
+	}
+	if _, err := os.Stat(path + ".old"); !os.IsNotExist(err) {
+		t.Error("name.log.old must be converted")
+	}
+
+	f.mutex.Lock()
+	f.startedAt = time.Now().Add(-2 * time.Hour)
+	f.mutex.Unlock()
+	f.Write([]byte("new\n"))
+	if data, _ := os.ReadFile(path + ".1"); string(data) != "current\n" {
+		t.Errorf("Generation 1 = %q, want the aged file", data)
+	}
+	if data, _ := os.ReadFile(path + ".2"); string(data) != "backup\n" {
+		t.Errorf("Generation 2 = %q", data)
+	}
+
+	var nilFile *RotatingLogFile
+	if n, err := nilFile.Write([]byte("x")); n != 1 || err != nil {
+		t.Errorf("Write to nil file: %d, %v", n, err)
+	}
+}
+
+// TestSharedLogFileIsNotRotatedInUse tests that a log another process writes to is kept
+// while that process runs and rotated after it exits
+func TestSharedLogFileIsNotRotatedInUse(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.log")
+	os.WriteFile(path, []byte(strings.Repeat("x", 100)+"\n"), 0644)
+
+	inUse := true
+	f, err := OpenSharedLogFile(path, LogRotationPolicy{MaxSize: 10, Generations: 2}, func() bool { return inUse })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.RotateIfNeeded()
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Fatal("A log in use by another process must not be rotated")
+	}
+
+	inUse = false
+	f.RotateIfNeeded()
+	if _, err := os.Stat(path + ".1"); err == nil {
+		t.Fatal("Rotation must wait for rotationRetryDelay after a postponed one")
+	}
+	f.mutex.Lock()
+	f.retryAfter = time.Time{}
+	f.mutex.Unlock()
+	f.RotateIfNeeded()
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Errorf("The log must be rotated once it is not in use: %v", err)
+	}
+}
+
+// TestEnforceLogBudget tests removal of the oldest rotated files over the disk budget
+func TestEnforceLogBudget(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{"a.log.2.gz", "a.log.1.gz", "b.log.1"}
+	for i, name := range files {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, make([]byte, 100), 0644)
+		mod := time.Now().Add(time.Duration(i-len(files)) * time.Hour)
+		os.Chtimes(path, mod, mod)
+	}
+	os.WriteFile(filepath.Join(dir, "a.log"), make([]byte, 100), 0644)
+
+	enforceLogBudget(dir, 250)
+	for name, want := range map[string]bool{"a.log.2.gz": false, "a.log.1.gz": false, "b.log.1": true, "a.log": true} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists := err == nil; exists != want {
+			t.Errorf("%s exists = %v, want %v", name, exists, want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package core
+
+import "log"
+
+// Notifier shows user-facing messages produced by core services.
+// The GUI implements it with Fyne dialogs (see ui.guiNotifier); without a GUI
+// (CLI, tests) messages are written to the log by LogNotifier.
+// Implementations must be safe to call from any goroutine.
+type Notifier interface {
+	// ShowError reports an error that requires attention
+	ShowError(err error)
+	// ShowErrorText reports an error with a title
+	ShowErrorText(title, message string)
+	// ShowInfo shows an informational message
+	ShowInfo(title, message string)
+	// ShowAutoHideInfo shows a transient notification
+	ShowAutoHideInfo(title, message string)
+	// ShowConfirm asks the user to confirm an action; onConfirm is called only if confirmed
+	ShowConfirm(title, message, confirmText string, onConfirm func())
+}
+
+// LogNotifier is a Notifier that writes messages to the log and never confirms actions
+type LogNotifier struct{}
+
+func (LogNotifier) ShowError(err error) {
+	log.Printf("Notifier: Error: %v", err)
+}
+
+func (LogNotifier) ShowErrorText(title, message string) {
+	log.Printf("Notifier: Error: %s: %s", title, message)
+}
+
+func (LogNotifier) ShowInfo(title, message string) {
+	log.Printf("Notifier: %s: %s", title, message)
+}
+
+func (LogNotifier) ShowAutoHideInfo(title, message string) {
+	log.Printf("Notifier: %s: %s", title, message)
+}
+
+func (LogNotifier) ShowConfirm(title, message, confirmText string, onConfirm func()) {
+	log.Printf("Notifier: %s: %s (not confirmed, no UI)", title, message)
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"singbox-launcher/internal/constants"
+)
+
+// parserConfigBlockPattern matches the /** @ParserConfig ... */ block in config.json
+var parserConfigBlockPattern = regexp.MustCompile(`(/\*\*\s*@ParserConfig\s*\n)([\s\S]*?)(\*/)`)
+
+// extractParserConfigBlock returns the JSON content of the @ParserConfig block
+func extractParserConfigBlock(data []byte) (string, error) {
+	matches := parserConfigBlockPattern.FindSubmatch(data)
+	if len(matches) < 4 {
+		return "", fmt.Errorf("@ParserConfig block not found in config.json")
+	}
+	return strings.TrimSpace(string(matches[2])), nil
+}
+
+// backupParserConfigBlock saves @ParserConfig block content to
+// <config dir>/parser_config_backups/parser_config_v<N>_<timestamp>_<hash>.json.
+// Identical content is backed up only once. Returns the backup path.
+func backupParserConfigBlock(configPath, jsonContent string) (string, error) {
+	dir := filepath.Join(filepath.Dir(configPath), constants.ParserConfigBackupDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(jsonContent))
+	hash := hex.EncodeToString(sum[:4])
+	if existing, _ := filepath.Glob(filepath.Join(dir, "parser_config_v*_"+hash+".json")); len(existing) > 0 {
+		return existing[0], nil
+	}
+
+	name := fmt.Sprintf("parser_config_v%d_%s_%s.json", extractVersion(jsonContent), time.Now().Format("20060102-150405"), hash)
+	backupPath := filepath.Join(dir, name)
+	if err := os.WriteFile(backupPath, []byte(jsonContent+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to write backup: %w", err)
+	}
+	log.Printf("ConfigMigrator: Backed up @ParserConfig block to %s", backupPath)
+	return backupPath, nil
+}
+
+// backupParserConfigBeforeMigration backs up the @ParserConfig block from config data
+// if its version differs from newVersion (i.e. the block is about to be migrated on write).
+// Returns empty path if no backup was needed.
+func backupParserConfigBeforeMigration(configPath string, data []byte, newVersion int) (string, error) {
+	jsonContent, err := extractParserConfigBlock(data)
+	if err != nil {
+		return "", nil
+	}
+	version := extractVersion(jsonContent)
+	if version == newVersion {
+		return "", nil
+	}
+	return backupParserConfigBlock(configPath, jsonContent)
+}
+
+// PreviewParserConfigMigration converts the @ParserConfig block of configPath to targetVersion
+// (upgrade or downgrade) without writing it, so the result can be reviewed first
+func PreviewParserConfigMigration(configPath string, targetVersion int) (*MigrationPreview, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config.json: %w", err)
+	}
+	jsonContent, err := extractParserConfigBlock(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewConfigMigrator().Preview(jsonContent, targetVersion)
+}
+
+// ApplyParserConfigMigration writes a previewed migration to configPath.
+// The original @ParserConfig block is backed up first. Fails if the block has changed since the preview.
+// Returns the backup path.
+func ApplyParserConfigMigration(configPath string, preview *MigrationPreview) (string, error) {
+	if preview == nil {
+		return "", fmt.Errorf("migration preview is empty")
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read config.json: %w", err)
+	}
+	matches := parserConfigBlockPattern.FindSubmatch(data)
+	if len(matches) < 4 {
+		return "", fmt.Errorf("@ParserConfig block not found in config.json")
+	}
+	if strings.TrimSpace(string(matches[2])) != preview.OriginalJSON {
+		return "", fmt.Errorf("@ParserConfig block has changed since the preview, please preview again")
+	}
+
+	backupPath, err := backupParserConfigBlock(configPath, preview.OriginalJSON)
+	if err != nil {
+		return "", err
+	}
+
+	block := string(matches[1]) + preview.MigratedJSON + "\n" + string(matches[3])
+	loc := parserConfigBlockPattern.FindIndex(data)
+	newContent := string(data[:loc[0]]) + block + string(data[loc[1]:])
+	if err := os.WriteFile(configPath, []byte(newContent), 0644); err != nil {
+		return "", fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	log.Printf("ConfigMigrator: Converted @ParserConfig from version %d to version %d", preview.FromVersion, preview.ToVersion)
+	return backupPath, nil
+}
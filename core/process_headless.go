@@ -0,0 +1,161 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	ps "github.com/mitchellh/go-ps"
+
+	"singbox-launcher/internal/platform"
+)
+
+// detachedStartCheckDelay is how long a detached sing-box must stay alive to be considered started
+const detachedStartCheckDelay = 1500 * time.Millisecond
+
+// Methods below control a sing-box process that is not tracked by this launcher instance
+// (started by another launcher instance or by the CLI). They never show dialogs
+// and are used by headless CLI subcommands.
+
+// FindRunning reports whether a sing-box process is running on the system and its PID
+func (svc *ProcessService) FindRunning() (bool, int) {
+	return svc.isSingBoxProcessRunning()
+}
+
+// detachedCoreRunning reports whether a sing-box process that this launcher instance did not start
+// is running, i.e. a detached sing-box or its log pump may write to the sing-box log. It is called
+// under the log file's mutex, so it must not use the standard logger (see RotatingLogFile.rotate).
+func (ac *AppController) detachedCoreRunning() bool {
+	if ac.RunningState != nil && ac.RunningState.IsRunning() {
+		return false
+	}
+	processes, err := ps.Processes()
+	if err != nil {
+		return false
+	}
+	processName := platform.GetProcessNameForCheck()
+	for _, p := range processes {
+		if strings.EqualFold(p.Executable(), processName) {
+			return true
+		}
+	}
+	return false
+}
+
+// StartDetached validates the config and starts sing-box in the background so that it keeps
+// running after the launcher exits. Output goes to the sing-box log file through a log pump
+// (see startLogPump). Returns the PID.
+func (svc *ProcessService) StartDetached() (int, error) {
+	ac := svc.ac
+	if _, err := os.Stat(ac.SingboxPath); err != nil {
+		return 0, fmt.Errorf("sing-box not found at %s", ac.SingboxPath)
+	}
+	if _, err := os.Stat(ac.GetConfigPath()); err != nil {
+		return 0, fmt.Errorf("config not found at %s", ac.GetConfigPath())
+	}
+	if found, pid := svc.FindRunning(); found {
+		return pid, fmt.Errorf("sing-box is already running (PID=%d)", pid)
+	}
+	// Root (systemd service, cron) does not need file capabilities
+	if os.Geteuid() != 0 {
+		if suggestion := platform.CheckAndSuggestCapabilities(ac.SingboxPath); suggestion != "" {
+			return 0, fmt.Errorf("Linux capabilities required: %s", suggestion)
+		}
+	}
+	if err := svc.CheckConfig(ac.GetConfigPath()); err != nil {
+		return 0, err
+	}
+
+	binDir := platform.GetBinDir(ac.ExecDir)
+	cmd := exec.Command(ac.SingboxPath, "run", "-c", configArgPath(binDir, ac.GetConfigPath()))
+	platform.PrepareDetachedCommand(cmd)
+	cmd.Dir = binDir
+	// The detached process outlives the launcher: its output goes through a log pump process,
+	// which redacts and rotates it
+	pump, err := svc.startLogPump()
+	if err != nil {
+		log.Printf("startDetached: Failed to start the log pump, sing-box output is written to the log without redaction: %v", err)
+		if ac.ChildLogFile != nil {
+			// The file is not rotated while sing-box runs (see detachedCoreRunning)
+			ac.ChildLogFile.RotateIfNeeded()
+			if file := ac.ChildLogFile.File(); file != nil {
+				cmd.Stdout = file
+				cmd.Stderr = file
+			}
+		}
+	} else {
+		cmd.Stdout = pump
+		cmd.Stderr = pump
+	}
+	err = cmd.Start()
+	if pump != nil {
+		pump.Close() // sing-box has its own copy; the pump exits with it
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to start Sing-Box process: %w", err)
+	}
+	pid := cmd.Process.Pid
+	log.Printf("startDetached: Sing-Box started. PID=%d", pid)
+
+	// Reap the process if it exits while we are still running
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	select {
+	case err := <-exited:
+		return 0, fmt.Errorf("sing-box exited right after start (%v), see logs/%s", err, childLogFileName)
+	case <-time.After(detachedStartCheckDelay):
+	}
+	_ = cmd.Process.Release()
+	return pid, nil
+}
+
+// StopPID gracefully stops a sing-box process by PID, killing it after gracefulShutdownTimeout
+func (svc *ProcessService) StopPID(pid int) error {
+	log.Printf("stopPID: Stopping Sing-Box (PID=%d)...", pid)
+	var err error
+	if runtime.GOOS == "windows" {
+		// CTRL_BREAK can't be delivered to a process of another console, kill it
+		err = fmt.Errorf("graceful stop is not supported on windows")
+	} else {
+		var process *os.Process
+		if process, err = os.FindProcess(pid); err == nil {
+			err = process.Signal(os.Interrupt)
+		}
+	}
+
+	if err == nil {
+		deadline := time.Now().Add(gracefulShutdownTimeout)
+		for time.Now().Before(deadline) {
+			if p, _ := ps.FindProcess(pid); p == nil {
+				log.Printf("stopPID: Sing-Box (PID=%d) stopped", pid)
+				return nil
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		log.Printf("stopPID: Process %d still running after timeout. Forcing kill.", pid)
+	}
+
+	if killErr := platform.KillProcessByPID(pid); killErr != nil {
+		if p, _ := ps.FindProcess(pid); p != nil {
+			return fmt.Errorf("failed to kill Sing-Box process %d: %w", pid, killErr)
+		}
+	}
+	return nil
+}
+
+// ReloadPID validates the current config and asks a running sing-box to reload it (SIGHUP).
+// Returns an error if the config is invalid or the platform has no hot reload.
+func (svc *ProcessService) ReloadPID(pid int) error {
+	if err := svc.CheckConfig(svc.ac.GetConfigPath()); err != nil {
+		return err
+	}
+	if !platform.SupportsReloadSignal() {
+		return fmt.Errorf("hot reload is not supported on %s, restart Sing-Box to apply the config", runtime.GOOS)
+	}
+	log.Printf("reloadPID: Sending SIGHUP to Sing-Box (PID=%d)...", pid)
+	return platform.SendReloadSignal(pid)
+}
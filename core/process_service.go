@@ -1,8 +1,11 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
@@ -10,28 +13,39 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/muhammadmuzzammil1998/jsonc"
 
 	"singbox-launcher/api"
-	"singbox-launcher/internal/dialogs"
+	"singbox-launcher/internal/constants"
 	"singbox-launcher/internal/platform"
 
 	ps "github.com/mitchellh/go-ps"
 )
 
 const (
-	// restartAttempts is the maximum number of consecutive crash restart attempts
-	restartAttempts = 3
-
-	// stabilityThreshold is the duration a process must run without crashing
-	// before the crash counter is reset
-	stabilityThreshold = 180 * time.Second
-
 	// gracefulShutdownTimeout is the maximum time to wait for graceful shutdown
 	// before forcing kill
 	gracefulShutdownTimeout = 2 * time.Second
+
+	// reloadSettleTime is how long sing-box must keep running after SIGHUP
+	// for the hot reload to be considered successful
+	reloadSettleTime = 3 * time.Second
+
+	// reloadPollInterval is how often the core is checked while a hot reload settles
+	reloadPollInterval = 100 * time.Millisecond
+
+	// configCheckTimeout limits `sing-box check` run before a hot reload
+	configCheckTimeout = 15 * time.Second
+
+	// outputWaitDelay limits how long Wait waits for core output to be copied after the process exited
+	outputWaitDelay = 2 * time.Second
+
+	// workingConfigTime is how long sing-box must keep running a config before it is kept
+	// as config.prev.json for "restore previous config" in the crash report
+	workingConfigTime = 30 * time.Second
 )
 
 // ProcessService encapsulates sing-box process lifecycle management.
@@ -39,6 +53,16 @@ const (
 // The service ensures proper cleanup of TUN interfaces, log rotation, and process state management.
 type ProcessService struct {
 	ac *AppController
+
+	// Guarded by ac.CmdMutex
+	tail          *outputTail   // Last lines of the current process output (for crash records)
+	output        *lineWriter   // Splits the current process output into lines for the log parser
+	startedAt     time.Time     // Start time of the current process
+	loadedAt      time.Time     // When the current process last loaded config.json (start or hot reload)
+	restartCancel chan struct{} // Closed by Stop to cancel a pending crash restart
+
+	crashMutex sync.Mutex
+	lastCrash  *CrashRecord
 }
 
 // NewProcessService constructs a ProcessService bound to the controller.
@@ -51,7 +75,7 @@ func NewProcessService(ac *AppController) *ProcessService {
 func (svc *ProcessService) Start(skipRunningCheck ...bool) {
 	ac := svc.ac
 	if ac.RunningState.IsRunning() {
-		dialogs.ShowAutoHideInfo(ac.Application, ac.MainWindow, "Info", "Sing-Box already running (according to internal state).")
+		ac.Notifier.ShowAutoHideInfo("Info", "Sing-Box already running (according to internal state).")
 		return
 	}
 
@@ -66,42 +90,22 @@ func (svc *ProcessService) Start(skipRunningCheck ...bool) {
 	ac.CmdMutex.Lock()
 	defer ac.CmdMutex.Unlock()
 
-	// Check capabilities on Linux before starting
-	if suggestion := platform.CheckAndSuggestCapabilities(ac.SingboxPath); suggestion != "" {
-		log.Printf("startSingBox: Capabilities check failed: %s", suggestion)
-		dialogs.ShowError(ac.MainWindow, fmt.Errorf("Linux capabilities required\n\n%s", suggestion))
-		return
+	// Check capabilities on Linux before starting (root does not need them)
+	if os.Geteuid() != 0 {
+		if suggestion := platform.CheckAndSuggestCapabilities(ac.SingboxPath); suggestion != "" {
+			log.Printf("startSingBox: Capabilities check failed: %s", suggestion)
+			ac.Notifier.ShowError(fmt.Errorf("Linux capabilities required\n\n%s", suggestion))
+			return
+		}
 	}
 
 	// Reload API config from config.json before starting (in case it was corrupted)
 	log.Println("startSingBox: Reloading API config from config.json...")
-	if base, tok, err := api.LoadClashAPIConfig(ac.ConfigPath); err != nil {
-		log.Printf("startSingBox: Clash API config error: %v", err)
-		ac.ClashAPIBaseURL = ""
-		ac.ClashAPIToken = ""
-		ac.ClashAPIEnabled = false
-	} else {
-		ac.ClashAPIBaseURL = base
-		ac.ClashAPIToken = tok
-		ac.ClashAPIEnabled = true
-		log.Printf("startSingBox: API config reloaded successfully")
-	}
-
-	// Reload SelectedClashGroup from config
-	if ac.ClashAPIEnabled {
-		_, defaultSelector, err := GetSelectorGroupsFromConfig(ac.ConfigPath)
-		if err != nil {
-			log.Printf("startSingBox: Failed to get selector groups: %v", err)
-			ac.SelectedClashGroup = "proxy-out" // Default fallback
-		} else {
-			ac.SelectedClashGroup = defaultSelector
-			log.Printf("startSingBox: SelectedClashGroup reloaded: %s", defaultSelector)
-		}
-	}
+	svc.reloadClashAPIConfig("startSingBox")
 
 	// Check and remove existing TUN interface before starting (prevents "file already exists" error)
 	if runtime.GOOS == "windows" {
-		interfaceName, err := svc.getTunInterfaceName(ac.ConfigPath)
+		interfaceName, err := svc.getTunInterfaceName(ac.GetConfigPath())
 		if err != nil {
 			log.Printf("startSingBox: Failed to get TUN interface name from config: %v", err)
 			// Continue anyway - maybe config doesn't have TUN
@@ -115,38 +119,48 @@ func (svc *ProcessService) Start(skipRunningCheck ...bool) {
 	}
 
 	// Reset API cache before starting
-	if ac.ResetAPIStateFunc != nil {
-		log.Println("startSingBox: Resetting API state cache...")
-		ac.ResetAPIStateFunc()
-	}
+	log.Println("startSingBox: Resetting API state cache...")
+	ac.publish(EventAPIStateReset)
+	ac.setClashMode("", nil)
 
 	log.Println("startSingBox: Starting Sing-Box...")
-	ac.SingboxCmd = exec.Command(ac.SingboxPath, "run", "-c", filepath.Base(ac.ConfigPath))
+	binDir := platform.GetBinDir(ac.ExecDir)
+	ac.SingboxCmd = exec.Command(ac.SingboxPath, "run", "-c", configArgPath(binDir, ac.GetConfigPath()))
 	platform.PrepareCommand(ac.SingboxCmd)
-	ac.SingboxCmd.Dir = platform.GetBinDir(ac.ExecDir)
+	ac.SingboxCmd.Dir = binDir
+	// Output goes to disk as is and through the log parser (CoreLog, events, crash record);
+	// only bounded buffers are kept in memory
+	tail := newOutputTail(crashOutputLines)
+	svc.tail = tail
+	svc.output = newLineWriter(func(line string) { ac.handleCoreOutputLine(tail, line) })
+	ac.SingboxCmd.Stdout = svc.output
 	if ac.ChildLogFile != nil {
-		// Check and rotate log file before starting new process to prevent unbounded growth
-		checkAndRotateLogFile(filepath.Join(ac.ExecDir, childLogFileName))
-
-		// Write directly to file - no buffering in memory
-		// This prevents memory leaks from accumulating log output
-		// Logs are written immediately to disk, not stored in memory
-		ac.SingboxCmd.Stdout = ac.ChildLogFile
-		ac.SingboxCmd.Stderr = ac.ChildLogFile
+		// ChildLogFile rotates itself while sing-box is writing
+		ac.SingboxCmd.Stdout = io.MultiWriter(ac.ChildLogFile, svc.output)
 	} else {
 		log.Println("startSingBox: Warning: sing-box log file not available, output will not be logged.")
 	}
+	ac.SingboxCmd.Stderr = ac.SingboxCmd.Stdout
+	ac.SingboxCmd.WaitDelay = outputWaitDelay
+	// Content of the config this process runs: kept as config.prev.json once it has proven to work
+	runningConfig, readErr := os.ReadFile(ac.GetConfigPath())
 	if err := ac.SingboxCmd.Start(); err != nil {
 		ac.ShowStartupError(fmt.Errorf("failed to start Sing-Box process: %w", err))
 		log.Printf("startSingBox: Failed to start Sing-Box: %v", err)
 		return
 	}
+	svc.startedAt = time.Now()
+	svc.loadedAt = svc.startedAt
+	if readErr == nil {
+		go svc.keepWorkingConfig(svc.loadedAt, ac.GetConfigPath(), runningConfig)
+	}
 	ac.RunningState.Set(true)
 	ac.StoppedByUser = false
 	// Add log with PID
 	log.Printf("startSingBox: Sing-Box started. PID=%d", ac.SingboxCmd.Process.Pid)
 
-	// Start auto-loading proxies after sing-box is running
+	// Start auto-loading proxies after sing-box is running; sing-box starts with default selections
+	ac.setRestorePending()
 	go func() {
 		// Small delay to ensure API is ready
 		time.Sleep(2 * time.Second)
@@ -176,7 +190,14 @@ func (svc *ProcessService) Monitor(cmdToMonitor *exec.Cmd) {
 		return
 	}
 
-	// 2. Then StoppedByUser (did user stop it?)
+	// 2. Then ReloadInProgress (did hot reload fail?) - Reload falls back to a full restart
+	if ac.ReloadInProgress {
+		log.Printf("monitorSingBox: Sing-Box exited during hot reload: %v", err)
+		ac.RunningState.Set(false)
+		return
+	}
+
+	// 3. Then StoppedByUser (did user stop it?)
 	if ac.StoppedByUser {
 		log.Println("monitorSingBox: Sing-Box exited as requested by user.")
 		ac.ConsecutiveCrashAttempts = 0
@@ -185,7 +206,7 @@ func (svc *ProcessService) Monitor(cmdToMonitor *exec.Cmd) {
 		return
 	}
 
-	// 3. Then err == nil (exited normally?)
+	// 4. Then err == nil (exited normally?)
 	if err == nil {
 		log.Println("monitorSingBox: Sing-Box exited gracefully (exit code 0).")
 		ac.ConsecutiveCrashAttempts = 0
@@ -193,27 +214,81 @@ func (svc *ProcessService) Monitor(cmdToMonitor *exec.Cmd) {
 		return
 	}
 
-	// 4. Only then — crash → restart
-	// Процесс завершился с ошибкой - проверяем лимит попыток
+	// 5. Only then — crash → restart
+	// Процесс завершился с ошибкой - проверяем политику перезапуска
 	ac.RunningState.Set(false)
+	policy := ac.RestartPolicy()
+	uptime := time.Since(svc.startedAt)
+	if uptime >= policy.StabilityThreshold {
+		// Ядро долго работало стабильно - это новый сбой, а не продолжение серии
+		ac.ConsecutiveCrashAttempts = 0
+	}
 	ac.ConsecutiveCrashAttempts++
-
-	if ac.ConsecutiveCrashAttempts > restartAttempts {
-		log.Printf("monitorSingBox: Maximum restart attempts (%d) reached. Stopping auto-restart.", restartAttempts)
-		dialogs.ShowError(ac.MainWindow, fmt.Errorf("Sing-Box failed to restart after %d attempts. Check sing-box.log for details.", restartAttempts))
+	attempt := ac.ConsecutiveCrashAttempts
+
+	svc.output.Flush()
+	record := newCrashRecord(err, monitoredPID, uptime, svc.tail)
+	record.Profile = ac.GetActiveProfile()
+	record.ConfigPath = ac.GetConfigPath()
+	record.Attempt = attempt
+	permanent := IsPermanentFatal(record.FatalKind)
+	record.WillRestart = policy.Enabled && attempt <= policy.MaxAttempts && !permanent
+	if saveErr := saveCrashRecord(ac.ExecDir, record); saveErr != nil {
+		log.Printf("monitorSingBox: Failed to save crash record: %v", saveErr)
+	}
+	svc.crashMutex.Lock()
+	svc.lastCrash = record
+	svc.crashMutex.Unlock()
+	log.Printf("monitorSingBox: Sing-Box crashed: %v (uptime %v, crash %d). Fatal line: %q. Crash record: %s",
+		err, uptime.Round(time.Second), attempt, record.FatalLine, record.Path)
+
+	if !record.WillRestart {
+		if permanent {
+			log.Printf("monitorSingBox: Not restarting, the crash (%s) would repeat.", record.FatalKind)
+		} else if policy.Enabled {
+			log.Printf("monitorSingBox: Maximum restart attempts (%d) reached. Stopping auto-restart.", policy.MaxAttempts)
+		} else {
+			log.Println("monitorSingBox: Auto-restart is disabled in launcher settings.")
+		}
 		ac.ConsecutiveCrashAttempts = 0
+		message := record.FatalLine
+		if message == "" {
+			message = "Sing-Box exited with " + record.ExitDescription()
+		}
+		ac.Events.Publish(Event{Type: EventCrashReport, Message: message})
 		return
 	}
 
-	// Try to restart
-	log.Printf("monitorSingBox: Sing-Box crashed: %v, attempting auto-restart (attempt %d/%d)", err, ac.ConsecutiveCrashAttempts, restartAttempts)
-	dialogs.ShowAutoHideInfo(ac.Application, ac.MainWindow, "Crash", fmt.Sprintf("Sing-Box crashed, restarting... (attempt %d/%d)", ac.ConsecutiveCrashAttempts, restartAttempts))
+	// Try to restart with exponential backoff
+	delay := policy.Delay(attempt)
+	log.Printf("monitorSingBox: Restarting in %v (attempt %d/%d)", delay, attempt, policy.MaxAttempts)
+	ac.Notifier.ShowAutoHideInfo("Crash", fmt.Sprintf("Sing-Box crashed (%s), restarting in %v... (attempt %d/%d)",
+		record.ExitDescription(), delay.Round(100*time.Millisecond), attempt, policy.MaxAttempts))
+	ac.publish(EventCoreStatusChanged)
 
-	// Wait 2 seconds before restart
+	cancel := make(chan struct{})
+	svc.restartCancel = cancel
 	ac.CmdMutex.Unlock()
-	time.Sleep(2 * time.Second)
+	select {
+	case <-time.After(delay):
+	case <-cancel:
+		log.Println("monitorSingBox: Pending restart cancelled by user.")
+		ac.CmdMutex.Lock()
+		return
+	case <-ac.ctx.Done():
+		ac.CmdMutex.Lock()
+		return
+	}
+	if ac.RunningState.IsRunning() {
+		// Started by the user while waiting
+		ac.CmdMutex.Lock()
+		return
+	}
 	svc.Start(true) // skipRunningCheck = true для автоперезапуска
 	ac.CmdMutex.Lock()
+	if svc.restartCancel == cancel {
+		svc.restartCancel = nil
+	}
 
 	if ac.RunningState.IsRunning() {
 		log.Println("monitorSingBox: Sing-Box restarted successfully.")
@@ -223,17 +298,15 @@ func (svc *ProcessService) Monitor(cmdToMonitor *exec.Cmd) {
 			case <-ac.ctx.Done():
 				log.Println("monitorSingBox: Stability check cancelled (context cancelled)")
 				return
-			case <-time.After(stabilityThreshold):
+			case <-time.After(policy.StabilityThreshold):
 				ac.CmdMutex.Lock()
 				defer ac.CmdMutex.Unlock()
 
 				if ac.RunningState.IsRunning() && ac.ConsecutiveCrashAttempts == currentAttemptCount {
-					log.Printf("monitorSingBox: Process has been stable for %v. Resetting crash counter from %d to 0.", stabilityThreshold, ac.ConsecutiveCrashAttempts)
+					log.Printf("monitorSingBox: Process has been stable for %v. Resetting crash counter from %d to 0.", policy.StabilityThreshold, ac.ConsecutiveCrashAttempts)
 					ac.ConsecutiveCrashAttempts = 0
 					// Обновляем UI, чтобы счетчик исчез из статуса на вкладке Core
-					if ac.UpdateCoreStatusFunc != nil {
-						ac.UpdateCoreStatusFunc()
-					}
+					ac.publish(EventCoreStatusChanged)
 				} else {
 					log.Printf("monitorSingBox: Stability timer expired, but conditions for reset not met (running: %v, current attempts: %d, attempts at timer start: %d).", ac.RunningState.IsRunning(), ac.ConsecutiveCrashAttempts, currentAttemptCount)
 				}
@@ -244,6 +317,13 @@ func (svc *ProcessService) Monitor(cmdToMonitor *exec.Cmd) {
 	}
 }
 
+// LastCrash returns the record of the last sing-box crash, or nil
+func (svc *ProcessService) LastCrash() *CrashRecord {
+	svc.crashMutex.Lock()
+	defer svc.crashMutex.Unlock()
+	return svc.lastCrash
+}
+
 // Stop attempts graceful shutdown, mirroring previous StopSingBoxProcess.
 func (svc *ProcessService) Stop() {
 	ac := svc.ac
@@ -256,6 +336,12 @@ func (svc *ProcessService) Stop() {
 
 	if !ac.RunningState.IsRunning() {
 		ac.StoppedByUser = false
+		// Stop also cancels a crash restart that is waiting for its backoff delay
+		if svc.restartCancel != nil {
+			close(svc.restartCancel)
+			svc.restartCancel = nil
+			ac.publish(EventCoreStatusChanged)
+		}
 		ac.CmdMutex.Unlock()
 		return
 	}
@@ -301,6 +387,226 @@ func (svc *ProcessService) Stop() {
 	}
 }
 
+// Restart stops sing-box (if running), waits for it to exit and starts it again.
+// Used when the active configuration is replaced (e.g. profile switch).
+func (svc *ProcessService) Restart() {
+	ac := svc.ac
+	if ac.RunningState.IsRunning() {
+		log.Println("restartSingBox: Stopping Sing-Box...")
+		svc.Stop()
+		deadline := time.Now().Add(gracefulShutdownTimeout + 3*time.Second)
+		for ac.RunningState.IsRunning() && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+		}
+		if ac.RunningState.IsRunning() {
+			log.Println("restartSingBox: Sing-Box did not stop in time, restart aborted")
+			ac.Notifier.ShowError(fmt.Errorf("Sing-Box did not stop in time, restart aborted"))
+			return
+		}
+	}
+	log.Println("restartSingBox: Starting Sing-Box...")
+	svc.Start(true)
+}
+
+// Reload applies the current config.json to the running sing-box without dropping TUN.
+// The config is validated with `sing-box check` first; an invalid config is not applied
+// and the running core keeps the previous one. On Linux/macOS SIGHUP is sent to the core;
+// if the signal cannot be delivered or the core exits while reloading, it is fully
+// restarted. On Windows a full restart is always used.
+// Returns once the config is checked and the reload is triggered; the result of the reload
+// is awaited in the background (see awaitReload). Does nothing if sing-box is not running.
+func (svc *ProcessService) Reload() error {
+	ac := svc.ac
+	if !ac.RunningState.IsRunning() {
+		return nil
+	}
+
+	if err := svc.CheckConfig(ac.GetConfigPath()); err != nil {
+		log.Printf("reloadSingBox: New config is invalid, keeping the running one: %v", err)
+		return err
+	}
+
+	if !platform.SupportsReloadSignal() {
+		log.Println("reloadSingBox: Hot reload is not supported on this platform, restarting...")
+		go svc.Restart()
+		return nil
+	}
+
+	ac.CmdMutex.Lock()
+	if ac.SingboxCmd == nil || ac.SingboxCmd.Process == nil || !ac.RunningState.IsRunning() {
+		ac.CmdMutex.Unlock()
+		return nil
+	}
+	pid := ac.SingboxCmd.Process.Pid
+	ac.ReloadInProgress = true
+	ac.CmdMutex.Unlock()
+	configPath := ac.GetConfigPath()
+	reloadedConfig, readErr := os.ReadFile(configPath)
+
+	log.Printf("reloadSingBox: Sending SIGHUP to Sing-Box (PID=%d)...", pid)
+	if err := platform.SendReloadSignal(pid); err != nil {
+		log.Printf("reloadSingBox: Failed to send reload signal: %v. Falling back to restart.", err)
+		svc.finishReload()
+		go svc.Restart()
+		return nil
+	}
+
+	if readErr != nil {
+		reloadedConfig = nil
+	}
+	go svc.awaitReload(configPath, reloadedConfig)
+	return nil
+}
+
+// awaitReload waits until sing-box has kept running for reloadSettleTime after SIGHUP
+// (sing-box exits if the new config fails to start) and completes the hot reload:
+// Clash API state is re-read on success, an exited core is started again.
+// content is the reloaded config.json, kept by keepWorkingConfig (nil if it could not be read).
+func (svc *ProcessService) awaitReload(configPath string, content []byte) {
+	ac := svc.ac
+	deadline := time.Now().Add(reloadSettleTime)
+	for ac.RunningState.IsRunning() && time.Now().Before(deadline) {
+		time.Sleep(reloadPollInterval)
+	}
+	if !svc.finishReload() {
+		log.Println("reloadSingBox: Sing-Box exited during hot reload. Falling back to restart.")
+		svc.Start(true)
+		return
+	}
+
+	log.Println("reloadSingBox: Sing-Box reloaded the configuration.")
+	svc.reloadClashAPIConfig("reloadSingBox")
+	ac.CmdMutex.Lock()
+	svc.loadedAt = time.Now()
+	loadedAt := svc.loadedAt
+	ac.CmdMutex.Unlock()
+	if content != nil {
+		go svc.keepWorkingConfig(loadedAt, configPath, content)
+	}
+	ac.publish(EventAPIStateReset)
+	// The new config may have changed tags and defaults of selectors
+	ac.setRestorePending()
+	go func() {
+		// Small delay to ensure API is ready after reload
+		time.Sleep(1 * time.Second)
+		ac.AutoLoadProxies()
+	}()
+}
+
+// keepWorkingConfig saves content as config.prev.json once sing-box has kept running the config
+// loaded at loadedAt for workingConfigTime, so that the crash report only restores a config
+// that actually worked. Nothing is saved if the core stopped or loaded another config meanwhile.
+func (svc *ProcessService) keepWorkingConfig(loadedAt time.Time, configPath string, content []byte) {
+	ac := svc.ac
+	select {
+	case <-ac.ctx.Done():
+		return
+	case <-time.After(workingConfigTime):
+	}
+	if !svc.ConfigLoadedAt().Equal(loadedAt) {
+		return
+	}
+	if err := saveWorkingConfig(configPath, content); err != nil {
+		log.Printf("startSingBox: Failed to keep the working config: %v", err)
+		return
+	}
+	log.Printf("startSingBox: Config has been running for %v, kept as %s", workingConfigTime, constants.PreviousConfigFileName)
+}
+
+// ConfigLoadedAt returns when the sing-box started by the launcher last loaded config.json
+// (start or hot reload); zero if it is not running
+func (svc *ProcessService) ConfigLoadedAt() time.Time {
+	ac := svc.ac
+	if !ac.RunningState.IsRunning() {
+		return time.Time{}
+	}
+	ac.CmdMutex.Lock()
+	defer ac.CmdMutex.Unlock()
+	return svc.loadedAt
+}
+
+// finishReload clears ReloadInProgress and reports whether sing-box survived the reload
+func (svc *ProcessService) finishReload() bool {
+	ac := svc.ac
+	ac.CmdMutex.Lock()
+	defer ac.CmdMutex.Unlock()
+	ac.ReloadInProgress = false
+	return ac.RunningState.IsRunning()
+}
+
+// ConfigCheckError is returned by CheckConfig when sing-box rejects the config
+type ConfigCheckError struct {
+	Output string // sing-box output describing the problem
+}
+
+func (e *ConfigCheckError) Error() string {
+	return fmt.Sprintf("config check failed: %s", e.Output)
+}
+
+// CheckConfig validates a config file with `sing-box check`.
+// Returns *ConfigCheckError if the config is invalid, other errors if sing-box could not be run.
+func (svc *ProcessService) CheckConfig(configPath string) error {
+	ac := svc.ac
+	binDir := platform.GetBinDir(ac.ExecDir)
+	ctx, cancel := context.WithTimeout(context.Background(), configCheckTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, ac.SingboxPath, "check", "-c", configArgPath(binDir, configPath))
+	platform.PrepareCommand(cmd)
+	cmd.Dir = binDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && ctx.Err() == nil {
+			msg := strings.TrimSpace(string(output))
+			if msg == "" {
+				msg = err.Error()
+			}
+			return &ConfigCheckError{Output: msg}
+		}
+		return fmt.Errorf("failed to run sing-box check: %w", err)
+	}
+	return nil
+}
+
+// reloadClashAPIConfig re-reads Clash API settings and the default selector group from config.json
+func (svc *ProcessService) reloadClashAPIConfig(caller string) {
+	ac := svc.ac
+	if base, tok, err := api.LoadClashAPIConfig(ac.GetConfigPath()); err != nil {
+		log.Printf("%s: Clash API config error: %v", caller, err)
+		ac.ClashAPIBaseURL = ""
+		ac.ClashAPIToken = ""
+		ac.ClashAPIEnabled = false
+	} else {
+		ac.ClashAPIBaseURL = base
+		ac.ClashAPIToken = tok
+		ac.ClashAPIEnabled = true
+		RegisterSecret(tok)
+		log.Printf("%s: API config reloaded successfully", caller)
+	}
+
+	// Reload SelectedClashGroup from config
+	if ac.ClashAPIEnabled {
+		_, defaultSelector, err := GetSelectorGroupsFromConfig(ac.GetConfigPath())
+		if err != nil {
+			log.Printf("%s: Failed to get selector groups: %v", caller, err)
+			ac.SelectedClashGroup = "proxy-out" // Default fallback
+		} else {
+			ac.SelectedClashGroup = defaultSelector
+			log.Printf("%s: SelectedClashGroup reloaded: %s", caller, defaultSelector)
+		}
+	}
+}
+
+// configArgPath returns the config path passed to sing-box relative to its working directory (bin).
+// Profiles keep config.json in bin/profiles/<name>/, so the base name alone is not enough.
+func configArgPath(binDir, configPath string) string {
+	if rel, err := filepath.Rel(binDir, configPath); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return configPath
+}
+
 // CheckIfRunningAtStart checks if sing-box is already running at application start.
 // Shows a warning dialog if a running instance is detected.
 func (svc *ProcessService) CheckIfRunningAtStart() {
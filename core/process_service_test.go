@@ -0,0 +1,124 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"singbox-launcher/internal/constants"
+)
+
+// TestConfigArgPath tests config path passed to sing-box running in bin
+func TestConfigArgPath(t *testing.T) {
+	binDir := filepath.Join("app", "bin")
+
+	tests := []struct {
+		configPath string
+		want       string
+	}{
+		{filepath.Join(binDir, "config.json"), "config.json"},
+		{filepath.Join(binDir, "profiles", "work", "config.json"), filepath.Join("profiles", "work", "config.json")},
+		{filepath.Join("other", "config.json"), filepath.Join("other", "config.json")},
+	}
+
+	for _, tt := range tests {
+		if got := configArgPath(binDir, tt.configPath); got != tt.want {
+			t.Errorf("configArgPath(%q) = %q, want %q", tt.configPath, got, tt.want)
+		}
+	}
+}
+
+// TestCheckConfig tests config validation with a fake sing-box binary
+func TestCheckConfig(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake sing-box is a shell script")
+	}
+
+	execDir := t.TempDir()
+	binDir := filepath.Join(execDir, constants.BinDirName)
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fake `sing-box check -c <path>`: fails if the config contains "bad"
+	singboxPath := filepath.Join(binDir, "sing-box")
+	script := "#!/bin/sh\nif grep -q bad \"$3\"; then echo 'FATAL decode config: unknown field bad'; exit 1; fi\n"
+	if err := os.WriteFile(singboxPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewProcessService(&AppController{ExecDir: execDir, SingboxPath: singboxPath})
+	configPath := filepath.Join(binDir, "config.json")
+
+	if err := os.WriteFile(configPath, []byte(`{"outbounds": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.CheckConfig(configPath); err != nil {
+		t.Errorf("Expected valid config, got error: %v", err)
+	}
+
+	if err := os.WriteFile(configPath, []byte(`{"bad": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := svc.CheckConfig(configPath)
+	if err == nil || !strings.Contains(err.Error(), "unknown field bad") {
+		t.Errorf("Expected error with sing-box output, got: %v", err)
+	}
+}
+
+// TestReloadDoesNotBlock tests that Reload returns before the hot reload settles
+// and the core keeps running after it (fake sing-box ignores SIGHUP)
+func TestReloadDoesNotBlock(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake sing-box is a shell script")
+	}
+
+	ac, _ := newTestController(t)
+	if err := os.WriteFile(ac.ConfigPath, []byte(`{"outbounds": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	startFakeSingBox(t, ac)
+
+	start := time.Now()
+	if err := ac.ProcessService.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= reloadSettleTime {
+		t.Errorf("Reload blocked for %v", elapsed)
+	}
+
+	reloading := func() bool {
+		ac.CmdMutex.Lock()
+		defer ac.CmdMutex.Unlock()
+		return ac.ReloadInProgress
+	}
+	deadline := time.Now().Add(reloadSettleTime + 2*time.Second)
+	for reloading() && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if reloading() || !ac.RunningState.IsRunning() {
+		t.Errorf("Expected the reload to finish with sing-box running (reloading=%v)", reloading())
+	}
+}
+
+// startFakeSingBox starts a shell script as sing-box that ignores SIGHUP (a successful hot reload)
+// and stops it at the end of the test
+func startFakeSingBox(t *testing.T, ac *AppController) {
+	t.Helper()
+	script := "#!/bin/sh\nif [ \"$1\" = run ]; then trap '' HUP; exec sleep 30; fi\n"
+	if err := os.WriteFile(ac.SingboxPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	ac.ProcessService.Start(true)
+	t.Cleanup(ac.ProcessService.Stop)
+	deadline := time.Now().Add(5 * time.Second)
+	for !ac.RunningState.IsRunning() && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if !ac.RunningState.IsRunning() {
+		t.Fatal("Expected sing-box to be running")
+	}
+}
@@ -0,0 +1,238 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"singbox-launcher/internal/constants"
+	"singbox-launcher/internal/platform"
+)
+
+// DefaultProfileName is the name of the built-in profile that uses bin/config.json
+const DefaultProfileName = "default"
+
+// profileNamePattern restricts profile names to safe directory names
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 _.-]{0,63}$`)
+
+// ProfileManager manages named profiles (config sets).
+// The default profile uses bin/config.json, other profiles live in bin/profiles/<name>/.
+// Each profile directory owns its config.json (with @ParserConfig), subscription cache
+// and @ParserConfig backups, because they are stored next to config.json.
+type ProfileManager struct {
+	binDir string
+}
+
+// NewProfileManager creates a profile manager for the bin directory of execDir
+func NewProfileManager(execDir string) *ProfileManager {
+	return &ProfileManager{binDir: platform.GetBinDir(execDir)}
+}
+
+// profilesDir returns the directory containing non-default profiles
+func (pm *ProfileManager) profilesDir() string {
+	return filepath.Join(pm.binDir, constants.ProfilesDirName)
+}
+
+// ProfileDir returns the directory of a profile.
+// Returns an empty string for an invalid name (see CheckProfileName): it must not
+// resolve to a directory outside bin/profiles.
+func (pm *ProfileManager) ProfileDir(name string) string {
+	if name == "" || name == DefaultProfileName {
+		return pm.binDir
+	}
+	if CheckProfileName(name) != nil {
+		return ""
+	}
+	return filepath.Join(pm.profilesDir(), name)
+}
+
+// ConfigPath returns the config.json path of a profile (empty for an invalid name)
+func (pm *ProfileManager) ConfigPath(name string) string {
+	dir := pm.ProfileDir(name)
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, constants.ConfigFileName)
+}
+
+// CheckProfileName checks that name can refer to an existing profile: the default profile or
+// a directory name in bin/profiles. Names with path separators or "..", absolute paths
+// and other names that fail profileNamePattern are rejected.
+func CheckProfileName(name string) error {
+	if name == "" || name == DefaultProfileName || profileNamePattern.MatchString(name) {
+		return nil
+	}
+	return fmt.Errorf("invalid profile name '%s'", name)
+}
+
+// ValidateProfileName checks that name can be used for a new profile
+func ValidateProfileName(name string) error {
+	if strings.EqualFold(name, DefaultProfileName) {
+		return fmt.Errorf("profile name '%s' is reserved", DefaultProfileName)
+	}
+	if !profileNamePattern.MatchString(name) || strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+		return fmt.Errorf("invalid profile name '%s': use letters, digits, spaces, '.', '_' or '-' (up to 64 characters)", name)
+	}
+	return nil
+}
+
+// List returns all profile names, default first, then the others sorted by name
+func (pm *ProfileManager) List() ([]string, error) {
+	profiles := []string{DefaultProfileName}
+
+	entries, err := os.ReadDir(pm.profilesDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return profiles, nil
+		}
+		return profiles, fmt.Errorf("failed to read profiles directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && profileNamePattern.MatchString(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return append(profiles, names...), nil
+}
+
+// Exists reports whether a profile exists
+func (pm *ProfileManager) Exists(name string) bool {
+	if name == "" || name == DefaultProfileName {
+		return true
+	}
+	if CheckProfileName(name) != nil {
+		return false
+	}
+	info, err := os.Stat(pm.ProfileDir(name))
+	return err == nil && info.IsDir()
+}
+
+// Create creates an empty profile. Its config.json is generated from
+// bin/config_template.json by the Config Wizard.
+func (pm *ProfileManager) Create(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if pm.Exists(name) {
+		return fmt.Errorf("profile '%s' already exists", name)
+	}
+	if err := os.MkdirAll(pm.ProfileDir(name), 0755); err != nil {
+		return fmt.Errorf("failed to create profile directory: %w", err)
+	}
+	return nil
+}
+
+// Clone creates a new profile with a copy of source's config.json and subscription cache
+func (pm *ProfileManager) Clone(source, name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if !pm.Exists(source) {
+		return fmt.Errorf("profile '%s' does not exist", source)
+	}
+	if pm.Exists(name) {
+		return fmt.Errorf("profile '%s' already exists", name)
+	}
+
+	if err := pm.Create(name); err != nil {
+		return err
+	}
+
+	sourceDir := pm.ProfileDir(source)
+	targetDir := pm.ProfileDir(name)
+	if err := copyFile(filepath.Join(sourceDir, constants.ConfigFileName), filepath.Join(targetDir, constants.ConfigFileName)); err != nil && !os.IsNotExist(err) {
+		_ = os.RemoveAll(targetDir)
+		return fmt.Errorf("failed to copy config.json: %w", err)
+	}
+	if err := copyDir(filepath.Join(sourceDir, constants.SubscriptionCacheDirName), filepath.Join(targetDir, constants.SubscriptionCacheDirName)); err != nil && !os.IsNotExist(err) {
+		_ = os.RemoveAll(targetDir)
+		return fmt.Errorf("failed to copy subscription cache: %w", err)
+	}
+	return nil
+}
+
+// Rename renames a profile. The default profile cannot be renamed.
+func (pm *ProfileManager) Rename(oldName, newName string) error {
+	if oldName == "" || oldName == DefaultProfileName {
+		return fmt.Errorf("the default profile cannot be renamed")
+	}
+	if err := CheckProfileName(oldName); err != nil {
+		return err
+	}
+	if err := ValidateProfileName(newName); err != nil {
+		return err
+	}
+	if !pm.Exists(oldName) {
+		return fmt.Errorf("profile '%s' does not exist", oldName)
+	}
+	if pm.Exists(newName) {
+		return fmt.Errorf("profile '%s' already exists", newName)
+	}
+	if err := os.Rename(pm.ProfileDir(oldName), pm.ProfileDir(newName)); err != nil {
+		return fmt.Errorf("failed to rename profile: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a profile with all its files. The default profile cannot be deleted.
+func (pm *ProfileManager) Delete(name string) error {
+	if name == "" || name == DefaultProfileName {
+		return fmt.Errorf("the default profile cannot be deleted")
+	}
+	if err := CheckProfileName(name); err != nil {
+		return err
+	}
+	if !pm.Exists(name) {
+		return fmt.Errorf("profile '%s' does not exist", name)
+	}
+	if err := os.RemoveAll(pm.ProfileDir(name)); err != nil {
+		return fmt.Errorf("failed to delete profile: %w", err)
+	}
+	return nil
+}
+
+// copyFile copies a regular file, creating the target directory if needed
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// copyDir copies regular files of a flat directory
+func copyDir(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		if err := copyFile(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
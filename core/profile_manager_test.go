@@ -0,0 +1,192 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"singbox-launcher/internal/constants"
+)
+
+// TestValidateProfileName tests profile name validation
+func TestValidateProfileName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"work", false},
+		{"Home Wi-Fi_2.0", false},
+		{"default", true},
+		{"Default", true},
+		{"", true},
+		{"../escape", true},
+		{"trailing.", true},
+		{".hidden", true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateProfileName(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateProfileName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// TestProfileManager tests create, clone, rename and delete of profiles
+func TestProfileManager(t *testing.T) {
+	execDir := t.TempDir()
+	pm := NewProfileManager(execDir)
+	binDir := filepath.Join(execDir, constants.BinDirName)
+
+	if got := pm.ConfigPath(DefaultProfileName); got != filepath.Join(binDir, constants.ConfigFileName) {
+		t.Errorf("Default profile config path = %s", got)
+	}
+
+	profiles, err := pm.List()
+	if err != nil || !reflect.DeepEqual(profiles, []string{DefaultProfileName}) {
+		t.Fatalf("Expected only default profile, got %v (err: %v)", profiles, err)
+	}
+
+	// Default profile with config and subscription cache
+	if err := os.MkdirAll(filepath.Join(binDir, constants.SubscriptionCacheDirName), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pm.ConfigPath(DefaultProfileName), []byte(`{"outbounds": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(binDir, constants.SubscriptionCacheDirName, "abc.txt"), []byte("vless://x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		if err := pm.Create("work"); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if err := pm.Create("work"); err == nil {
+			t.Error("Expected error when creating existing profile")
+		}
+		if _, err := os.Stat(pm.ConfigPath("work")); !os.IsNotExist(err) {
+			t.Error("New profile must not have config.json")
+		}
+	})
+
+	t.Run("Clone", func(t *testing.T) {
+		if err := pm.Clone(DefaultProfileName, "home"); err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+		data, err := os.ReadFile(pm.ConfigPath("home"))
+		if err != nil || string(data) != `{"outbounds": []}` {
+			t.Errorf("config.json not copied: %q (err: %v)", data, err)
+		}
+		if _, err := os.Stat(filepath.Join(pm.ProfileDir("home"), constants.SubscriptionCacheDirName, "abc.txt")); err != nil {
+			t.Errorf("Subscription cache not copied: %v", err)
+		}
+		if err := pm.Clone("missing", "other"); err == nil {
+			t.Error("Expected error when cloning missing profile")
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		profiles, err := pm.List()
+		want := []string{DefaultProfileName, "home", "work"}
+		if err != nil || !reflect.DeepEqual(profiles, want) {
+			t.Errorf("List() = %v, want %v (err: %v)", profiles, want, err)
+		}
+	})
+
+	t.Run("Rename", func(t *testing.T) {
+		if err := pm.Rename(DefaultProfileName, "main"); err == nil {
+			t.Error("Expected error when renaming default profile")
+		}
+		if err := pm.Rename("work", "home"); err == nil {
+			t.Error("Expected error when renaming to existing profile")
+		}
+		if err := pm.Rename("work", "office"); err != nil {
+			t.Fatalf("Rename failed: %v", err)
+		}
+		if pm.Exists("work") || !pm.Exists("office") {
+			t.Error("Profile was not renamed")
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := pm.Delete(DefaultProfileName); err == nil {
+			t.Error("Expected error when deleting default profile")
+		}
+		if err := pm.Delete("office"); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		if pm.Exists("office") {
+			t.Error("Profile still exists after delete")
+		}
+	})
+}
+
+// TestSwitchProfileConcurrentReads tests that the active profile and its config path change together
+// while other goroutines read them (run with -race)
+func TestSwitchProfileConcurrentReads(t *testing.T) {
+	ac, _ := newTestController(t)
+	if err := ac.CreateProfile("work"); err != nil {
+		t.Fatalf("CreateProfile failed: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			_ = ac.GetConfigPath()
+			_ = ac.GetActiveProfile()
+		}
+	}()
+	for _, name := range []string{"work", DefaultProfileName, "work"} {
+		if err := ac.SwitchProfile(name); err != nil {
+			t.Fatalf("SwitchProfile(%q) failed: %v", name, err)
+		}
+	}
+	<-done
+
+	if ac.GetActiveProfile() != "work" || ac.GetConfigPath() != ac.Profiles.ConfigPath("work") {
+		t.Errorf("Active profile %q with config %s", ac.GetActiveProfile(), ac.GetConfigPath())
+	}
+}
+
+// TestProfileNamesOutsideProfilesDir tests that names of existing profiles cannot point outside bin/profiles
+func TestProfileNamesOutsideProfilesDir(t *testing.T) {
+	ac, _ := newTestController(t)
+	pm := ac.Profiles
+	if err := os.MkdirAll(filepath.Join(pm.profilesDir(), "work"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	outside := t.TempDir()
+
+	for _, name := range []string{"..", "../..", "../x", "work/../..", outside} {
+		if err := CheckProfileName(name); err == nil {
+			t.Errorf("CheckProfileName(%q) accepted the name", name)
+		}
+		if dir := pm.ProfileDir(name); dir != "" {
+			t.Errorf("ProfileDir(%q) = %s", name, dir)
+		}
+		if pm.Exists(name) {
+			t.Errorf("Exists(%q) = true", name)
+		}
+		if err := pm.Delete(name); err == nil {
+			t.Errorf("Delete(%q) succeeded", name)
+		}
+		if err := pm.Rename(name, "renamed"); err == nil {
+			t.Errorf("Rename(%q) succeeded", name)
+		}
+		if err := ac.SwitchProfile(name); err == nil {
+			t.Errorf("SwitchProfile(%q) succeeded", name)
+		}
+	}
+	if ac.GetActiveProfile() != DefaultProfileName {
+		t.Errorf("Active profile changed to %q", ac.GetActiveProfile())
+	}
+	if _, err := os.Stat(pm.binDir); err != nil {
+		t.Errorf("bin directory was removed: %v", err)
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("Directory outside bin was removed: %v", err)
+	}
+}
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"singbox-launcher/api"
+)
+
+// maxRecentProxies is the number of recently switched proxies kept in launcher settings
+const maxRecentProxies = 5
+
+// latencyRangePattern matches latency terms of a search query: "<300", ">=100ms", "100-500"
+var latencyRangePattern = regexp.MustCompile(`^(?:([<>]=?)(\d+)|(\d+)-(\d+))(?:ms)?$`)
+
+// ProxyFilter is a parsed search query of the proxy list. Terms are separated by spaces and all must match:
+//   - a latency range ("<300", ">100", "100-500", optionally with "ms") matches tested proxies by delay;
+//   - any other term matches a part of the tag (case-insensitive) or the country of its flag ("de" matches "🇩🇪 Berlin").
+type ProxyFilter struct {
+	terms    []string
+	minDelay int64 // Inclusive; 0 = no bound
+	maxDelay int64 // Inclusive; 0 = no bound
+}
+
+// ParseProxyFilter parses a search query; an empty query matches all proxies
+func ParseProxyFilter(query string) ProxyFilter {
+	var f ProxyFilter
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		m := latencyRangePattern.FindStringSubmatch(term)
+		if m == nil {
+			f.terms = append(f.terms, term)
+			continue
+		}
+		if m[1] == "" {
+			low, _ := strconv.ParseInt(m[3], 10, 64)
+			high, _ := strconv.ParseInt(m[4], 10, 64)
+			if low > high {
+				low, high = high, low
+			}
+			f.minDelay, f.maxDelay = max(low, 1), high
+			continue
+		}
+		value, _ := strconv.ParseInt(m[2], 10, 64)
+		switch m[1] {
+		case "<":
+			f.maxDelay = max(value-1, 1)
+		case "<=":
+			f.maxDelay = max(value, 1)
+		case ">":
+			f.minDelay = value + 1
+		case ">=":
+			f.minDelay = value
+		}
+	}
+	return f
+}
+
+// Empty reports whether the filter matches all proxies
+func (f ProxyFilter) Empty() bool {
+	return len(f.terms) == 0 && f.minDelay == 0 && f.maxDelay == 0
+}
+
+// Match reports whether a proxy matches the filter
+func (f ProxyFilter) Match(p api.ProxyInfo) bool {
+	if f.minDelay > 0 || f.maxDelay > 0 {
+		if p.Delay <= 0 || p.Delay < f.minDelay || (f.maxDelay > 0 && p.Delay > f.maxDelay) {
+			return false
+		}
+	}
+	if len(f.terms) == 0 {
+		return true
+	}
+	name := strings.ToLower(p.Name)
+	country := strings.ToLower(ProxyCountry(p.Name))
+	for _, term := range f.terms {
+		if !strings.Contains(name, term) && term != country {
+			return false
+		}
+	}
+	return true
+}
+
+// ProxyCountry returns the ISO country code of the first flag emoji in a proxy tag ("🇩🇪 Berlin" -> "DE"),
+// or "" if the tag has no flag
+func ProxyCountry(name string) string {
+	runes := []rune(name)
+	for i := 0; i+1 < len(runes); i++ {
+		if isRegionalIndicator(runes[i]) && isRegionalIndicator(runes[i+1]) {
+			return string([]rune{'A' + runes[i] - 0x1F1E6, 'A' + runes[i+1] - 0x1F1E6})
+		}
+	}
+	return ""
+}
+
+// isRegionalIndicator reports whether r is a regional indicator symbol (flag emojis are pairs of them)
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// FilterProxies returns proxies matching f with favorites pinned at the top;
+// the order of ProxiesList is kept within favorites and the rest
+func FilterProxies(proxies []api.ProxyInfo, f ProxyFilter, favorites []string) []api.ProxyInfo {
+	pinned := make(map[string]bool, len(favorites))
+	for _, name := range favorites {
+		pinned[name] = true
+	}
+	var top, rest []api.ProxyInfo
+	for _, p := range proxies {
+		switch {
+		case !f.Match(p):
+		case pinned[p.Name]:
+			top = append(top, p)
+		default:
+			rest = append(rest, p)
+		}
+	}
+	return append(top, rest...)
+}
+
+// QuickSwitchProxies picks proxies for quick switching (tray menu): favorites and the active proxy,
+// then the fastest tested proxies up to n in total (favorites are never dropped). If no proxy was
+// tested yet, the first proxies of the list are used. Favorites keep the list order, the rest is by delay.
+func QuickSwitchProxies(proxies []api.ProxyInfo, active string, favorites []string, n int) []api.ProxyInfo {
+	var quick, rest []api.ProxyInfo
+	tested := false
+	for _, p := range proxies {
+		if containsString(favorites, p.Name) {
+			quick = append(quick, p)
+		} else {
+			rest = append(rest, p)
+		}
+		tested = tested || p.Delay > 0
+	}
+	for i, p := range rest {
+		if p.Name == active {
+			quick = append(quick, p)
+			rest = append(rest[:i:i], rest[i+1:]...)
+			break
+		}
+	}
+	if tested {
+		var fastest []api.ProxyInfo
+		for _, p := range rest {
+			if p.Delay > 0 {
+				fastest = append(fastest, p)
+			}
+		}
+		SortProxies(fastest, true)
+		rest = fastest
+	}
+	for _, p := range rest {
+		if len(quick) >= n {
+			break
+		}
+		quick = append(quick, p)
+	}
+	return quick
+}
+
+// FavoriteProxies returns tags of proxies pinned at the top of the proxy list
+func (ac *AppController) FavoriteProxies() []string {
+	var favorites []string
+	ac.Settings.Get(func(s *LauncherSettings) { favorites = append(favorites, s.FavoriteProxies...) })
+	return favorites
+}
+
+// IsFavoriteProxy reports whether a proxy is pinned
+func (ac *AppController) IsFavoriteProxy(name string) bool {
+	return containsString(ac.FavoriteProxies(), name)
+}
+
+// ToggleFavoriteProxy pins or unpins a proxy and returns whether it is pinned now
+func (ac *AppController) ToggleFavoriteProxy(name string) (bool, error) {
+	pinned := false
+	err := ac.Settings.Update(func(s *LauncherSettings) {
+		for i, favorite := range s.FavoriteProxies {
+			if favorite == name {
+				s.FavoriteProxies = append(s.FavoriteProxies[:i:i], s.FavoriteProxies[i+1:]...)
+				return
+			}
+		}
+		s.FavoriteProxies = append(s.FavoriteProxies, name)
+		pinned = true
+	})
+	return pinned, err
+}
+
+// RecentProxies returns tags of recently switched proxies, the latest first
+func (ac *AppController) RecentProxies() []string {
+	var recent []string
+	ac.Settings.Get(func(s *LauncherSettings) { recent = append(recent, s.RecentProxies...) })
+	return recent
+}
+
+// addRecentProxy moves a switched proxy to the front of RecentProxies
+func (ac *AppController) addRecentProxy(name string) {
+	err := ac.Settings.Update(func(s *LauncherSettings) {
+		recent := []string{name}
+		for _, r := range s.RecentProxies {
+			if r != name && len(recent) < maxRecentProxies {
+				recent = append(recent, r)
+			}
+		}
+		s.RecentProxies = recent
+	})
+	if err != nil {
+		log.Printf("SelectProxy: Failed to save recent proxies: %v", err)
+	}
+}
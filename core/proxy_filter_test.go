@@ -0,0 +1,125 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"singbox-launcher/api"
+)
+
+// TestFilterProxies tests search by tag, country and latency range with favorites pinned
+func TestFilterProxies(t *testing.T) {
+	proxies := []api.ProxyInfo{
+		{Name: "🇩🇪 Berlin", Delay: 120},
+		{Name: "🇳🇱 Amsterdam", Delay: 450},
+		{Name: "DE-Frankfurt", Delay: api.DelayFailed},
+		{Name: "🇯🇵 Tokyo"},
+		{Name: "🇩🇪 Munich", Delay: 900},
+	}
+	names := func(list []api.ProxyInfo) string {
+		var s []string
+		for _, p := range list {
+			s = append(s, p.Name)
+		}
+		return strings.Join(s, ", ")
+	}
+	tests := []struct {
+		query     string
+		favorites []string
+		want      string
+	}{
+		{"", nil, "🇩🇪 Berlin, 🇳🇱 Amsterdam, DE-Frankfurt, 🇯🇵 Tokyo, 🇩🇪 Munich"},
+		{"", []string{"🇩🇪 Munich", "gone"}, "🇩🇪 Munich, 🇩🇪 Berlin, 🇳🇱 Amsterdam, DE-Frankfurt, 🇯🇵 Tokyo"},
+		{"ams", nil, "🇳🇱 Amsterdam"},
+		{"DE", nil, "🇩🇪 Berlin, DE-Frankfurt, 🇩🇪 Munich"},
+		{"de <500", nil, "🇩🇪 Berlin"},
+		{"<500ms", []string{"🇳🇱 Amsterdam"}, "🇳🇱 Amsterdam, 🇩🇪 Berlin"},
+		{">=450", nil, "🇳🇱 Amsterdam, 🇩🇪 Munich"},
+		{"500-100", nil, "🇩🇪 Berlin, 🇳🇱 Amsterdam"},
+		{"tokyo 1-1000", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := names(FilterProxies(proxies, ParseProxyFilter(tt.query), tt.favorites)); got != tt.want {
+			t.Errorf("%q, favorites %v: got %q, want %q", tt.query, tt.favorites, got, tt.want)
+		}
+	}
+	if !ParseProxyFilter("  ").Empty() || ParseProxyFilter("<300").Empty() {
+		t.Error("Unexpected Empty()")
+	}
+	if c := ProxyCountry("Fast 🇺🇸 US-1"); c != "US" {
+		t.Errorf("ProxyCountry = %q", c)
+	}
+}
+
+// TestQuickSwitchProxies tests picking favorites, the active proxy and the fastest proxies
+func TestQuickSwitchProxies(t *testing.T) {
+	proxies := []api.ProxyInfo{
+		{Name: "a", Delay: 500},
+		{Name: "b", Delay: 100},
+		{Name: "c", Delay: api.DelayFailed},
+		{Name: "d"},
+		{Name: "e", Delay: 300},
+		{Name: "f", Delay: 50},
+	}
+	names := func(list []api.ProxyInfo) string {
+		var s []string
+		for _, p := range list {
+			s = append(s, p.Name)
+		}
+		return strings.Join(s, ",")
+	}
+	tests := []struct {
+		active    string
+		favorites []string
+		n         int
+		want      string
+	}{
+		{"", nil, 3, "f,b,e"},
+		{"c", []string{"e", "d"}, 4, "d,e,c,f"},
+		{"", []string{"a", "b", "c"}, 2, "a,b,c"},
+		{"f", nil, 10, "f,b,e,a"},
+	}
+	for _, tt := range tests {
+		if got := names(QuickSwitchProxies(proxies, tt.active, tt.favorites, tt.n)); got != tt.want {
+			t.Errorf("active %q, favorites %v, n %d: got %s, want %s", tt.active, tt.favorites, tt.n, got, tt.want)
+		}
+	}
+
+	// Nothing tested: the first proxies of the list
+	untested := []api.ProxyInfo{{Name: "x"}, {Name: "y"}, {Name: "z"}}
+	if got := names(QuickSwitchProxies(untested, "z", nil, 2)); got != "z,x" {
+		t.Errorf("Untested: got %s", got)
+	}
+}
+
+// TestFavoriteAndRecentProxies tests pinning proxies and the recent list kept by SelectProxy
+func TestFavoriteAndRecentProxies(t *testing.T) {
+	ac, _ := newTestController(t)
+	newGroupsFakeServer(t, ac)
+
+	if pinned, err := ac.ToggleFavoriteProxy("de-1"); err != nil || !pinned || !ac.IsFavoriteProxy("de-1") {
+		t.Fatalf("Pin: %v, %v", pinned, err)
+	}
+	ac.ToggleFavoriteProxy("nl-2")
+	if pinned, err := ac.ToggleFavoriteProxy("de-1"); err != nil || pinned {
+		t.Fatalf("Unpin: %v, %v", pinned, err)
+	}
+	if favorites := ac.FavoriteProxies(); len(favorites) != 1 || favorites[0] != "nl-2" {
+		t.Errorf("Favorites = %v", favorites)
+	}
+
+	for _, proxy := range []string{"de-1", "nl-2", "auto", "de-1", "nl-2", "de-1"} {
+		if err := ac.SelectProxy("proxy-out", proxy); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if recent := strings.Join(ac.RecentProxies(), ","); recent != "de-1,nl-2,auto" {
+		t.Errorf("Recent = %s", recent)
+	}
+	for i := 0; i < 10; i++ {
+		ac.addRecentProxy(string(rune('a' + i)))
+	}
+	if recent := ac.RecentProxies(); len(recent) != maxRecentProxies || recent[0] != "j" {
+		t.Errorf("Recent = %v", recent)
+	}
+}
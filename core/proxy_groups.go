@@ -0,0 +1,262 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"singbox-launcher/api"
+	"singbox-launcher/internal/constants"
+)
+
+// GlobalGroupName is the group sing-box adds to the Clash API with all outbounds
+const GlobalGroupName = "GLOBAL"
+
+// ProxyGroup is a proxy group from the Clash API with its members
+type ProxyGroup struct {
+	Name    string
+	Type    string          // "Selector", "URLTest", ...
+	Now     string          // Active member; chosen by sing-box unless the group is Selectable
+	Members []api.ProxyInfo // In config order; nested groups are members too
+}
+
+// Selectable reports whether the active member can be switched (selector groups)
+func (g *ProxyGroup) Selectable() bool {
+	return g.Type == "Selector"
+}
+
+// ProxyGroups returns all proxy groups from the Clash API sorted by name, GLOBAL last
+func (ac *AppController) ProxyGroups(ctx context.Context) ([]ProxyGroup, error) {
+	if !ac.ClashAPIEnabled {
+		return nil, fmt.Errorf("Clash API is disabled in %s", ac.GetConfigPath())
+	}
+	all, err := ac.ClashClient().Proxies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []ProxyGroup
+	for name, p := range all {
+		if !p.IsGroup() {
+			continue
+		}
+		group := ProxyGroup{Name: name, Type: p.Type, Now: p.Now, Members: make([]api.ProxyInfo, 0, len(p.All))}
+		for _, member := range p.All {
+			info := api.ProxyInfo{Name: member}
+			if m, ok := all[member]; ok {
+				info.Type = m.Type
+				info.Delay = m.LastDelay()
+			}
+			group.Members = append(group.Members, info)
+		}
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if (groups[i].Name == GlobalGroupName) != (groups[j].Name == GlobalGroupName) {
+			return groups[j].Name == GlobalGroupName
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups, nil
+}
+
+// ProxyGroupRoots returns names of groups that are not members of another group (GLOBAL aside):
+// the top level of the group tree. Nested groups are found through Members.
+func ProxyGroupRoots(groups []ProxyGroup) []string {
+	nested := make(map[string]bool)
+	for _, g := range groups {
+		if g.Name == GlobalGroupName {
+			continue
+		}
+		for _, m := range g.Members {
+			nested[m.Name] = true
+		}
+	}
+	var roots []string
+	for _, g := range groups {
+		if !nested[g.Name] {
+			roots = append(roots, g.Name)
+		}
+	}
+	return roots
+}
+
+// ProxySelection is a manual proxy choice of a selector group
+type ProxySelection struct {
+	Proxy  string    `json:"proxy"`
+	Server string    `json:"server,omitempty"` // ConfigOutbound.Identity, finds the proxy after its tag changes
+	Time   time.Time `json:"time"`
+}
+
+// proxySelectionsMutex serializes read-modify-write of selection files
+var proxySelectionsMutex sync.Mutex
+
+// ProxySelections stores manual proxy choices per selector group, so they survive core restarts
+// and config regeneration.
+// The file lives next to config.json, so each profile keeps its own choices.
+type ProxySelections struct {
+	path string
+}
+
+// NewProxySelections creates a store bound to the directory of configPath.
+// Returns nil if configPath is empty; all methods are safe to call on a nil store.
+func NewProxySelections(configPath string) *ProxySelections {
+	if configPath == "" {
+		return nil
+	}
+	return &ProxySelections{path: filepath.Join(filepath.Dir(configPath), constants.ProxySelectionsFileName)}
+}
+
+// Load returns saved selections by group; a missing or broken file means no selections
+func (s *ProxySelections) Load() map[string]ProxySelection {
+	if s == nil {
+		return nil
+	}
+	proxySelectionsMutex.Lock()
+	defer proxySelectionsMutex.Unlock()
+	return s.load()
+}
+
+func (s *ProxySelections) load() map[string]ProxySelection {
+	selections := make(map[string]ProxySelection)
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("ProxySelections: Failed to read %s: %v", s.path, err)
+		}
+		return selections
+	}
+	if err := json.Unmarshal(data, &selections); err != nil {
+		log.Printf("ProxySelections: Failed to parse %s: %v", s.path, err)
+	}
+	return selections
+}
+
+// Set saves the proxy chosen in group; a zero Time is set to now
+func (s *ProxySelections) Set(group string, selection ProxySelection) error {
+	if s == nil {
+		return nil
+	}
+	proxySelectionsMutex.Lock()
+	defer proxySelectionsMutex.Unlock()
+
+	if selection.Time.IsZero() {
+		selection.Time = time.Now()
+	}
+	selections := s.load()
+	selections[group] = selection
+	data, err := json.MarshalIndent(selections, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal proxy selections: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write proxy selections: %w", err)
+	}
+	return nil
+}
+
+// ProxySelections returns the selection store of the current config
+func (ac *AppController) ProxySelections() *ProxySelections {
+	return NewProxySelections(ac.GetConfigPath())
+}
+
+// saveProxySelection remembers a manual choice with the server identity from config.json
+func (ac *AppController) saveProxySelection(group, proxy string) {
+	selection := ProxySelection{Proxy: proxy}
+	if outbounds, err := GetOutboundsFromConfig(ac.GetConfigPath()); err == nil {
+		selection.Server = outbounds[proxy].Identity
+	}
+	if err := ac.ProxySelections().Set(group, selection); err != nil {
+		log.Printf("SelectProxy: Failed to save selection: %v", err)
+	}
+}
+
+// RestoreProxySelections switches selector groups back to the proxies saved by SelectProxy
+// (sing-box starts with the defaults of config.json). A proxy whose tag changed after a subscription
+// update is found by its server; if it is gone, the group falls back to its default from config.json
+// (preferredDefault). Groups that no longer exist are skipped. Returns the number of switched groups.
+func (ac *AppController) RestoreProxySelections(ctx context.Context) (int, error) {
+	store := ac.ProxySelections()
+	selections := store.Load()
+	if len(selections) == 0 {
+		return 0, nil
+	}
+	client := ac.ClashClient()
+	all, err := client.Proxies(ctx)
+	if err != nil {
+		return 0, err
+	}
+	outbounds, err := GetOutboundsFromConfig(ac.GetConfigPath())
+	if err != nil {
+		log.Printf("RestoreProxySelections: %v", err)
+	}
+
+	restored := 0
+	for group, selection := range selections {
+		g, ok := all[group]
+		if !ok || g.Type != "Selector" {
+			continue
+		}
+		target := resolveProxySelection(g, selection, outbounds)
+		switch {
+		case target == "":
+			log.Printf("RestoreProxySelections: '%s' is no longer in group '%s' and there is no default, keeping %s", selection.Proxy, group, g.Now)
+			continue
+		case target != selection.Proxy && selection.Server != "" && outbounds[target].Identity == selection.Server:
+			// Same server under a new tag: remember the new tag
+			log.Printf("RestoreProxySelections: '%s' of group '%s' is now '%s'", selection.Proxy, group, target)
+			selection.Proxy = target
+			if err := store.Set(group, selection); err != nil {
+				log.Printf("RestoreProxySelections: Failed to update selection: %v", err)
+			}
+		case target != selection.Proxy:
+			// The choice is kept: the node may come back with the next subscription update
+			log.Printf("RestoreProxySelections: '%s' is no longer in group '%s', using default %s", selection.Proxy, group, target)
+		}
+		if target == g.Now {
+			continue
+		}
+		if err := client.SwitchProxy(ctx, group, target); err != nil {
+			log.Printf("RestoreProxySelections: Failed to switch '%s' to %s: %v", group, target, err)
+			continue
+		}
+		log.Printf("RestoreProxySelections: Restored '%s' -> %s", group, target)
+		restored++
+	}
+	return restored, nil
+}
+
+// resolveProxySelection returns the member of group g to restore: the saved tag, a member with
+// the saved server, or the default of the group from config.json ("" if none of them exists)
+func resolveProxySelection(g *api.Proxy, selection ProxySelection, outbounds map[string]ConfigOutbound) string {
+	if containsString(g.All, selection.Proxy) {
+		return selection.Proxy
+	}
+	if selection.Server != "" {
+		for _, member := range g.All {
+			if outbounds[member].Identity == selection.Server {
+				return member
+			}
+		}
+	}
+	if def := outbounds[g.Name].Default; containsString(g.All, def) {
+		return def
+	}
+	return ""
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"singbox-launcher/api/apitest"
+)
+
+// newGroupsFakeServer creates a fake Clash API with a selector containing a nested urltest group
+func newGroupsFakeServer(t *testing.T, ac *AppController) *apitest.FakeServer {
+	t.Helper()
+	fake := apitest.NewFakeServer("secret")
+	t.Cleanup(fake.Close)
+	for _, name := range []string{"de-1", "nl-2", "jp-3"} {
+		fake.AddProxy(name, "VLESS", 100)
+	}
+	fake.AddProxy("direct", "Direct", 1)
+	fake.AddGroup("auto", "URLTest", "nl-2", "jp-3")
+	fake.AddGroup("proxy-out", "Selector", "auto", "de-1", "nl-2")
+	fake.AddGroup(GlobalGroupName, "Selector", "direct", "auto", "proxy-out", "de-1", "nl-2", "jp-3")
+	ac.ClashAPIBaseURL, ac.ClashAPIToken, ac.ClashAPIEnabled = fake.URL, fake.Secret, true
+	return fake
+}
+
+// TestProxyGroups tests the group tree from /proxies
+func TestProxyGroups(t *testing.T) {
+	ac, _ := newTestController(t)
+	newGroupsFakeServer(t, ac)
+
+	groups, err := ac.ProxyGroups(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 3 || groups[0].Name != "auto" || groups[1].Name != "proxy-out" || groups[2].Name != GlobalGroupName {
+		t.Fatalf("Unexpected groups %+v", groups)
+	}
+	auto, out := groups[0], groups[1]
+	if auto.Selectable() || auto.Now != "nl-2" || !out.Selectable() || out.Members[0].Name != "auto" || out.Members[0].Type != "URLTest" {
+		t.Errorf("Unexpected group details %+v, %+v", auto, out)
+	}
+	// auto is nested in proxy-out; GLOBAL does not make groups nested
+	if roots := ProxyGroupRoots(groups); len(roots) != 2 || roots[0] != "proxy-out" || roots[1] != GlobalGroupName {
+		t.Errorf("Roots = %v", roots)
+	}
+}
+
+// TestRestoreProxySelections tests that manual choices are saved and re-applied after a restart
+func TestRestoreProxySelections(t *testing.T) {
+	ac, _ := newTestController(t)
+	fake := newGroupsFakeServer(t, ac)
+
+	if err := ac.SelectProxy("proxy-out", "de-1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := ac.ProxySelections().Load()["proxy-out"].Proxy; got != "de-1" {
+		t.Fatalf("Saved selection = %q", got)
+	}
+	if err := ac.ProxySelections().Set("auto", ProxySelection{Proxy: "jp-3"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ac.ProxySelections().Set("removed-group", ProxySelection{Proxy: "de-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// sing-box restarted with the default of config.json
+	if err := fake.Client().SwitchProxy(context.Background(), "proxy-out", "auto"); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := ac.RestoreProxySelections(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only selectors are switched; missing groups are skipped
+	if restored != 1 || fake.Now("proxy-out") != "de-1" || fake.Now("auto") != "nl-2" {
+		t.Errorf("Restored %d, proxy-out = %s, auto = %s", restored, fake.Now("proxy-out"), fake.Now("auto"))
+	}
+
+	// A proxy that disappeared from its group is not restored without a default in config.json
+	if err := ac.ProxySelections().Set("proxy-out", ProxySelection{Proxy: "gone"}); err != nil {
+		t.Fatal(err)
+	}
+	if restored, err := ac.RestoreProxySelections(context.Background()); err != nil || restored != 0 || fake.Now("proxy-out") != "de-1" {
+		t.Errorf("Restored %d (%v), proxy-out = %s", restored, err, fake.Now("proxy-out"))
+	}
+}
+
+// TestRestoreProxySelectionsAfterUpdate tests restoring after a subscription update renamed or removed nodes
+func TestRestoreProxySelectionsAfterUpdate(t *testing.T) {
+	ac, _ := newTestController(t)
+	writeConfig := func(config string) {
+		t.Helper()
+		if err := os.WriteFile(ac.ConfigPath, []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig(`{"outbounds": [
+		{"type": "selector", "tag": "proxy-out", "outbounds": ["auto", "de-1", "nl-2"], "default": "nl-2"},
+		{"type": "vless", "tag": "de-1", "server": "de.example.com", "server_port": 443, "uuid": "u1"},
+		{"type": "vless", "tag": "nl-2", "server": "nl.example.com", "server_port": 443, "uuid": "u2"}
+	]}`)
+	fake := newGroupsFakeServer(t, ac)
+	if err := ac.SelectProxy("proxy-out", "de-1"); err != nil {
+		t.Fatal(err)
+	}
+	saved := ac.ProxySelections().Load()["proxy-out"]
+	if saved.Proxy != "de-1" || saved.Server == "" {
+		t.Fatalf("Saved selection %+v", saved)
+	}
+
+	// The update renamed de-1; sing-box restarted with the default
+	writeConfig(`{"outbounds": [
+		{"type": "selector", "tag": "proxy-out", "outbounds": ["auto", "🇩🇪 Germany", "nl-2"], "default": "nl-2"},
+		{"type": "vless", "tag": "🇩🇪 Germany", "server": "de.example.com", "server_port": 443, "uuid": "u1"},
+		{"type": "vless", "tag": "nl-2", "server": "nl.example.com", "server_port": 443, "uuid": "u2"}
+	]}`)
+	fake = newGroupsFakeServer(t, ac)
+	fake.AddProxy("🇩🇪 Germany", "VLESS", 100)
+	fake.AddGroup("proxy-out", "Selector", "nl-2", "auto", "🇩🇪 Germany")
+	if restored, err := ac.RestoreProxySelections(context.Background()); err != nil || restored != 1 || fake.Now("proxy-out") != "🇩🇪 Germany" {
+		t.Errorf("Renamed node: restored %d (%v), proxy-out = %s", restored, err, fake.Now("proxy-out"))
+	}
+	if saved := ac.ProxySelections().Load()["proxy-out"]; saved.Proxy != "🇩🇪 Germany" {
+		t.Errorf("The new tag is not saved: %+v", saved)
+	}
+
+	// The node is gone: fall back to the default of the selector (preferredDefault)
+	writeConfig(`{"outbounds": [
+		{"type": "selector", "tag": "proxy-out", "outbounds": ["auto", "nl-2"], "default": "nl-2"},
+		{"type": "vless", "tag": "nl-2", "server": "nl.example.com", "server_port": 443, "uuid": "u2"}
+	]}`)
+	fake = newGroupsFakeServer(t, ac)
+	fake.AddGroup("proxy-out", "Selector", "auto", "nl-2")
+	if restored, err := ac.RestoreProxySelections(context.Background()); err != nil || restored != 1 || fake.Now("proxy-out") != "nl-2" {
+		t.Errorf("Removed node: restored %d (%v), proxy-out = %s", restored, err, fake.Now("proxy-out"))
+	}
+	if saved := ac.ProxySelections().Load()["proxy-out"]; saved.Proxy != "🇩🇪 Germany" {
+		t.Errorf("The choice must be kept for the next update: %+v", saved)
+	}
+}
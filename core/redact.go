@@ -0,0 +1,101 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// redactedMask replaces secrets in log output
+const redactedMask = "***"
+
+// minRegisteredSecretLength protects logs from masking of short common words
+const minRegisteredSecretLength = 6
+
+// redactionDisabled is set by the "debug_unredacted_logs" launcher setting
+var redactionDisabled atomic.Bool
+
+// registeredSecrets are exact values masked everywhere (Clash API secret, control API token)
+var registeredSecrets struct {
+	sync.RWMutex
+	values map[string]bool
+}
+
+// redactionRule replaces matches of pattern with replacement (regexp.Expand syntax)
+type redactionRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// secretKeys are names of fields, query parameters and settings that hold secrets
+const secretKeys = `password|passwd|pass|secret|token|access_token|auth_str|auth_key|uuid|private_key|public_key|` +
+	`pre_shared_key|psk|short_id|pbk|sid|obfs-password|api_key|access_key|secret_key`
+
+// redactionRules are applied in order: whole links first, then keys with values, then bare values
+var redactionRules = []redactionRule{
+	// vmess://<base64 JSON with uuid>
+	{regexp.MustCompile(`(?i)\b(vmess://)[A-Za-z0-9+/=_-]{8,}`), "${1}" + redactedMask},
+	// Credentials before @ in share links and URLs: vless://uuid@, ss://base64@, trojan://password@, https://user:pass@
+	{regexp.MustCompile(`(?i)\b([a-z][a-z0-9+.-]*://)[^/@\s"'<>]+@`), "${1}" + redactedMask + "@"},
+	// JSON fields: "password": "..."
+	{regexp.MustCompile(`(?i)("(?:` + secretKeys + `)"\s*:\s*")[^"]*(")`), "${1}" + redactedMask + "${2}"},
+	// Query parameters and key=value / key: value pairs: ?token=..., &pbk=..., password=..., Secret: ...
+	{regexp.MustCompile(`(?i)((?:^|[?&\s,;({\[])(?:` + secretKeys + `)\s*[=:]\s*)([^\s&#"',;)}\]]+)`), "${1}" + redactedMask},
+	// Authorization headers
+	{regexp.MustCompile(`(?i)(bearer\s+)[^\s"',]+`), "${1}" + redactedMask},
+	// Subscription tokens in URL paths: https://host/sub/AbCdEf0123456789xyz
+	{regexp.MustCompile(`(https?://[^/\s"'<>]+/(?:[^\s?#"'<>]*/)?)([A-Za-z0-9_-]{16,})\b`), "${1}" + redactedMask},
+	// Bare UUIDs (VLESS/VMess/TUIC user ids)
+	{regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`), redactedMask},
+}
+
+// Redact masks secrets in log text: credentials of share links and URLs, subscription tokens,
+// UUIDs, passwords and keys in JSON or key=value form, bearer tokens and registered secrets.
+// Returns text unchanged when redaction is disabled by the debug setting.
+func Redact(text string) string {
+	if redactionDisabled.Load() {
+		return text
+	}
+	return redactAlways(text)
+}
+
+// redactAlways masks secrets like Redact, ignoring the debug setting (for data leaving the machine)
+func redactAlways(text string) string {
+	if text == "" {
+		return text
+	}
+	registeredSecrets.RLock()
+	for secret := range registeredSecrets.values {
+		text = strings.ReplaceAll(text, secret, redactedMask)
+	}
+	registeredSecrets.RUnlock()
+	for _, rule := range redactionRules {
+		text = rule.pattern.ReplaceAllString(text, rule.replacement)
+	}
+	return text
+}
+
+// RegisterSecret makes Redact mask every occurrence of secret (values shorter than
+// minRegisteredSecretLength are ignored)
+func RegisterSecret(secret string) {
+	if len(secret) < minRegisteredSecretLength {
+		return
+	}
+	registeredSecrets.Lock()
+	defer registeredSecrets.Unlock()
+	if registeredSecrets.values == nil {
+		registeredSecrets.values = make(map[string]bool)
+	}
+	registeredSecrets.values[secret] = true
+}
+
+// SetLogRedaction enables or disables Redact for the whole process
+func SetLogRedaction(enabled bool) {
+	redactionDisabled.Store(!enabled)
+}
+
+// LogRedactionEnabled reports whether log output is redacted
+func LogRedactionEnabled() bool {
+	return !redactionDisabled.Load()
+}
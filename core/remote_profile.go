@@ -0,0 +1,145 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/muhammadmuzzammil1998/jsonc"
+)
+
+const (
+	// RemoteProfileScheme is the URL scheme of deep links handled by the launcher
+	RemoteProfileScheme = "sing-box"
+	remoteProfileHost   = "import-remote-profile"
+)
+
+// RemoteProfileLink is a parsed sing-box://import-remote-profile?url=<config URL>#<name> deep link
+type RemoteProfileLink struct {
+	URL  string // URL of a sing-box config.json
+	Name string // Profile name (from the fragment or the URL host)
+}
+
+// IsRemoteProfileLink reports whether arg looks like a sing-box://import-remote-profile deep link
+func IsRemoteProfileLink(arg string) bool {
+	return strings.HasPrefix(strings.ToLower(arg), RemoteProfileScheme+"://"+remoteProfileHost)
+}
+
+// ParseRemoteProfileLink parses a sing-box://import-remote-profile deep link.
+// Only http(s) config URLs are accepted; the name is adjusted to a valid profile name.
+func ParseRemoteProfileLink(link string) (RemoteProfileLink, error) {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return RemoteProfileLink{}, fmt.Errorf("invalid link: %w", err)
+	}
+	if !strings.EqualFold(u.Scheme, RemoteProfileScheme) || !strings.EqualFold(u.Host, remoteProfileHost) {
+		return RemoteProfileLink{}, fmt.Errorf("unsupported link %q, expected %s://%s?url=...", link, RemoteProfileScheme, remoteProfileHost)
+	}
+
+	remote := u.Query().Get("url")
+	remoteURL, err := url.Parse(remote)
+	if remote == "" || err != nil || (remoteURL.Scheme != "http" && remoteURL.Scheme != "https") || remoteURL.Host == "" {
+		return RemoteProfileLink{}, fmt.Errorf("link has no valid http(s) config URL")
+	}
+
+	name := u.Fragment
+	if name == "" {
+		name = remoteURL.Hostname()
+	}
+	return RemoteProfileLink{URL: remote, Name: sanitizeProfileName(name)}, nil
+}
+
+// sanitizeProfileName replaces characters that are not allowed in profile names
+func sanitizeProfileName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(name) {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == ' ', r == '_', r == '.', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	result := strings.TrimLeft(b.String(), " _.-")
+	if len(result) > 64 {
+		result = result[:64]
+	}
+	result = strings.TrimRight(result, " .")
+	if result == "" {
+		result = "remote"
+	}
+	if strings.EqualFold(result, DefaultProfileName) {
+		result += "-remote"
+	}
+	return result
+}
+
+// uniqueProfileName returns name, or name with a numeric suffix if such a profile exists
+func (pm *ProfileManager) uniqueProfileName(name string) string {
+	if !pm.Exists(name) {
+		return name
+	}
+	for i := 2; ; i++ {
+		suffix := fmt.Sprintf(" %d", i)
+		candidate := name
+		if len(candidate)+len(suffix) > 64 {
+			candidate = strings.TrimRight(candidate[:64-len(suffix)], " .")
+		}
+		candidate += suffix
+		if !pm.Exists(candidate) {
+			return candidate
+		}
+	}
+}
+
+// ImportRemoteProfile downloads the sing-box config of link into a new profile and returns its name.
+// The active profile is not changed.
+func (ac *AppController) ImportRemoteProfile(link RemoteProfileLink) (string, error) {
+	content, err := FetchSubscription(link.URL)
+	if err != nil {
+		return "", fmt.Errorf("failed to download profile: %w", err)
+	}
+	var config map[string]interface{}
+	if err := json.Unmarshal(jsonc.ToJSON(content), &config); err != nil {
+		return "", fmt.Errorf("downloaded profile is not a sing-box config: %w", err)
+	}
+	if _, ok := config["outbounds"]; !ok {
+		return "", fmt.Errorf("downloaded profile is not a sing-box config: no outbounds")
+	}
+
+	ac.ProfileMutex.Lock()
+	defer ac.ProfileMutex.Unlock()
+
+	name := ac.Profiles.uniqueProfileName(link.Name)
+	if err := ac.Profiles.Create(name); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(ac.Profiles.ConfigPath(name), content, 0644); err != nil {
+		_ = os.RemoveAll(ac.Profiles.ProfileDir(name))
+		return "", fmt.Errorf("failed to save profile config: %w", err)
+	}
+	log.Printf("Profiles: Imported remote profile '%s' from %s", name, link.URL)
+	ac.notifyProfilesChanged()
+	return name, nil
+}
+
+// RequestRemoteProfileImport asks the user to confirm a deep-link import, then imports in the background.
+// Links come from browsers and other programs, so nothing is downloaded without confirmation.
+func (ac *AppController) RequestRemoteProfileImport(link RemoteProfileLink) {
+	message := fmt.Sprintf("Import remote profile '%s'?\n\nConfig URL:\n%s", link.Name, link.URL)
+	ac.Notifier.ShowConfirm("Import Profile", message, "Import", func() {
+		go func() {
+			name, err := ac.ImportRemoteProfile(link)
+			if err != nil {
+				log.Printf("Profiles: Remote profile import failed: %v", err)
+				ac.Notifier.ShowErrorText("Import Profile", err.Error())
+				return
+			}
+			ac.Notifier.ShowInfo("Import Profile",
+				fmt.Sprintf("Profile '%s' imported.\nSwitch to it in the tray menu or in Profiles.", name))
+		}()
+	})
+}
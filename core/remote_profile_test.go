@@ -0,0 +1,89 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// TestParseRemoteProfileLink tests deep link parsing and profile name derivation
+func TestParseRemoteProfileLink(t *testing.T) {
+	tests := []struct {
+		link     string
+		wantURL  string
+		wantName string
+		wantErr  bool
+	}{
+		{"sing-box://import-remote-profile?url=https%3A%2F%2Fexample.com%2Fconfig.json#Work", "https://example.com/config.json", "Work", false},
+		{"sing-box://import-remote-profile?url=https://example.com/c.json", "https://example.com/c.json", "example.com", false},
+		{"sing-box://import-remote-profile?url=https://example.com/c.json#My/VPN:1", "https://example.com/c.json", "My-VPN-1", false},
+		{"sing-box://import-remote-profile?url=https://example.com/c.json#default", "https://example.com/c.json", "default-remote", false},
+		{"sing-box://import-remote-profile?url=file:///etc/passwd", "", "", true},
+		{"sing-box://import-remote-profile", "", "", true},
+		{"sing-box://other?url=https://example.com", "", "", true},
+		{"https://example.com/config.json", "", "", true},
+	}
+
+	for _, tt := range tests {
+		link, err := ParseRemoteProfileLink(tt.link)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRemoteProfileLink(%q) error = %v, wantErr %v", tt.link, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (link.URL != tt.wantURL || link.Name != tt.wantName) {
+			t.Errorf("ParseRemoteProfileLink(%q) = %+v, want URL %q name %q", tt.link, link, tt.wantURL, tt.wantName)
+		}
+	}
+
+	if !IsRemoteProfileLink("SING-BOX://import-remote-profile?url=x") || IsRemoteProfileLink("-start") {
+		t.Error("IsRemoteProfileLink misdetects links")
+	}
+}
+
+// TestImportRemoteProfile tests download into a new profile and name conflicts
+func TestImportRemoteProfile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bad" {
+			w.Write([]byte("vless://not-a-config"))
+			return
+		}
+		w.Write([]byte(`{"outbounds": [{"type": "direct", "tag": "direct"}]}`))
+	}))
+	defer server.Close()
+
+	ac, _ := newTestController(t)
+	var profileEvents int
+	ac.Events.Subscribe(func(e Event) {
+		if e.Type == EventProfilesChanged {
+			profileEvents++
+		}
+	})
+
+	link := RemoteProfileLink{URL: server.URL + "/config.json", Name: "Remote"}
+	for _, want := range []string{"Remote", "Remote 2"} {
+		name, err := ac.ImportRemoteProfile(link)
+		if err != nil {
+			t.Fatalf("ImportRemoteProfile failed: %v", err)
+		}
+		if name != want {
+			t.Errorf("Imported as %q, want %q", name, want)
+		}
+		if _, err := os.Stat(ac.Profiles.ConfigPath(name)); err != nil {
+			t.Errorf("config.json of %q not written: %v", name, err)
+		}
+	}
+	if profileEvents != 2 {
+		t.Errorf("Expected 2 profile events, got %d", profileEvents)
+	}
+	if ac.ActiveProfile != DefaultProfileName {
+		t.Errorf("Import must not switch the active profile, got %q", ac.ActiveProfile)
+	}
+
+	if _, err := ac.ImportRemoteProfile(RemoteProfileLink{URL: server.URL + "/bad", Name: "Bad"}); err == nil {
+		t.Error("Expected error for content that is not a sing-box config")
+	}
+	if ac.Profiles.Exists("Bad") {
+		t.Error("Profile must not be created on failed import")
+	}
+}
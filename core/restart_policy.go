@@ -0,0 +1,97 @@
+package core
+
+import (
+	"log"
+	"math"
+	"time"
+)
+
+// Default crash-restart policy
+const (
+	defaultRestartAttempts    = 3
+	defaultRestartDelay       = 2 * time.Second
+	defaultMaxRestartDelay    = time.Minute
+	defaultRestartMultiplier  = 2.0
+	defaultStabilityThreshold = 180 * time.Second
+	minStabilityThreshold     = 5 * time.Second
+	maxRestartAttemptsAllowed = 100
+)
+
+// RestartPolicy decides whether and when a crashed sing-box is restarted
+type RestartPolicy struct {
+	Enabled            bool
+	MaxAttempts        int           // Consecutive restarts before giving up
+	InitialDelay       time.Duration // Delay before the first restart
+	MaxDelay           time.Duration // Upper bound of the delay
+	Multiplier         float64       // Delay growth factor per attempt
+	StabilityThreshold time.Duration // A core running this long is considered stable, the crash counter resets
+}
+
+// DefaultRestartPolicy returns the policy used without launcher settings
+func DefaultRestartPolicy() RestartPolicy {
+	return RestartPolicy{
+		Enabled:            true,
+		MaxAttempts:        defaultRestartAttempts,
+		InitialDelay:       defaultRestartDelay,
+		MaxDelay:           defaultMaxRestartDelay,
+		Multiplier:         defaultRestartMultiplier,
+		StabilityThreshold: defaultStabilityThreshold,
+	}
+}
+
+// Policy converts settings to a RestartPolicy; invalid values are logged and replaced by defaults
+func (s CrashRestartSettings) Policy() RestartPolicy {
+	p := DefaultRestartPolicy()
+	p.Enabled = !s.Disabled
+
+	if s.MaxAttempts > 0 && s.MaxAttempts <= maxRestartAttemptsAllowed {
+		p.MaxAttempts = s.MaxAttempts
+	} else if s.MaxAttempts != 0 {
+		log.Printf("RestartPolicy: Invalid crash_restart.max_attempts %d, using %d", s.MaxAttempts, p.MaxAttempts)
+	}
+	if s.Multiplier >= 1 {
+		p.Multiplier = s.Multiplier
+	} else if s.Multiplier != 0 {
+		log.Printf("RestartPolicy: Invalid crash_restart.multiplier %v, using %v", s.Multiplier, p.Multiplier)
+	}
+	p.InitialDelay = parsePolicyDuration("initial_delay", s.InitialDelay, p.InitialDelay, 0)
+	p.MaxDelay = parsePolicyDuration("max_delay", s.MaxDelay, p.MaxDelay, 0)
+	if p.MaxDelay < p.InitialDelay {
+		p.MaxDelay = p.InitialDelay
+	}
+	p.StabilityThreshold = parsePolicyDuration("stability_threshold", s.StabilityThreshold, p.StabilityThreshold, minStabilityThreshold)
+	return p
+}
+
+// parsePolicyDuration parses a duration setting, falling back to def if it is empty, invalid or below min
+func parsePolicyDuration(name, value string, def, min time.Duration) time.Duration {
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < min {
+		log.Printf("RestartPolicy: Invalid crash_restart.%s %q, using %v", name, value, def)
+		return def
+	}
+	return d
+}
+
+// Delay returns the wait before restart attempt number attempt (1-based):
+// InitialDelay * Multiplier^(attempt-1), capped at MaxDelay
+func (p RestartPolicy) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := float64(p.InitialDelay) * math.Pow(p.Multiplier, float64(attempt-1))
+	if delay > float64(p.MaxDelay) {
+		return p.MaxDelay
+	}
+	return time.Duration(delay)
+}
+
+// RestartPolicy returns the crash-restart policy from launcher settings
+func (ac *AppController) RestartPolicy() RestartPolicy {
+	var settings CrashRestartSettings
+	ac.Settings.Get(func(s *LauncherSettings) { settings = s.CrashRestart })
+	return settings.Policy()
+}
@@ -0,0 +1,255 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/muhammadmuzzammil1998/jsonc"
+
+	"singbox-launcher/api"
+)
+
+// RuleSet is a rule-set of config.json with the state of its rule provider from the Clash API
+type RuleSet struct {
+	Tag            string
+	Type           string    // "remote", "local" or "inline"
+	Format         string    // "binary" (.srs) or "source"
+	URL            string    // Download URL of remote rule-sets
+	UpdateInterval string    // Update interval of remote rule-sets ("" = sing-box default, 1d)
+	Provider       bool      // The core reports the rule-set as a rule provider
+	Refreshable    bool      // Can be refreshed now (see RefreshRuleSets)
+	NeedsReload    bool      // Refreshing it reloads the whole config of the running sing-box
+	Cached         bool      // Remote rule-set kept in experimental.cache_file: a reload does not download it
+	RuleCount      int       // Rules reported by the core or listed inline (0 if unknown)
+	UpdatedAt      time.Time // Last download reported by the core (zero if unknown)
+}
+
+// ruleSetConfig is the part of config.json describing rule-sets
+type ruleSetConfig struct {
+	Route struct {
+		RuleSet []struct {
+			Tag            string            `json:"tag"`
+			Type           string            `json:"type"`
+			Format         string            `json:"format"`
+			URL            string            `json:"url"`
+			UpdateInterval string            `json:"update_interval"`
+			Rules          []json.RawMessage `json:"rules"`
+		} `json:"rule_set"`
+	} `json:"route"`
+	Experimental struct {
+		CacheFile struct {
+			Enabled bool `json:"enabled"`
+		} `json:"cache_file"`
+	} `json:"experimental"`
+}
+
+// GetRuleSetsFromConfig returns route.rule_set of config.json in config order
+func GetRuleSetsFromConfig(configPath string) ([]RuleSet, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var config ruleSetConfig
+	if err := json.Unmarshal(jsonc.ToJSON(data), &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	sets := make([]RuleSet, 0, len(config.Route.RuleSet))
+	for _, rs := range config.Route.RuleSet {
+		if rs.Tag == "" {
+			continue
+		}
+		set := RuleSet{Tag: rs.Tag, Type: rs.Type, Format: rs.Format, URL: rs.URL, UpdateInterval: rs.UpdateInterval}
+		switch rs.Type {
+		case "inline":
+			set.RuleCount = len(rs.Rules)
+		case "remote":
+			set.Cached = config.Experimental.CacheFile.Enabled
+		}
+		sets = append(sets, set)
+	}
+	return sets, nil
+}
+
+// RuleSets returns rule-sets of config.json; if the core is reachable, the rule count and last update
+// of rule providers are filled in from the Clash API (GET /providers/rules).
+// Providers that are not in config.json are appended sorted by name.
+// sing-box reports no rule providers: its remote rule-sets are refreshable while it is running, but only
+// by reloading the whole config (NeedsReload), and their last update is unknown.
+func (ac *AppController) RuleSets(ctx context.Context) ([]RuleSet, error) {
+	sets, err := GetRuleSetsFromConfig(ac.GetConfigPath())
+	if err != nil {
+		return nil, err
+	}
+	var providers map[string]api.RuleProvider
+	if ac.ClashAPIEnabled {
+		providers, err = ac.ClashClient().RuleProviders(ctx)
+		if err != nil {
+			// sing-box is not running or Clash API is unreachable
+			log.Printf("RuleSets: Failed to get rule providers: %v", err)
+		}
+	}
+	if len(providers) == 0 {
+		ac.markReloadableRuleSets(sets)
+		return sets, nil
+	}
+
+	for i := range sets {
+		if p, ok := providers[sets[i].Tag]; ok {
+			sets[i].Provider, sets[i].Refreshable, sets[i].RuleCount, sets[i].UpdatedAt = true, true, p.RuleCount, p.UpdatedAt
+			delete(providers, sets[i].Tag)
+		}
+	}
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := providers[name]
+		sets = append(sets, RuleSet{Tag: name, Type: p.VehicleType, Format: p.Format, Provider: true, Refreshable: true,
+			RuleCount: p.RuleCount, UpdatedAt: p.UpdatedAt})
+	}
+	return sets, nil
+}
+
+// markReloadableRuleSets marks remote rule-sets that a reload of the running sing-box downloads again
+func (ac *AppController) markReloadableRuleSets(sets []RuleSet) {
+	running, _ := ac.coreProcess()
+	if !running {
+		return
+	}
+	for i := range sets {
+		if sets[i].Type != "remote" || sets[i].Cached {
+			continue
+		}
+		sets[i].Refreshable = true
+		sets[i].NeedsReload = true
+	}
+}
+
+// ErrRuleSetReloadRequired is returned by RefreshRuleSets when rule-sets can only be refreshed
+// by reloading sing-box and the caller did not allow it
+var ErrRuleSetReloadRequired = errors.New("sing-box cannot refresh single rule-sets: it downloads them again only when it reloads the whole config, " +
+	"which recreates all inbounds and outbounds and drops all connections (a full restart on Windows)")
+
+// RefreshRuleSets makes the running core download rule-sets again; without tags all rule-sets are
+// refreshed. Returns the refreshed tags; rule-sets that could not be refreshed are reported in the error.
+// Rule providers of the Clash API are refreshed in place with PUT /providers/rules/{name}.
+// sing-box does not expose rule-sets as rule providers, so its remote rule-sets are refreshed by
+// reloading config.json (see reloadRuleSets); without allowReload ErrRuleSetReloadRequired is returned instead.
+func (ac *AppController) RefreshRuleSets(ctx context.Context, allowReload bool, tags ...string) ([]string, error) {
+	if ac.ClashAPIEnabled {
+		providers, err := ac.ClashClient().RuleProviders(ctx)
+		if err == nil && len(providers) > 0 {
+			return ac.refreshRuleProviders(ctx, providers, tags)
+		}
+	}
+	return ac.reloadRuleSets(tags, allowReload)
+}
+
+// refreshRuleProviders refreshes rule providers reported by the core via Clash API
+func (ac *AppController) refreshRuleProviders(ctx context.Context, providers map[string]api.RuleProvider, tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		for name := range providers {
+			tags = append(tags, name)
+		}
+		sort.Strings(tags)
+	}
+
+	var (
+		refreshed []string
+		errs      []error
+	)
+	client := ac.ClashClient()
+	for _, tag := range tags {
+		if _, ok := providers[tag]; !ok {
+			errs = append(errs, fmt.Errorf("'%s' is not a rule provider of the running core", tag))
+			continue
+		}
+		if err := client.UpdateRuleProvider(ctx, tag); err != nil {
+			errs = append(errs, fmt.Errorf("failed to refresh '%s': %w", tag, err))
+			continue
+		}
+		refreshed = append(refreshed, tag)
+	}
+	log.Printf("RefreshRuleSets: Refreshed %d of %d rule-sets", len(refreshed), len(tags))
+	return refreshed, errors.Join(errs...)
+}
+
+// reloadRuleSets refreshes remote rule-sets of sing-box: it downloads them when it loads the config,
+// so the whole config of the running core is reloaded (a full restart on Windows), which drops all
+// connections; allowReload confirms that. Rule-sets kept in experimental.cache_file are only
+// downloaded by sing-box after their update_interval.
+func (ac *AppController) reloadRuleSets(tags []string, allowReload bool) ([]string, error) {
+	sets, err := GetRuleSetsFromConfig(ac.GetConfigPath())
+	if err != nil {
+		return nil, err
+	}
+	all := len(tags) == 0
+	byTag := make(map[string]RuleSet, len(sets))
+	for _, rs := range sets {
+		byTag[rs.Tag] = rs
+		if all && rs.Type == "remote" {
+			tags = append(tags, rs.Tag)
+		}
+	}
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("no remote rule-sets in %s", ac.GetConfigPath())
+	}
+
+	var (
+		reload []string
+		errs   []error
+	)
+	for _, tag := range tags {
+		rs, ok := byTag[tag]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("'%s' is not a rule-set of %s", tag, ac.GetConfigPath()))
+		case rs.Type != "remote":
+			errs = append(errs, fmt.Errorf("'%s' is a %s rule-set, only remote rule-sets are downloaded", tag, rs.Type))
+		case rs.Cached:
+			errs = append(errs, fmt.Errorf("'%s' is kept in experimental.cache_file: sing-box downloads it again after update_interval", tag))
+		default:
+			reload = append(reload, tag)
+		}
+	}
+	if len(reload) == 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	running, pid := ac.coreProcess()
+	switch {
+	case !running:
+		err = errors.New("sing-box is not running")
+	case !allowReload:
+		return nil, errors.Join(append(errs, ErrRuleSetReloadRequired)...)
+	case ac.RunningState.IsRunning():
+		err = ac.ProcessService.Reload()
+	default:
+		// Started by the CLI or another launcher instance
+		err = ac.ProcessService.ReloadPID(pid)
+	}
+	if err != nil {
+		return nil, errors.Join(append(errs, fmt.Errorf("failed to reload sing-box: %w", err))...)
+	}
+	log.Printf("RefreshRuleSets: Reloaded sing-box to download %d rule-sets", len(reload))
+	return reload, errors.Join(errs...)
+}
+
+// coreProcess reports whether sing-box is running: started by this launcher or found on the system
+// (started by the CLI or another instance). The PID is only returned for a process found on the system.
+func (ac *AppController) coreProcess() (bool, int) {
+	if ac.RunningState.IsRunning() {
+		return true, 0
+	}
+	return ac.ProcessService.FindRunning()
+}
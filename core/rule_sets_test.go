@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"singbox-launcher/api"
+	"singbox-launcher/api/apitest"
+)
+
+// TestRuleSets tests merging rule-sets of config.json with rule providers and refreshing them
+func TestRuleSets(t *testing.T) {
+	ac, _ := newTestController(t)
+	config := `{
+		// JSONC
+		"route": {"rule_set": [
+			{"tag": "ru-domains", "type": "inline", "format": "domain_suffix", "rules": [{"domain_suffix": ["ru"]}]},
+			{"tag": "ads-all", "type": "remote", "format": "binary", "url": "https://example.com/ads.srs", "update_interval": "24h"},
+			{"tag": "games", "type": "remote", "format": "binary", "url": "https://example.com/games.srs"}
+		]}
+	}`
+	if err := os.WriteFile(ac.ConfigPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without Clash API only config.json is read
+	sets, err := ac.RuleSets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sets) != 3 || sets[1].Tag != "ads-all" || sets[1].URL != "https://example.com/ads.srs" || sets[1].UpdateInterval != "24h" ||
+		sets[1].Provider || sets[1].Refreshable || sets[0].RuleCount != 1 {
+		t.Fatalf("Unexpected rule-sets %+v", sets)
+	}
+
+	fake := apitest.NewFakeServer("secret")
+	defer fake.Close()
+	ac.ClashAPIBaseURL, ac.ClashAPIToken, ac.ClashAPIEnabled = fake.URL, fake.Secret, true
+
+	// sing-box does not report rule providers: its rule-sets are refreshed by a reload
+	if _, err := ac.RefreshRuleSets(context.Background(), true); err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Errorf("Expected an error for a stopped sing-box, got %v", err)
+	}
+
+	updated := time.Now().Add(-time.Hour)
+	fake.AddRuleProvider(api.RuleProvider{Name: "ads-all", VehicleType: "HTTP", RuleCount: 500, UpdatedAt: updated})
+	fake.AddRuleProvider(api.RuleProvider{Name: "extra", VehicleType: "File", RuleCount: 7, UpdatedAt: updated})
+	sets, err = ac.RuleSets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sets) != 4 || !sets[1].Provider || !sets[1].Refreshable || sets[1].RuleCount != 500 || sets[2].Provider || sets[3].Tag != "extra" || sets[3].Type != "File" {
+		t.Fatalf("Unexpected merged rule-sets %+v", sets)
+	}
+
+	// Rule providers are refreshed in place, no reload is needed
+	if sets[1].NeedsReload {
+		t.Errorf("A rule provider must not need a reload: %+v", sets[1])
+	}
+	refreshed, err := ac.RefreshRuleSets(context.Background(), false, "ads-all", "games")
+	if len(refreshed) != 1 || refreshed[0] != "ads-all" || err == nil || !strings.Contains(err.Error(), "'games'") {
+		t.Errorf("RefreshRuleSets = %v, %v", refreshed, err)
+	}
+	if !fake.RuleProviderUpdatedAt("ads-all").After(updated) || !fake.RuleProviderUpdatedAt("extra").Equal(updated) {
+		t.Error("Only ads-all must be refreshed")
+	}
+	if refreshed, err := ac.RefreshRuleSets(context.Background(), false); err != nil || len(refreshed) != 2 {
+		t.Errorf("Refresh all = %v, %v", refreshed, err)
+	}
+}
+
+// TestRefreshRuleSetsReload tests refreshing remote rule-sets of sing-box by a reload of the whole config,
+// which must be allowed by the caller
+func TestRefreshRuleSetsReload(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake sing-box is a shell script")
+	}
+
+	ac, _ := newTestController(t)
+	config := `{
+		"route": {"rule_set": [
+			{"tag": "ru-domains", "type": "inline", "rules": [{"domain_suffix": ["ru"]}]},
+			{"tag": "ads-all", "type": "remote", "format": "binary", "url": "https://example.com/ads.srs"},
+			{"tag": "games", "type": "remote", "format": "binary", "url": "https://example.com/games.srs"}
+		]}
+	}`
+	if err := os.WriteFile(ac.ConfigPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	startFakeSingBox(t, ac)
+
+	sets, err := ac.RuleSets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sets[0].Refreshable || !sets[1].Refreshable || !sets[1].NeedsReload || !sets[2].Refreshable {
+		t.Fatalf("Remote rule-sets of a running sing-box must be refreshable by a reload: %+v", sets)
+	}
+	if !sets[1].UpdatedAt.IsZero() {
+		t.Errorf("sing-box does not report when it downloaded a rule-set, got %v", sets[1].UpdatedAt)
+	}
+	loadedAt := ac.ProcessService.ConfigLoadedAt()
+
+	// Without the caller's consent the core is not reloaded
+	if refreshed, err := ac.RefreshRuleSets(context.Background(), false, "ads-all"); len(refreshed) != 0 || !errors.Is(err, ErrRuleSetReloadRequired) {
+		t.Errorf("RefreshRuleSets without reload = %v, %v", refreshed, err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if got := ac.ProcessService.ConfigLoadedAt(); !got.Equal(loadedAt) {
+		t.Errorf("sing-box must not be reloaded without consent: loaded at %v, was %v", got, loadedAt)
+	}
+
+	refreshed, err := ac.RefreshRuleSets(context.Background(), true, "ads-all", "ru-domains")
+	if len(refreshed) != 1 || refreshed[0] != "ads-all" || err == nil || !strings.Contains(err.Error(), "'ru-domains'") {
+		t.Errorf("RefreshRuleSets = %v, %v", refreshed, err)
+	}
+	deadline := time.Now().Add(reloadSettleTime + 2*time.Second)
+	for !ac.ProcessService.ConfigLoadedAt().After(loadedAt) && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if got := ac.ProcessService.ConfigLoadedAt(); !got.After(loadedAt) {
+		t.Errorf("Reload must update the load time: %v, was %v", got, loadedAt)
+	}
+
+	// Rule-sets in cache_file are not downloaded again by a reload
+	cached := strings.Replace(config, `"route"`, `"experimental": {"cache_file": {"enabled": true}}, "route"`, 1)
+	if err := os.WriteFile(ac.ConfigPath, []byte(cached), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if sets, _ := ac.RuleSets(context.Background()); !sets[1].Cached || sets[1].Refreshable {
+		t.Errorf("Cached rule-set must not be refreshable: %+v", sets[1])
+	}
+	if refreshed, err := ac.RefreshRuleSets(context.Background(), true); len(refreshed) != 0 || err == nil || !strings.Contains(err.Error(), "cache_file") {
+		t.Errorf("RefreshRuleSets with cache_file = %v, %v", refreshed, err)
+	}
+}
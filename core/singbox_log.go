@@ -0,0 +1,242 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// coreLogCapacity is the number of sing-box log entries kept in memory (AppController.CoreLog)
+const coreLogCapacity = 2000
+
+// LogLevel is the level of a sing-box log line
+type LogLevel string
+
+const (
+	LogLevelTrace LogLevel = "trace"
+	LogLevelDebug LogLevel = "debug"
+	LogLevelInfo  LogLevel = "info"
+	LogLevelWarn  LogLevel = "warn"
+	LogLevelError LogLevel = "error"
+	LogLevelFatal LogLevel = "fatal"
+	LogLevelPanic LogLevel = "panic"
+)
+
+var logLevelRanks = map[LogLevel]int{
+	LogLevelTrace: 0,
+	LogLevelDebug: 1,
+	LogLevelInfo:  2,
+	LogLevelWarn:  3,
+	LogLevelError: 4,
+	LogLevelFatal: 5,
+	LogLevelPanic: 6,
+}
+
+// Rank orders levels from trace (0) to panic (6). Lines without a level rank as info.
+func (l LogLevel) Rank() int {
+	if rank, ok := logLevelRanks[l]; ok {
+		return rank
+	}
+	return logLevelRanks[LogLevelInfo]
+}
+
+// ParseLogLevel converts a level name (case-insensitive, "warning" accepted) to LogLevel
+func ParseLogLevel(name string) (LogLevel, bool) {
+	level := LogLevel(strings.ToLower(strings.TrimSpace(name)))
+	if level == "warning" {
+		level = LogLevelWarn
+	}
+	_, ok := logLevelRanks[level]
+	return level, ok
+}
+
+// Fatal kinds detected in sing-box output (LogEntry.FatalKind)
+const (
+	FatalAddressInUse  = "address_in_use"
+	FatalTunPermission = "tun_permission"
+	FatalInvalidConfig = "invalid_config"
+	FatalUnknown       = "unknown"
+)
+
+// fatalPattern is a known reason for sing-box to exit, with a hint for the user
+type fatalPattern struct {
+	kind      string
+	hint      string
+	permanent bool // Restarting sing-box with the same config and rights won't help
+	pattern   *regexp.Regexp
+}
+
+// fatalPatterns are checked in order against FATAL/PANIC lines
+var fatalPatterns = []fatalPattern{
+	{
+		kind:    FatalAddressInUse,
+		hint:    "A port from config.json is already used by another program (another sing-box or proxy?). Stop it or change the listen port.",
+		pattern: regexp.MustCompile(`(?i)address already in use|only one usage of each socket address`),
+	},
+	{
+		kind:      FatalTunPermission,
+		hint:      "No permission to create the TUN interface. Run the launcher as administrator (Windows) or grant sing-box the capabilities (Linux, see the Core tab).",
+		permanent: true,
+		pattern:   regexp.MustCompile(`(?i)(tun|wintun).*(operation not permitted|permission denied|access is denied)`),
+	},
+	{
+		kind:      FatalInvalidConfig,
+		hint:      "sing-box rejected config.json. Check it with the Config Wizard or \"sing-box check\".",
+		permanent: true,
+		pattern:   regexp.MustCompile(`(?i)decode config|read config|unknown field|cannot unmarshal|unknown (inbound|outbound|transport|rule|dns server) type|initialize (inbound|outbound|dns|router|rule)`),
+	},
+}
+
+// FatalHint returns the user hint for a fatal kind ("" if none)
+func FatalHint(kind string) string {
+	for _, p := range fatalPatterns {
+		if p.kind == kind {
+			return p.hint
+		}
+	}
+	return ""
+}
+
+// IsPermanentFatal reports whether a crash of this kind repeats on restart
+// (auto-restart is skipped for it)
+func IsPermanentFatal(kind string) bool {
+	for _, p := range fatalPatterns {
+		if p.kind == kind {
+			return p.permanent
+		}
+	}
+	return false
+}
+
+// LogEntry is a parsed sing-box log line
+type LogEntry struct {
+	Seq          uint64    `json:"seq"`  // Position in AppController.CoreLog, increasing
+	Time         time.Time `json:"time"` // Timestamp from the line, or receive time
+	Level        LogLevel  `json:"level,omitempty"`
+	Component    string    `json:"component,omitempty"`     // e.g. "router", "inbound/tun[tun-in]"
+	ConnectionID string    `json:"connection_id,omitempty"` // Connection id of per-connection lines
+	Elapsed      string    `json:"elapsed,omitempty"`       // Connection age of per-connection lines, e.g. "1.2s"
+	Message      string    `json:"message"`
+	FatalKind    string    `json:"fatal_kind,omitempty"` // Set for FATAL/PANIC lines
+	Raw          string    `json:"raw"`
+}
+
+// singboxLogPattern matches sing-box log lines with or without timestamps:
+//
+//	+0300 2025-01-15 12:00:00 INFO [3517438497 1.2s] inbound/tun[tun-in]: inbound connection to example.com:443
+//	FATAL[0000] start service: start inbound/mixed[mixed-in]: listen tcp 127.0.0.1:2080: bind: address already in use
+var singboxLogPattern = regexp.MustCompile(
+	`^(?:([+-]\d{4} \d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)?) )?` + // 1: timestamp
+		`(TRACE|DEBUG|INFO|WARNING|WARN|ERROR|FATAL|PANIC)(?:\[\d+\])?\s*` + // 2: level
+		`(?:\[(\d+)(?: ([^\]]+))?\] )?` + // 3: connection id, 4: elapsed
+		`(?:([\w./-]+(?:\[[^\]]*\])?): )?` + // 5: component
+		`(.*)$`) // 6: message
+
+const singboxTimeLayout = "-0700 2006-01-02 15:04:05"
+
+// ParseSingboxLogLine parses a line of sing-box output. Lines in other formats
+// (panic traces, messages of other programs) are kept with an empty level.
+func ParseSingboxLogLine(line string, received time.Time) LogEntry {
+	clean := strings.TrimSpace(ansiEscapePattern.ReplaceAllString(line, ""))
+	entry := LogEntry{Time: received, Message: clean, Raw: clean}
+
+	if m := singboxLogPattern.FindStringSubmatch(clean); m != nil {
+		if m[1] != "" {
+			if t, err := time.Parse(singboxTimeLayout, m[1]); err == nil {
+				entry.Time = t
+			}
+		}
+		entry.Level, _ = ParseLogLevel(m[2])
+		entry.ConnectionID = m[3]
+		entry.Elapsed = m[4]
+		entry.Component = m[5]
+		entry.Message = m[6]
+	} else if strings.HasPrefix(clean, "panic:") {
+		entry.Level = LogLevelPanic
+	}
+
+	if entry.Level == LogLevelFatal || entry.Level == LogLevelPanic {
+		entry.FatalKind = FatalUnknown
+		for _, p := range fatalPatterns {
+			if p.pattern.MatchString(clean) {
+				entry.FatalKind = p.kind
+				break
+			}
+		}
+	}
+	return entry
+}
+
+// LogBuffer is a bounded ring buffer of sing-box log entries. Safe for concurrent use.
+type LogBuffer struct {
+	mutex   sync.RWMutex
+	entries []LogEntry
+	next    int // Index of the next write when the buffer is full
+	lastSeq uint64
+}
+
+// NewLogBuffer creates a buffer that keeps the last capacity entries
+func NewLogBuffer(capacity int) *LogBuffer {
+	return &LogBuffer{entries: make([]LogEntry, 0, capacity)}
+}
+
+// Add stores the entry, assigns its Seq and returns the stored entry
+func (b *LogBuffer) Add(entry LogEntry) LogEntry {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.lastSeq++
+	entry.Seq = b.lastSeq
+	if len(b.entries) < cap(b.entries) {
+		b.entries = append(b.entries, entry)
+	} else {
+		b.entries[b.next] = entry
+		b.next = (b.next + 1) % len(b.entries)
+	}
+	return entry
+}
+
+// Entries returns entries with Seq > since and level >= minLevel ("" = all), oldest first.
+// If limit > 0, only the newest limit matching entries are returned.
+func (b *LogBuffer) Entries(since uint64, minLevel LogLevel, limit int) []LogEntry {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	minRank := 0
+	if minLevel != "" {
+		minRank = minLevel.Rank()
+	}
+	var result []LogEntry
+	for i := range b.entries {
+		entry := b.entries[(b.next+i)%len(b.entries)]
+		if entry.Seq > since && entry.Level.Rank() >= minRank {
+			result = append(result, entry)
+		}
+	}
+	if limit > 0 && len(result) > limit {
+		result = result[len(result)-limit:]
+	}
+	return result
+}
+
+// LastSeq returns the Seq of the newest entry (0 if none)
+func (b *LogBuffer) LastSeq() uint64 {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.lastSeq
+}
+
+// handleCoreOutputLine parses a line of sing-box output, stores it in CoreLog and publishes it.
+// FATAL/PANIC lines are remembered in the process output for the crash record and published
+// as EventCoreFatal with a hint for known causes.
+func (ac *AppController) handleCoreOutputLine(output *outputTail, line string) {
+	output.Add(line)
+	entry := ac.CoreLog.Add(ParseSingboxLogLine(line, time.Now()))
+	ac.Events.Publish(Event{Type: EventCoreLog, Log: &entry})
+
+	if entry.FatalKind != "" {
+		output.SetFatal(entry)
+		ac.Events.Publish(Event{Type: EventCoreFatal, Message: FatalHint(entry.FatalKind), Log: &entry})
+	}
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseSingboxLogLine tests parsing of sing-box log lines and fatal pattern detection
+func TestParseSingboxLogLine(t *testing.T) {
+	received := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	entry := ParseSingboxLogLine("+0300 2025-01-15 12:00:00 INFO [3517438497 1.2s] inbound/tun[tun-in]: inbound connection to example.com:443", received)
+	if entry.Level != LogLevelInfo || entry.ConnectionID != "3517438497" || entry.Elapsed != "1.2s" ||
+		entry.Component != "inbound/tun[tun-in]" || entry.Message != "inbound connection to example.com:443" {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+	if want := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC); !entry.Time.Equal(want) {
+		t.Errorf("Time = %v, want %v", entry.Time, want)
+	}
+	if entry.FatalKind != "" {
+		t.Errorf("Unexpected fatal kind %q for INFO line", entry.FatalKind)
+	}
+
+	entry = ParseSingboxLogLine("\x1b[33mWARN\x1b[0m[0012] dns: exchange failed", received)
+	if entry.Level != LogLevelWarn || entry.Component != "dns" || entry.Message != "exchange failed" || !entry.Time.Equal(received) {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+
+	entry = ParseSingboxLogLine("some other output", received)
+	if entry.Level != "" || entry.Message != "some other output" || entry.Level.Rank() != LogLevelInfo.Rank() {
+		t.Errorf("Unexpected entry for plain line %+v", entry)
+	}
+
+	fatals := []struct {
+		line string
+		kind string
+	}{
+		{"FATAL[0000] start service: start inbound/mixed[mixed-in]: listen tcp 127.0.0.1:2080: bind: address already in use", FatalAddressInUse},
+		{"FATAL[0000] start service: start inbound/tun[tun-in]: configure tun interface: operation not permitted", FatalTunPermission},
+		{"FATAL[0000] decode config at ./config.json: outbounds[1].server_port: json: unknown field \"port\"", FatalInvalidConfig},
+		{"panic: runtime error: invalid memory address", FatalUnknown},
+		{"ERROR[0001] connection: bind: address already in use", ""}, // Not fatal
+	}
+	for _, tt := range fatals {
+		if got := ParseSingboxLogLine(tt.line, received).FatalKind; got != tt.kind {
+			t.Errorf("FatalKind(%q) = %q, want %q", tt.line, got, tt.kind)
+		}
+		if tt.kind != "" && tt.kind != FatalUnknown && FatalHint(tt.kind) == "" {
+			t.Errorf("No hint for %q", tt.kind)
+		}
+	}
+}
+
+// TestLogBuffer tests the ring buffer wraparound and Entries filters
+func TestLogBuffer(t *testing.T) {
+	buffer := NewLogBuffer(3)
+	levels := []LogLevel{LogLevelInfo, LogLevelDebug, LogLevelWarn, LogLevelError, LogLevelInfo}
+	for _, level := range levels {
+		buffer.Add(LogEntry{Level: level})
+	}
+	if buffer.LastSeq() != 5 {
+		t.Errorf("LastSeq = %d, want 5", buffer.LastSeq())
+	}
+
+	seqs := func(entries []LogEntry) []uint64 {
+		var result []uint64
+		for _, e := range entries {
+			result = append(result, e.Seq)
+		}
+		return result
+	}
+	tests := []struct {
+		since    uint64
+		minLevel LogLevel
+		limit    int
+		want     []uint64
+	}{
+		{0, "", 0, []uint64{3, 4, 5}},
+		{3, "", 0, []uint64{4, 5}},
+		{0, LogLevelWarn, 0, []uint64{3, 4}},
+		{0, "", 1, []uint64{5}},
+		{5, "", 0, nil},
+	}
+	for _, tt := range tests {
+		got := seqs(buffer.Entries(tt.since, tt.minLevel, tt.limit))
+		if len(got) != len(tt.want) {
+			t.Errorf("Entries(%d, %q, %d) = %v, want %v", tt.since, tt.minLevel, tt.limit, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Entries(%d, %q, %d) = %v, want %v", tt.since, tt.minLevel, tt.limit, got, tt.want)
+				break
+			}
+		}
+	}
+}
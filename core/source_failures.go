@@ -0,0 +1,148 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+)
+
+// SourceFailure is the auto-update state of a subscription whose last download failed.
+// Each subscription backs off on its own, so one broken or expired subscription
+// does not delay or stop automatic updates of the others.
+type SourceFailure struct {
+	Source   string            // Subscription URL
+	Kind     UpdateFailureKind // Kind of the last failure
+	Attempts int               // Consecutive failed downloads
+	RetryAt  time.Time         // Next automatic attempt (zero: stopped until a manual update)
+	Error    string            // Last download error
+}
+
+// Stopped reports whether automatic updates of the subscription are stopped until a manual update
+func (f SourceFailure) Stopped() bool {
+	return f.RetryAt.IsZero()
+}
+
+// SourceFailures returns subscriptions whose last download failed
+func (ac *AppController) SourceFailures() []SourceFailure {
+	ac.AutoUpdateMutex.Lock()
+	defer ac.AutoUpdateMutex.Unlock()
+	failures := make([]SourceFailure, 0, len(ac.sourceFailures))
+	for _, f := range ac.sourceFailures {
+		failures = append(failures, f)
+	}
+	return failures
+}
+
+// recordSourceResults updates the per-subscription failure state after downloads:
+// refreshed sources are reset, failed ones back off exponentially. Auth/expired errors stop
+// automatic updates of that subscription, other errors stop them after autoUpdateMaxRetries
+// consecutive failures (network errors are retried forever). Stops are reported to the user
+// only for automatic updates, a manual update reports its errors itself.
+func (ac *AppController) recordSourceResults(config *ParserConfig, result *OutboundGenerationResult, automatic bool) {
+	now := time.Now()
+	var notifications []string
+
+	ac.AutoUpdateMutex.Lock()
+	if ac.sourceFailures == nil {
+		ac.sourceFailures = make(map[string]SourceFailure)
+	}
+	for _, i := range result.RefreshedSources {
+		delete(ac.sourceFailures, config.ParserConfig.Proxies[i].Source)
+	}
+	for i, err := range result.SourceErrors {
+		source := config.ParserConfig.Proxies[i].Source
+		f := ac.sourceFailures[source]
+		wasStopped := f.Attempts > 0 && f.Stopped()
+		f.Source = source
+		f.Kind = ClassifyUpdateError(err)
+		f.Attempts++
+		f.Error = err.Error()
+
+		name := sourceDisplayName(i, source)
+		switch {
+		case f.Kind == UpdateFailureAuth:
+			f.RetryAt = time.Time{}
+			if automatic && !wasStopped {
+				notifications = append(notifications, fmt.Sprintf("Subscription server rejected the request for %s:\n%v\n\nThe subscription may have expired. Its automatic updates are stopped, check the subscription URL and use manual update.", name, err))
+			}
+		case f.Kind != UpdateFailureNetwork && f.Attempts >= autoUpdateMaxRetries:
+			f.RetryAt = time.Time{}
+			if automatic && !wasStopped {
+				notifications = append(notifications, fmt.Sprintf("Automatic updates of %s stopped after %d failed attempts (%s error). Use manual update.", name, f.Attempts, f.Kind))
+			}
+		default:
+			f.RetryAt = now.Add(autoUpdateBackoff(f.Attempts, autoUpdateRetryInterval, autoUpdateMaxBackoff))
+		}
+		ac.sourceFailures[source] = f
+
+		if f.Stopped() {
+			log.Printf("Auto-update: %s failed (%s error, attempt %d), its automatic updates are stopped: %v", name, f.Kind, f.Attempts, err)
+		} else {
+			log.Printf("Auto-update: %s failed (%s error, attempt %d), retrying at %s: %v", name, f.Kind, f.Attempts, f.RetryAt.Format("15:04:05"), err)
+		}
+	}
+	ac.AutoUpdateMutex.Unlock()
+
+	if len(result.RefreshedSources) > 0 || len(result.SourceErrors) > 0 {
+		ac.publish(EventAutoUpdateStatusChanged)
+	}
+	for _, notification := range notifications {
+		ac.Notifier.ShowErrorText("Subscription update stopped", notification)
+	}
+}
+
+// sourceFailure returns the failure state of a subscription
+func (ac *AppController) sourceFailure(source string) (SourceFailure, bool) {
+	ac.AutoUpdateMutex.Lock()
+	defer ac.AutoUpdateMutex.Unlock()
+	f, ok := ac.sourceFailures[source]
+	return f, ok
+}
+
+// dueSources returns DueSources without subscriptions that back off after a failed download
+// or whose automatic updates are stopped
+func (ac *AppController) dueSources(config *ParserConfig, now time.Time) []int {
+	var due []int
+	for _, i := range DueSources(config, now) {
+		if f, ok := ac.sourceFailure(config.ParserConfig.Proxies[i].Source); ok && (f.Stopped() || f.RetryAt.After(now)) {
+			continue
+		}
+		due = append(due, i)
+	}
+	return due
+}
+
+// nextAutoUpdateTime returns NextAutoUpdateTime taking the backoff of failed subscriptions into account.
+// Returns zero time if no subscription is updated automatically.
+func (ac *AppController) nextAutoUpdateTime(config *ParserConfig) time.Time {
+	var next time.Time
+	found := false
+	for _, source := range config.ParserConfig.Proxies {
+		if !isScheduledSource(source) {
+			continue
+		}
+		sourceNext := SourceNextUpdate(config, source)
+		if f, ok := ac.sourceFailure(source.Source); ok {
+			if f.Stopped() {
+				continue
+			}
+			if f.RetryAt.After(sourceNext) {
+				sourceNext = f.RetryAt
+			}
+		}
+		if !found || sourceNext.Before(next) {
+			next = sourceNext
+			found = true
+		}
+	}
+	return next
+}
+
+// sourceDisplayName names a subscription in messages without exposing its token
+func sourceDisplayName(index int, source string) string {
+	if u, err := url.Parse(source); err == nil && u.Host != "" {
+		return fmt.Sprintf("subscription %d (%s)", index+1, u.Host)
+	}
+	return fmt.Sprintf("subscription %d", index+1)
+}
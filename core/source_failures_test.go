@@ -0,0 +1,70 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestSourceFailuresBackOffPerSource tests that a rejected subscription stops only its own automatic
+// updates: auto-update stays enabled and the other subscriptions keep their schedule
+func TestSourceFailuresBackOffPerSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/expired":
+			w.WriteHeader(http.StatusForbidden)
+		case "/flaky":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			fmt.Fprint(w, "vless://4a3ece53-6000-4ba3-a9fa-fd0d7ba61cf3@example.com:443?security=none&type=tcp#ok")
+		}
+	}))
+	defer server.Close()
+
+	ac, notifier := newTestController(t)
+	ac.AutoUpdateEnabled = true
+	writeScheduleTestConfig(t, ac.ConfigPath, ParserSettings{Reload: "4h"}, []ProxySource{
+		{Source: server.URL + "/ok"}, {Source: server.URL + "/expired"}, {Source: server.URL + "/flaky"},
+	})
+
+	if err := NewConfigService(ac).UpdateDueSubscriptions(); err != nil {
+		t.Fatalf("UpdateDueSubscriptions failed: %v", err)
+	}
+	status := ac.GetAutoUpdateStatus()
+	if !status.Enabled || status.FailedAttempts != 0 || status.FailedSources != 2 {
+		t.Errorf("Expected auto-update enabled with 2 failing subscriptions, got %+v", status)
+	}
+	if msgs := notifier.Messages(); len(msgs) != 1 || msgs[0] != "error: Subscription update stopped" {
+		t.Errorf("Expected one notification for the rejected subscription, got %v", msgs)
+	}
+
+	config, err := ExtractParserConfig(ac.ConfigPath)
+	if err != nil {
+		t.Fatalf("ExtractParserConfig failed: %v", err)
+	}
+	now := time.Now()
+	if due := DueSources(config, now); len(due) != 2 || due[0] != 1 || due[1] != 2 {
+		t.Errorf("Expected failed subscriptions [1 2] to stay due, got %v", due)
+	}
+	// The rejected subscription is stopped, the flaky one backs off
+	if due := ac.dueSources(config, now); len(due) != 0 {
+		t.Errorf("Expected no subscription to be retried right away, got %v", due)
+	}
+	if due := ac.dueSources(config, now.Add(autoUpdateRetryInterval)); len(due) != 1 || due[0] != 2 {
+		t.Errorf("Expected only the flaky subscription to be retried after the backoff, got %v", due)
+	}
+	next := ac.nextAutoUpdateTime(config)
+	if next.Before(now) || next.After(now.Add(autoUpdateRetryInterval)) {
+		t.Errorf("Expected the next check at the flaky subscription's retry, got %v", next)
+	}
+
+	// A manual update reports its errors itself, without notifications
+	if err := NewConfigService(ac).UpdateConfigFromSubscriptions(); err != nil {
+		t.Fatalf("UpdateConfigFromSubscriptions failed: %v", err)
+	}
+	if msgs := notifier.Messages(); len(msgs) != 1 {
+		t.Errorf("Expected no new notifications, got %v", msgs)
+	}
+}
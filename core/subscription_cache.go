@@ -0,0 +1,65 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"singbox-launcher/internal/constants"
+)
+
+// SubscriptionCache stores the last successfully fetched content of every subscription.
+// It allows regenerating config.json for sources that are not due for reload
+// (or whose server is temporarily unavailable) without downloading them again.
+// The cache lives next to config.json, so each config keeps its own cache.
+type SubscriptionCache struct {
+	dir string
+}
+
+// NewSubscriptionCache creates a cache bound to the directory of configPath.
+// Returns nil if configPath is empty; all methods are safe to call on a nil cache.
+func NewSubscriptionCache(configPath string) *SubscriptionCache {
+	if configPath == "" {
+		return nil
+	}
+	return &SubscriptionCache{
+		dir: filepath.Join(filepath.Dir(configPath), constants.SubscriptionCacheDirName),
+	}
+}
+
+// entryPath returns the cache file path for a subscription URL
+func (c *SubscriptionCache) entryPath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:16])+".txt")
+}
+
+// Load returns cached decoded content for the subscription URL
+func (c *SubscriptionCache) Load(url string) ([]byte, error) {
+	if c == nil {
+		return nil, fmt.Errorf("subscription cache is not available")
+	}
+	content, err := os.ReadFile(c.entryPath(url))
+	if err != nil {
+		return nil, fmt.Errorf("no cached content for subscription: %w", err)
+	}
+	if len(content) == 0 {
+		return nil, fmt.Errorf("cached content for subscription is empty")
+	}
+	return content, nil
+}
+
+// Store saves decoded content for the subscription URL
+func (c *SubscriptionCache) Store(url string, content []byte) error {
+	if c == nil {
+		return nil
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create subscription cache directory: %w", err)
+	}
+	if err := os.WriteFile(c.entryPath(url), content, 0644); err != nil {
+		return fmt.Errorf("failed to write subscription cache: %w", err)
+	}
+	return nil
+}
@@ -9,7 +9,6 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"regexp"
 	"strings"
 	"time"
 )
@@ -73,9 +72,9 @@ func FetchSubscription(url string) ([]byte, error) {
 		log.Printf("[DEBUG] FetchSubscription: HTTP request failed (took %v): %v", doDuration, err)
 		// Проверяем тип ошибки
 		if IsNetworkError(err) {
-			return nil, fmt.Errorf("network error: %s", GetNetworkErrorMessage(err))
+			return nil, newUpdateError(UpdateFailureNetwork, fmt.Errorf("network error: %s", GetNetworkErrorMessage(err)))
 		}
-		return nil, fmt.Errorf("failed to fetch subscription: %w", err)
+		return nil, newUpdateError(UpdateFailureNetwork, fmt.Errorf("failed to fetch subscription: %w", err))
 	}
 	defer resp.Body.Close()
 	log.Printf("[DEBUG] FetchSubscription: Received HTTP response in %v (status: %d, content-length: %d)",
@@ -83,7 +82,7 @@ func FetchSubscription(url string) ([]byte, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("[DEBUG] FetchSubscription: Non-OK status code: %d", resp.StatusCode)
-		return nil, fmt.Errorf("subscription server returned status %d", resp.StatusCode)
+		return nil, &SubscriptionHTTPError{StatusCode: resp.StatusCode}
 	}
 
 	readStartTime := time.Now()
@@ -92,14 +91,14 @@ func FetchSubscription(url string) ([]byte, error) {
 	readDuration := time.Since(readStartTime)
 	if err != nil {
 		log.Printf("[DEBUG] FetchSubscription: Failed to read response body (took %v): %v", readDuration, err)
-		return nil, fmt.Errorf("failed to read subscription content: %w", err)
+		return nil, newUpdateError(UpdateFailureNetwork, fmt.Errorf("failed to read subscription content: %w", err))
 	}
 	log.Printf("[DEBUG] FetchSubscription: Read %d bytes in %v", len(content), readDuration)
 
 	// Check if content is empty
 	if len(content) == 0 {
 		log.Printf("[DEBUG] FetchSubscription: Empty content received")
-		return nil, fmt.Errorf("subscription returned empty content")
+		return nil, newUpdateError(UpdateFailureParse, fmt.Errorf("subscription returned empty content"))
 	}
 
 	// Decode base64 if needed
@@ -109,7 +108,7 @@ func FetchSubscription(url string) ([]byte, error) {
 	decodeDuration := time.Since(decodeStartTime)
 	if err != nil {
 		log.Printf("[DEBUG] FetchSubscription: Failed to decode content (took %v): %v", decodeDuration, err)
-		return nil, fmt.Errorf("failed to decode subscription content: %w", err)
+		return nil, newUpdateError(UpdateFailureParse, fmt.Errorf("failed to decode subscription content: %w", err))
 	}
 	log.Printf("[DEBUG] FetchSubscription: Decoded content in %v (original: %d bytes, decoded: %d bytes)",
 		decodeDuration, len(content), len(decoded))
@@ -126,13 +125,18 @@ type ParserConfig struct {
 		Version   int              `json:"version,omitempty"`
 		Proxies   []ProxySource    `json:"proxies"`
 		Outbounds []OutboundConfig `json:"outbounds"`
-		Parser    struct {
-			Reload      string `json:"reload,omitempty"`       // Интервал автоматического обновления
-			LastUpdated string `json:"last_updated,omitempty"` // Время последнего обновления (RFC3339, UTC)
-		} `json:"parser,omitempty"`
+		Parser    ParserSettings   `json:"parser,omitempty"`
 	} `json:"ParserConfig"`
 }
 
+// ParserSettings represents the "parser" section of @ParserConfig (auto-update settings)
+type ParserSettings struct {
+	Reload      string      `json:"reload,omitempty"`       // Интервал автоматического обновления (по умолчанию для всех источников)
+	LastUpdated string      `json:"last_updated,omitempty"` // Время последнего обновления (RFC3339, UTC)
+	Jitter      string      `json:"jitter,omitempty"`       // Максимальная случайная задержка перед автообновлением (например, "5m")
+	QuietHours  *QuietHours `json:"quiet_hours,omitempty"`  // Окно, в течение которого автообновления откладываются
+}
+
 // ParserConfigVersion is the current version of ParserConfig format
 const ParserConfigVersion = 4
 
@@ -167,10 +171,13 @@ type ProxySource struct {
 	Source      string              `json:"source,omitempty"`
 	Connections []string            `json:"connections,omitempty"`
 	Skip        []map[string]string `json:"skip,omitempty"`
-	Outbounds   []OutboundConfig    `json:"outbounds,omitempty"`   // Local outbounds for this source (version 4)
-	TagPrefix   string              `json:"tag_prefix,omitempty"`  // Prefix to add to all node tags from this source
-	TagPostfix  string              `json:"tag_postfix,omitempty"` // Postfix to add to all node tags from this source
-	TagMask     string              `json:"tag_mask,omitempty"`    // Mask to replace entire tag (ignores tag_prefix and tag_postfix if set)
+	Outbounds   []OutboundConfig    `json:"outbounds,omitempty"`    // Local outbounds for this source (version 4)
+	TagPrefix   string              `json:"tag_prefix,omitempty"`   // Prefix to add to all node tags from this source
+	TagPostfix  string              `json:"tag_postfix,omitempty"`  // Postfix to add to all node tags from this source
+	TagMask     string              `json:"tag_mask,omitempty"`     // Mask to replace entire tag (ignores tag_prefix and tag_postfix if set)
+	Reload      string              `json:"reload,omitempty"`       // Per-source reload interval (overrides parser.reload)
+	LastUpdated string              `json:"last_updated,omitempty"` // Time this source was last fetched (RFC3339, UTC)
+	LastFailed  string              `json:"last_failed,omitempty"`  // Time of the last failed download (RFC3339, UTC), cleared by a successful one
 }
 
 // OutboundConfig represents an outbound selector configuration (version 3)
@@ -195,18 +202,12 @@ func ExtractParserConfig(configPath string) (*ParserConfig, error) {
 		return nil, fmt.Errorf("failed to read config.json: %w", err)
 	}
 
-	// Find the @ParserConfig block using regex
-	// Pattern matches: /** @ParserConfig ... */
-	pattern := regexp.MustCompile(`/\*\*\s*@ParserConfig\s*\n([\s\S]*?)\*/`)
-	matches := pattern.FindSubmatch(data)
-
-	if len(matches) < 2 {
-		return nil, fmt.Errorf("@ParserConfig block not found in config.json")
+	// Find the @ParserConfig block and extract the JSON content from the comment block
+	jsonContent, err := extractParserConfigBlock(data)
+	if err != nil {
+		return nil, err
 	}
 
-	// Extract the JSON content from the comment block
-	jsonContent := strings.TrimSpace(string(matches[1]))
-
 	// Extract version from JSON to check if migration is needed
 	currentVersion := extractVersion(jsonContent)
 
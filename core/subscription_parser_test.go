@@ -91,10 +91,7 @@ func TestNormalizeParserConfig(t *testing.T) {
 				Version   int              `json:"version,omitempty"`
 				Proxies   []ProxySource    `json:"proxies"`
 				Outbounds []OutboundConfig `json:"outbounds"`
-				Parser    struct {
-					Reload      string `json:"reload,omitempty"`
-					LastUpdated string `json:"last_updated,omitempty"`
-				} `json:"parser,omitempty"`
+				Parser    ParserSettings   `json:"parser,omitempty"`
 			}{},
 		}
 		NormalizeParserConfig(config, false)
@@ -109,10 +106,7 @@ func TestNormalizeParserConfig(t *testing.T) {
 				Version   int              `json:"version,omitempty"`
 				Proxies   []ProxySource    `json:"proxies"`
 				Outbounds []OutboundConfig `json:"outbounds"`
-				Parser    struct {
-					Reload      string `json:"reload,omitempty"`
-					LastUpdated string `json:"last_updated,omitempty"`
-				} `json:"parser,omitempty"`
+				Parser    ParserSettings   `json:"parser,omitempty"`
 			}{},
 		}
 		NormalizeParserConfig(config, false)
@@ -127,10 +121,7 @@ func TestNormalizeParserConfig(t *testing.T) {
 				Version   int              `json:"version,omitempty"`
 				Proxies   []ProxySource    `json:"proxies"`
 				Outbounds []OutboundConfig `json:"outbounds"`
-				Parser    struct {
-					Reload      string `json:"reload,omitempty"`
-					LastUpdated string `json:"last_updated,omitempty"`
-				} `json:"parser,omitempty"`
+				Parser    ParserSettings   `json:"parser,omitempty"`
 			}{},
 		}
 		before := time.Now()
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QuietHours describes a daily window in local time during which automatic
+// configuration updates are deferred. Start and End use "HH:MM" format.
+// The window may wrap around midnight (for example "23:00" - "07:00").
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// parseClock parses "HH:MM" and returns the offset from midnight
+func parseClock(value string) (time.Duration, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil || hours < 0 || hours > 23 {
+		return 0, fmt.Errorf("invalid hours in %q", value)
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil || minutes < 0 || minutes > 59 {
+		return 0, fmt.Errorf("invalid minutes in %q", value)
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
+
+// bounds returns window start/end offsets from midnight; ok is false if the window is not configured or invalid
+func (q *QuietHours) bounds() (start, end time.Duration, ok bool) {
+	if q == nil || q.Start == "" || q.End == "" {
+		return 0, 0, false
+	}
+	start, err := parseClock(q.Start)
+	if err != nil {
+		log.Printf("Auto-update: Ignoring quiet_hours: %v", err)
+		return 0, 0, false
+	}
+	end, err = parseClock(q.End)
+	if err != nil {
+		log.Printf("Auto-update: Ignoring quiet_hours: %v", err)
+		return 0, 0, false
+	}
+	if start == end {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// Contains reports whether t falls inside the quiet window
+func (q *QuietHours) Contains(t time.Time) bool {
+	start, end, ok := q.bounds()
+	if !ok {
+		return false
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+	if start < end {
+		return offset >= start && offset < end
+	}
+	// Window wraps around midnight
+	return offset >= start || offset < end
+}
+
+// EndAfter returns the moment the quiet window containing t ends.
+// If t is outside the window, t is returned unchanged.
+func (q *QuietHours) EndAfter(t time.Time) time.Time {
+	if !q.Contains(t) {
+		return t
+	}
+	_, end, _ := q.bounds()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	windowEnd := midnight.Add(end)
+	if !windowEnd.After(t) {
+		windowEnd = windowEnd.AddDate(0, 0, 1)
+	}
+	return windowEnd
+}
+
+// SourceReloadInterval returns the reload interval for a proxy source:
+// source.reload, then parser.reload, then the default, never less than autoUpdateMinInterval
+func SourceReloadInterval(config *ParserConfig, source ProxySource) time.Duration {
+	defaultDuration, _ := time.ParseDuration(autoUpdateDefaultReload)
+
+	for _, reloadStr := range []string{source.Reload, config.ParserConfig.Parser.Reload} {
+		if reloadStr == "" {
+			continue
+		}
+		reloadDuration, err := time.ParseDuration(reloadStr)
+		if err != nil {
+			log.Printf("Auto-update: Failed to parse reload duration '%s': %v, using default", reloadStr, err)
+			continue
+		}
+		return maxDuration(autoUpdateMinInterval, reloadDuration)
+	}
+
+	return maxDuration(autoUpdateMinInterval, defaultDuration)
+}
+
+// SourceLastUpdated returns the time a proxy source was last fetched successfully.
+// Falls back to parser.last_updated for configs written before per-source timestamps existed.
+// Returns zero time if the source has never been downloaded (also if its first download failed).
+func SourceLastUpdated(config *ParserConfig, source ProxySource) time.Time {
+	candidates := []string{source.LastUpdated, config.ParserConfig.Parser.LastUpdated}
+	if source.LastUpdated == "" && source.LastFailed != "" {
+		candidates = candidates[:1]
+	}
+	for _, lastUpdatedStr := range candidates {
+		if lastUpdatedStr == "" {
+			continue
+		}
+		lastUpdated, err := time.Parse(time.RFC3339, lastUpdatedStr)
+		if err != nil {
+			log.Printf("Auto-update: Failed to parse last_updated '%s': %v", lastUpdatedStr, err)
+			continue
+		}
+		return lastUpdated.UTC()
+	}
+	return time.Time{}
+}
+
+// isScheduledSource reports whether the source is a remote subscription that needs periodic reloads.
+// Direct links (connections) never change and are not scheduled.
+func isScheduledSource(source ProxySource) bool {
+	return IsSubscriptionURL(source.Source)
+}
+
+// SourceFailed reports whether the last download of the source failed (last_failed is not
+// older than its last successful download)
+func SourceFailed(config *ParserConfig, source ProxySource) bool {
+	if source.LastFailed == "" {
+		return false
+	}
+	failedAt, err := time.Parse(time.RFC3339, source.LastFailed)
+	if err != nil {
+		log.Printf("Auto-update: Failed to parse last_failed '%s': %v", source.LastFailed, err)
+		return false
+	}
+	return !failedAt.Before(SourceLastUpdated(config, source))
+}
+
+// SourceNextUpdate returns the time the source becomes due for reload (zero time means "now").
+// A source whose last download failed stays due; the auto-update loop backs it off.
+func SourceNextUpdate(config *ParserConfig, source ProxySource) time.Time {
+	lastUpdated := SourceLastUpdated(config, source)
+	if lastUpdated.IsZero() || SourceFailed(config, source) {
+		return time.Time{}
+	}
+	return lastUpdated.Add(SourceReloadInterval(config, source))
+}
+
+// DueSources returns indexes of proxy sources whose reload interval has elapsed at now
+func DueSources(config *ParserConfig, now time.Time) []int {
+	if config == nil {
+		return nil
+	}
+	var due []int
+	for i, source := range config.ParserConfig.Proxies {
+		if !isScheduledSource(source) {
+			continue
+		}
+		if !SourceNextUpdate(config, source).After(now) {
+			due = append(due, i)
+		}
+	}
+	return due
+}
+
+// NextAutoUpdateTime returns the earliest time any proxy source becomes due.
+// Returns zero time if the config has no scheduled sources.
+func NextAutoUpdateTime(config *ParserConfig) time.Time {
+	if config == nil {
+		return time.Time{}
+	}
+	var next time.Time
+	found := false
+	for _, source := range config.ParserConfig.Proxies {
+		if !isScheduledSource(source) {
+			continue
+		}
+		sourceNext := SourceNextUpdate(config, source)
+		if !found || sourceNext.Before(next) {
+			next = sourceNext
+			found = true
+		}
+	}
+	return next
+}
+
+// autoUpdateJitter returns a random delay in [0, parser.jitter) to spread update requests over time
+func autoUpdateJitter(config *ParserConfig) time.Duration {
+	jitterStr := config.ParserConfig.Parser.Jitter
+	if jitterStr == "" {
+		return 0
+	}
+	maxJitter, err := time.ParseDuration(jitterStr)
+	if err != nil || maxJitter <= 0 {
+		log.Printf("Auto-update: Ignoring invalid jitter '%s': %v", jitterStr, err)
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(maxJitter)))
+}
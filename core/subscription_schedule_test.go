@@ -0,0 +1,112 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+// TestQuietHours tests quiet window detection, including windows that wrap around midnight
+func TestQuietHours(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 5, 10, hour, minute, 0, 0, time.Local)
+	}
+
+	t.Run("Nil quiet hours", func(t *testing.T) {
+		var q *QuietHours
+		if q.Contains(at(3, 0)) {
+			t.Error("Expected nil quiet hours to contain nothing")
+		}
+		if !q.EndAfter(at(3, 0)).Equal(at(3, 0)) {
+			t.Error("Expected EndAfter to return input time for nil quiet hours")
+		}
+	})
+
+	t.Run("Same-day window", func(t *testing.T) {
+		q := &QuietHours{Start: "09:00", End: "18:00"}
+		if !q.Contains(at(9, 0)) || !q.Contains(at(17, 59)) {
+			t.Error("Expected window to contain 09:00 and 17:59")
+		}
+		if q.Contains(at(18, 0)) || q.Contains(at(8, 59)) {
+			t.Error("Expected window to exclude 18:00 and 08:59")
+		}
+		if end := q.EndAfter(at(12, 0)); !end.Equal(at(18, 0)) {
+			t.Errorf("Expected window end 18:00, got %v", end)
+		}
+	})
+
+	t.Run("Window wrapping midnight", func(t *testing.T) {
+		q := &QuietHours{Start: "23:00", End: "07:00"}
+		if !q.Contains(at(23, 30)) || !q.Contains(at(2, 0)) {
+			t.Error("Expected window to contain 23:30 and 02:00")
+		}
+		if q.Contains(at(7, 0)) || q.Contains(at(12, 0)) {
+			t.Error("Expected window to exclude 07:00 and 12:00")
+		}
+		if end := q.EndAfter(at(23, 30)); !end.Equal(at(7, 0).AddDate(0, 0, 1)) {
+			t.Errorf("Expected window end next day 07:00, got %v", end)
+		}
+		if end := q.EndAfter(at(2, 0)); !end.Equal(at(7, 0)) {
+			t.Errorf("Expected window end 07:00, got %v", end)
+		}
+	})
+
+	t.Run("Invalid window is ignored", func(t *testing.T) {
+		q := &QuietHours{Start: "25:00", End: "07:00"}
+		if q.Contains(at(2, 0)) {
+			t.Error("Expected invalid window to be ignored")
+		}
+	})
+}
+
+// TestSourceReloadInterval tests reload interval resolution
+func TestSourceReloadInterval(t *testing.T) {
+	config := &ParserConfig{}
+	config.ParserConfig.Parser.Reload = "2h"
+
+	if got := SourceReloadInterval(config, ProxySource{Reload: "30m"}); got != 30*time.Minute {
+		t.Errorf("Expected source reload 30m, got %v", got)
+	}
+	if got := SourceReloadInterval(config, ProxySource{}); got != 2*time.Hour {
+		t.Errorf("Expected parser reload 2h, got %v", got)
+	}
+	if got := SourceReloadInterval(config, ProxySource{Reload: "1m"}); got != autoUpdateMinInterval {
+		t.Errorf("Expected reload clamped to %v, got %v", autoUpdateMinInterval, got)
+	}
+	if got := SourceReloadInterval(&ParserConfig{}, ProxySource{Reload: "invalid"}); got != 4*time.Hour {
+		t.Errorf("Expected default reload 4h, got %v", got)
+	}
+}
+
+// TestDueSources tests per-source scheduling
+func TestDueSources(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	config := &ParserConfig{}
+	config.ParserConfig.Parser.Reload = "4h"
+	config.ParserConfig.Parser.LastUpdated = now.Add(-1 * time.Hour).Format(time.RFC3339)
+	config.ParserConfig.Proxies = []ProxySource{
+		// Due: own interval elapsed
+		{Source: "https://a.example.com/sub", Reload: "30m", LastUpdated: now.Add(-1 * time.Hour).Format(time.RFC3339)},
+		// Not due: falls back to parser.reload / parser.last_updated
+		{Source: "https://b.example.com/sub"},
+		// Due: own timestamp is older than parser.reload
+		{Source: "https://c.example.com/sub", LastUpdated: now.Add(-5 * time.Hour).Format(time.RFC3339)},
+		// Direct links are never scheduled
+		{Connections: []string{"vless://uuid@example.com:443#Direct"}},
+	}
+
+	due := DueSources(config, now)
+	if len(due) != 2 || due[0] != 0 || due[1] != 2 {
+		t.Fatalf("Expected sources [0 2] to be due, got %v", due)
+	}
+
+	config.ParserConfig.Proxies[0].LastUpdated = now.Format(time.RFC3339)
+	config.ParserConfig.Proxies[2].LastUpdated = now.Format(time.RFC3339)
+	next := NextAutoUpdateTime(config)
+	if want := now.Add(30 * time.Minute); !next.Equal(want) {
+		t.Errorf("Expected next update at %v, got %v", want, next)
+	}
+
+	if next := NextAutoUpdateTime(&ParserConfig{}); !next.IsZero() {
+		t.Errorf("Expected zero next update time without sources, got %v", next)
+	}
+}
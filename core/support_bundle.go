@@ -0,0 +1,333 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"singbox-launcher/internal/constants"
+	"singbox-launcher/internal/platform"
+)
+
+const (
+	supportBundleLogBytes    = 2 * 1024 * 1024 // Last bytes of each log included in a support bundle
+	supportBundleCrashes     = 5               // Newest crash records included
+	connectivityChecksKept   = 10
+	connectivityProbeURL     = "http://cp.cloudflare.com/generate_204"
+	connectivityProbeTimeout = 5 * time.Second
+)
+
+// ConnectivityCheck is the result of a connectivity test (STUN check, proxy delay test,
+// support bundle probe). Details must not contain addresses of the user.
+type ConnectivityCheck struct {
+	Time   time.Time `json:"time"`
+	Kind   string    `json:"kind"`   // "stun", "proxy_delay", "http_probe"
+	Target string    `json:"target"` // Server, proxy or URL
+	OK     bool      `json:"ok"`
+	Detail string    `json:"detail,omitempty"` // e.g. "152 ms" or the error
+}
+
+// RecordConnectivityCheck remembers a connectivity test result for support bundles
+func (ac *AppController) RecordConnectivityCheck(check ConnectivityCheck) {
+	if check.Time.IsZero() {
+		check.Time = time.Now()
+	}
+	ac.connectivityMutex.Lock()
+	defer ac.connectivityMutex.Unlock()
+	ac.connectivityChecks = append(ac.connectivityChecks, check)
+	if len(ac.connectivityChecks) > connectivityChecksKept {
+		ac.connectivityChecks = ac.connectivityChecks[len(ac.connectivityChecks)-connectivityChecksKept:]
+	}
+}
+
+// RecentConnectivityChecks returns remembered connectivity test results, oldest first
+func (ac *AppController) RecentConnectivityChecks() []ConnectivityCheck {
+	ac.connectivityMutex.Lock()
+	defer ac.connectivityMutex.Unlock()
+	return append([]ConnectivityCheck(nil), ac.connectivityChecks...)
+}
+
+// probeConnectivity requests connectivityProbeURL (through TUN if it is active)
+func probeConnectivity() ConnectivityCheck {
+	check := ConnectivityCheck{Time: time.Now(), Kind: "http_probe", Target: connectivityProbeURL}
+	client := &http.Client{Timeout: connectivityProbeTimeout}
+	resp, err := client.Get(connectivityProbeURL)
+	if err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+	resp.Body.Close()
+	check.OK = resp.StatusCode == http.StatusNoContent
+	check.Detail = fmt.Sprintf("HTTP %d in %d ms", resp.StatusCode, time.Since(check.Time).Milliseconds())
+	return check
+}
+
+// NetworkInterfaceInfo describes a network interface in a support bundle (without addresses)
+type NetworkInterfaceInfo struct {
+	Name  string `json:"name"`
+	MTU   int    `json:"mtu"`
+	Flags string `json:"flags"`
+}
+
+// SupportBundleFile is a file of a support bundle
+type SupportBundleFile struct {
+	Name        string `json:"name"`
+	Size        int    `json:"size"`
+	Description string `json:"description"`
+}
+
+// SupportBundleManifest is manifest.json of a support bundle
+type SupportBundleManifest struct {
+	CreatedAt       time.Time `json:"created_at"`
+	LauncherVersion string    `json:"launcher_version"`
+	CoreVersion     string    `json:"core_version,omitempty"`
+	GoVersion       string    `json:"go_version"`
+	OS              string    `json:"os"`
+	Arch            string    `json:"arch"`
+	OSRelease       string    `json:"os_release,omitempty"` // Linux distribution
+	Profile         string    `json:"profile"`
+	CoreRunning     bool      `json:"core_running"`
+	Redacted        bool      `json:"redacted"` // Always true: bundle content is redacted even with debug_unredacted_logs
+
+	CapabilityCheck string                 `json:"capability_check"`        // Linux capabilities of sing-box for TUN
+	TUNInterface    string                 `json:"tun_interface,omitempty"` // interface_name of the TUN inbound
+	TUNState        string                 `json:"tun_state,omitempty"`     // "up", "down" or "missing"
+	Interfaces      []NetworkInterfaceInfo `json:"interfaces"`
+	Connectivity    []ConnectivityCheck    `json:"connectivity"`
+
+	Files  []SupportBundleFile `json:"files"`
+	Errors []string            `json:"errors,omitempty"` // Parts that could not be collected
+}
+
+// supportBundle collects files of a support bundle in memory
+type supportBundle struct {
+	manifest SupportBundleManifest
+	files    map[string][]byte
+}
+
+func (b *supportBundle) add(name, description string, data []byte) {
+	b.files[name] = data
+	b.manifest.Files = append(b.manifest.Files, SupportBundleFile{Name: name, Size: len(data), Description: description})
+}
+
+func (b *supportBundle) fail(part string, err error) {
+	b.manifest.Errors = append(b.manifest.Errors, fmt.Sprintf("%s: %v", part, err))
+}
+
+// WriteSupportBundle writes a zip archive for bug reports to w: redacted config.json and
+// ParserConfig, the last supportBundleLogBytes of every log, recent crash records and
+// manifest.json with versions, platform, capability check, TUN state and connectivity results.
+// probe runs a fresh connectivity test in addition to the remembered ones.
+func (ac *AppController) WriteSupportBundle(w io.Writer, probe bool) (*SupportBundleManifest, error) {
+	b := &supportBundle{files: make(map[string][]byte)}
+	m := &b.manifest
+	m.CreatedAt = time.Now()
+	m.LauncherVersion = constants.AppVersion
+	m.GoVersion = runtime.Version()
+	m.OS = runtime.GOOS
+	m.Arch = runtime.GOARCH
+	m.OSRelease = osRelease()
+	m.Profile = ac.GetActiveProfile()
+	m.CoreRunning = ac.RunningState.IsRunning()
+	m.Redacted = true // See redactAlways
+	if version, err := ac.GetInstalledCoreVersion(); err != nil {
+		b.fail("core version", err)
+	} else {
+		m.CoreVersion = version
+	}
+
+	// Config: secrets are masked, the structure stays for debugging. Content is redacted with
+	// redactAlways: logs written with debug_unredacted_logs must not leave the machine either.
+	if data, err := os.ReadFile(ac.GetConfigPath()); err != nil {
+		b.fail("config.json", err)
+	} else {
+		b.add("config.json", "Active config.json, redacted", []byte(redactAlways(string(data))))
+		if block, err := extractParserConfigBlock(data); err != nil {
+			b.fail("ParserConfig", err)
+		} else {
+			b.add("parser_config.json", "@ParserConfig block of config.json, redacted", []byte(redactAlways(block)))
+		}
+	}
+
+	for _, source := range LogSources {
+		path := LogSourcePath(ac.ExecDir, source)
+		data, err := readLogTailBytes(path, supportBundleLogBytes)
+		if err != nil {
+			b.fail(filepath.Base(path), err)
+			continue
+		}
+		b.add("logs/"+filepath.Base(path), fmt.Sprintf("Last %d MB of the %s log, redacted", supportBundleLogBytes/1024/1024, source),
+			[]byte(redactAlways(string(data))))
+	}
+	for _, path := range recentCrashRecords(ac.ExecDir, supportBundleCrashes) {
+		if data, err := os.ReadFile(path); err == nil {
+			b.add("crashes/"+filepath.Base(path), "sing-box crash record, redacted", []byte(redactAlways(string(data))))
+		}
+	}
+
+	// Platform state
+	m.CapabilityCheck = "not required on " + runtime.GOOS
+	if runtime.GOOS == "linux" {
+		m.CapabilityCheck = "ok"
+		if suggestion := platform.CheckAndSuggestCapabilities(ac.SingboxPath); suggestion != "" {
+			m.CapabilityCheck = suggestion
+		}
+	}
+	if name, err := getTunInterfaceName(ac.GetConfigPath()); err == nil && name != "" {
+		m.TUNInterface = name
+		m.TUNState = "missing"
+		if iface, err := net.InterfaceByName(name); err == nil {
+			m.TUNState = "down"
+			if iface.Flags&net.FlagUp != 0 {
+				m.TUNState = "up"
+			}
+		}
+	}
+	if ifaces, err := net.Interfaces(); err != nil {
+		b.fail("network interfaces", err)
+	} else {
+		for _, iface := range ifaces {
+			m.Interfaces = append(m.Interfaces, NetworkInterfaceInfo{Name: iface.Name, MTU: iface.MTU, Flags: iface.Flags.String()})
+		}
+	}
+	m.Connectivity = ac.RecentConnectivityChecks()
+	if probe {
+		check := probeConnectivity()
+		ac.RecordConnectivityCheck(check)
+		m.Connectivity = append(m.Connectivity, check)
+	}
+
+	return m, b.write(w)
+}
+
+// write writes manifest.json and the files as a zip archive
+func (b *supportBundle) write(w io.Writer) error {
+	manifest, err := json.MarshalIndent(&b.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(w)
+	names := []string{"manifest.json"}
+	for _, f := range b.manifest.Files {
+		names = append(names, f.Name)
+	}
+	for _, name := range names {
+		data := manifest
+		if name != "manifest.json" {
+			data = b.files[name]
+		}
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: b.manifest.CreatedAt})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// readLogTailBytes returns about the last maxBytes of a log, continuing into rotated
+// generations if the current file is smaller. The first (cut) line is dropped.
+func readLogTailBytes(logPath string, maxBytes int64) ([]byte, error) {
+	files := LogFiles(logPath)
+	if len(files) == 0 {
+		return nil, os.ErrNotExist
+	}
+	var result []byte
+	truncated := false
+	for i := len(files) - 1; i >= 0 && int64(len(result)) < maxBytes; i-- {
+		data, cut, err := readFileTail(files[i], maxBytes-int64(len(result)))
+		if err != nil {
+			return nil, err
+		}
+		result = append(data, result...)
+		truncated = cut
+	}
+	if truncated {
+		if i := bytes.IndexByte(result, '\n'); i >= 0 {
+			result = result[i+1:]
+		}
+	}
+	return result, nil
+}
+
+// readFileTail returns the last maxBytes of a plain or gzip-compressed file
+// and whether the file was longer
+func readFileTail(path string, maxBytes int64) ([]byte, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, false, fmt.Errorf("read %s: %w", path, err)
+		}
+		defer zr.Close()
+		data, err := io.ReadAll(zr)
+		if err != nil {
+			return nil, false, fmt.Errorf("read %s: %w", path, err)
+		}
+		if int64(len(data)) > maxBytes {
+			return data[int64(len(data))-maxBytes:], true, nil
+		}
+		return data, false, nil
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+	offset := info.Size() - maxBytes
+	if offset < 0 {
+		offset = 0
+	}
+	data := make([]byte, info.Size()-offset)
+	n, err := f.ReadAt(data, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, false, fmt.Errorf("read %s: %w", path, err)
+	}
+	return data[:n], offset > 0, nil
+}
+
+// recentCrashRecords returns paths of the newest crash records, oldest first
+func recentCrashRecords(execDir string, n int) []string {
+	files, _ := filepath.Glob(filepath.Join(platform.GetLogsDir(execDir), constants.CrashReportsDirName, "crash_*.json"))
+	sort.Strings(files)
+	if len(files) > n {
+		files = files[len(files)-n:]
+	}
+	return files
+}
+
+// osRelease returns the distribution name on Linux ("" elsewhere)
+func osRelease() string {
+	if runtime.GOOS != "linux" {
+		return ""
+	}
+	data, err := os.ReadFile("/etc/os-release")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "PRETTY_NAME="); ok {
+			return strings.Trim(value, `"`)
+		}
+	}
+	return ""
+}
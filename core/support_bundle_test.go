@@ -0,0 +1,150 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"singbox-launcher/internal/constants"
+	"singbox-launcher/internal/platform"
+)
+
+// TestWriteSupportBundle tests the archive content, the manifest and redaction
+func TestWriteSupportBundle(t *testing.T) {
+	ac, _ := newTestController(t)
+	const secret = "0b6e1c6a-5a4b-4c1e-9d4f-3b2a1c0d9e8f"
+	config := `{
+/** @ParserConfig
+{"ParserConfig": {"version": 4, "proxies": [{"source": "https://sub.example.com/s?token=SubToken12345"}]}}
+*/
+"outbounds": [{"type": "vless", "tag": "node", "uuid": "` + secret + `"}]
+}`
+	if err := os.WriteFile(ac.ConfigPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	SetLogRedaction(false) // Simulate a log written with the debug opt-in
+	ac.ChildLogFile.Write([]byte("INFO outbound/vless[node]: uuid=" + secret + "\n"))
+	SetLogRedaction(true)
+	ac.RecordConnectivityCheck(ConnectivityCheck{Kind: "proxy_delay", Target: "node", OK: true, Detail: "42 ms"})
+
+	var buf bytes.Buffer
+	manifest, err := ac.WriteSupportBundle(&buf, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := readBundle(t, buf.Bytes())
+	for _, name := range []string{"manifest.json", "config.json", "parser_config.json", "logs/sing-box.log", "logs/singbox-launcher.log"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Missing %s in bundle (files: %v, errors: %v)", name, manifest.Files, manifest.Errors)
+		}
+	}
+	for name, content := range files {
+		for _, s := range []string{secret, "SubToken12345"} {
+			if strings.Contains(content, s) {
+				t.Errorf("Secret %q found in %s", s, name)
+			}
+		}
+	}
+	if !strings.Contains(files["config.json"], `"tag": "node"`) {
+		t.Error("config.json must keep non-secret fields")
+	}
+
+	var saved SupportBundleManifest
+	if err := json.Unmarshal([]byte(files["manifest.json"]), &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.LauncherVersion == "" || saved.OS == "" || !saved.Redacted || len(saved.Connectivity) != 1 || len(saved.Files) != len(manifest.Files) {
+		t.Errorf("Unexpected manifest %+v", saved)
+	}
+}
+
+// TestWriteSupportBundleUnredactedLogs tests that no secret reaches the archive while
+// debug_unredacted_logs is on
+func TestWriteSupportBundleUnredactedLogs(t *testing.T) {
+	ac, _ := newTestController(t)
+	SetLogRedaction(false)
+	defer SetLogRedaction(true)
+
+	const secret = "0b6e1c6a-5a4b-4c1e-9d4f-3b2a1c0d9e8f"
+	config := `{
+/** @ParserConfig
+{"ParserConfig": {"version": 4, "proxies": [{"source": "https://sub.example.com/s?token=SubToken12345"}]}}
+*/
+"outbounds": [{"type": "shadowsocks", "tag": "node", "password": "RawPassword1", "uuid": "` + secret + `"}]
+}`
+	if err := os.WriteFile(ac.ConfigPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ac.ChildLogFile.Write([]byte("INFO outbound/vless[node]: uuid=" + secret + " password=RawPassword1\n"))
+	crashDir := filepath.Join(platform.GetLogsDir(ac.ExecDir), constants.CrashReportsDirName)
+	os.MkdirAll(crashDir, 0755)
+	os.WriteFile(filepath.Join(crashDir, "crash_20260101-000000_1.json"), []byte(`{"output": ["uuid=`+secret+`"]}`), 0644)
+
+	var buf bytes.Buffer
+	manifest, err := ac.WriteSupportBundle(&buf, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := readBundle(t, buf.Bytes())
+	if len(files) < 5 {
+		t.Fatalf("Unexpected bundle files %v (errors: %v)", manifest.Files, manifest.Errors)
+	}
+	for name, content := range files {
+		for _, s := range []string{secret, "SubToken12345", "RawPassword1"} {
+			if strings.Contains(content, s) {
+				t.Errorf("Secret %q found in %s", s, name)
+			}
+		}
+	}
+	if !manifest.Redacted {
+		t.Error("Bundle must be marked as redacted")
+	}
+}
+
+// readBundle returns the files of a support bundle archive by name
+func readBundle(t *testing.T, archive []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(r)
+		r.Close()
+		files[f.Name] = string(data)
+	}
+	return files
+}
+
+// TestReadLogTailBytes tests reading the end of a log across a compressed generation
+func TestReadLogTailBytes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	os.WriteFile(path+".1", []byte("aaaa\nbbbb\n"), 0644)
+	if err := gzipFile(path+".1", path+".1.gz"); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(path + ".1")
+	os.WriteFile(path, []byte("cccc\n"), 0644)
+
+	data, err := readLogTailBytes(path, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "bbbb\ncccc\n" {
+		t.Errorf("readLogTailBytes = %q", data)
+	}
+	if data, _ := readLogTailBytes(path, 100); string(data) != "aaaa\nbbbb\ncccc\n" {
+		t.Errorf("readLogTailBytes (all) = %q", data)
+	}
+}
@@ -0,0 +1,158 @@
+package core
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"singbox-launcher/api"
+)
+
+const (
+	// TrafficHistoryWindow is how long traffic samples are kept for the graph
+	TrafficHistoryWindow  = 60 * time.Minute
+	trafficReconnectDelay = 2 * time.Second
+)
+
+// TrafficSample is a second of traffic through sing-box with the memory it used at that time
+type TrafficSample struct {
+	Time   time.Time `json:"time"`
+	Up     int64     `json:"up"`     // Bytes per second
+	Down   int64     `json:"down"`   // Bytes per second
+	Memory uint64    `json:"memory"` // Bytes in use
+}
+
+// TrafficMonitor reads the Clash API /traffic and /memory streams while sing-box is running,
+// keeps samples for TrafficHistoryWindow and publishes EventTraffic for every sample.
+// Streams are reconnected when sing-box restarts or the connection breaks.
+type TrafficMonitor struct {
+	ac             *AppController
+	reconnectDelay time.Duration
+	wake           chan struct{}
+
+	mutex         sync.Mutex
+	samples       []TrafficSample // Oldest first
+	memory        uint64
+	connected     bool
+	cancelSession context.CancelFunc
+}
+
+// NewTrafficMonitor creates a monitor; it does nothing until Run is called
+func NewTrafficMonitor(ac *AppController) *TrafficMonitor {
+	return &TrafficMonitor{
+		ac:             ac,
+		reconnectDelay: trafficReconnectDelay,
+		wake:           make(chan struct{}, 1),
+	}
+}
+
+// Run streams traffic while sing-box is running until ctx is cancelled
+func (m *TrafficMonitor) Run(ctx context.Context) {
+	unsubscribe := m.ac.Events.Subscribe(func(e Event) {
+		if e.Type != EventCoreStatusChanged && e.Type != EventConfigChanged {
+			return
+		}
+		if !m.ac.RunningState.IsRunning() {
+			m.stopSession()
+		}
+		select {
+		case m.wake <- struct{}{}:
+		default:
+		}
+	})
+	defer unsubscribe()
+
+	for {
+		if m.ac.RunningState.IsRunning() && m.ac.ClashAPIEnabled {
+			if err := m.session(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("TrafficMonitor: Stream ended: %v", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.wake:
+		case <-time.After(m.reconnectDelay):
+		}
+	}
+}
+
+// session reads the streams until they break or sing-box stops.
+// Returns nil if the streams could not be opened (sing-box is still starting).
+func (m *TrafficMonitor) session(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	m.mutex.Lock()
+	m.cancelSession = cancel
+	m.mutex.Unlock()
+
+	client := m.ac.ClashClient()
+	go func() {
+		client.StreamMemory(ctx, func(mem api.Memory) {
+			m.mutex.Lock()
+			m.memory = mem.InUse
+			m.mutex.Unlock()
+		})
+	}()
+
+	err := client.StreamTraffic(ctx, m.add)
+
+	m.mutex.Lock()
+	wasConnected := m.connected
+	m.connected = false
+	m.memory = 0
+	m.cancelSession = nil
+	m.mutex.Unlock()
+	if !wasConnected {
+		return nil
+	}
+	return err
+}
+
+// stopSession cancels the current streams
+func (m *TrafficMonitor) stopSession() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.cancelSession != nil {
+		m.cancelSession()
+	}
+}
+
+// add stores a sample of the /traffic stream and publishes EventTraffic
+func (m *TrafficMonitor) add(t api.Traffic) {
+	m.mutex.Lock()
+	sample := TrafficSample{Time: time.Now(), Up: t.Up, Down: t.Down, Memory: m.memory}
+	if !m.connected {
+		m.connected = true
+		log.Printf("TrafficMonitor: Connected to Clash API streams")
+	}
+	m.samples = append(m.samples, sample)
+	cutoff := sample.Time.Add(-TrafficHistoryWindow)
+	if i := sort.Search(len(m.samples), func(i int) bool { return m.samples[i].Time.After(cutoff) }); i > 0 {
+		m.samples = m.samples[i:]
+	}
+	m.mutex.Unlock()
+
+	m.ac.Events.Publish(Event{Type: EventTraffic, Time: sample.Time, Traffic: &sample})
+}
+
+// Latest returns the last sample and whether the streams are connected
+func (m *TrafficMonitor) Latest() (TrafficSample, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if !m.connected || len(m.samples) == 0 {
+		return TrafficSample{}, false
+	}
+	return m.samples[len(m.samples)-1], true
+}
+
+// History returns samples of the last window, oldest first
+func (m *TrafficMonitor) History(window time.Duration) []TrafficSample {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	cutoff := time.Now().Add(-window)
+	i := sort.Search(len(m.samples), func(i int) bool { return m.samples[i].Time.After(cutoff) })
+	return append([]TrafficSample(nil), m.samples[i:]...)
+}
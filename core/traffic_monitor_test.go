@@ -0,0 +1,100 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"singbox-launcher/api/apitest"
+)
+
+// waitFor polls cond until it is true or the timeout expires
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestTrafficMonitor tests sampling, reconnection after a broken stream and stop with the core
+func TestTrafficMonitor(t *testing.T) {
+	ac, _ := newTestController(t)
+	fake := apitest.NewFakeServer("secret")
+	defer fake.Close()
+	fake.StreamInterval = 20 * time.Millisecond
+	fake.SetTraffic(1000, 5000)
+	fake.SetMemory(30 << 20)
+	ac.ClashAPIBaseURL, ac.ClashAPIToken, ac.ClashAPIEnabled = fake.URL, fake.Secret, true
+
+	events := make(chan TrafficSample, 100)
+	unsubscribe := ac.Events.Subscribe(func(e Event) {
+		if e.Type == EventTraffic {
+			select {
+			case events <- *e.Traffic:
+			default:
+			}
+		}
+	})
+	defer unsubscribe()
+
+	m := NewTrafficMonitor(ac)
+	m.reconnectDelay = 20 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	if _, ok := m.Latest(); ok {
+		t.Error("Monitor must not be connected while sing-box is stopped")
+	}
+	ac.RunningState.Set(true)
+	select {
+	case sample := <-events:
+		if sample.Up != 1000 || sample.Down != 5000 {
+			t.Errorf("Unexpected sample %+v", sample)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("No traffic event")
+	}
+	waitFor(t, 5*time.Second, "memory", func() bool {
+		latest, ok := m.Latest()
+		return ok && latest.Memory == 30<<20
+	})
+
+	// A restarted core breaks the streams; the monitor reconnects
+	fake.SetTraffic(2000, 0)
+	fake.CloseClientConnections()
+	waitFor(t, 5*time.Second, "reconnection", func() bool {
+		latest, ok := m.Latest()
+		return ok && latest.Up == 2000
+	})
+	connects := 0
+	for _, r := range fake.Requests() {
+		if strings.HasPrefix(r, "GET /traffic") {
+			connects++
+		}
+	}
+	if connects < 2 {
+		t.Errorf("Expected a reconnection, /traffic requested %d times", connects)
+	}
+	if history := m.History(time.Minute); len(history) < 2 || history[0].Up != 1000 || history[len(history)-1].Up != 2000 {
+		t.Errorf("Unexpected history of %d samples", len(history))
+	}
+
+	ac.RunningState.Set(false)
+	waitFor(t, 5*time.Second, "disconnect", func() bool {
+		_, ok := m.Latest()
+		return !ok
+	})
+}
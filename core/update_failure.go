@@ -0,0 +1,120 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// UpdateFailureKind classifies why a configuration update failed.
+// The auto-update loop uses it to decide whether to retry, back off or stop.
+type UpdateFailureKind int
+
+const (
+	UpdateFailureUnknown    UpdateFailureKind = iota // Unclassified error
+	UpdateFailureNetwork                             // Transient network/server problem, always retried
+	UpdateFailureAuth                                // HTTP 4xx: subscription token is invalid or expired
+	UpdateFailureParse                               // Subscription content cannot be decoded
+	UpdateFailureValidation                          // ParserConfig or generated config is invalid
+)
+
+// String returns a human-readable name of the failure kind
+func (k UpdateFailureKind) String() string {
+	switch k {
+	case UpdateFailureNetwork:
+		return "network"
+	case UpdateFailureAuth:
+		return "auth"
+	case UpdateFailureParse:
+		return "parse"
+	case UpdateFailureValidation:
+		return "validation"
+	default:
+		return "unknown"
+	}
+}
+
+// UpdateError is an error tagged with its failure kind
+type UpdateError struct {
+	Kind UpdateFailureKind
+	Err  error
+}
+
+func (e *UpdateError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UpdateError) Unwrap() error {
+	return e.Err
+}
+
+// newUpdateError wraps err with the given failure kind
+func newUpdateError(kind UpdateFailureKind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &UpdateError{Kind: kind, Err: err}
+}
+
+// SubscriptionHTTPError is returned when a subscription server responds with a non-OK status
+type SubscriptionHTTPError struct {
+	StatusCode int
+}
+
+func (e *SubscriptionHTTPError) Error() string {
+	return fmt.Sprintf("subscription server returned status %d", e.StatusCode)
+}
+
+// ClassifyUpdateError determines the failure kind of a configuration update error.
+// HTTP 401/403/404/410 and other 4xx responses (except 408/429) mean the subscription
+// is no longer accessible; 5xx, 408 and 429 are treated as transient network failures.
+func ClassifyUpdateError(err error) UpdateFailureKind {
+	if err == nil {
+		return UpdateFailureUnknown
+	}
+
+	var httpErr *SubscriptionHTTPError
+	if errors.As(err, &httpErr) {
+		switch {
+		case httpErr.StatusCode == http.StatusRequestTimeout || httpErr.StatusCode == http.StatusTooManyRequests:
+			return UpdateFailureNetwork
+		case httpErr.StatusCode >= 400 && httpErr.StatusCode < 500:
+			return UpdateFailureAuth
+		default:
+			return UpdateFailureNetwork
+		}
+	}
+
+	var updateErr *UpdateError
+	if errors.As(err, &updateErr) {
+		return updateErr.Kind
+	}
+
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if IsNetworkError(e) {
+			return UpdateFailureNetwork
+		}
+	}
+
+	return UpdateFailureUnknown
+}
+
+// autoUpdateBackoff returns the delay before the next auto-update attempt after
+// the given number of consecutive failures: retryInterval * 2^(failures-1), capped at maxBackoff
+func autoUpdateBackoff(failures int, retryInterval, maxBackoff time.Duration) time.Duration {
+	if failures < 1 {
+		return retryInterval
+	}
+	delay := retryInterval
+	for i := 1; i < failures; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	if delay > maxBackoff {
+		return maxBackoff
+	}
+	return delay
+}
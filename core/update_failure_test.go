@@ -0,0 +1,75 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestClassifyUpdateError tests failure classification used by the auto-update loop
+func TestClassifyUpdateError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want UpdateFailureKind
+	}{
+		{"Nil error", nil, UpdateFailureUnknown},
+		{"HTTP 403", &SubscriptionHTTPError{StatusCode: http.StatusForbidden}, UpdateFailureAuth},
+		{"HTTP 410 wrapped", fmt.Errorf("failed to fetch 1 subscription(s): %w", &SubscriptionHTTPError{StatusCode: http.StatusGone}), UpdateFailureAuth},
+		{"HTTP 429", &SubscriptionHTTPError{StatusCode: http.StatusTooManyRequests}, UpdateFailureNetwork},
+		{"HTTP 502", &SubscriptionHTTPError{StatusCode: http.StatusBadGateway}, UpdateFailureNetwork},
+		{"Tagged parse error", newUpdateError(UpdateFailureParse, errors.New("bad base64")), UpdateFailureParse},
+		{"Tagged validation error", fmt.Errorf("outer: %w", newUpdateError(UpdateFailureValidation, errors.New("no content"))), UpdateFailureValidation},
+		{"Raw network error", fmt.Errorf("dial: %w", &net.DNSError{Name: "example.invalid"}), UpdateFailureNetwork},
+		{"Unknown error", errors.New("something else"), UpdateFailureUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyUpdateError(tt.err); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestFetchSubscription_HTTPStatus tests that HTTP status errors are classified
+func TestFetchSubscription_HTTPStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := FetchSubscription(server.URL)
+	if err == nil {
+		t.Fatal("Expected error for HTTP 401")
+	}
+	if kind := ClassifyUpdateError(err); kind != UpdateFailureAuth {
+		t.Errorf("Expected auth failure, got %s (%v)", kind, err)
+	}
+}
+
+// TestAutoUpdateBackoff tests exponential backoff with cap
+func TestAutoUpdateBackoff(t *testing.T) {
+	base := 10 * time.Second
+	maxBackoff := 5 * time.Minute
+
+	expected := map[int]time.Duration{
+		0:   base,
+		1:   base,
+		2:   20 * time.Second,
+		3:   40 * time.Second,
+		5:   160 * time.Second,
+		6:   maxBackoff,
+		100: maxBackoff,
+	}
+	for failures, want := range expected {
+		if got := autoUpdateBackoff(failures, base, maxBackoff); got != want {
+			t.Errorf("autoUpdateBackoff(%d) = %v, want %v", failures, got, want)
+		}
+	}
+}
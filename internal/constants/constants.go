@@ -6,12 +6,22 @@ const (
 	TunDLLName      = "tun.dll"
 	ConfigFileName  = "config.json"
 	SingBoxExecName = "sing-box"
+
+	LauncherSettingsFileName = "launcher_settings.json"
+	ControlSocketFileName    = "control.sock"
+	InstanceLockFileName     = "launcher.lock"
+	PreviousConfigFileName   = "config.prev.json"      // Last config.json that sing-box ran successfully
+	ProxySelectionsFileName  = "proxy_selections.json" // Manual proxy choices per group, next to config.json
 )
 
 // Directory names
 const (
-	BinDirName  = "bin"
-	LogsDirName = "logs"
+	BinDirName                = "bin"
+	LogsDirName               = "logs"
+	SubscriptionCacheDirName  = "subscription_cache"
+	ParserConfigBackupDirName = "parser_config_backups"
+	ProfilesDirName           = "profiles"
+	CrashReportsDirName       = "crashes" // In logs/
 )
 
 // Log file names
@@ -31,6 +41,8 @@ const (
 // Network constants
 const (
 	DefaultSTUNServer = "stun.l.google.com:19302"
+
+	DefaultControlAPIAddress = "127.0.0.1:19090" // Local control API on platforms without Unix sockets
 )
 
 // Application version
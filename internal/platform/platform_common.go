@@ -1,6 +1,7 @@
 package platform
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 
@@ -12,6 +13,14 @@ func GetConfigPath(execDir string) string {
 	return filepath.Join(execDir, constants.BinDirName, constants.ConfigFileName)
 }
 
+// ErrInstanceLocked is returned by LockInstance when another launcher holds the lock
+var ErrInstanceLocked = errors.New("another launcher instance is running")
+
+// GetInstanceLockPath returns the path to the single-instance lock file
+func GetInstanceLockPath(execDir string) string {
+	return filepath.Join(execDir, constants.BinDirName, constants.InstanceLockFileName)
+}
+
 // GetBinDir returns the path to bin directory
 func GetBinDir(execDir string) string {
 	return filepath.Join(execDir, constants.BinDirName)
@@ -5,9 +5,11 @@ package platform
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
+	"syscall"
 
 	"singbox-launcher/internal/constants"
 )
@@ -48,11 +50,27 @@ func SendCtrlBreak(pid int) error {
 	return fmt.Errorf("SendCtrlBreak not supported on darwin for pid %d", pid)
 }
 
+// SupportsReloadSignal reports whether sing-box can reload its config on SIGHUP
+func SupportsReloadSignal() bool {
+	return true
+}
+
+// SendReloadSignal asks the process to reload its configuration (SIGHUP)
+func SendReloadSignal(pid int) error {
+	return syscall.Kill(pid, syscall.SIGHUP)
+}
+
 // PrepareCommand prepares a command with platform-specific attributes
 func PrepareCommand(cmd *exec.Cmd) {
 	// No special attributes needed for macOS
 }
 
+// PrepareDetachedCommand prepares a command that keeps running after the launcher exits
+// (new session, not affected by terminal hangup)
+func PrepareDetachedCommand(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
 // GetRequiredFiles returns platform-specific required files
 func GetRequiredFiles(execDir string) []struct {
 	Name string
@@ -81,3 +99,35 @@ func GetBuildFlags() string {
 func CheckAndSuggestCapabilities(singboxPath string) string {
 	return "" // Capabilities are Linux-specific, not needed on macOS
 }
+
+// LockInstance takes an exclusive lock on path for the lifetime of the process.
+// The lock is released by the OS when the process exits, even after a crash.
+// Returns ErrInstanceLocked if another process holds it.
+func LockInstance(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, ErrInstanceLocked
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	// PID for diagnostics only, the lock itself is what matters
+	_ = file.Truncate(0)
+	_, _ = file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return file, nil
+}
+
+// RegisterURLScheme is not supported on macOS: links are registered by CFBundleURLTypes of an
+// app bundle and delivered as Apple Events, not as command line arguments
+func RegisterURLScheme(scheme, exePath string) error {
+	return fmt.Errorf("registering %s:// links is not supported on macOS", scheme)
+}
+
+// URLSchemeHandler is not supported on macOS, see RegisterURLScheme
+func URLSchemeHandler(scheme, exePath string) (string, bool, error) {
+	return "", false, fmt.Errorf("registering %s:// links is not supported on macOS", scheme)
+}
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
 
 	"singbox-launcher/internal/constants"
 )
@@ -50,12 +51,28 @@ func SendCtrlBreak(pid int) error {
 	return fmt.Errorf("SendCtrlBreak not supported on linux for pid %d", pid)
 }
 
+// SupportsReloadSignal reports whether sing-box can reload its config on SIGHUP
+func SupportsReloadSignal() bool {
+	return true
+}
+
+// SendReloadSignal asks the process to reload its configuration (SIGHUP)
+func SendReloadSignal(pid int) error {
+	return syscall.Kill(pid, syscall.SIGHUP)
+}
+
 // PrepareCommand prepares a command with platform-specific attributes
 func PrepareCommand(cmd *exec.Cmd) {
 	// No special attributes needed for Linux
 	// Capabilities should be set on the sing-box binary itself
 }
 
+// PrepareDetachedCommand prepares a command that keeps running after the launcher exits
+// (new session, not affected by terminal hangup)
+func PrepareDetachedCommand(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
 // GetRequiredFiles returns platform-specific required files
 func GetRequiredFiles(execDir string) []struct {
 	Name string
@@ -131,3 +148,81 @@ func CheckAndSuggestCapabilities(singboxPath string) string {
 
 	return "" // Capabilities are OK
 }
+
+// LockInstance takes an exclusive lock on path for the lifetime of the process.
+// The lock is released by the OS when the process exits, even after a crash.
+// Returns ErrInstanceLocked if another process holds it.
+func LockInstance(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, ErrInstanceLocked
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	// PID for diagnostics only, the lock itself is what matters
+	_ = file.Truncate(0)
+	_, _ = file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return file, nil
+}
+
+// urlHandlerDesktopFile is the desktop entry that registers the launcher as a URL scheme handler
+const urlHandlerDesktopFile = "singbox-launcher-url-handler.desktop"
+
+// RegisterURLScheme makes exePath the handler of scheme:// links for the current user:
+// a hidden desktop entry with MimeType x-scheme-handler/<scheme> is written to
+// $XDG_DATA_HOME/applications and set as the default handler with xdg-mime.
+// Nothing is changed if the entry is up to date.
+func RegisterURLScheme(scheme, exePath string) error {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	appsDir := filepath.Join(dataHome, "applications")
+	path := filepath.Join(appsDir, urlHandlerDesktopFile)
+	mimeType := "x-scheme-handler/" + scheme
+	entry := fmt.Sprintf("[Desktop Entry]\nType=Application\nName=Singbox Launcher\nExec=%s %%u\n"+
+		"NoDisplay=true\nTerminal=false\nMimeType=%s;\n", desktopExecQuote(exePath), mimeType)
+
+	if current, err := os.ReadFile(path); err == nil && string(current) == entry {
+		return nil
+	}
+	if err := os.MkdirAll(appsDir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(entry), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	// Optional: refreshes the MIME cache of desktop environments that use it
+	_ = exec.Command("update-desktop-database", appsDir).Run()
+	if out, err := exec.Command("xdg-mime", "default", urlHandlerDesktopFile, mimeType).CombinedOutput(); err != nil {
+		return fmt.Errorf("xdg-mime default failed: %v %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// URLSchemeHandler returns the desktop entry that handles scheme:// links for the current user
+// ("" if none) and whether it is the launcher's entry (see RegisterURLScheme)
+func URLSchemeHandler(scheme, exePath string) (string, bool, error) {
+	out, err := exec.Command("xdg-mime", "query", "default", "x-scheme-handler/"+scheme).Output()
+	if err != nil {
+		return "", false, fmt.Errorf("xdg-mime query failed: %w", err)
+	}
+	handler := strings.TrimSpace(string(out))
+	return handler, handler == urlHandlerDesktopFile, nil
+}
+
+// desktopExecQuote quotes a path for the Exec key of a desktop entry (quoting rules of the
+// Desktop Entry Specification, then escaping of backslashes in string values)
+func desktopExecQuote(path string) string {
+	r := strings.NewReplacer(`\`, `\\\\`, `"`, `\\"`, "`", "\\\\`", `$`, `\\$`, `%`, `%%`)
+	return `"` + r.Replace(path) + `"`
+}
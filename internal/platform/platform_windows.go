@@ -4,11 +4,16 @@
 package platform
 
 import (
+	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"syscall"
 
+	"golang.org/x/sys/windows/registry"
+
 	"singbox-launcher/internal/constants"
 )
 
@@ -52,11 +57,32 @@ func SendCtrlBreak(pid int) error {
 	return nil
 }
 
+// SupportsReloadSignal reports whether sing-box can reload its config on SIGHUP.
+// There is no SIGHUP on Windows, config changes require a full restart.
+func SupportsReloadSignal() bool {
+	return false
+}
+
+// SendReloadSignal is not applicable on Windows; provided for interface parity.
+func SendReloadSignal(pid int) error {
+	return fmt.Errorf("SendReloadSignal not supported on windows for pid %d", pid)
+}
+
 // PrepareCommand prepares a command with platform-specific attributes
 func PrepareCommand(cmd *exec.Cmd) {
 	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
 }
 
+// PrepareDetachedCommand prepares a command that keeps running after the launcher exits
+// (own process group, no console)
+func PrepareDetachedCommand(cmd *exec.Cmd) {
+	const detachedProcess = 0x00000008 // DETACHED_PROCESS
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		HideWindow:    true,
+		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | detachedProcess,
+	}
+}
+
 // GetRequiredFiles returns platform-specific required files
 func GetRequiredFiles(execDir string) []struct {
 	Name string
@@ -86,3 +112,95 @@ func GetBuildFlags() string {
 func CheckAndSuggestCapabilities(singboxPath string) string {
 	return "" // Capabilities are Windows-specific, not needed here
 }
+
+// errorSharingViolation is ERROR_SHARING_VIOLATION
+const errorSharingViolation syscall.Errno = 32
+
+// LockInstance opens path without sharing for the lifetime of the process.
+// The handle is closed by the OS when the process exits, even after a crash.
+// Returns ErrInstanceLocked if another process holds it.
+func LockInstance(path string) (*os.File, error) {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := syscall.CreateFile(pathPtr,
+		syscall.GENERIC_READ|syscall.GENERIC_WRITE,
+		0, // No sharing: a second open fails while we hold the handle
+		nil, syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		if err == errorSharingViolation {
+			return nil, ErrInstanceLocked
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	file := os.NewFile(uintptr(handle), path)
+	// PID for diagnostics only, the lock itself is what matters
+	_ = file.Truncate(0)
+	_, _ = file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\r\n"), 0)
+	return file, nil
+}
+
+// URLSchemeHandler returns the command that opens scheme:// links (HKEY_CLASSES_ROOT, "" if none)
+// and whether it starts a launcher executable (the same name as exePath, possibly moved)
+func URLSchemeHandler(scheme, exePath string) (string, bool, error) {
+	key, err := registry.OpenKey(registry.CLASSES_ROOT, scheme+`\shell\open\command`, registry.QUERY_VALUE)
+	if err == registry.ErrNotExist {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	defer key.Close()
+	command, _, err := key.GetStringValue("")
+	if err != nil {
+		return "", false, nil
+	}
+	program := command
+	if strings.HasPrefix(program, `"`) {
+		if end := strings.Index(program[1:], `"`); end >= 0 {
+			program = program[1 : end+1]
+		}
+	} else if i := strings.Index(program, " "); i >= 0 {
+		program = program[:i]
+	}
+	return command, strings.EqualFold(filepath.Base(program), filepath.Base(exePath)), nil
+}
+
+// RegisterURLScheme makes exePath the handler of scheme:// links for the current user
+// (HKCU\Software\Classes\<scheme>); the link is passed as the first argument.
+// Keys are only written if the registered command differs.
+func RegisterURLScheme(scheme, exePath string) error {
+	command := fmt.Sprintf("\"%s\" \"%%1\"", exePath)
+	commandPath := `Software\Classes\` + scheme + `\shell\open\command`
+	if key, err := registry.OpenKey(registry.CURRENT_USER, commandPath, registry.QUERY_VALUE); err == nil {
+		current, _, err := key.GetStringValue("")
+		key.Close()
+		if err == nil && current == command {
+			return nil
+		}
+	}
+
+	key, _, err := registry.CreateKey(registry.CURRENT_USER, `Software\Classes\`+scheme, registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("failed to create URL scheme key: %w", err)
+	}
+	err = key.SetStringValue("", "URL:"+scheme+" Protocol")
+	if err == nil {
+		err = key.SetStringValue("URL Protocol", "")
+	}
+	key.Close()
+	if err != nil {
+		return fmt.Errorf("failed to register URL scheme: %w", err)
+	}
+
+	key, _, err = registry.CreateKey(registry.CURRENT_USER, commandPath, registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("failed to create URL scheme command key: %w", err)
+	}
+	defer key.Close()
+	if err := key.SetStringValue("", command); err != nil {
+		return fmt.Errorf("failed to register URL scheme command: %w", err)
+	}
+	return nil
+}
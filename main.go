@@ -2,15 +2,22 @@ package main
 
 import (
 	_ "embed" // For embedding resource files (icons)
+	"errors"
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"runtime"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/driver/desktop"
 
 	// Import our new packages
+	"singbox-launcher/cli"
+	"singbox-launcher/control"
 	"singbox-launcher/core"
+	"singbox-launcher/internal/platform"
 	"singbox-launcher/ui"
 )
 
@@ -27,22 +34,51 @@ var greenIconData []byte // Icon for "on" state
 
 // Constants
 const (
-	autoStartDelay = 1 * time.Second // Delay before auto-starting VPN with -start parameter
+	autoStartDelay = 1 * time.Second  // Delay before auto-starting VPN with -start parameter
+	switchTimeout  = 15 * time.Second // How long -switch waits for the Clash API of the started sing-box
 )
 
 // main is the application's entry point. It simply creates and runs the AppController.
 func main() {
+	// Headless CLI mode: subcommands (update, start, status, ...) run without creating a window
+	if cli.IsCommand(os.Args[1:]) {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
+
 	// Parse command line arguments
 	autoStart := flag.Bool("start", false, "Automatically start VPN on launch")
 	startInTray := flag.Bool("tray", false, "Start minimized to system tray (hide window on launch)")
+	switchProxy := flag.String("switch", "", "Switch the selected proxy group to this proxy (with -start or a running launcher)")
 	flag.Parse()
 
+	// sing-box://import-remote-profile deep link (from a browser or the command line)
+	var deepLink string
+	for _, arg := range flag.Args() {
+		if core.IsRemoteProfileLink(arg) {
+			deepLink = arg
+		}
+	}
+
+	// Single instance: a second invocation forwards its command line to the running launcher and exits
+	instanceLock := lockInstance(control.ForwardRequest{Start: *autoStart, Switch: *switchProxy, Link: deepLink})
+
 	// Create the application controller. If an error occurs, print it and exit the program.
-	// Use greyIconData for red icon (no separate red icon yet)
-	controller, err := core.NewAppController(appIconData, greyIconData, greenIconData, greyIconData)
+	appController, err := core.NewAppController()
 	if err != nil {
 		log.Fatalf("Failed to initialize application: %v", err)
 	}
+	// Wrap it with the GUI (Fyne application, tray, dialogs)
+	// Use greyIconData for red icon (no separate red icon yet)
+	controller := ui.NewController(appController, appIconData, greyIconData, greenIconData, greyIconData)
+
+	// Start the local control API (scripts, browser extensions, Stream Deck)
+	controlServer := startControlServer(appController)
+
+	// Browsers open sing-box:// links with the launcher if the user registered it (Diagnostics tab);
+	// the running instance receives them via Forward
+	if runtime.GOOS != "darwin" {
+		go updateURLScheme()
+	}
 
 	// Check launcher version on startup
 	controller.CheckLauncherVersionOnStartup()
@@ -57,12 +93,14 @@ func main() {
 				fyne.Do(func() {
 					// Set the initial icon on the main thread after the delay
 					desk.SetSystemTrayIcon(controller.GreyIconData)
+					controller.TrayReady = true
 				})
 			}()
 			// Create the menu for the system tray with proxy selection submenu
 			// Safe wrapper with debounce to prevent "Invalid menu handle" errors
 			// when menu updates happen too quickly
-			updateTrayMenu := func() {
+			var updateTrayMenu func()
+			updateTrayMenu = func() {
 				controller.TrayMenuUpdateMutex.Lock()
 				defer controller.TrayMenuUpdateMutex.Unlock()
 
@@ -97,22 +135,32 @@ func main() {
 					// Check if update is already in progress
 					controller.TrayMenuUpdateMutex.Lock()
 					if controller.TrayMenuUpdateInProgress {
+						// Repeat after the running update, it may show stale data
+						controller.TrayMenuUpdatePending = true
 						controller.TrayMenuUpdateMutex.Unlock()
-						return // Skip update if already in progress
+						return
 					}
 					controller.TrayMenuUpdateInProgress = true
 					controller.TrayMenuUpdateMutex.Unlock()
 
+					// Menu data is collected here, outside the UI thread (Clash API, profiles, core check)
+					state := controller.LoadTrayMenuState()
+
 					fyne.Do(func() {
 						defer func() {
 							// Reset flag after update completes
 							controller.TrayMenuUpdateMutex.Lock()
 							controller.TrayMenuUpdateInProgress = false
 							controller.TrayMenuUpdateTimer = nil
+							pending := controller.TrayMenuUpdatePending
+							controller.TrayMenuUpdatePending = false
 							controller.TrayMenuUpdateMutex.Unlock()
+							if pending {
+								updateTrayMenu()
+							}
 						}()
 
-						menu := controller.CreateTrayMenu()
+						menu := controller.CreateTrayMenu(state)
 						// Use recover to handle any panics during menu update
 						func() {
 							defer func() {
@@ -133,7 +181,7 @@ func main() {
 			// Read config once at application startup
 			go func() {
 				log.Println("Application startup: Reading config...")
-				config, err := core.ExtractParserConfig(controller.ConfigPath)
+				config, err := core.ExtractParserConfig(controller.GetConfigPath())
 				if err != nil {
 					log.Printf("Application startup: Failed to read config: %v", err)
 					return
@@ -150,8 +198,13 @@ func main() {
 					// Wait a bit for everything to initialize
 					time.Sleep(autoStartDelay)
 					log.Println("Auto-start: Starting VPN due to -start parameter")
-					core.StartSingBoxProcess(controller)
+					core.StartSingBoxProcess(appController)
+					if *switchProxy != "" {
+						selectProxyAfterStart(appController, *switchProxy)
+					}
 				}()
+			} else if *switchProxy != "" {
+				log.Printf("Switch: Ignoring -switch %q without -start", *switchProxy)
 			}
 
 			// Hide window if -tray flag is provided
@@ -177,7 +230,13 @@ func main() {
 	controller.MainWindow.Resize(fyne.NewSize(350, 450)) // initial window size
 	controller.MainWindow.CenterOnScreen()               // Center the window on the screen
 
-	core.CheckIfLauncherAlreadyRunningUtil(controller)
+	if deepLink != "" {
+		if link, err := core.ParseRemoteProfileLink(deepLink); err != nil {
+			appController.Notifier.ShowErrorText("Import Profile", err.Error())
+		} else {
+			appController.RequestRemoteProfileImport(link)
+		}
+	}
 
 	// Intercept the window close event (clicking "X") to hide it instead of exiting completely.
 	controller.MainWindow.SetCloseIntercept(func() {
@@ -196,18 +255,21 @@ func main() {
 	}()
 
 	// Check if config.json exists and show a warning if it doesn't
-	core.CheckConfigFileExists(controller)
+	core.CheckConfigFileExists(appController)
 
 	// Check Linux capabilities and suggest setup if needed
-	core.CheckLinuxCapabilities(controller)
+	core.CheckLinuxCapabilities(appController)
 
 	// Check if sing-box is running on startup and show a warning if it is.
-	core.CheckIfSingBoxRunningAtStartUtil(controller)
+	core.CheckIfSingBoxRunningAtStartUtil(appController)
 
 	controller.MainWindow.ShowAndRun() // Show the main window and start the main Fyne event loop.
 	// The code below executes only after ShowAndRun() finishes.
 	// This is where final cleanup is performed.
 	log.Println("Application shutting down.")
+	if controlServer != nil {
+		controlServer.Stop()
+	}
 	controller.GracefulExit()
 
 	if controller.MainLogFile != nil {
@@ -219,4 +281,97 @@ func main() {
 	if controller.ApiLogFile != nil {
 		controller.ApiLogFile.Close()
 	}
+	if instanceLock != nil {
+		instanceLock.Close()
+	}
+}
+
+// lockInstance takes the single-instance lock of the launcher directory. If another launcher
+// holds it, req is forwarded to that launcher and the process exits.
+func lockInstance(req control.ForwardRequest) *os.File {
+	execDir, err := core.ExecutableDir()
+	if err == nil {
+		err = platform.EnsureDirectories(execDir)
+	}
+	if err != nil {
+		log.Printf("Single instance: %v", err)
+		return nil
+	}
+
+	lock, err := platform.LockInstance(platform.GetInstanceLockPath(execDir))
+	if err == nil {
+		return lock
+	}
+	if !errors.Is(err, platform.ErrInstanceLocked) {
+		// Don't refuse to start because of a lock file problem
+		log.Printf("Single instance: %v", err)
+		return nil
+	}
+
+	log.Println("Single instance: Launcher is already running, forwarding command line")
+	if err := control.Forward(execDir, req); err != nil {
+		fmt.Fprintf(os.Stderr, "singbox-launcher: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+	return nil
+}
+
+// updateURLScheme updates the path of the launcher executable in its sing-box:// link registration
+// (the launcher was moved). The scheme is registered only by the user, never taken from another handler.
+func updateURLScheme() {
+	exePath, err := os.Executable()
+	if err != nil {
+		log.Printf("URL scheme: %v", err)
+		return
+	}
+	if _, ours, err := platform.URLSchemeHandler(core.RemoteProfileScheme, exePath); err != nil || !ours {
+		return
+	}
+	if err := platform.RegisterURLScheme(core.RemoteProfileScheme, exePath); err != nil {
+		log.Printf("URL scheme: Failed to update %s:// links: %v", core.RemoteProfileScheme, err)
+	}
+}
+
+// selectProxyAfterStart applies -switch once the Clash API of the just started sing-box responds
+func selectProxyAfterStart(ac *core.AppController, proxy string) {
+	ac.APIStateMutex.RLock()
+	group := ac.SelectedClashGroup
+	ac.APIStateMutex.RUnlock()
+
+	deadline := time.Now().Add(switchTimeout)
+	for {
+		err := ac.SelectProxy(group, proxy)
+		if err == nil {
+			log.Printf("Switch: Switched '%s' to %s", group, proxy)
+			return
+		}
+		if !ac.RunningState.IsRunning() || time.Now().After(deadline) {
+			log.Printf("Switch: Failed to switch '%s' to %s: %v", group, proxy, err)
+			ac.Notifier.ShowError(fmt.Errorf("failed to switch to %s: %w", proxy, err))
+			return
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// startControlServer starts the local control API unless it is disabled in launcher settings.
+// Errors are logged: the launcher works without the API.
+func startControlServer(ac *core.AppController) *control.Server {
+	disabled := false
+	ac.Settings.Get(func(s *core.LauncherSettings) { disabled = s.ControlAPI.Disabled })
+	if disabled {
+		log.Println("ControlAPI: Disabled in launcher settings")
+		return nil
+	}
+
+	server, err := control.NewServer(ac)
+	if err == nil {
+		err = server.Start()
+	}
+	if err != nil {
+		log.Printf("ControlAPI: Failed to start: %v", err)
+		return nil
+	}
+	return server
 }
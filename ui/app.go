@@ -10,14 +10,17 @@ import (
 // App manages the UI structure and tabs
 type App struct {
 	window      fyne.Window
-	core        *core.AppController
+	core        *Controller
 	tabs        *container.AppTabs
 	clashAPITab *container.TabItem
 	currentTab  *container.TabItem
+
+	connections    *connectionsTab
+	connectionsTab *container.TabItem
 }
 
 // NewApp creates a new App instance
-func NewApp(window fyne.Window, controller *core.AppController) *App {
+func NewApp(window fyne.Window, controller *Controller) *App {
 	app := &App{
 		window: window,
 		core:   controller,
@@ -27,16 +30,21 @@ func NewApp(window fyne.Window, controller *core.AppController) *App {
 	// Создаем вкладку Core первой, чтобы её callback установился
 	coreTabItem := container.NewTabItem("⚙️ Core", CreateCoreDashboardTab(controller))
 	app.clashAPITab = container.NewTabItem("🖥️ Servers", CreateClashAPITab(controller))
+	app.connections = newConnectionsTab(controller)
+	app.connectionsTab = container.NewTabItem("🔗 Connections", app.connections.content)
 	app.tabs = container.NewAppTabs(
 		coreTabItem,
 		app.clashAPITab,
+		app.connectionsTab,
+		container.NewTabItem("📜 Logs", CreateLogsTab(controller)),
 		container.NewTabItem("🔍 Diagnostics", CreateDiagnosticsTab(controller)),
-		container.NewTabItem("❓ Help", CreateToolsTab(controller)),
+		container.NewTabItem("❓ Help", CreateHelpTab(controller)),
 	)
 
 	// Set tab selection handler
 	app.tabs.OnSelected = func(item *container.TabItem) {
 		app.currentTab = item
+		app.connections.setActive(item == app.connectionsTab)
 		if item == app.clashAPITab {
 			// Проверяем, запущен ли sing-box
 			if !controller.RunningState.IsRunning() {
@@ -49,20 +57,10 @@ func NewApp(window fyne.Window, controller *core.AppController) *App {
 		}
 	}
 
-	// Сохраняем оригинальный callback, который был установлен в CreateCoreDashboardTab
-	originalUpdateCoreStatusFunc := controller.UpdateCoreStatusFunc
-
-	// Регистрируем комбинированный callback для обновления состояния вкладки Servers
-	controller.UpdateCoreStatusFunc = func() {
-		// Вызываем оригинальный callback, если он есть
-		if originalUpdateCoreStatusFunc != nil {
-			originalUpdateCoreStatusFunc()
-		}
-		// Обновляем состояние вкладки Servers
-		fyne.Do(func() {
-			app.updateClashAPITabState()
-		})
-	}
+	// Обновляем состояние вкладки Servers при изменении статуса sing-box
+	controller.onEvent(func(core.Event) {
+		app.updateClashAPITabState()
+	}, core.EventCoreStatusChanged)
 
 	// Инициализируем состояние вкладки
 	app.updateClashAPITabState()
@@ -80,8 +78,8 @@ func (a *App) GetWindow() fyne.Window {
 	return a.window
 }
 
-// GetController returns the core controller
-func (a *App) GetController() *core.AppController {
+// GetController returns the controller
+func (a *App) GetController() *Controller {
 	return a.core
 }
 
@@ -1,9 +1,11 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"image/color"
 	"log"
+	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -15,13 +17,91 @@ import (
 	"singbox-launcher/core"
 )
 
+// Sort orders of the proxy list
+const (
+	proxySortName    = "Name"
+	proxySortLatency = "Latency"
+)
+
+// Delay thresholds of the latency badge colors
+const (
+	latencyFast = 300 // ms, green below
+	latencySlow = 800 // ms, yellow below, red above
+)
+
+// setLatencyBadge shows the delay on the ping button colored by speed ("Ping" if not tested)
+func setLatencyBadge(button *widget.Button, delay int64) {
+	switch {
+	case delay == api.DelayFailed:
+		button.SetText("Timeout")
+		button.Importance = widget.DangerImportance
+	case delay <= 0:
+		button.SetText("Ping")
+		button.Importance = widget.MediumImportance
+	case delay < latencyFast:
+		button.SetText(fmt.Sprintf("%d ms", delay))
+		button.Importance = widget.SuccessImportance
+	case delay < latencySlow:
+		button.SetText(fmt.Sprintf("%d ms", delay))
+		button.Importance = widget.WarningImportance
+	default:
+		button.SetText(fmt.Sprintf("%d ms", delay))
+		button.Importance = widget.DangerImportance
+	}
+	button.Refresh()
+}
+
+// nestedGroups returns the members of g that are groups themselves
+func nestedGroups(g *core.ProxyGroup, groups map[string]*core.ProxyGroup) []string {
+	var nested []string
+	for _, m := range g.Members {
+		if _, ok := groups[m.Name]; ok {
+			nested = append(nested, m.Name)
+		}
+	}
+	return nested
+}
+
+// groupSummary describes a group in the tree: "Selector → de-1", "URLTest (auto) → nl-2"
+func groupSummary(g *core.ProxyGroup) string {
+	kind := g.Type
+	if !g.Selectable() {
+		kind += " (auto)"
+	}
+	if g.Now == "" {
+		return kind
+	}
+	return kind + " → " + g.Now
+}
+
+// proxySearchEntry is the search field of the proxy list; navigation keys are passed to onKey,
+// so the list can be used without a mouse
+type proxySearchEntry struct {
+	widget.Entry
+	onKey func(key fyne.KeyName) bool // Returns true if the key was handled
+}
+
+func newProxySearchEntry() *proxySearchEntry {
+	e := &proxySearchEntry{}
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+// TypedKey handles list navigation keys; other keys edit the query
+func (e *proxySearchEntry) TypedKey(key *fyne.KeyEvent) {
+	if e.onKey != nil && e.onKey(key.Name) {
+		return
+	}
+	e.Entry.TypedKey(key)
+}
+
 // CreateClashAPITab creates and returns the content for the "Clash API" tab.
-func CreateClashAPITab(ac *core.AppController) fyne.CanvasObject {
+func CreateClashAPITab(ac *Controller) fyne.CanvasObject {
 	ac.ApiStatusLabel = widget.NewLabel("Status: Not checked")
 	status := widget.NewLabel("Click 'Load Proxies' or 'Test API'")
 	ac.ListStatusLabel = status
 
-	selectorOptions, defaultSelector, err := core.GetSelectorGroupsFromConfig(ac.ConfigPath)
+	selectorOptions, defaultSelector, err := core.GetSelectorGroupsFromConfig(ac.GetConfigPath())
 	if err != nil {
 		log.Printf("clash_api_tab: failed to get selector groups: %v", err)
 	}
@@ -41,10 +121,44 @@ func CreateClashAPITab(ac *core.AppController) fyne.CanvasObject {
 	}
 
 	var (
-		groupSelect            *widget.Select
-		suppressSelectCallback bool
+		groupTree *widget.Tree
+		groups    = make(map[string]*core.ProxyGroup) // Groups from Clash API /proxies by name
+		roots     []string                            // Top level of the group tree
+
+		view           []api.ProxyInfo // Shown part of ac.ProxiesList: matches of filter, favorites first
+		filter         core.ProxyFilter
+		favorites      map[string]bool
+		selectedProxy  string // Proxy selected in the list; its index in view is ac.SelectedIndex
+		refreshProxies func() // Recomputes view and redraws the list
+		updateRecent   func() // Shows recently switched proxies of the selected group
 	)
 
+	// groupNodeSeparator joins group names in tree node IDs ("parent\x00child"):
+	// a nested group can be a member of several groups
+	const groupNodeSeparator = "\x00"
+	nodeGroup := func(id widget.TreeNodeID) string {
+		return id[strings.LastIndex(id, groupNodeSeparator)+1:]
+	}
+	// groupNode returns the tree node of a group (the first one found from the roots)
+	var groupNode func(parent widget.TreeNodeID, names []string, name string) (widget.TreeNodeID, bool)
+	groupNode = func(parent widget.TreeNodeID, names []string, name string) (widget.TreeNodeID, bool) {
+		for _, n := range names {
+			id := n
+			if parent != "" {
+				id = parent + groupNodeSeparator + n
+			}
+			if n == name {
+				return id, true
+			}
+			if g, ok := groups[n]; ok {
+				if found, ok := groupNode(id, nestedGroups(g, groups), name); ok {
+					return found, true
+				}
+			}
+		}
+		return "", false
+	}
+
 	// --- Логика обновления и сброса ---
 
 	onLoadAndRefreshProxies := func() {
@@ -64,7 +178,7 @@ func CreateClashAPITab(ac *core.AppController) fyne.CanvasObject {
 			ac.ListStatusLabel.SetText(fmt.Sprintf("Loading proxies for '%s'...", group))
 		}
 		go func(group string) {
-			proxies, now, err := api.GetProxiesInGroup(ac.ClashAPIBaseURL, ac.ClashAPIToken, group, ac.ApiLogFile)
+			proxies, now, err := ac.ClashClient().ProxiesInGroup(context.Background(), group)
 			fyne.Do(func() {
 				if err != nil {
 					ShowError(ac.MainWindow, err)
@@ -76,14 +190,12 @@ func CreateClashAPITab(ac *core.AppController) fyne.CanvasObject {
 
 				ac.SetProxiesList(proxies)
 				ac.SetActiveProxyName(now)
-
-				if ac.ProxiesListWidget != nil {
-					ac.ProxiesListWidget.Refresh()
-				}
+				refreshProxies()
 
 				if ac.ListStatusLabel != nil {
 					ac.ListStatusLabel.SetText(fmt.Sprintf("Proxies loaded for '%s'. Active: %s", group, now))
 				}
+				updateRecent()
 
 				// Update tray menu with new proxy list
 				if ac.UpdateTrayMenuFunc != nil {
@@ -93,33 +205,40 @@ func CreateClashAPITab(ac *core.AppController) fyne.CanvasObject {
 		}(group)
 	}
 
-	// Функция для обновления списка селекторов из конфига (вызывается когда sing-box запущен и конфиг загружен)
-	updateSelectorList := func() {
-		updatedSelectorOptions, updatedDefaultSelector, err := core.GetSelectorGroupsFromConfig(ac.ConfigPath)
-		if err == nil && len(updatedSelectorOptions) > 0 && groupSelect != nil {
-			groupSelect.SetOptions(updatedSelectorOptions)
-
-			// Обновить selectedGroup если текущий выбор больше не доступен
-			currentSelected := selectedGroup
-			found := false
-			for _, opt := range updatedSelectorOptions {
-				if opt == currentSelected {
-					found = true
-					break
+	var selectGroup func(value string)
+
+	// Загрузка дерева групп из /proxies (вызывается когда sing-box запущен и конфиг загружен)
+	reloadGroups := func() {
+		go func() {
+			list, err := ac.ProxyGroups(context.Background())
+			fyne.Do(func() {
+				if err != nil {
+					log.Printf("clash_api_tab: failed to load proxy groups: %v", err)
+					return
 				}
-			}
-			if !found {
-				if updatedDefaultSelector != "" {
-					selectedGroup = updatedDefaultSelector
-				} else if len(updatedSelectorOptions) > 0 {
-					selectedGroup = updatedSelectorOptions[0]
+				groups = make(map[string]*core.ProxyGroup, len(list))
+				for i := range list {
+					groups[list[i].Name] = &list[i]
 				}
-				suppressSelectCallback = true
-				groupSelect.SetSelected(selectedGroup)
-				suppressSelectCallback = false
-				ac.SelectedClashGroup = selectedGroup
-			}
-		}
+				roots = core.ProxyGroupRoots(list)
+				groupTree.Refresh()
+				groupTree.OpenAllBranches()
+
+				// Выбрать группу по умолчанию, если текущая больше не существует
+				if _, ok := groups[selectedGroup]; !ok {
+					_, defaultSelector, _ := core.GetSelectorGroupsFromConfig(ac.GetConfigPath())
+					if _, ok := groups[defaultSelector]; ok {
+						selectGroup(defaultSelector)
+					} else if len(roots) > 0 {
+						selectGroup(roots[0])
+					}
+					return
+				}
+				if id, ok := groupNode("", roots, selectedGroup); ok {
+					groupTree.Select(id)
+				}
+			})
+		}()
 	}
 
 	onTestAPIConnection := func() {
@@ -129,7 +248,7 @@ func CreateClashAPITab(ac *core.AppController) fyne.CanvasObject {
 			return
 		}
 		go func() {
-			err := api.TestAPIConnection(ac.ClashAPIBaseURL, ac.ClashAPIToken, ac.ApiLogFile)
+			_, err := ac.ClashClient().Version(context.Background())
 			fyne.Do(func() {
 				if err != nil {
 					ac.ApiStatusLabel.SetText("❌ Clash API Off (Error)")
@@ -137,8 +256,8 @@ func CreateClashAPITab(ac *core.AppController) fyne.CanvasObject {
 					return
 				}
 				ac.ApiStatusLabel.SetText("✅ Clash API On")
-				// Обновить список селекторов после успешного подключения (sing-box запущен, конфиг загружен)
-				updateSelectorList()
+				// Обновить дерево групп после успешного подключения (sing-box запущен, конфиг загружен)
+				reloadGroups()
 				onLoadAndRefreshProxies()
 			})
 		}()
@@ -148,48 +267,150 @@ func CreateClashAPITab(ac *core.AppController) fyne.CanvasObject {
 		log.Println("clash_api_tab: Resetting API state.")
 		ac.SetProxiesList([]api.ProxyInfo{})
 		ac.SetActiveProxyName("")
-		ac.SetSelectedIndex(-1)
-		fyne.Do(func() {
-			if ac.ApiStatusLabel != nil {
-				ac.ApiStatusLabel.SetText("Status: Not running")
-			}
-			if ac.ListStatusLabel != nil {
-				ac.ListStatusLabel.SetText("Sing-box is stopped.")
-			}
-			if ac.ProxiesListWidget != nil {
-				ac.ProxiesListWidget.Refresh()
-			}
-			// Update tray menu when API state is reset
-			if ac.UpdateTrayMenuFunc != nil {
-				ac.UpdateTrayMenuFunc()
-			}
-		})
+		selectedProxy = ""
+		if ac.ApiStatusLabel != nil {
+			ac.ApiStatusLabel.SetText("Status: Not running")
+		}
+		if ac.ListStatusLabel != nil {
+			ac.ListStatusLabel.SetText("Sing-box is stopped.")
+		}
+		refreshProxies()
+		groups = make(map[string]*core.ProxyGroup)
+		roots = nil
+		groupTree.Refresh()
+		updateRecent()
+		// Tray menu is updated by Controller on EventAPIStateReset
 	}
 
-	// --- Регистрация колбэков в контроллере ---
+	// --- Регистрация колбэков и подписка на события ---
 	ac.RefreshAPIFunc = onTestAPIConnection
-	ac.ResetAPIStateFunc = onResetAPIState
+	ac.onEvent(func(e core.Event) {
+		// Сбрасываем API состояние, если sing-box остановлен
+		if e.Type == core.EventCoreStatusChanged && ac.RunningState.IsRunning() {
+			return
+		}
+		onResetAPIState()
+	}, core.EventAPIStateReset, core.EventCoreStatusChanged)
+	ac.onEvent(func(e core.Event) {
+		// Прокси загружены AutoLoadProxies
+		refreshProxies()
+		if ac.ListStatusLabel != nil && e.Message != "" {
+			ac.ListStatusLabel.SetText(e.Message)
+		}
+		updateRecent()
+		onTestAPIConnection()
+	}, core.EventProxiesChanged)
+	ac.onEvent(func(e core.Event) {
+		// Задержки выбранной группы обновлены TestGroupLatency; при сортировке по задержке порядок меняется
+		refreshProxies()
+		if ac.ListStatusLabel != nil && e.Message != "" {
+			ac.ListStatusLabel.SetText(e.Message)
+		}
+	}, core.EventLatencyTested)
 
 	// --- Вспомогательная функция для пинга ---
 	pingProxy := func(proxyName string, button *widget.Button) {
 		go func() {
-			fyne.Do(func() { button.SetText("...") })
-			delay, err := api.GetDelay(ac.ClashAPIBaseURL, ac.ClashAPIToken, proxyName, ac.ApiLogFile)
+			fyne.Do(func() {
+				button.SetText("...")
+				button.Importance = widget.MediumImportance
+				button.Refresh()
+			})
+			policy := ac.LatencyTestPolicy()
+			delay, err := ac.ClashClient().Delay(context.Background(), proxyName, policy.URL, policy.Timeout)
+			check := core.ConnectivityCheck{Kind: "proxy_delay", Target: proxyName, OK: err == nil}
+			if err != nil {
+				check.Detail = err.Error()
+				ac.SetProxyDelay(proxyName, api.DelayFailed)
+			} else {
+				check.Detail = fmt.Sprintf("%d ms", delay)
+				ac.SetProxyDelay(proxyName, delay)
+			}
+			ac.RecordConnectivityCheck(check)
 			fyne.Do(func() {
 				if err != nil {
-					button.SetText("Error")
+					setLatencyBadge(button, api.DelayFailed)
 					status.SetText("Delay error: " + err.Error())
 					ShowError(ac.MainWindow, err)
 				} else {
-					button.SetText(fmt.Sprintf("%d ms", delay))
+					setLatencyBadge(button, delay)
 					status.SetText(fmt.Sprintf("Delay: %d ms for %s", delay, proxyName))
 				}
 			})
 		}()
 	}
 
+	// Тест всей группы (кнопка "Test All"); список обновляется по EventLatencyTested
+	var testAllButton *widget.Button
+	onTestGroupLatency := func() {
+		if !ac.ClashAPIEnabled {
+			ShowErrorText(ac.MainWindow, "Clash API", "API is disabled: config error")
+			return
+		}
+		group := selectedGroup
+		if group == "" {
+			return
+		}
+		testAllButton.Disable()
+		status.SetText(fmt.Sprintf("Testing proxies of '%s'...", group))
+		go func() {
+			_, err := ac.TestGroupLatency(context.Background(), group)
+			fyne.Do(func() {
+				testAllButton.Enable()
+				if err != nil {
+					status.SetText("Latency test error: " + err.Error())
+					ShowError(ac.MainWindow, err)
+				}
+			})
+		}()
+	}
+
+	// switchProxy switches the selected group to a proxy; onSwitched is called in the UI thread on success
+	switchProxy := func(name string, onSwitched func()) {
+		if !ac.ClashAPIEnabled {
+			ShowErrorText(ac.MainWindow, "Clash API", "API is disabled: config error")
+			return
+		}
+		if g, ok := groups[selectedGroup]; ok && !g.Selectable() {
+			status.SetText(fmt.Sprintf("'%s' is a %s group: sing-box chooses %s automatically.", selectedGroup, g.Type, g.Now))
+			return
+		}
+		go func(group string) {
+			// SelectProxy saves the choice; list and tree are refreshed on EventProxiesChanged
+			err := ac.SelectProxy(group, name)
+			fyne.Do(func() {
+				if err != nil {
+					ShowError(ac.MainWindow, err)
+					status.SetText("Switch error: " + err.Error())
+				} else if onSwitched != nil {
+					onSwitched()
+				}
+			})
+		}(selectedGroup)
+	}
+
 	// --- Создание виджета списка ---
 
+	var proxiesListWidget *widget.List
+	// toggleFavorite pins a proxy to the top of the list or unpins it
+	toggleFavorite := func(name string) {
+		pinned, err := ac.ToggleFavoriteProxy(name)
+		if err != nil {
+			ShowError(ac.MainWindow, fmt.Errorf("failed to save favorites: %w", err))
+			return
+		}
+		if pinned {
+			status.SetText(fmt.Sprintf("%s is pinned to the top.", name))
+		} else {
+			status.SetText(fmt.Sprintf("%s is unpinned.", name))
+		}
+		selectedProxy = ""
+		refreshProxies()
+		if ac.UpdateTrayMenuFunc != nil {
+			ac.UpdateTrayMenuFunc()
+		}
+	}
+
 	createItem := func() fyne.CanvasObject {
 		background := canvas.NewRectangle(color.Transparent)
 		background.CornerRadius = 5
@@ -197,10 +418,13 @@ func CreateClashAPITab(ac *core.AppController) fyne.CanvasObject {
 		nameLabel := widget.NewLabel("Proxy Name")
 		nameLabel.TextStyle.Bold = true
 
+		favoriteButton := widget.NewButton("☆", nil)
+		favoriteButton.Importance = widget.LowImportance
 		pingButton := widget.NewButton("Ping", nil)
 		switchButton := widget.NewButton("▶️", nil)
 
 		content := container.NewHBox(
+			favoriteButton,
 			nameLabel,
 			layout.NewSpacer(),
 			pingButton,
@@ -212,29 +436,42 @@ func CreateClashAPITab(ac *core.AppController) fyne.CanvasObject {
 	}
 
 	updateItem := func(id int, o fyne.CanvasObject) {
-		proxies := ac.GetProxiesList()
-		if id < 0 || id >= len(proxies) {
+		if id < 0 || id >= len(view) {
 			return
 		}
-		proxyInfo := proxies[id]
+		proxyInfo := view[id]
 
 		stack := o.(*fyne.Container)
 		background := stack.Objects[0].(*canvas.Rectangle)
 		paddedContent := stack.Objects[1].(*fyne.Container)
 		content := paddedContent.Objects[0].(*fyne.Container)
 
-		nameLabel := content.Objects[0].(*widget.Label)
-		pingButton := content.Objects[2].(*widget.Button)
-		switchButton := content.Objects[3].(*widget.Button)
+		favoriteButton := content.Objects[0].(*widget.Button)
+		nameLabel := content.Objects[1].(*widget.Label)
+		pingButton := content.Objects[3].(*widget.Button)
+		switchButton := content.Objects[4].(*widget.Button)
 
-		nameLabel.SetText(proxyInfo.Name)
+		// Избранные закреплены вверху списка
+		if favorites[proxyInfo.Name] {
+			favoriteButton.SetText("★")
+		} else {
+			favoriteButton.SetText("☆")
+		}
 
-		if proxyInfo.Delay > 0 {
-			pingButton.SetText(fmt.Sprintf("%d ms", proxyInfo.Delay))
+		// Вложенные группы помечаются папкой; переключать можно только в Selector
+		if _, isGroup := groups[proxyInfo.Name]; isGroup {
+			nameLabel.SetText("📂 " + proxyInfo.Name)
+		} else {
+			nameLabel.SetText(proxyInfo.Name)
+		}
+		if g, ok := groups[selectedGroup]; ok && !g.Selectable() {
+			switchButton.Disable()
 		} else {
-			pingButton.SetText("Ping")
+			switchButton.Enable()
 		}
 
+		setLatencyBadge(pingButton, proxyInfo.Delay)
+
 		// Обновляем фон
 		if proxyInfo.Name == ac.GetActiveProxyName() {
 			background.FillColor = color.NRGBA{R: 144, G: 238, B: 144, A: 128}
@@ -248,50 +485,132 @@ func CreateClashAPITab(ac *core.AppController) fyne.CanvasObject {
 		// Обновляем колбэки кнопок
 		proxyNameForCallback := proxyInfo.Name
 
+		favoriteButton.OnTapped = func() {
+			toggleFavorite(proxyNameForCallback)
+		}
+
 		pingButton.OnTapped = func() {
 			pingProxy(proxyNameForCallback, pingButton)
 		}
 
 		switchButton.OnTapped = func() {
-			if !ac.ClashAPIEnabled {
-				ShowErrorText(ac.MainWindow, "Clash API", "API is disabled: config error")
-				return
-			}
-			go func(group string) {
-				err := api.SwitchProxy(ac.ClashAPIBaseURL, ac.ClashAPIToken, group, proxyNameForCallback, ac.ApiLogFile)
-				fyne.Do(func() {
-					if err != nil {
-						ShowError(ac.MainWindow, err)
-						status.SetText("Switch error: " + err.Error())
-					} else {
-						ac.SetActiveProxyName(proxyNameForCallback)
-						ac.ProxiesListWidget.Refresh()
-						pingProxy(proxyNameForCallback, pingButton)
-						if ac.ListStatusLabel != nil {
-							ac.ListStatusLabel.SetText(fmt.Sprintf("Switched '%s' to %s", group, proxyNameForCallback))
-						}
-					}
-				})
-			}(selectedGroup)
+			switchProxy(proxyNameForCallback, func() { pingProxy(proxyNameForCallback, pingButton) })
 		}
 	}
 
-	proxiesListWidget := widget.NewList(
-		func() int { return len(ac.GetProxiesList()) },
+	proxiesListWidget = widget.NewList(
+		func() int { return len(view) },
 		createItem,
 		updateItem,
 	)
 
+	// refreshProxies пересчитывает представление из ac.ProxiesList и сохраняет выбор по имени прокси:
+	// после теста задержек с сортировкой по задержке тот же прокси оказывается на другой позиции
+	refreshProxies = func() {
+		pinned := ac.FavoriteProxies()
+		favorites = make(map[string]bool, len(pinned))
+		for _, name := range pinned {
+			favorites[name] = true
+		}
+		view = core.FilterProxies(ac.GetProxiesList(), filter, pinned)
+
+		index := -1
+		for i, p := range view {
+			if p.Name == selectedProxy {
+				index = i
+				break
+			}
+		}
+		ac.SetSelectedIndex(index)
+		if index < 0 {
+			selectedProxy = ""
+			proxiesListWidget.UnselectAll()
+		} else {
+			proxiesListWidget.Select(index)
+		}
+		proxiesListWidget.Refresh()
+	}
+
 	proxiesListWidget.OnSelected = func(id int) {
+		if id < 0 || id >= len(view) {
+			return
+		}
 		ac.SetSelectedIndex(id)
-		proxies := ac.GetProxiesList()
-		if id >= 0 && id < len(proxies) {
-			status.SetText("Selected: " + proxies[id].Name)
+		if view[id].Name != selectedProxy {
+			selectedProxy = view[id].Name
+			status.SetText("Selected: " + selectedProxy + " (Enter in the search field switches to it)")
 		}
 		proxiesListWidget.Refresh()
 	}
 
+	// Поиск: фильтр по имени, стране флага и диапазону задержки; стрелки выбирают, Enter переключает
+	searchEntry := newProxySearchEntry()
+	searchEntry.SetPlaceHolder("Search: name, country (de), latency (<300, 100-500)")
+	searchEntry.OnChanged = func(query string) {
+		filter = core.ParseProxyFilter(query)
+		selectedProxy = ""
+		refreshProxies()
+		proxiesListWidget.ScrollToTop()
+	}
+	searchEntry.onKey = func(key fyne.KeyName) bool {
+		index := ac.GetSelectedIndex()
+		switch key {
+		case fyne.KeyDown, fyne.KeyUp, fyne.KeyPageDown, fyne.KeyPageUp:
+			if len(view) == 0 {
+				return true
+			}
+			step := map[fyne.KeyName]int{fyne.KeyDown: 1, fyne.KeyUp: -1, fyne.KeyPageDown: 10, fyne.KeyPageUp: -10}[key]
+			index = min(max(index+step, 0), len(view)-1)
+			proxiesListWidget.Select(index)
+			proxiesListWidget.ScrollTo(index)
+		case fyne.KeyReturn, fyne.KeyEnter:
+			// Без выбора переключаемся на первое совпадение
+			if index < 0 || index >= len(view) {
+				index = 0
+			}
+			if index < len(view) {
+				switchProxy(view[index].Name, nil)
+			}
+		case fyne.KeyEscape:
+			searchEntry.SetText("")
+		default:
+			return false
+		}
+		return true
+	}
+
+	// Недавние: последние переключённые прокси выбранной группы (кроме активного)
+	recentBox := container.NewHBox()
+	recentRow := container.NewHBox(widget.NewLabel("Recent:"), recentBox)
+	updateRecent = func() {
+		present := make(map[string]bool)
+		for _, p := range ac.GetProxiesList() {
+			present[p.Name] = true
+		}
+		recentBox.RemoveAll()
+		for _, name := range ac.RecentProxies() {
+			if !present[name] || name == ac.GetActiveProxyName() {
+				continue
+			}
+			proxyName := name
+			label := proxyName
+			if runes := []rune(label); len(runes) > 24 {
+				label = string(runes[:23]) + "…"
+			}
+			button := widget.NewButton(label, func() { switchProxy(proxyName, nil) })
+			button.Importance = widget.LowImportance
+			recentBox.Add(button)
+		}
+		if len(recentBox.Objects) == 0 {
+			recentRow.Hide()
+		} else {
+			recentRow.Show()
+		}
+	}
+	updateRecent()
+
 	ac.ProxiesListWidget = proxiesListWidget
+	refreshProxies()
 
 	// --- Сборка всего контента ---
 	scrollContainer := container.NewScroll(proxiesListWidget)
@@ -299,17 +618,39 @@ func CreateClashAPITab(ac *core.AppController) fyne.CanvasObject {
 
 	loadButton := widget.NewButton("Load Proxies", onLoadAndRefreshProxies)
 	testAPIButton := widget.NewButton("Test API Connection", onTestAPIConnection)
+	testAllButton = widget.NewButton("Test All", onTestGroupLatency)
+	ruleSetsButton := widget.NewButton("Rule-sets", func() { showRuleSetsDialog(ac) })
 
-	groupSelect = widget.NewSelect(selectorOptions, func(value string) {
-		if value == "" {
+	sortSelect := widget.NewSelect([]string{proxySortName, proxySortLatency}, func(value string) {
+		if err := ac.SetProxySortByLatency(value == proxySortLatency); err != nil {
+			log.Printf("clash_api_tab: failed to save sort order: %v", err)
+		}
+		selectedProxy = ""
+		refreshProxies()
+		if ac.UpdateTrayMenuFunc != nil {
+			ac.UpdateTrayMenuFunc()
+		}
+	})
+	if ac.ProxySortByLatency() {
+		sortSelect.Selected = proxySortLatency
+	} else {
+		sortSelect.Selected = proxySortName
+	}
+
+	selectGroup = func(value string) {
+		if value == "" || value == selectedGroup && ac.SelectedClashGroup == value {
 			return
 		}
 		selectedGroup = value
 		ac.SelectedClashGroup = value
-		if suppressSelectCallback {
-			return
+		if id, ok := groupNode("", roots, value); ok {
+			groupTree.Select(id)
+		}
+		if g, ok := groups[value]; ok && !g.Selectable() {
+			status.SetText(fmt.Sprintf("'%s' is a %s group: sing-box chooses %s automatically.", value, g.Type, g.Now))
+		} else {
+			status.SetText(fmt.Sprintf("Selected group '%s'.", value))
 		}
-		status.SetText(fmt.Sprintf("Selected group '%s'.", value))
 		// Update tray menu when group changes
 		if ac.UpdateTrayMenuFunc != nil {
 			ac.UpdateTrayMenuFunc()
@@ -319,20 +660,64 @@ func CreateClashAPITab(ac *core.AppController) fyne.CanvasObject {
 			ac.AutoLoadProxies()
 		}
 		onLoadAndRefreshProxies()
-	})
-	groupSelect.PlaceHolder = "Select selector group"
-	if selectedGroup != "" {
-		suppressSelectCallback = true
-		groupSelect.SetSelected(selectedGroup)
-		suppressSelectCallback = false
 	}
 
+	// Дерево групп: корни — группы, не входящие в другие группы; ветви — вложенные группы
+	groupTree = widget.NewTree(
+		func(id widget.TreeNodeID) []widget.TreeNodeID {
+			names := roots
+			if id != "" {
+				g, ok := groups[nodeGroup(id)]
+				if !ok {
+					return nil
+				}
+				names = nestedGroups(g, groups)
+			}
+			ids := make([]widget.TreeNodeID, len(names))
+			for i, name := range names {
+				if id == "" {
+					ids[i] = name
+				} else {
+					ids[i] = id + groupNodeSeparator + name
+				}
+			}
+			return ids
+		},
+		func(id widget.TreeNodeID) bool {
+			if id == "" {
+				return true
+			}
+			g, ok := groups[nodeGroup(id)]
+			return ok && len(nestedGroups(g, groups)) > 0
+		},
+		func(branch bool) fyne.CanvasObject {
+			name := widget.NewLabel("Group")
+			name.TextStyle.Bold = true
+			return container.NewHBox(name, widget.NewLabel("Selector → proxy"))
+		},
+		func(id widget.TreeNodeID, branch bool, o fyne.CanvasObject) {
+			box := o.(*fyne.Container)
+			name := nodeGroup(id)
+			box.Objects[0].(*widget.Label).SetText(name)
+			if g, ok := groups[name]; ok {
+				box.Objects[1].(*widget.Label).SetText(groupSummary(g))
+			}
+		},
+	)
+	groupTree.OnSelected = func(id widget.TreeNodeID) {
+		selectGroup(nodeGroup(id))
+	}
+
+	groupsPanel := container.NewBorder(widget.NewLabel("Groups"), nil, nil, nil, groupTree)
+	proxiesPanel := container.NewBorder(container.NewVBox(searchEntry, recentRow), nil, nil, nil, scrollContainer)
+	split := container.NewHSplit(groupsPanel, proxiesPanel)
+	split.Offset = 0.35
+
 	topControls := container.NewVBox(
 		ac.ApiStatusLabel,
-		container.NewHBox(widget.NewLabel("Selector group:"), groupSelect),
 		testAPIButton,
 		widget.NewSeparator(),
-		loadButton,
+		container.NewHBox(loadButton, testAllButton, ruleSetsButton, layout.NewSpacer(), widget.NewLabel("Sort:"), sortSelect),
 	)
 
 	contentContainer := container.NewBorder(
@@ -340,7 +725,7 @@ func CreateClashAPITab(ac *core.AppController) fyne.CanvasObject {
 		status,
 		nil,
 		nil,
-		scrollContainer,
+		split,
 	)
 
 	return contentContainer
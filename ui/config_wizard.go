@@ -41,7 +41,7 @@ func generateTagPrefix(index int) string {
 }
 
 // ShowConfigWizard открывает окно мастера конфигурации
-func ShowConfigWizard(parent fyne.Window, controller *core.AppController) {
+func ShowConfigWizard(parent fyne.Window, controller *Controller) {
 	state := &WizardState{
 		Controller:        controller,
 		previewNeedsParse: true,
@@ -49,10 +49,8 @@ func ShowConfigWizard(parent fyne.Window, controller *core.AppController) {
 
 	if templateData, err := loadTemplateData(controller.ExecDir); err != nil {
 		errorLog("ConfigWizard: failed to load config_template.json from %s: %v", filepath.Join(controller.ExecDir, "bin", "config_template.json"), err)
-		// Update config status in Core Dashboard (similar to UpdateConfigStatusFunc)
-		if controller.UpdateConfigStatusFunc != nil {
-			controller.UpdateConfigStatusFunc()
-		}
+		// Update config status in Core Dashboard
+		controller.NotifyConfigChanged()
 		// Show error to user
 		//	dialog.ShowError(fmt.Errorf("Failed to load template file:\n%v\n\nPlease ensure bin/config_template.json exists and is valid.", err), wizardWindow)
 		return
@@ -783,7 +781,7 @@ func (state *WizardState) saveConfigWithBackup(text string) (string, error) {
 		finalText = string(finalJSONBytes)
 	}
 
-	configPath := state.Controller.ConfigPath
+	configPath := state.Controller.GetConfigPath()
 	if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
 		return "", err
 	}
@@ -798,9 +796,9 @@ func (state *WizardState) saveConfigWithBackup(text string) (string, error) {
 	if err := os.WriteFile(configPath, []byte(finalText), 0o644); err != nil {
 		return "", err
 	}
-	// Update config status in Core Dashboard if callback is set
-	if state.Controller != nil && state.Controller.UpdateConfigStatusFunc != nil {
-		state.Controller.UpdateConfigStatusFunc()
+	// Update config status in Core Dashboard
+	if state.Controller != nil {
+		state.Controller.NotifyConfigChanged()
 	}
 	return configPath, nil
 }
@@ -834,9 +832,9 @@ func loadConfigFromFile(state *WizardState) (bool, error) {
 		} else {
 			// Проверяем наличие config.json для получения proxies
 			var configParserConfig *core.ParserConfig
-			if _, err := os.Stat(state.Controller.ConfigPath); err == nil {
+			if _, err := os.Stat(state.Controller.GetConfigPath()); err == nil {
 				// config.json существует - берем proxies оттуда
-				configParserConfig, err = core.ExtractParserConfig(state.Controller.ConfigPath)
+				configParserConfig, err = core.ExtractParserConfig(state.Controller.GetConfigPath())
 				if err != nil {
 					infoLog("ConfigWizard: Failed to extract ParserConfig from config.json, using template proxies: %v", err)
 				}
@@ -879,14 +877,14 @@ func loadConfigFromFile(state *WizardState) (bool, error) {
 	}
 
 	// Проверяем наличие config.json
-	if _, err := os.Stat(state.Controller.ConfigPath); os.IsNotExist(err) {
+	if _, err := os.Stat(state.Controller.GetConfigPath()); os.IsNotExist(err) {
 		// Конфиг не существует - оставляем значения по умолчанию
 		infoLog("ConfigWizard: config.json not found, using default values")
 		return false, nil
 	}
 
 	// Извлекаем ParserConfig из config.json (fallback)
-	parserConfig, err := core.ExtractParserConfig(state.Controller.ConfigPath)
+	parserConfig, err := core.ExtractParserConfig(state.Controller.GetConfigPath())
 	if err != nil {
 		// Если не удалось извлечь - оставляем значения по умолчанию
 		errorLog("ConfigWizard: Failed to extract ParserConfig: %v", err)
@@ -1415,14 +1413,16 @@ func (state *WizardState) applyURLToParserConfig(input string) {
 	debugLog("applyURLToParserConfig: Classified lines: %d subscriptions, %d connections (took %v)",
 		len(subscriptions), len(connections), time.Since(splitStartTime))
 
-	// Сохраняем существующие локальные outbounds, tag_prefix и tag_postfix для каждого источника
+	// Сохраняем существующие локальные outbounds, tag_prefix, tag_postfix и reload для каждого источника
 	// Используем source URL как ключ для сопоставления
 	existingOutboundsMap := make(map[string][]core.OutboundConfig)
 	existingTagPrefixMap := make(map[string]string)
 	existingTagPostfixMap := make(map[string]string)
+	existingReloadMap := make(map[string]core.ProxySource)
 	for i, existingProxy := range parserConfig.ParserConfig.Proxies {
 		if existingProxy.Source != "" {
 			existingOutboundsMap[existingProxy.Source] = existingProxy.Outbounds
+			existingReloadMap[existingProxy.Source] = existingProxy
 			if existingProxy.TagPrefix != "" {
 				existingTagPrefixMap[existingProxy.Source] = existingProxy.TagPrefix
 			}
@@ -1472,6 +1472,12 @@ func (state *WizardState) applyURLToParserConfig(input string) {
 			proxySource.TagPostfix = existingTagPostfix
 			debugLog("applyURLToParserConfig: Restored tag_postfix '%s' for subscription: %s", existingTagPostfix, sub)
 		}
+		// Восстанавливаем индивидуальный интервал обновления и время последней загрузки источника
+		if existingProxy, ok := existingReloadMap[sub]; ok {
+			proxySource.Reload = existingProxy.Reload
+			proxySource.LastUpdated = existingProxy.LastUpdated
+			proxySource.LastFailed = existingProxy.LastFailed
+		}
 		newProxies = append(newProxies, proxySource)
 	}
 
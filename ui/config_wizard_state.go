@@ -34,7 +34,7 @@ func errorLog(format string, args ...interface{}) {
 
 // WizardState хранит состояние мастера конфигурации.
 type WizardState struct {
-	Controller *core.AppController
+	Controller *Controller
 	Window     fyne.Window
 
 	// Tab 1: VLESS Sources
@@ -97,10 +97,7 @@ func TestSerializeParserConfig(t *testing.T) {
 				Version   int                 `json:"version,omitempty"`
 				Proxies   []core.ProxySource   `json:"proxies"`
 				Outbounds []core.OutboundConfig `json:"outbounds"`
-				Parser    struct {
-					Reload      string `json:"reload,omitempty"`
-					LastUpdated string `json:"last_updated,omitempty"`
-				} `json:"parser,omitempty"`
+				Parser    core.ParserSettings   `json:"parser,omitempty"`
 			}{
 				Version: 2,
 				Proxies: []core.ProxySource{
@@ -155,10 +152,7 @@ func TestSerializeParserConfig(t *testing.T) {
 				Version   int                 `json:"version,omitempty"`
 				Proxies   []core.ProxySource   `json:"proxies"`
 				Outbounds []core.OutboundConfig `json:"outbounds"`
-				Parser    struct {
-					Reload      string `json:"reload,omitempty"`
-					LastUpdated string `json:"last_updated,omitempty"`
-				} `json:"parser,omitempty"`
+				Parser    core.ParserSettings   `json:"parser,omitempty"`
 			}{
 				Version: 2,
 			},
@@ -194,10 +188,7 @@ func TestGetAvailableOutbounds(t *testing.T) {
 					Version   int                 `json:"version,omitempty"`
 					Proxies   []core.ProxySource   `json:"proxies"`
 					Outbounds []core.OutboundConfig `json:"outbounds"`
-					Parser    struct {
-						Reload      string `json:"reload,omitempty"`
-						LastUpdated string `json:"last_updated,omitempty"`
-					} `json:"parser,omitempty"`
+					Parser    core.ParserSettings   `json:"parser,omitempty"`
 				}{
 				Outbounds: []core.OutboundConfig{
 					{
@@ -329,10 +320,7 @@ func TestRealWorldSubscriptionParsing(t *testing.T) {
 			Version   int                 `json:"version,omitempty"`
 			Proxies   []core.ProxySource   `json:"proxies"`
 			Outbounds []core.OutboundConfig `json:"outbounds"`
-			Parser    struct {
-				Reload      string `json:"reload,omitempty"`
-				LastUpdated string `json:"last_updated,omitempty"`
-			} `json:"parser,omitempty"`
+			Parser    core.ParserSettings   `json:"parser,omitempty"`
 		}{
 			Version: 2,
 			Proxies: []core.ProxySource{
@@ -0,0 +1,453 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"singbox-launcher/api"
+	"singbox-launcher/core"
+)
+
+const (
+	connectionsTabPollInterval = time.Second
+	connectionsRequestTimeout  = 5 * time.Second
+)
+
+// connectionSortOptions are the entries of the sort selector
+var connectionSortOptions = []struct {
+	label string
+	sort  core.ConnectionSort
+}{
+	{"Newest", core.ConnectionSortDuration},
+	{"Download", core.ConnectionSortDownload},
+	{"Upload", core.ConnectionSortUpload},
+	{"Host", core.ConnectionSortHost},
+}
+
+// connectionGroupOptions are the entries of the grouping selector
+var connectionGroupOptions = []struct {
+	label    string
+	grouping core.ConnectionGrouping
+}{
+	{"No grouping", core.ConnectionGroupNone},
+	{"By process", core.ConnectionGroupProcess},
+	{"By host", core.ConnectionGroupHost},
+}
+
+// connectionRow is a line of the list: a group header (conn is nil) or a connection
+type connectionRow struct {
+	group *core.ConnectionGroup
+	conn  *api.Connection
+}
+
+// connectionsTab is the state of the Connections tab. Fields are used on the UI goroutine,
+// except active and wake which control the poll goroutine (run).
+type connectionsTab struct {
+	c      *Controller
+	active atomic.Bool // The tab is selected; connections are polled only then
+	wake   chan struct{}
+
+	snapshot   *api.Connections
+	query      string
+	sortBy     core.ConnectionSort
+	groupBy    core.ConnectionGrouping
+	paused     bool
+	groups     []core.ConnectionGroup
+	rows       []connectionRow
+	selectedID string
+
+	content      fyne.CanvasObject
+	list         *widget.List
+	statusLabel  *widget.Label
+	pauseButton  *widget.Button
+	details      *widget.Label
+	closeButton  *widget.Button // Closes the selected connection
+	closeHostBtn *widget.Button // Closes all connections of the selected connection's host
+}
+
+// newConnectionsTab creates the Connections tab: active connections from the Clash API
+// with search, sorting, grouping and close actions.
+func newConnectionsTab(ac *Controller) *connectionsTab {
+	t := &connectionsTab{
+		c:      ac,
+		wake:   make(chan struct{}, 1),
+		sortBy: core.ConnectionSortDuration,
+	}
+
+	t.list = widget.NewList(
+		func() int { return len(t.rows) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, nil, widget.NewButton("✕", nil), label)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(t.rows) {
+				return
+			}
+			t.updateRow(t.rows[id], obj.(*fyne.Container))
+		},
+	)
+	t.list.OnSelected = func(id widget.ListItemID) {
+		// Selection is drawn by the item: the list is rebuilt every second and
+		// a selected list item would scroll back to it
+		t.list.UnselectAll()
+		if id < len(t.rows) && t.rows[id].conn != nil {
+			t.selectedID = t.rows[id].conn.ID
+		} else {
+			t.selectedID = ""
+		}
+		t.list.Refresh()
+		t.updateDetails()
+	}
+
+	searchEntry := widget.NewEntry()
+	searchEntry.SetPlaceHolder("Search host, process, rule, chain...")
+	searchEntry.OnChanged = func(text string) {
+		t.query = strings.TrimSpace(text)
+		t.rebuild()
+	}
+
+	sortLabels := make([]string, len(connectionSortOptions))
+	for i, o := range connectionSortOptions {
+		sortLabels[i] = o.label
+	}
+	sortSelect := widget.NewSelect(sortLabels, func(selected string) {
+		for _, o := range connectionSortOptions {
+			if o.label == selected {
+				t.sortBy = o.sort
+			}
+		}
+		t.rebuild()
+	})
+
+	groupLabels := make([]string, len(connectionGroupOptions))
+	for i, o := range connectionGroupOptions {
+		groupLabels[i] = o.label
+	}
+	groupSelect := widget.NewSelect(groupLabels, func(selected string) {
+		for _, o := range connectionGroupOptions {
+			if o.label == selected {
+				t.groupBy = o.grouping
+			}
+		}
+		t.rebuild()
+	})
+
+	t.pauseButton = widget.NewButton("Pause", func() {
+		t.paused = !t.paused
+		if t.paused {
+			t.pauseButton.SetText("Resume")
+		} else {
+			t.pauseButton.SetText("Pause")
+			t.poll()
+		}
+		t.updateStatus()
+	})
+	closeAllButton := widget.NewButton("Close All", func() {
+		ShowConfirm(ac.MainWindow, "Close All Connections", "Close all active connections?\nApplications will reconnect.", func(ok bool) {
+			if ok {
+				t.closeAll()
+			}
+		})
+	})
+	closeAllButton.Importance = widget.DangerImportance
+	t.statusLabel = widget.NewLabel("")
+
+	t.details = widget.NewLabel("Select a connection to see its details.")
+	t.details.Wrapping = fyne.TextWrapWord
+	t.details.Selectable = true
+	t.closeButton = widget.NewButton("Close Connection", func() {
+		if c := t.selected(); c != nil {
+			t.close([]string{c.ID}, "connection to "+c.Host())
+		}
+	})
+	t.closeHostBtn = widget.NewButton("Close All to Host", func() {
+		if c := t.selected(); c != nil {
+			t.closeHost(c.Host())
+		}
+	})
+	t.closeButton.Disable()
+	t.closeHostBtn.Disable()
+
+	sortSelect.SetSelected(sortLabels[0])
+	groupSelect.SetSelected(groupLabels[0])
+
+	toolbar := container.NewBorder(nil, nil, nil,
+		container.NewHBox(sortSelect, groupSelect, t.pauseButton, closeAllButton),
+		searchEntry)
+	detailsBox := container.NewBorder(nil, container.NewHBox(t.closeButton, t.closeHostBtn), nil, nil, t.details)
+	split := container.NewVSplit(t.list, container.NewVScroll(detailsBox))
+	split.Offset = 0.7
+	t.content = container.NewBorder(container.NewVBox(toolbar, t.statusLabel), nil, nil, nil, split)
+
+	// Сбрасываем список при остановке sing-box
+	ac.onEvent(func(core.Event) {
+		if !ac.RunningState.IsRunning() {
+			t.setSnapshot(nil, nil)
+		}
+		t.poll()
+	}, core.EventCoreStatusChanged)
+
+	t.updateStatus()
+	go t.run()
+	return t
+}
+
+// setActive starts or stops polling when the tab is selected or left
+func (t *connectionsTab) setActive(active bool) {
+	t.active.Store(active)
+	if active {
+		t.poll()
+	}
+}
+
+// poll asks run to fetch connections now
+func (t *connectionsTab) poll() {
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run polls /connections while the tab is selected and sing-box is running
+func (t *connectionsTab) run() {
+	ticker := time.NewTicker(connectionsTabPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-t.wake:
+		}
+		if !t.active.Load() || !t.c.RunningState.IsRunning() || !t.c.ClashAPIEnabled {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), connectionsRequestTimeout)
+		res, err := t.c.ClashClient().Connections(ctx)
+		cancel()
+		fyne.Do(func() {
+			if !t.paused {
+				t.setSnapshot(res, err)
+			}
+		})
+	}
+}
+
+// setSnapshot shows a response of /connections (nil when sing-box is stopped)
+func (t *connectionsTab) setSnapshot(res *api.Connections, err error) {
+	if err != nil {
+		t.statusLabel.SetText("Error: " + err.Error())
+		return
+	}
+	t.snapshot = res
+	t.rebuild()
+}
+
+// rebuild filters, sorts and groups the last snapshot into list rows
+func (t *connectionsTab) rebuild() {
+	if t.list == nil || t.statusLabel == nil {
+		return // Still creating widgets
+	}
+	t.rows = t.rows[:0]
+	t.groups = nil
+	if t.snapshot != nil {
+		conns := core.FilterConnections(t.snapshot.Connections, t.query)
+		core.SortConnections(conns, t.sortBy)
+		t.groups = core.GroupConnections(conns, t.groupBy)
+		for i := range t.groups {
+			group := &t.groups[i]
+			if t.groupBy != core.ConnectionGroupNone {
+				t.rows = append(t.rows, connectionRow{group: group})
+			}
+			for j := range group.Connections {
+				t.rows = append(t.rows, connectionRow{group: group, conn: &group.Connections[j]})
+			}
+		}
+	}
+
+	// The selected connection stays selected while it is open
+	if t.selected() == nil {
+		t.selectedID = ""
+	}
+	t.updateDetails()
+	t.list.Refresh()
+	t.updateStatus()
+}
+
+func (t *connectionsTab) updateRow(row connectionRow, item *fyne.Container) {
+	label := item.Objects[0].(*widget.Label)
+	button := item.Objects[1].(*widget.Button)
+	if row.conn == nil {
+		g := row.group
+		label.TextStyle.Bold = true
+		label.Importance = widget.MediumImportance
+		label.SetText(fmt.Sprintf("%s — %d connection(s), ↓ %s ↑ %s",
+			g.Name, len(g.Connections), core.FormatBytesUtil(g.Download), core.FormatBytesUtil(g.Upload)))
+		button.SetText(fmt.Sprintf("Close %d", len(g.Connections)))
+		button.OnTapped = func() {
+			ids := make([]string, len(g.Connections))
+			for i, c := range g.Connections {
+				ids[i] = c.ID
+			}
+			t.close(ids, fmt.Sprintf("%d connection(s) of %s", len(ids), g.Name))
+		}
+		return
+	}
+
+	c := row.conn
+	label.TextStyle.Bold = false
+	label.Importance = widget.MediumImportance
+	if c.ID == t.selectedID {
+		label.Importance = widget.HighImportance
+	}
+	indent := ""
+	if t.groupBy != core.ConnectionGroupNone {
+		indent = "    "
+	}
+	rule := c.Rule
+	if c.RulePayload != "" {
+		rule += "(" + c.RulePayload + ")"
+	}
+	label.SetText(fmt.Sprintf("%s%s  %s/%s  %s → %s  ↓ %s ↑ %s  %s",
+		indent, c.Host(), c.Metadata.Network, c.Destination(), rule, c.Chain(),
+		core.FormatBytesUtil(c.Download), core.FormatBytesUtil(c.Upload), formatConnectionDuration(time.Since(c.Start))))
+	button.SetText("✕")
+	id, host := c.ID, c.Host()
+	button.OnTapped = func() {
+		t.close([]string{id}, "connection to "+host)
+	}
+}
+
+// selected returns the selected connection of the last snapshot
+func (t *connectionsTab) selected() *api.Connection {
+	for _, row := range t.rows {
+		if row.conn != nil && row.conn.ID == t.selectedID {
+			return row.conn
+		}
+	}
+	return nil
+}
+
+// updateDetails shows all fields of the selected connection
+func (t *connectionsTab) updateDetails() {
+	c := t.selected()
+	if c == nil {
+		t.details.SetText("Select a connection to see its details.")
+		t.closeButton.Disable()
+		t.closeHostBtn.Disable()
+		return
+	}
+	m := c.Metadata
+	lines := []string{
+		"Host: " + c.Host(),
+		"Destination: " + c.Destination() + " (" + m.Network + ")",
+		"Source: " + m.SourceIP + ":" + m.SourcePort + ", inbound " + m.Type,
+		"Process: " + valueOrUnknown(m.ProcessPath),
+		"Rule: " + valueOrUnknown(strings.TrimSpace(c.Rule+" "+c.RulePayload)),
+		"Chain: " + c.Chain(),
+		fmt.Sprintf("Traffic: ↓ %s ↑ %s", core.FormatBytesUtil(c.Download), core.FormatBytesUtil(c.Upload)),
+		fmt.Sprintf("Started: %s (%s ago)", c.Start.Local().Format("2006-01-02 15:04:05"), formatConnectionDuration(time.Since(c.Start))),
+	}
+	if m.DNSMode != "" {
+		lines = append(lines, "DNS mode: "+m.DNSMode)
+	}
+	lines = append(lines, "ID: "+c.ID)
+	t.details.SetText(strings.Join(lines, "\n"))
+	t.closeButton.Enable()
+	t.closeHostBtn.Enable()
+}
+
+func (t *connectionsTab) updateStatus() {
+	if !t.c.RunningState.IsRunning() {
+		t.statusLabel.SetText("sing-box is not running")
+		return
+	}
+	if t.snapshot == nil {
+		t.statusLabel.SetText("Loading connections...")
+		return
+	}
+	shown := 0
+	for _, g := range t.groups {
+		shown += len(g.Connections)
+	}
+	status := fmt.Sprintf("%d of %d connections, total ↓ %s ↑ %s", shown, len(t.snapshot.Connections),
+		core.FormatBytesUtil(t.snapshot.DownloadTotal), core.FormatBytesUtil(t.snapshot.UploadTotal))
+	if t.paused {
+		status += " (paused)"
+	}
+	t.statusLabel.SetText(status)
+}
+
+// closeHost closes every connection to host from the last snapshot
+func (t *connectionsTab) closeHost(host string) {
+	if t.snapshot == nil {
+		return
+	}
+	var ids []string
+	for _, c := range t.snapshot.Connections {
+		if c.Host() == host {
+			ids = append(ids, c.ID)
+		}
+	}
+	t.close(ids, fmt.Sprintf("%d connection(s) to %s", len(ids), host))
+}
+
+// close closes connections in the background and refreshes the list
+func (t *connectionsTab) close(ids []string, what string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), connectionsRequestTimeout)
+		defer cancel()
+		err := t.c.CloseConnections(ctx, ids)
+		fyne.Do(func() {
+			if err != nil {
+				ShowError(t.c.MainWindow, err)
+				return
+			}
+			log.Printf("connectionsTab: Closed %s", what)
+			t.poll()
+		})
+	}()
+}
+
+func (t *connectionsTab) closeAll() {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), connectionsRequestTimeout)
+		defer cancel()
+		err := t.c.ClashClient().CloseAllConnections(ctx)
+		fyne.Do(func() {
+			if err != nil {
+				ShowError(t.c.MainWindow, err)
+				return
+			}
+			log.Println("connectionsTab: Closed all connections")
+			t.poll()
+		})
+	}()
+}
+
+// formatConnectionDuration formats a connection age: "45s", "3m05s", "2h04m"
+func formatConnectionDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+func valueOrUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
@@ -0,0 +1,240 @@
+package ui
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+	"fyne.io/systray"
+
+	"singbox-launcher/core"
+)
+
+// Controller is the GUI side of the application.
+// It wraps core.AppController with the Fyne application, main window, tray icons and
+// widgets shared between tabs, and updates them on core events (core.AppController.Events).
+type Controller struct {
+	*core.AppController
+
+	// --- Fyne application ---
+	Application fyne.App
+	MainWindow  fyne.Window
+
+	// --- Resources ---
+	AppIconData   fyne.Resource
+	GreyIconData  fyne.Resource
+	GreenIconData fyne.Resource
+	RedIconData   fyne.Resource
+
+	// --- Widgets shared between Clash API tab and tray ---
+	ApiStatusLabel    *widget.Label
+	ProxiesListWidget *widget.List
+	ListStatusLabel   *widget.Label
+
+	// --- Callbacks ---
+	RefreshAPIFunc     func() // Set by Clash API tab: tests API connection and reloads proxies
+	UpdateTrayMenuFunc func() // Set in main.go: debounced tray menu update
+
+	// --- Tray menu update protection ---
+	TrayMenuUpdateMutex      sync.Mutex  // Mutex for tray menu updates
+	TrayMenuUpdateInProgress bool        // Flag to prevent concurrent menu updates
+	TrayMenuUpdatePending    bool        // An update was requested while another one was in progress
+	TrayMenuUpdateTimer      *time.Timer // Timer for debouncing menu updates
+	trayParserRunning        bool        // Subscription update in progress, from EventParserProgress (guarded by TrayMenuUpdateMutex)
+	trayLatencyPending       bool        // A menu update for new delays is scheduled (UI thread only)
+
+	// --- Tray tooltip ---
+	TrayReady   bool   // Set in main.go once the tray icon is shown (the tooltip cannot be set before)
+	trayTooltip string // Last tooltip set, to skip identical updates
+}
+
+// NewController creates the Fyne application for ac, installs the GUI notifier
+// and subscribes the tray to core events.
+func NewController(ac *core.AppController, appIconData, greyIconData, greenIconData, redIconData []byte) *Controller {
+	c := &Controller{
+		AppController: ac,
+		AppIconData:   fyne.NewStaticResource("appIcon", appIconData),
+		GreyIconData:  fyne.NewStaticResource("trayIcon", greyIconData),
+		GreenIconData: fyne.NewStaticResource("runningIcon", greenIconData),
+		RedIconData:   fyne.NewStaticResource("errorIcon", redIconData),
+	}
+	c.RefreshAPIFunc = func() { log.Println("RefreshAPIFunc handler is not set yet.") }
+	c.UpdateTrayMenuFunc = func() { log.Println("UpdateTrayMenuFunc handler is not set yet.") }
+
+	c.Application = app.NewWithID("com.singbox.launcher")
+	c.Application.SetIcon(c.AppIconData)
+	ac.Notifier = &guiNotifier{c: c}
+
+	// Tray reflects core state, proxies, routing mode and profiles
+	c.onEvent(func(e core.Event) {
+		if e.Type == core.EventCoreStatusChanged {
+			c.updateTrayIcon()
+		}
+		c.updateTrayMenu()
+	}, core.EventCoreStatusChanged, core.EventConfigChanged, core.EventProfilesChanged,
+		core.EventProxiesChanged, core.EventAPIStateReset, core.EventClashModeChanged)
+
+	// Subscription update: the menu only changes when an update starts or ends
+	c.onEvent(func(e core.Event) {
+		running := e.Progress >= 0 && e.Progress < 100
+		c.TrayMenuUpdateMutex.Lock()
+		changed := running != c.trayParserRunning
+		c.trayParserRunning = running
+		c.TrayMenuUpdateMutex.Unlock()
+		if changed {
+			c.updateTrayMenu()
+		}
+	}, core.EventParserProgress)
+
+	// Delays shown in the tray: one update per trayLatencyDelay while proxies are tested
+	c.onEvent(func(core.Event) {
+		if c.trayLatencyPending {
+			return
+		}
+		c.trayLatencyPending = true
+		time.AfterFunc(trayLatencyDelay, func() {
+			fyne.Do(func() {
+				c.trayLatencyPending = false
+				c.updateTrayMenu()
+			})
+		})
+	}, core.EventLatencyTested)
+
+	// Tray tooltip shows live speed and memory
+	c.onEvent(func(e core.Event) {
+		c.updateTrayTooltip(e.Traffic)
+	}, core.EventTraffic, core.EventCoreStatusChanged)
+
+	// Another launcher invocation forwarded its command line (single instance)
+	c.onEvent(func(core.Event) {
+		if c.MainWindow != nil {
+			c.MainWindow.Show()
+			c.MainWindow.RequestFocus()
+		}
+	}, core.EventShowWindowRequested)
+
+	// sing-box crashed and auto-restart gave up
+	c.onEvent(func(core.Event) {
+		if c.MainWindow != nil {
+			showCrashReportDialog(c)
+		}
+	}, core.EventCrashReport)
+
+	return c
+}
+
+// onEvent subscribes handler to the given event types; handler runs on the UI thread
+func (c *Controller) onEvent(handler func(core.Event), types ...core.EventType) {
+	c.Events.Subscribe(func(e core.Event) {
+		for _, t := range types {
+			if e.Type == t {
+				fyne.Do(func() { handler(e) })
+				return
+			}
+		}
+	})
+}
+
+// UpdateUI updates all UI elements (tray icon and menu, Core Dashboard, Clash API tab)
+// based on the current application state.
+func (c *Controller) UpdateUI() {
+	c.Events.Publish(core.Event{Type: core.EventCoreStatusChanged})
+}
+
+// updateTrayIcon sets the tray icon for the current sing-box state. Must be called on the UI thread.
+func (c *Controller) updateTrayIcon() {
+	desk, ok := c.Application.(desktop.App)
+	if !ok {
+		return
+	}
+
+	var iconToSet fyne.Resource
+	if c.RunningState.IsRunning() {
+		// Green icon - if running
+		iconToSet = c.GreenIconData
+	} else if _, err := os.Stat(c.SingboxPath); os.IsNotExist(err) {
+		// Red icon - on error (binary not found)
+		iconToSet = c.RedIconData
+	} else {
+		// Grey icon - on normal stop
+		iconToSet = c.GreyIconData
+	}
+	desk.SetSystemTrayIcon(iconToSet)
+}
+
+// updateTrayTooltip shows traffic in the tray tooltip (the application name if sample is nil).
+// Must be called on the UI thread.
+func (c *Controller) updateTrayTooltip(sample *core.TrafficSample) {
+	if !c.TrayReady {
+		return
+	}
+	tooltip := "Singbox Launcher"
+	if sample != nil && c.RunningState.IsRunning() {
+		tooltip += "\n" + formatTrafficSample(*sample)
+	}
+	if tooltip == c.trayTooltip {
+		return
+	}
+	c.trayTooltip = tooltip
+	systray.SetTooltip(tooltip)
+}
+
+// updateTrayMenu calls UpdateTrayMenuFunc if it is set
+func (c *Controller) updateTrayMenu() {
+	if c.UpdateTrayMenuFunc != nil {
+		c.UpdateTrayMenuFunc()
+	}
+}
+
+// Quit stops sing-box and background goroutines and quits the application
+func (c *Controller) Quit() {
+	// Stop any pending menu update timer
+	c.TrayMenuUpdateMutex.Lock()
+	if c.TrayMenuUpdateTimer != nil {
+		c.TrayMenuUpdateTimer.Stop()
+		c.TrayMenuUpdateTimer = nil
+	}
+	c.TrayMenuUpdateMutex.Unlock()
+
+	c.GracefulExit()
+	c.Application.Quit()
+}
+
+// guiNotifier shows core messages as dialogs of the main window
+type guiNotifier struct {
+	c *Controller
+}
+
+func (n *guiNotifier) ShowError(err error) {
+	ShowError(n.c.MainWindow, err)
+}
+
+func (n *guiNotifier) ShowErrorText(title, message string) {
+	ShowErrorText(n.c.MainWindow, title, message)
+}
+
+func (n *guiNotifier) ShowInfo(title, message string) {
+	ShowInfo(n.c.MainWindow, title, message)
+}
+
+func (n *guiNotifier) ShowAutoHideInfo(title, message string) {
+	ShowAutoHideInfo(n.c.Application, n.c.MainWindow, title, message)
+}
+
+func (n *guiNotifier) ShowConfirm(title, message, confirmText string, onConfirm func()) {
+	fyne.Do(func() {
+		d := dialog.NewConfirm(title, message, func(ok bool) {
+			if ok {
+				onConfirm()
+			}
+		}, n.c.MainWindow)
+		d.SetConfirmText(confirmText)
+		d.Show()
+	})
+}
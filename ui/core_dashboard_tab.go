@@ -18,6 +18,7 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
 	"singbox-launcher/core"
@@ -27,7 +28,7 @@ const downloadPlaceholderWidth = 180
 
 // CoreDashboardTab управляет вкладкой Core Dashboard
 type CoreDashboardTab struct {
-	controller *core.AppController
+	controller *Controller
 
 	// UI elements
 	statusLabel               *widget.Label // Full status: "Core Status" + icon + text
@@ -44,11 +45,20 @@ type CoreDashboardTab struct {
 	wintunDownloadContainer   fyne.CanvasObject   // Container for wintun button/progress bar
 	wintunDownloadPlaceholder *canvas.Rectangle   // keeps width when button hidden
 	configStatusLabel         *widget.Button      // Используем Button для возможности клика
+	profileButton             *widget.Button      // Active profile, opens the profile manager
 	templateDownloadButton    *widget.Button
 	wizardButton              *widget.Button
 	updateConfigButton        *widget.Button
 	parserProgressBar         *widget.ProgressBar // Progress bar for parser
 	parserStatusLabel         *widget.Label       // Status label for parser
+	autoUpdateStatusLabel     *widget.Label       // Next scheduled auto-update attempt / last failure
+	trafficLabel              *widget.Label       // Current speed and memory from the Clash API
+	trafficPeakLabel          *widget.Label       // Graph period and peak speed
+	trafficGraph              *trafficGraph
+	modeSelect                *widget.Select // Routing mode (Clash API /configs)
+	modeWarningLabel          *widget.Label  // Shown when config.json has no clash_mode rules
+	modeUpdating              bool           // Suppresses modeSelect callback while it is updated from core
+	modeRulesMissing          bool           // config.json has no clash_mode rules, see loadModeRules
 
 	// Data
 	stopAutoUpdate           chan bool
@@ -58,7 +68,7 @@ type CoreDashboardTab struct {
 }
 
 // CreateCoreDashboardTab creates and returns the Core Dashboard tab
-func CreateCoreDashboardTab(ac *core.AppController) fyne.CanvasObject {
+func CreateCoreDashboardTab(ac *Controller) fyne.CanvasObject {
 	tab := &CoreDashboardTab{
 		controller:     ac,
 		stopAutoUpdate: make(chan bool),
@@ -87,79 +97,93 @@ func CreateCoreDashboardTab(ac *core.AppController) fyne.CanvasObject {
 		widget.NewSeparator(),
 		coreInfo,
 		widget.NewSeparator(),
+		tab.createModeBlock(),
+		widget.NewSeparator(),
+		tab.createTrafficBlock(),
+		widget.NewSeparator(),
 	}
 
 	// Горизонтальная линия и кнопка Exit в конце списка
-	exitButton := widget.NewButton("Exit", ac.GracefulExit)
+	exitButton := widget.NewButton("Exit", ac.Quit)
 	// Кнопка Exit в отдельной строке с отступом вниз
 	contentItems = append(contentItems, widget.NewLabel("")) // Отступ
 	contentItems = append(contentItems, container.NewCenter(exitButton))
 
 	content := container.NewVBox(contentItems...)
 
-	// Регистрируем callback для обновления статуса при изменении RunningState
-	// Сохраняем оригинальный callback, если он есть
-	originalUpdateCoreStatusFunc := tab.controller.UpdateCoreStatusFunc
-	tab.controller.UpdateCoreStatusFunc = func() {
-		// Вызываем оригинальный callback, если он есть
-		if originalUpdateCoreStatusFunc != nil {
-			originalUpdateCoreStatusFunc()
-		}
-		// Вызываем наш callback
-		fyne.Do(func() {
-			tab.updateRunningStatus()
-		})
-	}
-
-	// Регистрируем callback для обновления статуса конфига
-	tab.controller.UpdateConfigStatusFunc = func() {
-		fyne.Do(func() {
-			tab.updateConfigInfo()
-		})
-	}
-
-	// Регистрируем callback для обновления прогресса парсера
-	tab.controller.UpdateParserProgressFunc = func(progress float64, status string) {
-		fyne.Do(func() {
-			if tab.parserProgressBar != nil {
-				if progress < 0 {
-					// Error state - hide progress bar
-					tab.parserProgressBar.Hide()
-					tab.parserStatusLabel.Hide()
-					// Проверяем, не запущен ли парсер
-					tab.controller.ParserMutex.Lock()
-					parserRunning := tab.controller.ParserRunning
-					tab.controller.ParserMutex.Unlock()
-					if !parserRunning {
-						tab.updateConfigButton.Enable()
-					}
-				} else {
-					// Show progress
-					tab.parserProgressBar.Show()
-					tab.parserStatusLabel.Show()
-					tab.parserProgressBar.SetValue(progress / 100.0)
-					tab.parserStatusLabel.SetText(status)
-					if progress >= 100 {
-						// Completed - hide after a short delay
-						go func() {
-							time.Sleep(1 * time.Second)
-							fyne.Do(func() {
-								tab.parserProgressBar.Hide()
-								tab.parserStatusLabel.Hide()
-								// Проверяем, не запущен ли парсер
-								tab.controller.ParserMutex.Lock()
-								parserRunning := tab.controller.ParserRunning
-								tab.controller.ParserMutex.Unlock()
-								if !parserRunning {
-									tab.updateConfigButton.Enable()
-								}
-							})
-						}()
-					}
+	// Подписываемся на обновление статуса при изменении RunningState
+	tab.controller.onEvent(func(core.Event) {
+		tab.updateRunningStatus()
+		tab.updateTraffic()
+		tab.updateMode()
+	}, core.EventCoreStatusChanged)
+
+	// Режим маршрутизации изменён (из трея, восстановлен после запуска)
+	tab.controller.onEvent(func(core.Event) {
+		tab.updateMode()
+	}, core.EventClashModeChanged)
+
+	// Конфиг изменён: заново проверяем правила clash_mode (вне UI потока)
+	tab.controller.onEvent(func(core.Event) {
+		go tab.loadModeRules()
+	}, core.EventConfigChanged)
+
+	// Скорость и память раз в секунду (TrafficMonitor)
+	tab.controller.onEvent(func(core.Event) {
+		tab.updateTraffic()
+	}, core.EventTraffic)
+
+	// Подписываемся на обновление статуса конфига (в т.ч. список профилей)
+	tab.controller.onEvent(func(core.Event) {
+		tab.updateConfigInfo()
+	}, core.EventConfigChanged, core.EventProfilesChanged)
+
+	// Подписываемся на прогресс парсера
+	tab.controller.onEvent(func(e core.Event) {
+		progress, status := e.Progress, e.Message
+		if tab.parserProgressBar != nil {
+			if progress < 0 {
+				// Error state - hide progress bar
+				tab.parserProgressBar.Hide()
+				tab.parserStatusLabel.Hide()
+				// Проверяем, не запущен ли парсер
+				tab.controller.ParserMutex.Lock()
+				parserRunning := tab.controller.ParserRunning
+				tab.controller.ParserMutex.Unlock()
+				if !parserRunning {
+					tab.updateConfigButton.Enable()
+				}
+			} else {
+				// Show progress
+				tab.parserProgressBar.Show()
+				tab.parserStatusLabel.Show()
+				tab.parserProgressBar.SetValue(progress / 100.0)
+				tab.parserStatusLabel.SetText(status)
+				if progress >= 100 {
+					// Completed - hide after a short delay
+					go func() {
+						time.Sleep(1 * time.Second)
+						fyne.Do(func() {
+							tab.parserProgressBar.Hide()
+							tab.parserStatusLabel.Hide()
+							// Проверяем, не запущен ли парсер
+							tab.controller.ParserMutex.Lock()
+							parserRunning := tab.controller.ParserRunning
+							tab.controller.ParserMutex.Unlock()
+							if !parserRunning {
+								tab.updateConfigButton.Enable()
+							}
+						})
+					}()
 				}
 			}
-		})
-	}
+		}
+	}, core.EventParserProgress)
+
+	// Подписываемся на обновление статуса автообновления
+	tab.controller.onEvent(func(core.Event) {
+		tab.updateAutoUpdateStatus()
+	}, core.EventAutoUpdateStatusChanged)
 
 	// Первоначальное обновление
 	tab.updateBinaryStatus() // Проверяет наличие бинарника и вызывает updateRunningStatus
@@ -168,6 +192,9 @@ func CreateCoreDashboardTab(ac *core.AppController) fyne.CanvasObject {
 		tab.updateWintunStatus() // Проверяет наличие wintun.dll
 	}
 	tab.updateConfigInfo()
+	tab.updateAutoUpdateStatus()
+	tab.updateMode()
+	go tab.loadModeRules()
 
 	// Запускаем автообновление версии
 	tab.startAutoUpdate()
@@ -184,13 +211,13 @@ func (tab *CoreDashboardTab) createStatusRow() fyne.CanvasObject {
 	tab.statusLabel.Importance = widget.MediumImportance
 
 	startButton := widget.NewButton("Start", func() {
-		core.StartSingBoxProcess(tab.controller)
-		// Status will be updated automatically via UpdateCoreStatusFunc
+		core.StartSingBoxProcess(tab.controller.AppController)
+		// Status will be updated automatically via EventCoreStatusChanged
 	})
 
 	stopButton := widget.NewButton("Stop", func() {
-		core.StopSingBoxProcess(tab.controller)
-		// Status will be updated automatically via UpdateCoreStatusFunc
+		core.StopSingBoxProcess(tab.controller.AppController)
+		// Status will be updated automatically via EventCoreStatusChanged
 	})
 
 	// Save button references for updating locks
@@ -232,6 +259,12 @@ func (tab *CoreDashboardTab) createConfigBlock() fyne.CanvasObject {
 	})
 	tab.configStatusLabel.Importance = widget.LowImportance
 
+	// Активный профиль; клик открывает менеджер профилей
+	tab.profileButton = widget.NewButton("Profile: "+tab.controller.GetActiveProfile(), func() {
+		showProfilesDialog(tab.controller)
+	})
+	tab.profileButton.Importance = widget.LowImportance
+
 	// Создаем прогрессбар и статус для парсера
 	tab.parserProgressBar = widget.NewProgressBar()
 	tab.parserProgressBar.Hide()
@@ -252,7 +285,7 @@ func (tab *CoreDashboardTab) createConfigBlock() fyne.CanvasObject {
 		tab.parserStatusLabel.SetText("Starting...")
 
 		// Запускаем парсер в отдельной горутине
-		go core.RunParserProcess(tab.controller)
+		go core.RunParserProcess(tab.controller.AppController)
 	})
 	tab.updateConfigButton.Importance = widget.MediumImportance
 
@@ -273,6 +306,7 @@ func (tab *CoreDashboardTab) createConfigBlock() fyne.CanvasObject {
 	// Строка со статусом
 	statusRow := container.NewHBox(
 		title,
+		tab.profileButton,
 		layout.NewSpacer(),
 		tab.configStatusLabel,
 	)
@@ -292,13 +326,190 @@ func (tab *CoreDashboardTab) createConfigBlock() fyne.CanvasObject {
 		tab.parserStatusLabel,
 	)
 
+	// Статус автообновления подписок (время следующей попытки)
+	tab.autoUpdateStatusLabel = widget.NewLabel("")
+	tab.autoUpdateStatusLabel.Alignment = fyne.TextAlignCenter
+	tab.autoUpdateStatusLabel.Wrapping = fyne.TextWrapWord
+	tab.autoUpdateStatusLabel.Hide()
+
 	return container.NewVBox(
 		statusRow,
 		buttonsRow,
 		parserProgressRow, // Прогрессбар и статус парсера в отдельной строке
+		tab.autoUpdateStatusLabel,
+	)
+}
+
+// createModeBlock creates the routing mode selector (rule/global/direct)
+func (tab *CoreDashboardTab) createModeBlock() fyne.CanvasObject {
+	title := widget.NewLabel("Routing mode")
+	title.TextStyle.Bold = true
+	tab.modeWarningLabel = widget.NewLabel("⚠️ config.json has no clash_mode rules: switching the mode changes nothing")
+	tab.modeWarningLabel.Importance = widget.WarningImportance
+	tab.modeWarningLabel.Wrapping = fyne.TextWrapWord
+	tab.modeWarningLabel.Hide()
+
+	tab.modeSelect = widget.NewSelect(core.DefaultClashModes, func(mode string) {
+		if tab.modeUpdating || mode == "" {
+			return
+		}
+		if current, _ := tab.controller.ClashMode(); mode == current {
+			return
+		}
+		go func() {
+			// EventClashModeChanged updates the selector and the tray
+			if err := tab.controller.SetClashMode(context.Background(), mode); err != nil {
+				log.Printf("CoreDashboard: Failed to switch mode: %v", err)
+				fyne.Do(func() {
+					ShowError(tab.controller.MainWindow, fmt.Errorf("failed to switch mode: %w", err))
+					tab.updateMode()
+				})
+			}
+		}()
+	})
+	tab.modeSelect.PlaceHolder = "sing-box is not running"
+
+	return container.NewVBox(
+		container.NewHBox(title, tab.modeSelect),
+		tab.modeWarningLabel,
 	)
 }
 
+// updateMode shows the current routing mode; the selector is disabled while the mode is unknown
+func (tab *CoreDashboardTab) updateMode() {
+	if tab.modeSelect == nil {
+		return
+	}
+	mode, modes := tab.controller.ClashMode()
+	running := tab.controller.RunningState.IsRunning()
+
+	tab.modeUpdating = true
+	if len(modes) > 0 {
+		tab.modeSelect.SetOptions(modes)
+	}
+	if running && mode != "" {
+		tab.modeSelect.SetSelected(mode)
+		tab.modeSelect.Enable()
+	} else {
+		tab.modeSelect.ClearSelected()
+		tab.modeSelect.Disable()
+	}
+	tab.modeUpdating = false
+
+	if tab.modeRulesMissing {
+		tab.modeWarningLabel.Show()
+	} else {
+		tab.modeWarningLabel.Hide()
+	}
+}
+
+// loadModeRules checks config.json for clash_mode rules and updates the mode warning.
+// It reads the config, so it is called outside the UI thread and only when the config changes.
+func (tab *CoreDashboardTab) loadModeRules() {
+	hasRules, err := core.ConfigHasClashModeRules(tab.controller.GetConfigPath())
+	fyne.Do(func() {
+		tab.modeRulesMissing = err == nil && !hasRules
+		tab.updateMode()
+	})
+}
+
+// createTrafficBlock creates the live speed/memory line and the traffic graph
+func (tab *CoreDashboardTab) createTrafficBlock() fyne.CanvasObject {
+	title := widget.NewLabel("Traffic")
+	title.TextStyle.Bold = true
+	tab.trafficLabel = widget.NewLabel("")
+	tab.trafficPeakLabel = widget.NewLabel("")
+	tab.trafficPeakLabel.Importance = widget.LowImportance
+	tab.trafficGraph = newTrafficGraph()
+
+	labels := make([]string, len(trafficGraphWindows))
+	for i, w := range trafficGraphWindows {
+		labels[i] = w.label
+	}
+	windowSelect := widget.NewSelect(labels, func(selected string) {
+		for _, w := range trafficGraphWindows {
+			if w.label == selected {
+				tab.trafficGraph.window = w.window
+			}
+		}
+		tab.updateTraffic()
+	})
+	windowSelect.SetSelected(labels[0])
+
+	downloadLegend := canvas.NewText("■ download", theme.Color(theme.ColorNamePrimary))
+	downloadLegend.TextSize = theme.CaptionTextSize()
+	uploadLegend := canvas.NewText("— upload", uploadColor)
+	uploadLegend.TextSize = theme.CaptionTextSize()
+
+	return container.NewVBox(
+		container.NewHBox(title, tab.trafficLabel, layout.NewSpacer(), windowSelect),
+		tab.trafficGraph.raster,
+		container.NewHBox(tab.trafficPeakLabel, layout.NewSpacer(), downloadLegend, uploadLegend),
+	)
+}
+
+// updateTraffic shows the latest sample and redraws the graph
+func (tab *CoreDashboardTab) updateTraffic() {
+	if tab.trafficLabel == nil {
+		return
+	}
+	monitor := tab.controller.TrafficMonitor
+	if latest, ok := monitor.Latest(); ok {
+		tab.trafficLabel.SetText(formatTrafficSample(latest))
+	} else if tab.controller.RunningState.IsRunning() {
+		tab.trafficLabel.SetText("Waiting for Clash API...")
+	} else {
+		tab.trafficLabel.SetText("sing-box is not running")
+	}
+	tab.trafficGraph.SetSamples(monitor.History(tab.trafficGraph.window))
+	tab.trafficPeakLabel.SetText("Peak " + formatSpeed(tab.trafficGraph.Peak()))
+}
+
+// updateAutoUpdateStatus обновляет строку со временем следующей попытки автообновления
+func (tab *CoreDashboardTab) updateAutoUpdateStatus() {
+	if tab.autoUpdateStatusLabel == nil {
+		return
+	}
+	text := formatAutoUpdateStatus(tab.controller.GetAutoUpdateStatus())
+	if text == "" {
+		tab.autoUpdateStatusLabel.Hide()
+		return
+	}
+	tab.autoUpdateStatusLabel.SetText(text)
+	tab.autoUpdateStatusLabel.Show()
+}
+
+// formatAutoUpdateStatus returns the dashboard text for the auto-update state
+func formatAutoUpdateStatus(status core.AutoUpdateStatus) string {
+	if !status.Enabled {
+		if status.LastError == "" {
+			return ""
+		}
+		return fmt.Sprintf("Auto-update stopped (%s error): use manual update", status.LastFailure)
+	}
+	if status.NextAttempt.IsZero() {
+		return ""
+	}
+	next := status.NextAttempt.Local().Format("15:04")
+	if !sameDay(status.NextAttempt, time.Now()) {
+		next = status.NextAttempt.Local().Format("2006-01-02 15:04")
+	}
+	if status.LastError != "" {
+		return fmt.Sprintf("Auto-update: retry at %s (%s error, attempt %d)", next, status.LastFailure, status.FailedAttempts)
+	}
+	if status.FailedSources > 0 {
+		return fmt.Sprintf("Auto-update: next check at %s (%d subscription(s) failing)", next, status.FailedSources)
+	}
+	return fmt.Sprintf("Auto-update: next check at %s", next)
+}
+
+// sameDay reports whether a and b fall on the same local calendar day
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Local().Date()
+	by, bm, bd := b.Local().Date()
+	return ay == by && am == bm && ad == bd
+}
+
 // createVersionBlock creates a block with version (similar to wintun)
 func (tab *CoreDashboardTab) createVersionBlock() fyne.CanvasObject {
 	title := widget.NewLabel("Sing-box")
@@ -457,7 +668,7 @@ func (tab *CoreDashboardTab) updateRunningStatus() {
 	// Update status label based on state
 	restartInfo := ""
 	if tab.controller.ConsecutiveCrashAttempts > 0 {
-		restartInfo = fmt.Sprintf(" [restart %d/%d]", tab.controller.ConsecutiveCrashAttempts, 3)
+		restartInfo = fmt.Sprintf(" [restart %d/%d]", tab.controller.ConsecutiveCrashAttempts, tab.controller.RestartPolicy().MaxAttempts)
 	}
 
 	if !buttonState.BinaryExists {
@@ -499,12 +710,10 @@ func (tab *CoreDashboardTab) updateRunningStatus() {
 // readConfigOnDemand reads config when user clicks on config label/title
 func (tab *CoreDashboardTab) readConfigOnDemand() {
 	// Обновляем информацию о конфиге в UI
-	if tab.controller.UpdateConfigStatusFunc != nil {
-		tab.controller.UpdateConfigStatusFunc()
-	}
+	tab.updateConfigInfo()
 
 	// Читаем конфиг
-	config, err := core.ExtractParserConfig(tab.controller.ConfigPath)
+	config, err := core.ExtractParserConfig(tab.controller.GetConfigPath())
 	if err != nil {
 		log.Printf("CoreDashboard: Failed to read config on demand: %v", err)
 		// Можно показать сообщение пользователю через dialog
@@ -527,7 +736,10 @@ func (tab *CoreDashboardTab) updateConfigInfo() {
 	if tab.configStatusLabel == nil {
 		return
 	}
-	configPath := tab.controller.ConfigPath
+	if tab.profileButton != nil {
+		tab.profileButton.SetText("Profile: " + tab.controller.GetActiveProfile())
+	}
+	configPath := tab.controller.GetConfigPath()
 	configExists := false
 	if info, err := os.Stat(configPath); err == nil {
 		modTime := info.ModTime().Format("2006-01-02")
@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"singbox-launcher/core"
+	"singbox-launcher/internal/platform"
+)
+
+// showCrashReportDialog shows the last sing-box crash after auto-restart gave up:
+// exit status, the extracted fatal error line and the last lines of core output.
+// The user can retry, or restore the previous config.json and retry.
+func showCrashReportDialog(c *Controller) {
+	record := c.ProcessService.LastCrash()
+	if record == nil {
+		return
+	}
+
+	summary := fmt.Sprintf("Sing-Box stopped with %s after %v (crash %d, profile '%s').",
+		record.ExitDescription(), time.Duration(record.UptimeSeconds)*time.Second, record.Attempt, record.Profile)
+	if policy := c.RestartPolicy(); !policy.Enabled {
+		summary += "\nAuto-restart is disabled in launcher settings."
+	} else if core.IsPermanentFatal(record.FatalKind) {
+		summary += "\nNot restarted automatically: the error would repeat."
+	} else if record.Attempt > policy.MaxAttempts {
+		summary += fmt.Sprintf("\nAuto-restart gave up after %d attempts.", policy.MaxAttempts)
+	}
+	if hint := core.FatalHint(record.FatalKind); hint != "" {
+		summary += "\n\n" + hint
+	}
+	summaryLabel := widget.NewLabel(summary)
+	summaryLabel.Wrapping = fyne.TextWrapWord
+
+	fatal := record.FatalLine
+	if fatal == "" {
+		fatal = "No error line found in the core output."
+	}
+	fatalEntry := widget.NewMultiLineEntry()
+	fatalEntry.SetText(fatal)
+	fatalEntry.Wrapping = fyne.TextWrapWord
+	fatalEntry.SetMinRowsVisible(2)
+
+	outputEntry := widget.NewMultiLineEntry()
+	outputEntry.SetText(strings.Join(record.Output, "\n"))
+	outputEntry.Wrapping = fyne.TextWrapOff
+	outputEntry.SetMinRowsVisible(10)
+
+	var d dialog.Dialog
+
+	retryButton := widget.NewButton("Retry", func() {
+		d.Hide()
+		go core.StartSingBoxProcess(c.AppController)
+	})
+
+	restoreButton := widget.NewButton("Restore previous config and retry", func() {
+		ShowConfirm(c.MainWindow, "Restore Config",
+			fmt.Sprintf("Replace %s with the last config that Sing-Box ran successfully and start Sing-Box?\n\nThe current config is kept as %s, so this can be undone. Automatic updates are paused until the next manual update.",
+				c.GetConfigPath(), filepath.Base(core.PreviousConfigPath(c.GetConfigPath()))),
+			func(ok bool) {
+				if !ok {
+					return
+				}
+				d.Hide()
+				go func() {
+					if err := c.RestorePreviousConfigAndRetry(); err != nil {
+						log.Printf("crashReport: Restore failed: %v", err)
+						ShowError(c.MainWindow, err)
+					}
+				}()
+			})
+	})
+	restoreButton.Importance = widget.HighImportance
+	if !c.HasPreviousConfig() {
+		restoreButton.Disable()
+	}
+
+	openLogsButton := widget.NewButton("Open Logs", func() {
+		if err := platform.OpenFolder(platform.GetLogsDir(c.ExecDir)); err != nil {
+			ShowError(c.MainWindow, err)
+		}
+	})
+
+	top := container.NewVBox(
+		summaryLabel,
+		widget.NewLabel("Error:"),
+		fatalEntry,
+		widget.NewLabel("Last core output:"),
+	)
+	bottom := container.NewVBox(
+		widget.NewLabel("Crash record: "+record.Path),
+		container.NewHBox(restoreButton, retryButton, openLogsButton),
+	)
+	content := container.NewBorder(top, bottom, nil, nil, outputEntry)
+
+	d = dialog.NewCustom("Sing-Box Crashed", "Close", content, c.MainWindow)
+	d.Resize(fyne.NewSize(700, 550))
+	c.MainWindow.Show()
+	d.Show()
+}
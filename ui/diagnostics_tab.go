@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"os"
+	"runtime"
+	"strings"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -75,7 +78,7 @@ func checkSTUN(serverAddr string) (string, error) {
 }
 
 // CreateDiagnosticsTab creates and returns the content for the "Diagnostics" tab.
-func CreateDiagnosticsTab(ac *core.AppController) fyne.CanvasObject {
+func CreateDiagnosticsTab(ac *Controller) fyne.CanvasObject {
 	// Кнопка для проверки STUN (Google STUN [UDP])
 	stunButton := widget.NewButton("Google STUN [UDP]", func() {
 		// Показываем диалог ожидания
@@ -85,6 +88,11 @@ func CreateDiagnosticsTab(ac *core.AppController) fyne.CanvasObject {
 		go func() {
 			stunServer := constants.DefaultSTUNServer
 			ip, err := checkSTUN(stunServer)
+			check := core.ConnectivityCheck{Kind: "stun", Target: stunServer, OK: err == nil, Detail: "external IP found"}
+			if err != nil {
+				check.Detail = err.Error()
+			}
+			ac.RecordConnectivityCheck(check)
 
 			// Закрываем диалог ожидания и показываем результат
 			fyne.Do(func() {
@@ -117,7 +125,7 @@ func CreateDiagnosticsTab(ac *core.AppController) fyne.CanvasObject {
 		})
 	}
 
-	return container.NewVBox(
+	content := container.NewVBox(
 		widget.NewLabel("IP Check Services:"),
 		stunButton, // Google STUN [UDP] перенесен в секцию IP Check Services
 		openBrowserButton("2ip.ru", "https://2ip.ru"),
@@ -126,5 +134,96 @@ func CreateDiagnosticsTab(ac *core.AppController) fyne.CanvasObject {
 		openBrowserButton("Yandex Internet", "https://yandex.ru/internet/"),
 		openBrowserButton("SpeedTest", "https://www.speedtest.net/"),
 		openBrowserButton("WhatIsMyIPAddress", "https://whatismyipaddress.com"),
+		widget.NewSeparator(),
+		widget.NewLabel("Config:"),
+		widget.NewButton("ParserConfig Version...", func() {
+			showParserConfigMigrationDialog(ac)
+		}),
+		widget.NewSeparator(),
+		widget.NewLabel("Support:"),
+		widget.NewButton("Create Support Bundle...", func() {
+			createSupportBundle(ac)
+		}),
 	)
+	// На macOS ссылки доставляются только бандлу приложения (Apple Events)
+	if runtime.GOOS != "darwin" {
+		content.Add(widget.NewSeparator())
+		content.Add(widget.NewLabel("Links:"))
+		content.Add(widget.NewButton("Open sing-box:// Links with Launcher...", func() {
+			registerURLScheme(ac)
+		}))
+	}
+	return content
+}
+
+// registerURLScheme makes the launcher the handler of sing-box:// links after a confirmation
+// that names the current handler (for example, an official sing-box app)
+func registerURLScheme(ac *Controller) {
+	exePath, err := os.Executable()
+	if err != nil {
+		ShowError(ac.MainWindow, err)
+		return
+	}
+	scheme := core.RemoteProfileScheme
+	handler, ours, err := platform.URLSchemeHandler(scheme, exePath)
+	if err != nil {
+		log.Printf("diagnosticsTab: Failed to query the %s:// handler: %v", scheme, err)
+	}
+	message := fmt.Sprintf("Open %s:// links (remote profile imports) from the browser with the launcher?", scheme)
+	switch {
+	case ours:
+		message = fmt.Sprintf("%s:// links are already opened with the launcher. Register it again?", scheme)
+	case handler != "":
+		message = fmt.Sprintf("%s:// links are opened by %s now.\n\nOpen them with the launcher instead?", scheme, handler)
+	}
+	dialog.ShowConfirm(scheme+":// Links", message, func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := platform.RegisterURLScheme(scheme, exePath); err != nil {
+			log.Printf("diagnosticsTab: Failed to register %s:// links: %v", scheme, err)
+			ShowError(ac.MainWindow, err)
+			return
+		}
+		log.Printf("diagnosticsTab: Registered the launcher as the handler of %s:// links (was %q)", scheme, handler)
+		ShowInfo(ac.MainWindow, scheme+":// Links", fmt.Sprintf("%s:// links now open with the launcher.", scheme))
+	}, ac.MainWindow)
+}
+
+// createSupportBundle asks where to save a support bundle and writes it (see core.WriteSupportBundle)
+func createSupportBundle(ac *Controller) {
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			ShowError(ac.MainWindow, err)
+			return
+		}
+		if writer == nil {
+			return // Cancelled
+		}
+
+		waitDialog := dialog.NewCustomWithoutButtons("Support Bundle", widget.NewLabel("Collecting logs and checking connectivity..."), ac.MainWindow)
+		waitDialog.Show()
+		go func() {
+			manifest, err := ac.WriteSupportBundle(writer, true)
+			if closeErr := writer.Close(); err == nil {
+				err = closeErr
+			}
+			fyne.Do(func() {
+				waitDialog.Hide()
+				if err != nil {
+					log.Printf("diagnosticsTab: Failed to create support bundle: %v", err)
+					ShowError(ac.MainWindow, err)
+					return
+				}
+				log.Printf("diagnosticsTab: Support bundle saved to %s (%d files)", writer.URI().Path(), len(manifest.Files))
+				message := fmt.Sprintf("Saved to %s.\n\nPasswords, UUIDs and subscription tokens are masked. Please check the archive before sharing it.", writer.URI().Path())
+				if len(manifest.Errors) > 0 {
+					message += "\n\nNot collected:\n" + strings.Join(manifest.Errors, "\n")
+				}
+				ShowInfo(ac.MainWindow, "Support Bundle", message)
+			})
+		}()
+	}, ac.MainWindow)
+	saveDialog.SetFileName(fmt.Sprintf("singbox-launcher-support-%s.zip", time.Now().Format("20060102-150405")))
+	saveDialog.Show()
 }
@@ -16,7 +16,7 @@ import (
 )
 
 // CreateHelpTab creates and returns the content for the "Help" tab.
-func CreateHelpTab(ac *core.AppController) fyne.CanvasObject {
+func CreateHelpTab(ac *Controller) fyne.CanvasObject {
 	logsButton := widget.NewButton("📁 Open Logs Folder", func() {
 		logsDir := platform.GetLogsDir(ac.ExecDir)
 		if err := platform.OpenFolder(logsDir); err != nil {
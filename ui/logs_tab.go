@@ -0,0 +1,386 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"singbox-launcher/core"
+	"singbox-launcher/internal/platform"
+)
+
+const (
+	logsTabMaxLines     = 5000        // Lines kept in the Logs tab
+	logsTabPollInterval = time.Second // Live tail interval
+)
+
+// logSourceLabels are the names of log sources in the source selector
+var logSourceLabels = map[core.LogSource]string{
+	core.LogSourceLauncher: "Launcher",
+	core.LogSourceSingbox:  "sing-box",
+	core.LogSourceAPI:      "API",
+}
+
+// logLevelOptions are the minimum levels of the level selector ("All" = no filter)
+var logLevelOptions = []string{"All", "Debug", "Info", "Warn", "Error"}
+
+// logsTab is the state of the Logs tab. Fields are used on the UI goroutine only;
+// files are read by the poll goroutine (run), which owns the LogTail.
+type logsTab struct {
+	c        *Controller
+	source   core.LogSource
+	sourceCh chan core.LogSource
+
+	entries []core.LogEntry // Loaded lines, Seq numbers them in the tab
+	visible []int           // Indexes of entries matching the filter
+	nextSeq uint64
+	filter  core.LogFilter
+	paused  bool
+	pending []core.LogEntry // Lines received while paused
+
+	selStart, selEnd uint64 // Seq range of selected lines, 0 = none
+
+	list        *widget.List
+	statusLabel *widget.Label
+	pauseButton *widget.Button
+}
+
+// CreateLogsTab creates and returns the content for the "Logs" tab:
+// launcher, sing-box and API logs with live tail, filters and search.
+func CreateLogsTab(ac *Controller) fyne.CanvasObject {
+	t := &logsTab{
+		c:        ac,
+		source:   core.LogSourceSingbox,
+		sourceCh: make(chan core.LogSource, 1),
+	}
+
+	t.list = widget.NewList(
+		func() int { return len(t.visible) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.TextStyle = fyne.TextStyle{Monospace: true}
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(t.visible) {
+				return
+			}
+			entry := t.entries[t.visible[id]]
+			label := obj.(*widget.Label)
+			label.Importance = logLevelImportance(entry.Level)
+			label.TextStyle.Bold = t.isSelected(entry.Seq)
+			label.SetText(entry.Raw)
+		},
+	)
+	t.list.OnSelected = func(id widget.ListItemID) {
+		t.list.UnselectAll() // Selection is drawn by the item, so the same line can be clicked again
+		if id >= len(t.visible) {
+			return
+		}
+		// First click selects a line, the second one extends the selection to a range
+		seq := t.entries[t.visible[id]].Seq
+		if t.selStart == 0 || t.selStart != t.selEnd {
+			t.selStart, t.selEnd = seq, seq
+		} else {
+			t.selEnd = seq
+		}
+		t.list.Refresh()
+		t.updateStatus()
+	}
+
+	sourceOptions := make([]string, 0, len(core.LogSources))
+	for _, source := range core.LogSources {
+		sourceOptions = append(sourceOptions, logSourceLabels[source])
+	}
+	sourceSelect := widget.NewSelect(sourceOptions, func(label string) {
+		for source, l := range logSourceLabels {
+			if l == label && source != t.source {
+				t.source = source
+				t.entries, t.visible, t.pending = nil, nil, nil
+				t.selStart, t.selEnd = 0, 0
+				t.list.Refresh()
+				t.statusLabel.SetText("Loading...")
+				t.requestSource(source)
+			}
+		}
+	})
+
+	levelSelect := widget.NewSelect(logLevelOptions, func(option string) {
+		level, ok := core.ParseLogLevel(option)
+		if !ok {
+			level = "" // "All"
+		}
+		t.filter.MinLevel = level
+		t.applyFilter()
+	})
+
+	componentEntry := widget.NewEntry()
+	componentEntry.SetPlaceHolder("Component")
+	componentEntry.OnChanged = func(text string) {
+		t.filter.Component = strings.TrimSpace(text)
+		t.applyFilter()
+	}
+
+	searchEntry := widget.NewEntry()
+	searchEntry.SetPlaceHolder("Search (regular expression)")
+	searchEntry.OnChanged = func(text string) {
+		if text == "" {
+			t.filter.Search = nil
+			t.applyFilter()
+			return
+		}
+		re, err := regexp.Compile("(?i)" + text)
+		if err != nil {
+			t.statusLabel.SetText("Invalid regular expression: " + err.Error())
+			return
+		}
+		t.filter.Search = re
+		t.applyFilter()
+	}
+
+	t.pauseButton = widget.NewButton("Pause", t.togglePause)
+	copyButton := widget.NewButton("Copy Selection", t.copySelection)
+	exportButton := widget.NewButton("Export...", t.export)
+	openFolderButton := widget.NewButton("Open Folder", func() {
+		if err := platform.OpenFolder(platform.GetLogsDir(ac.ExecDir)); err != nil {
+			ShowError(ac.MainWindow, err)
+		}
+	})
+	t.statusLabel = widget.NewLabel("Loading...")
+
+	sourceSelect.SetSelected(logSourceLabels[t.source])
+	levelSelect.SetSelected(logLevelOptions[0])
+
+	filters := container.NewBorder(nil, nil,
+		container.NewHBox(sourceSelect, levelSelect), nil,
+		container.NewGridWithColumns(2, componentEntry, searchEntry))
+	actions := container.NewBorder(nil, nil,
+		container.NewHBox(t.pauseButton, copyButton, exportButton, openFolderButton), nil,
+		t.statusLabel)
+
+	go t.run(t.source)
+	return container.NewBorder(container.NewVBox(filters, actions), nil, nil, nil, t.list)
+}
+
+// requestSource asks run to load another source, replacing a request not taken yet
+func (t *logsTab) requestSource(source core.LogSource) {
+	for {
+		select {
+		case t.sourceCh <- source:
+			return
+		default:
+			select {
+			case <-t.sourceCh:
+			default:
+			}
+		}
+	}
+}
+
+// run loads the selected log and polls it for new lines
+func (t *logsTab) run(source core.LogSource) {
+	var tail *core.LogTail
+	load := func() {
+		tail = core.NewLogTail(core.LogSourcePath(t.c.ExecDir, source), logsTabMaxLines)
+		lines, err := tail.Load()
+		if err != nil {
+			log.Printf("logsTab: Failed to load %s log: %v", source, err)
+		}
+		entries := parseLogLines(source, lines)
+		src := source
+		fyne.Do(func() { t.setEntries(src, entries, err) })
+	}
+	load()
+
+	ticker := time.NewTicker(logsTabPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case source = <-t.sourceCh:
+			load()
+		case <-ticker.C:
+			lines, err := tail.Poll()
+			if err != nil {
+				log.Printf("logsTab: Failed to read %s log: %v", source, err)
+				continue
+			}
+			if len(lines) > 0 {
+				entries := parseLogLines(source, lines)
+				src := source
+				fyne.Do(func() { t.appendEntries(src, entries) })
+			}
+		}
+	}
+}
+
+// parseLogLines parses lines of a log source
+func parseLogLines(source core.LogSource, lines []string) []core.LogEntry {
+	entries := make([]core.LogEntry, len(lines))
+	for i, line := range lines {
+		entries[i] = core.ParseLogLine(source, line)
+	}
+	return entries
+}
+
+// setEntries replaces the shown lines after a source was loaded
+func (t *logsTab) setEntries(source core.LogSource, entries []core.LogEntry, err error) {
+	if source != t.source {
+		return // Another source was selected meanwhile
+	}
+	t.entries = nil
+	t.add(entries)
+	t.applyFilter()
+	if err != nil {
+		t.statusLabel.SetText("Error: " + err.Error())
+	}
+	t.list.ScrollToBottom()
+}
+
+// appendEntries adds new lines of the live tail
+func (t *logsTab) appendEntries(source core.LogSource, entries []core.LogEntry) {
+	if source != t.source {
+		return
+	}
+	if t.paused {
+		t.pending = append(t.pending, entries...)
+		if len(t.pending) > logsTabMaxLines {
+			t.pending = t.pending[len(t.pending)-logsTabMaxLines:]
+		}
+		t.updateStatus()
+		return
+	}
+	t.add(entries)
+	t.applyFilter()
+	t.list.ScrollToBottom()
+}
+
+// add numbers entries and appends them, dropping the oldest ones above logsTabMaxLines
+func (t *logsTab) add(entries []core.LogEntry) {
+	for _, entry := range entries {
+		t.nextSeq++
+		entry.Seq = t.nextSeq
+		t.entries = append(t.entries, entry)
+	}
+	if len(t.entries) > logsTabMaxLines {
+		t.entries = append(t.entries[:0], t.entries[len(t.entries)-logsTabMaxLines:]...)
+	}
+}
+
+// applyFilter rebuilds the visible lines
+func (t *logsTab) applyFilter() {
+	t.visible = t.visible[:0]
+	for i, entry := range t.entries {
+		if t.filter.Match(entry) {
+			t.visible = append(t.visible, i)
+		}
+	}
+	t.list.Refresh()
+	t.updateStatus()
+}
+
+func (t *logsTab) updateStatus() {
+	status := fmt.Sprintf("%d of %d lines", len(t.visible), len(t.entries))
+	if n := len(t.selectedLines()); n > 0 {
+		status += fmt.Sprintf(", %d selected", n)
+	}
+	if t.paused {
+		status += fmt.Sprintf(" (paused, %d new)", len(t.pending))
+	}
+	t.statusLabel.SetText(status)
+}
+
+func (t *logsTab) togglePause() {
+	t.paused = !t.paused
+	if t.paused {
+		t.pauseButton.SetText("Resume")
+		t.updateStatus()
+		return
+	}
+	t.pauseButton.SetText("Pause")
+	pending := t.pending
+	t.pending = nil
+	t.appendEntries(t.source, pending)
+}
+
+func (t *logsTab) isSelected(seq uint64) bool {
+	if t.selStart == 0 {
+		return false
+	}
+	from, to := t.selStart, t.selEnd
+	if from > to {
+		from, to = to, from
+	}
+	return seq >= from && seq <= to
+}
+
+// selectedLines returns the visible selected lines
+func (t *logsTab) selectedLines() []string {
+	var lines []string
+	for _, i := range t.visible {
+		if t.isSelected(t.entries[i].Seq) {
+			lines = append(lines, t.entries[i].Raw)
+		}
+	}
+	return lines
+}
+
+func (t *logsTab) copySelection() {
+	lines := t.selectedLines()
+	if len(lines) == 0 {
+		ShowErrorText(t.c.MainWindow, "Copy", "Click a line to select it, click another line to select a range.")
+		return
+	}
+	t.c.MainWindow.Clipboard().SetContent(strings.Join(lines, "\n"))
+}
+
+// export saves the visible lines to a file
+func (t *logsTab) export() {
+	lines := make([]string, 0, len(t.visible))
+	for _, i := range t.visible {
+		lines = append(lines, t.entries[i].Raw)
+	}
+	if len(lines) == 0 {
+		ShowErrorText(t.c.MainWindow, "Export", "No lines to export.")
+		return
+	}
+	content := strings.Join(lines, "\n") + "\n"
+
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			ShowError(t.c.MainWindow, err)
+			return
+		}
+		if writer == nil {
+			return // Cancelled
+		}
+		defer writer.Close()
+		if _, err := writer.Write([]byte(content)); err != nil {
+			ShowError(t.c.MainWindow, err)
+			return
+		}
+		log.Printf("logsTab: Exported %d lines to %s", len(lines), writer.URI().Path())
+	}, t.c.MainWindow)
+	saveDialog.SetFileName(fmt.Sprintf("%s-%s.log", t.source, time.Now().Format("20060102-150405")))
+	saveDialog.Show()
+}
+
+// logLevelImportance returns the label color of a log level
+func logLevelImportance(level core.LogLevel) widget.Importance {
+	switch {
+	case level.Rank() >= core.LogLevelError.Rank():
+		return widget.DangerImportance
+	case level == core.LogLevelWarn:
+		return widget.WarningImportance
+	case level == core.LogLevelDebug || level == core.LogLevelTrace:
+		return widget.LowImportance
+	}
+	return widget.MediumImportance
+}
@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"singbox-launcher/core"
+)
+
+// showParserConfigMigrationDialog shows a dialog that converts the @ParserConfig block
+// to another version (e.g. downgrade for an older launcher) with a preview before writing
+func showParserConfigMigrationDialog(ac *Controller) {
+	versions := make([]string, 0, core.ParserConfigVersion)
+	for v := core.ParserConfigVersion; v >= 1; v-- {
+		label := strconv.Itoa(v)
+		if v == core.ParserConfigVersion {
+			label += " (current)"
+		}
+		versions = append(versions, label)
+	}
+
+	var preview *core.MigrationPreview
+
+	infoLabel := widget.NewLabel("Select target version and press Preview.")
+	infoLabel.Wrapping = fyne.TextWrapWord
+
+	// Предпросмотр только для чтения: Apply записывает результат конвертации, а не текст поля
+	previewEntry := widget.NewMultiLineEntry()
+	previewEntry.Wrapping = fyne.TextWrapOff
+	previewEntry.SetMinRowsVisible(16)
+	previewEntry.Disable()
+
+	var applyButton *widget.Button
+
+	versionSelect := widget.NewSelect(versions, func(string) {
+		// Выбор новой версии делает предыдущий предпросмотр неактуальным
+		preview = nil
+		if applyButton != nil {
+			applyButton.Disable()
+		}
+	})
+	versionSelect.SetSelectedIndex(0)
+
+	previewButton := widget.NewButton("Preview", func() {
+		targetVersion, _ := strconv.Atoi(strings.Fields(versionSelect.Selected)[0])
+		result, err := core.PreviewParserConfigMigration(ac.GetConfigPath(), targetVersion)
+		if err != nil {
+			log.Printf("parserConfigMigration: Preview failed: %v", err)
+			ShowError(ac.MainWindow, err)
+			return
+		}
+		preview = result
+		previewEntry.SetText(result.MigratedJSON)
+
+		text := fmt.Sprintf("Version %d → %d.", result.FromVersion, result.ToVersion)
+		if result.FromVersion == result.ToVersion {
+			text += " Config already has this version."
+		}
+		if len(result.Warnings) > 0 {
+			text += "\nThe following settings will be lost:\n- " + strings.Join(result.Warnings, "\n- ")
+		}
+		infoLabel.SetText(text)
+
+		if result.FromVersion != result.ToVersion {
+			applyButton.Enable()
+		} else {
+			applyButton.Disable()
+		}
+	})
+
+	applyButton = widget.NewButton("Apply", func() {
+		if preview == nil {
+			return
+		}
+		current := preview
+		message := fmt.Sprintf("Write @ParserConfig version %d to %s?\nThe current block will be backed up.", current.ToVersion, ac.GetConfigPath())
+		if current.ToVersion < core.ParserConfigVersion {
+			message += "\n\nNote: updating subscriptions with this launcher converts the config back to the current version."
+		}
+		ShowConfirm(ac.MainWindow, "Convert ParserConfig", message, func(ok bool) {
+			if !ok {
+				return
+			}
+			backupPath, err := core.ApplyParserConfigMigration(ac.GetConfigPath(), current)
+			if err != nil {
+				log.Printf("parserConfigMigration: Apply failed: %v", err)
+				ShowError(ac.MainWindow, err)
+				return
+			}
+			preview = nil
+			applyButton.Disable()
+			infoLabel.SetText(fmt.Sprintf("Converted to version %d. Backup: %s", current.ToVersion, backupPath))
+			ac.NotifyConfigChanged()
+		})
+	})
+	applyButton.Importance = widget.HighImportance
+	applyButton.Disable()
+
+	content := container.NewBorder(
+		container.NewVBox(
+			container.NewHBox(widget.NewLabel("Target version:"), versionSelect, previewButton, applyButton),
+			infoLabel,
+		),
+		nil, nil, nil,
+		previewEntry,
+	)
+
+	d := dialog.NewCustom("ParserConfig Version", "Close", content, ac.MainWindow)
+	d.Resize(fyne.NewSize(700, 550))
+	d.Show()
+}
@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"singbox-launcher/core"
+)
+
+// showProfilesDialog shows the profile manager: create (from template via Config Wizard),
+// clone, rename, delete and switch profiles
+func showProfilesDialog(ac *Controller) {
+	var profiles []string
+	selected := ""
+
+	list := widget.NewList(
+		func() int { return len(profiles) },
+		func() fyne.CanvasObject { return widget.NewLabel("profile") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			label := profiles[id]
+			if label == ac.GetActiveProfile() {
+				label = "✓ " + label
+			}
+			obj.(*widget.Label).SetText(label)
+		},
+	)
+
+	reload := func() {
+		var err error
+		profiles, err = ac.Profiles.List()
+		if err != nil {
+			log.Printf("profilesDialog: Failed to list profiles: %v", err)
+		}
+		list.Refresh()
+	}
+	list.OnSelected = func(id widget.ListItemID) {
+		if id >= 0 && id < len(profiles) {
+			selected = profiles[id]
+		}
+	}
+
+	// askName shows a name entry dialog and calls onName with the entered name
+	askName := func(title, initial string, onName func(name string) error) {
+		entry := widget.NewEntry()
+		entry.SetText(initial)
+		dialog.ShowForm(title, "OK", "Cancel", []*widget.FormItem{widget.NewFormItem("Name", entry)}, func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := onName(entry.Text); err != nil {
+				ShowError(ac.MainWindow, err)
+				return
+			}
+			reload()
+		}, ac.MainWindow)
+	}
+
+	switchTo := func(name string, openWizard bool) {
+		go func() {
+			err := ac.SwitchProfile(name)
+			fyne.Do(func() {
+				if err != nil {
+					ShowError(ac.MainWindow, fmt.Errorf("failed to switch profile: %w", err))
+					return
+				}
+				reload()
+				if openWizard {
+					ShowConfigWizard(ac.MainWindow, ac)
+				}
+			})
+		}()
+	}
+
+	createButton := widget.NewButton("New...", func() {
+		askName("New Profile", "", func(name string) error {
+			if err := ac.CreateProfile(name); err != nil {
+				return err
+			}
+			// Новый профиль пустой: переключаемся и создаём config.json из шаблона через визард
+			switchTo(name, true)
+			return nil
+		})
+	})
+	cloneButton := widget.NewButton("Clone...", func() {
+		source := selected
+		if source == "" {
+			source = ac.GetActiveProfile()
+		}
+		askName(fmt.Sprintf("Clone '%s'", source), source+"-copy", func(name string) error {
+			return ac.CloneProfile(source, name)
+		})
+	})
+	renameButton := widget.NewButton("Rename...", func() {
+		if selected == "" || selected == core.DefaultProfileName {
+			ShowError(ac.MainWindow, fmt.Errorf("select a profile other than '%s'", core.DefaultProfileName))
+			return
+		}
+		oldName := selected
+		askName(fmt.Sprintf("Rename '%s'", oldName), oldName, func(name string) error {
+			if err := ac.RenameProfile(oldName, name); err != nil {
+				return err
+			}
+			selected = ""
+			list.UnselectAll()
+			return nil
+		})
+	})
+	deleteButton := widget.NewButton("Delete", func() {
+		if selected == "" {
+			return
+		}
+		name := selected
+		ShowConfirm(ac.MainWindow, "Delete Profile", fmt.Sprintf("Delete profile '%s' with its config.json and subscription cache?", name), func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := ac.DeleteProfile(name); err != nil {
+				ShowError(ac.MainWindow, err)
+				return
+			}
+			selected = ""
+			list.UnselectAll()
+			reload()
+		})
+	})
+	switchButton := widget.NewButton("Switch", func() {
+		if selected == "" || selected == ac.GetActiveProfile() {
+			return
+		}
+		switchTo(selected, false)
+	})
+	switchButton.Importance = widget.HighImportance
+
+	reload()
+
+	content := container.NewBorder(
+		nil,
+		container.NewHBox(createButton, cloneButton, renameButton, deleteButton, switchButton),
+		nil, nil,
+		list,
+	)
+	d := dialog.NewCustom("Profiles", "Close", content, ac.MainWindow)
+	d.Resize(fyne.NewSize(480, 360))
+	d.Show()
+}
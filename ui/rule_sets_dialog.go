@@ -0,0 +1,185 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+
+	"singbox-launcher/core"
+)
+
+// ruleSetDetails describes a rule-set in one line: kind, rule count and last update
+func ruleSetDetails(rs core.RuleSet) string {
+	parts := []string{strings.TrimSpace(rs.Type + " " + rs.Format)}
+	if rs.Provider || rs.RuleCount > 0 {
+		parts = append(parts, fmt.Sprintf("%d rules", rs.RuleCount))
+	}
+	if !rs.UpdatedAt.IsZero() {
+		parts = append(parts, "updated "+rs.UpdatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	if !rs.Provider && rs.Type == "remote" {
+		interval := rs.UpdateInterval
+		if interval == "" {
+			interval = "1d"
+		}
+		parts = append(parts, "updated by sing-box every "+interval)
+		if rs.Cached {
+			parts = append(parts, "kept in cache_file")
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+// showRuleSetsDialog lists rule-sets of config.json with the state of their rule providers
+// and refreshes single or all rule-sets (see core.RefreshRuleSets). Rule-sets of sing-box are
+// refreshed by reloading its whole config, which the user confirms first.
+func showRuleSetsDialog(ac *Controller) {
+	var sets []core.RuleSet
+	needsReload := false // Refresh All reloads sing-box
+	status := widget.NewLabel("Loading...")
+	status.Wrapping = fyne.TextWrapWord
+
+	var reload func(note string)
+	refreshAllButton := widget.NewButton("Refresh All", nil)
+	refreshAllButton.Importance = widget.HighImportance
+	refreshAllButton.Disable()
+
+	// refresh makes the core download rule-sets (all if no tags) and reloads the list
+	refresh := func(allowReload bool, tags ...string) {
+		status.SetText("Refreshing...")
+		go func() {
+			refreshed, err := ac.RefreshRuleSets(context.Background(), allowReload, tags...)
+			fyne.Do(func() {
+				if err != nil {
+					log.Printf("ruleSetsDialog: %v", err)
+					ShowError(ac.MainWindow, err)
+				}
+				note := ""
+				if len(refreshed) > 0 {
+					note = "Refreshed: " + strings.Join(refreshed, ", ")
+				}
+				reload(note)
+			})
+		}()
+	}
+
+	// confirmRefresh refreshes rule-sets; if that reloads sing-box, the user confirms it first
+	confirmRefresh := func(needsReload bool, tags ...string) {
+		if !needsReload {
+			refresh(false, tags...)
+			return
+		}
+		message := "sing-box cannot refresh single rule-sets: it downloads them again only when it reloads\n" +
+			"the whole config. All inbounds and outbounds are recreated and all connections are dropped."
+		if runtime.GOOS == "windows" {
+			message = "sing-box cannot refresh single rule-sets: it downloads them again only when it loads\n" +
+				"the config. sing-box is restarted and all connections are dropped."
+		}
+		dialog.ShowConfirm("Reload sing-box?", message+"\n\nRefresh rule-sets anyway?", func(ok bool) {
+			if ok {
+				refresh(true, tags...)
+			}
+		}, ac.MainWindow)
+	}
+
+	list := widget.NewList(
+		func() int { return len(sets) },
+		func() fyne.CanvasObject {
+			tag := widget.NewLabel("rule-set")
+			tag.TextStyle.Bold = true
+			return container.NewHBox(tag, widget.NewLabel("remote binary"), layout.NewSpacer(), widget.NewButton("Refresh", nil))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id < 0 || id >= len(sets) {
+				return
+			}
+			rs := sets[id]
+			box := obj.(*fyne.Container)
+			box.Objects[0].(*widget.Label).SetText(rs.Tag)
+			box.Objects[1].(*widget.Label).SetText(ruleSetDetails(rs))
+			button := box.Objects[3].(*widget.Button)
+			button.OnTapped = func() { confirmRefresh(rs.NeedsReload, rs.Tag) }
+			if rs.Refreshable {
+				button.Enable()
+			} else {
+				button.Disable()
+			}
+		},
+	)
+
+	// reload reads rule-sets again; note is shown after the summary
+	reload = func(note string) {
+		go func() {
+			loaded, err := ac.RuleSets(context.Background())
+			fyne.Do(func() {
+				if err != nil {
+					status.SetText(err.Error())
+					return
+				}
+				sets = loaded
+				list.Refresh()
+				providers, refreshable, cached := 0, 0, 0
+				needsReload = false
+				for _, rs := range sets {
+					if rs.Provider {
+						providers++
+					}
+					if rs.Refreshable {
+						refreshable++
+					}
+					if rs.NeedsReload {
+						needsReload = true
+					}
+					if rs.Cached {
+						cached++
+					}
+				}
+				if refreshable > 0 {
+					refreshAllButton.Enable()
+				} else {
+					refreshAllButton.Disable()
+				}
+				var summary string
+				switch {
+				case len(sets) == 0:
+					summary = "No rule-sets in config.json."
+				case !ac.RunningState.IsRunning() && refreshable == 0:
+					summary = fmt.Sprintf("%d rule-sets. Start sing-box to see their state.", len(sets))
+				case providers == 0 && refreshable > 0:
+					summary = fmt.Sprintf("%d rule-sets, %d remote can be refreshed only by reloading sing-box (all connections are dropped).", len(sets), refreshable)
+				case cached > 0 && refreshable == 0:
+					summary = fmt.Sprintf("%d rule-sets. Remote rule-sets are kept in experimental.cache_file: sing-box downloads them every update_interval.", len(sets))
+				default:
+					summary = fmt.Sprintf("%d rule-sets, %d can be refreshed.", len(sets), refreshable)
+				}
+				if note != "" {
+					summary += "\n" + note
+				}
+				status.SetText(summary)
+			})
+		}()
+	}
+
+	refreshAllButton.OnTapped = func() { confirmRefresh(needsReload) }
+	reloadButton := widget.NewButton("Reload", func() { reload("") })
+
+	reload("")
+
+	content := container.NewBorder(
+		nil,
+		container.NewVBox(status, container.NewHBox(reloadButton, layout.NewSpacer(), refreshAllButton)),
+		nil, nil,
+		list,
+	)
+	d := dialog.NewCustom("Rule-sets", "Close", content, ac.MainWindow)
+	d.Resize(fyne.NewSize(640, 420))
+	d.Show()
+}
@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+
+	"singbox-launcher/core"
+)
+
+// trafficGraphWindows are the selectable graph periods
+var trafficGraphWindows = []struct {
+	label  string
+	window time.Duration
+}{
+	{"5 min", 5 * time.Minute},
+	{"15 min", 15 * time.Minute},
+	{"30 min", 30 * time.Minute},
+	{"60 min", core.TrafficHistoryWindow},
+}
+
+var uploadColor = color.NRGBA{R: 255, G: 152, B: 0, A: 255}
+
+// trafficGraph draws download speed as a filled area and upload speed as a line.
+// The right edge is the current time.
+type trafficGraph struct {
+	raster  *canvas.Raster
+	window  time.Duration
+	samples []core.TrafficSample
+	peak    int64 // Largest speed in the window, the top of the graph
+}
+
+func newTrafficGraph() *trafficGraph {
+	g := &trafficGraph{window: trafficGraphWindows[0].window}
+	g.raster = canvas.NewRaster(g.draw)
+	g.raster.SetMinSize(fyne.NewSize(320, 90))
+	return g
+}
+
+// SetSamples replaces the samples and redraws; must be called on the UI thread
+func (g *trafficGraph) SetSamples(samples []core.TrafficSample) {
+	g.samples = samples
+	g.peak = 0
+	for _, s := range samples {
+		g.peak = max(g.peak, s.Up, s.Down)
+	}
+	g.raster.Refresh()
+}
+
+// Peak returns the speed at the top of the graph in bytes per second
+func (g *trafficGraph) Peak() int64 {
+	return g.peak
+}
+
+// draw renders the graph; every pixel column shows the highest speeds of its time slice
+func (g *trafficGraph) draw(w, h int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	background := color.NRGBAModel.Convert(theme.Color(theme.ColorNameInputBackground)).(color.NRGBA)
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: background}, image.Point{}, draw.Src)
+	if w == 0 || h == 0 || len(g.samples) == 0 {
+		return img
+	}
+
+	downColor := color.NRGBAModel.Convert(theme.Color(theme.ColorNamePrimary)).(color.NRGBA)
+	downColor.A = 160
+	peak := max(g.peak, 1024) // Keep idle traffic near the bottom
+	start := time.Now().Add(-g.window)
+	columnDuration := g.window / time.Duration(w)
+	if columnDuration <= 0 {
+		columnDuration = 1
+	}
+
+	ups := make([]int64, w)
+	downs := make([]int64, w)
+	seen := make([]bool, w)
+	for _, s := range g.samples {
+		x := int(s.Time.Sub(start) / columnDuration)
+		if x < 0 || x >= w {
+			continue
+		}
+		ups[x] = max(ups[x], s.Up)
+		downs[x] = max(downs[x], s.Down)
+		seen[x] = true
+	}
+
+	height := func(v int64) int {
+		return int(float64(v) / float64(peak) * float64(h-1))
+	}
+	prevY := -1
+	for x := 0; x < w; x++ {
+		if !seen[x] {
+			prevY = -1 // No samples (sing-box stopped): leave a gap
+			continue
+		}
+		for y := h - 1 - height(downs[x]); y < h; y++ {
+			img.SetNRGBA(x, y, downColor)
+		}
+		y := h - 1 - height(ups[x])
+		from, to := y, y
+		if prevY >= 0 {
+			from, to = min(y, prevY), max(y, prevY)
+		}
+		for yy := from; yy <= to; yy++ {
+			img.SetNRGBA(x, yy, uploadColor)
+			if yy+1 < h {
+				img.SetNRGBA(x, yy+1, uploadColor)
+			}
+		}
+		prevY = y
+	}
+	return img
+}
+
+// formatSpeed formats bytes per second
+func formatSpeed(bytesPerSecond int64) string {
+	return core.FormatBytesUtil(bytesPerSecond) + "/s"
+}
+
+// formatTrafficSample returns "↑ 1.2 KB/s  ↓ 3.4 MB/s  Memory 45.0 MB"
+func formatTrafficSample(s core.TrafficSample) string {
+	text := fmt.Sprintf("↑ %s  ↓ %s", formatSpeed(s.Up), formatSpeed(s.Down))
+	if s.Memory > 0 {
+		text += "  Memory " + core.FormatBytesUtil(int64(s.Memory))
+	}
+	return text
+}
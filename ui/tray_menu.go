@@ -0,0 +1,299 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"singbox-launcher/api"
+	"singbox-launcher/core"
+)
+
+// Tray menu limits
+const (
+	trayQuickProxies  = 10              // Proxies in a quick-switch submenu (favorites are always shown)
+	trayGroupsTimeout = 2 * time.Second // Clash API request for the groups submenu
+	trayLatencyDelay  = 5 * time.Second // Delays tested meanwhile are shown with one menu update
+)
+
+// TrayMenuState is the data shown in the tray menu. It is collected by LoadTrayMenuState outside
+// the UI thread (Clash API request, profile list, core check), so CreateTrayMenu does not block.
+type TrayMenuState struct {
+	buttons         core.VPNButtonState
+	clashAPIEnabled bool
+	selectedGroup   string
+	activeProxy     string
+	proxies         []api.ProxyInfo   // Proxies of the selected group (ProxiesList)
+	favorites       []string          // Pinned proxies, see FavoriteProxies
+	groups          []core.ProxyGroup // Selector groups other than the selected one
+	mode            string
+	modes           []string
+	profiles        []string
+	parserRunning   bool
+}
+
+// LoadTrayMenuState collects the tray menu data; call it outside the UI thread
+func (c *Controller) LoadTrayMenuState() *TrayMenuState {
+	state := &TrayMenuState{
+		buttons:   c.GetVPNButtonState(),
+		favorites: c.FavoriteProxies(),
+	}
+	c.APIStateMutex.RLock()
+	state.proxies = append(state.proxies, c.ProxiesList...)
+	state.activeProxy = c.ActiveProxyName
+	state.selectedGroup = c.SelectedClashGroup
+	state.clashAPIEnabled = c.ClashAPIEnabled
+	c.APIStateMutex.RUnlock()
+	state.mode, state.modes = c.ClashMode()
+	c.TrayMenuUpdateMutex.Lock()
+	state.parserRunning = c.trayParserRunning
+	c.TrayMenuUpdateMutex.Unlock()
+
+	running := c.RunningState.IsRunning()
+	// Auto-load proxies if list is empty and API is enabled
+	// Note: AutoLoadProxies has internal guard to prevent multiple simultaneous loads
+	if state.clashAPIEnabled && running && state.selectedGroup != "" && len(state.proxies) == 0 {
+		// Check if auto-load is already in progress to avoid duplicate calls
+		c.AutoLoadMutex.Lock()
+		alreadyInProgress := c.AutoLoadInProgress
+		c.AutoLoadMutex.Unlock()
+
+		if !alreadyInProgress {
+			// Start auto-loading in background (non-blocking)
+			go c.AutoLoadProxies()
+		}
+	}
+
+	// Other selector groups; GLOBAL is only used by sing-box in the Global mode
+	if state.clashAPIEnabled && running {
+		ctx, cancel := context.WithTimeout(context.Background(), trayGroupsTimeout)
+		groups, err := c.ProxyGroups(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("CreateTrayMenu: Failed to load proxy groups: %v", err)
+		}
+		for _, g := range groups {
+			if !g.Selectable() || g.Name == state.selectedGroup ||
+				(g.Name == core.GlobalGroupName && !strings.EqualFold(state.mode, "Global")) {
+				continue
+			}
+			state.groups = append(state.groups, g)
+		}
+	}
+
+	if c.Profiles != nil {
+		profiles, err := c.Profiles.List()
+		if err != nil {
+			log.Printf("CreateTrayMenu: Failed to list profiles: %v", err)
+		}
+		state.profiles = profiles
+	}
+	return state
+}
+
+// CreateTrayMenu creates the system tray menu from state (see LoadTrayMenuState)
+func (c *Controller) CreateTrayMenu(state *TrayMenuState) *fyne.Menu {
+	// Create main menu items
+	menuItems := []*fyne.MenuItem{
+		fyne.NewMenuItem("Open", func() { c.MainWindow.Show() }),
+		fyne.NewMenuItemSeparator(),
+	}
+
+	// Add Start/Stop VPN buttons based on centralized state
+	if state.buttons.StartEnabled {
+		menuItems = append(menuItems, fyne.NewMenuItem("Start VPN", func() { core.StartSingBoxProcess(c.AppController) }))
+	} else {
+		startItem := fyne.NewMenuItem("Start VPN", nil)
+		startItem.Disabled = true
+		menuItems = append(menuItems, startItem)
+	}
+
+	if state.buttons.StopEnabled {
+		menuItems = append(menuItems, fyne.NewMenuItem("Stop VPN", func() { core.StopSingBoxProcess(c.AppController) }))
+	} else {
+		stopItem := fyne.NewMenuItem("Stop VPN", nil)
+		stopItem.Disabled = true
+		menuItems = append(menuItems, stopItem)
+	}
+
+	menuItems = append(menuItems, fyne.NewMenuItemSeparator())
+
+	// Add proxy and group submenus if Clash API is enabled
+	if state.clashAPIEnabled && state.selectedGroup != "" {
+		var proxyMenuItems []*fyne.MenuItem
+		if len(state.proxies) > 0 {
+			proxyMenuItems = c.proxyMenuItems(state.selectedGroup, state.proxies, state.activeProxy, state.favorites)
+		} else {
+			// Show disabled item if no proxies available
+			disabledItem := fyne.NewMenuItem("No proxies available", nil)
+			disabledItem.Disabled = true
+			proxyMenuItems = append(proxyMenuItems, disabledItem)
+		}
+		selectProxyItem := fyne.NewMenuItem("Select Proxy", nil)
+		selectProxyItem.ChildMenu = fyne.NewMenu("Select Proxy", proxyMenuItems...)
+		menuItems = append(menuItems, selectProxyItem)
+
+		if groupsItem := c.createGroupsMenuItem(state); groupsItem != nil {
+			menuItems = append(menuItems, groupsItem)
+		}
+		menuItems = append(menuItems, fyne.NewMenuItemSeparator())
+	}
+
+	// Add routing mode submenu while the mode is known
+	if modeItem := c.createModeMenuItem(state); modeItem != nil {
+		menuItems = append(menuItems, modeItem)
+		menuItems = append(menuItems, fyne.NewMenuItemSeparator())
+	}
+
+	// Add profile submenu if there is more than one profile
+	if profileItem := c.createProfileMenuItem(state); profileItem != nil {
+		menuItems = append(menuItems, profileItem)
+	}
+	menuItems = append(menuItems, c.createUpdateMenuItem(state))
+	menuItems = append(menuItems, fyne.NewMenuItemSeparator())
+
+	// Add Quit item
+	menuItems = append(menuItems, fyne.NewMenuItem("Quit", c.Quit))
+
+	return fyne.NewMenu("Singbox Launcher", menuItems...)
+}
+
+// proxyMenuItems creates quick-switch items of a group: favorites, the active proxy and the fastest proxies
+// (see QuickSwitchProxies), followed by an "All Proxies" submenu if some proxies are not shown
+func (c *Controller) proxyMenuItems(group string, proxies []api.ProxyInfo, active string, favorites []string) []*fyne.MenuItem {
+	newItem := func(proxy api.ProxyInfo) *fyne.MenuItem {
+		label := proxy.Name
+		if proxy.Delay > 0 {
+			label = fmt.Sprintf("%s (%d ms)", proxy.Name, proxy.Delay)
+		}
+		// Mark active proxy with checkmark and favorites with a star
+		switch {
+		case proxy.Name == active:
+			label = "✓ " + label
+		case containsString(favorites, proxy.Name):
+			label = "★ " + label
+		}
+		// Create local copy for closure
+		pName := proxy.Name
+		return fyne.NewMenuItem(label, func() {
+			// Switch to selected proxy
+			go func() {
+				// Tray menu and Clash API tab are refreshed on EventProxiesChanged (selected group only)
+				if err := c.SelectProxy(group, pName); err != nil {
+					log.Printf("CreateTrayMenu: Failed to switch proxy: %v", err)
+					ShowError(c.MainWindow, fmt.Errorf("failed to switch proxy: %w", err))
+					return
+				}
+				c.updateTrayMenu()
+			}()
+		})
+	}
+
+	quick := core.QuickSwitchProxies(proxies, active, favorites, trayQuickProxies)
+	items := make([]*fyne.MenuItem, 0, len(quick)+2)
+	for _, proxy := range quick {
+		items = append(items, newItem(proxy))
+	}
+	if len(quick) < len(proxies) {
+		all := make([]*fyne.MenuItem, 0, len(proxies))
+		for _, proxy := range proxies {
+			all = append(all, newItem(proxy))
+		}
+		allItem := fyne.NewMenuItem(fmt.Sprintf("All Proxies (%d)", len(proxies)), nil)
+		allItem.ChildMenu = fyne.NewMenu("All Proxies", all...)
+		items = append(items, fyne.NewMenuItemSeparator(), allItem)
+	}
+	return items
+}
+
+// createGroupsMenuItem creates the "Groups" tray submenu with the other selector groups;
+// returns nil if there are none
+func (c *Controller) createGroupsMenuItem(state *TrayMenuState) *fyne.MenuItem {
+	if len(state.groups) == 0 {
+		return nil
+	}
+	items := make([]*fyne.MenuItem, 0, len(state.groups))
+	for _, g := range state.groups {
+		item := fyne.NewMenuItem(fmt.Sprintf("%s → %s", g.Name, g.Now), nil)
+		item.ChildMenu = fyne.NewMenu(g.Name, c.proxyMenuItems(g.Name, g.Members, g.Now, state.favorites)...)
+		items = append(items, item)
+	}
+	item := fyne.NewMenuItem("Groups", nil)
+	item.ChildMenu = fyne.NewMenu("Groups", items...)
+	return item
+}
+
+// createModeMenuItem creates the "Mode" tray submenu; returns nil if sing-box is not running
+// or the mode has not been read from Clash API yet
+func (c *Controller) createModeMenuItem(state *TrayMenuState) *fyne.MenuItem {
+	if !state.clashAPIEnabled || state.mode == "" || !state.buttons.IsRunning {
+		return nil
+	}
+
+	items := make([]*fyne.MenuItem, 0, len(state.modes))
+	for _, name := range state.modes {
+		modeName := name
+		label := modeName
+		if modeName == state.mode {
+			label = "✓ " + modeName
+		}
+		items = append(items, fyne.NewMenuItem(label, func() {
+			go func() {
+				// Tray menu and dashboard are refreshed on EventClashModeChanged
+				if err := c.SetClashMode(context.Background(), modeName); err != nil {
+					log.Printf("CreateTrayMenu: Failed to switch mode: %v", err)
+					ShowError(c.MainWindow, fmt.Errorf("failed to switch mode: %w", err))
+				}
+			}()
+		}))
+	}
+
+	item := fyne.NewMenuItem("Mode: "+state.mode, nil)
+	item.ChildMenu = fyne.NewMenu("Mode", items...)
+	return item
+}
+
+// createProfileMenuItem creates the "Profile" tray submenu; returns nil if only the default profile exists
+func (c *Controller) createProfileMenuItem(state *TrayMenuState) *fyne.MenuItem {
+	if len(state.profiles) < 2 {
+		return nil
+	}
+
+	items := make([]*fyne.MenuItem, 0, len(state.profiles))
+	for _, name := range state.profiles {
+		profileName := name
+		label := profileName
+		if profileName == c.GetActiveProfile() {
+			label = "✓ " + profileName
+		}
+		items = append(items, fyne.NewMenuItem(label, func() {
+			go func() {
+				if err := c.SwitchProfile(profileName); err != nil {
+					log.Printf("CreateTrayMenu: Failed to switch profile: %v", err)
+					ShowError(c.MainWindow, fmt.Errorf("failed to switch profile: %w", err))
+				}
+			}()
+		}))
+	}
+
+	item := fyne.NewMenuItem("Profile: "+c.GetActiveProfile(), nil)
+	item.ChildMenu = fyne.NewMenu("Profile", items...)
+	return item
+}
+
+// createUpdateMenuItem creates the "Update Subscriptions Now" item (disabled while an update is running);
+// the tray is refreshed when an update starts or ends
+func (c *Controller) createUpdateMenuItem(state *TrayMenuState) *fyne.MenuItem {
+	if state.parserRunning {
+		item := fyne.NewMenuItem("Updating Subscriptions...", nil)
+		item.Disabled = true
+		return item
+	}
+	return fyne.NewMenuItem("Update Subscriptions Now", func() {
+		go core.RunParserProcess(c.AppController)
+	})
+}